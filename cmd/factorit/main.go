@@ -2,25 +2,39 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
-	"strconv"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/jsonschema"
 	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/postgres"
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	usersgrpc "github.com/cristianortiz/observ-monit-go/internal/users/ports/grpc"
 	"github.com/cristianortiz/observ-monit-go/internal/users/ports/http"
 	"github.com/cristianortiz/observ-monit-go/internal/users/usecase"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
 	"github.com/cristianortiz/observ-monit-go/pkg/config"
 	"github.com/cristianortiz/observ-monit-go/pkg/database"
 	"github.com/cristianortiz/observ-monit-go/pkg/http-utils/middleware"
+	"github.com/cristianortiz/observ-monit-go/pkg/http-utils/openapi"
 	"github.com/cristianortiz/observ-monit-go/pkg/observability/health"
 	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
 	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/slo"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/tracing"
+	"github.com/cristianortiz/observ-monit-go/pkg/secretbox"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -40,6 +54,10 @@ func main() {
 		panic("failed to create logger: " + err.Error())
 	}
 	defer log.Sync()
+	if cfg.Observability.Tracing.Enabled {
+		// error entries logged with log.Ctx(ctx) show up in the trace too
+		log = log.WithSpanEvents()
+	}
 	//Init Validator
 	middleware.InitValidator()
 	log.Info("Validator initialized")
@@ -54,7 +72,25 @@ func main() {
 	// 3. INITIALIZE DATABASE
 	// ========================================
 	ctx := context.Background()
-	db, err := database.NewPostgresDB(ctx, cfg, log.Logger)
+
+	// every metric of the service goes to this registry, served on the metrics path
+	metricsConfig := cfg.Observability.Metrics
+	metricsRegistry := metrics.NewRegistry()
+	metricsOptions := []metrics.Option{
+		metrics.WithRegistry(metricsRegistry),
+		metrics.WithNamespace(metricsConfig.Namespace),
+		metrics.WithConstLabels(metricsConfig.ConstLabels),
+	}
+	latencyOptions := metricsOptions
+	if len(metricsConfig.LatencyBuckets) > 0 {
+		latencyOptions = append(slices.Clone(metricsOptions), metrics.WithBuckets(metricsConfig.LatencyBuckets))
+	}
+
+	// every query gets a span and its duration and errors recorded
+	queryTracer := database.NewQueryTracer(database.QueryTracerConfig{
+		Metrics: metrics.NewDatabaseMetrics(cfg.Service.Name, metricsOptions...),
+	})
+	db, err := database.NewPostgresDB(ctx, cfg, log.Logger, database.WithTracer(queryTracer))
 	if err != nil {
 		log.Fatal("failed to initialize database", zap.Error(err))
 	}
@@ -73,30 +109,190 @@ func main() {
 	healthSystem.SetDatabase(db)
 	healthHandler := health.NewHandler(healthSystem, log)
 
+	// Tracing, spans are exported over OTLP, or to stdout/a file in development, when enabled
+	tracingConfig := cfg.Observability.Tracing
+	shutdownTracing, err := tracing.InitTracing(tracing.TracingConfig{
+		ServiceName:        tracingConfig.ServiceName,
+		ServiceVersion:     tracingConfig.ServiceVersion,
+		Environment:        tracingConfig.Environment,
+		OTLPEndpoint:       tracingConfig.OTLPEndpoint,
+		Enabled:            tracingConfig.Enabled,
+		Exporter:           tracingConfig.Exporter,
+		StdoutFormat:       tracingConfig.StdoutFormat,
+		FilePath:           tracingConfig.FilePath,
+		FileMaxSize:        int64(tracingConfig.FileMaxSizeMB) << 20,
+		FileMaxBackups:     tracingConfig.FileMaxBackups,
+		Protocol:           tracingConfig.OTLPProtocol,
+		Insecure:           tracingConfig.OTLPInsecure,
+		CACertFile:         tracingConfig.OTLPCAFile,
+		ClientCertFile:     tracingConfig.OTLPCertFile,
+		ClientKeyFile:      tracingConfig.OTLPKeyFile,
+		Headers:            tracingConfig.OTLPHeaders,
+		Compression:        tracingConfig.OTLPCompression,
+		SampleRatio:        tracingConfig.SampleRatio,
+		BatchTimeout:       tracingConfig.BatchTimeout,
+		ExportTimeout:      tracingConfig.ExportTimeout,
+		MaxExportBatchSize: tracingConfig.MaxExportBatchSize,
+		MaxQueueSize:       tracingConfig.MaxQueueSize,
+	})
+	if err != nil {
+		log.Fatal("failed to initialize tracing", zap.Error(err))
+	}
+
 	// Metrics System
-	metricsSystem := metrics.New(cfg.Service.Name)
+	metricsSystem := metrics.New(cfg.Service.Name, latencyOptions...)
 	metricsHandler := metrics.NewHandler(metricsSystem)
 
-	userMetrics := metrics.NewUserMetrics(cfg.Service.Name)
+	userMetrics := metrics.NewUserMetrics(cfg.Service.Name, metricsOptions...)
+	grpcMetrics := metrics.NewGRPCMetrics(cfg.Service.Name, latencyOptions...)
+	// pool statistics read on every scrape
+	database.NewPoolCollector(cfg.Service.Name, map[string]*pgxpool.Pool{"main": db.Pool}, metricsOptions...)
+
+	// SLOs evaluated from the HTTP metrics above, error budget and burn rates exported
+	var sloEngine *slo.Engine
+	sloCtx, stopSLO := context.WithCancel(ctx)
+	defer stopSLO()
+	if sloConfig := cfg.Observability.SLO; sloConfig.Enabled {
+		objectives, err := slo.ObjectivesFromConfig(sloConfig, cfg.API.BasePath)
+		if err != nil {
+			log.Fatal("failed to load SLO objectives", zap.Error(err))
+		}
+		sloEngine, err = slo.New(slo.Config{
+			Objectives:  objectives,
+			Registry:    metricsRegistry,
+			Namespace:   metricsConfig.Namespace,
+			ConstLabels: metricsConfig.ConstLabels,
+			Period:      sloConfig.Period,
+			Interval:    sloConfig.EvaluationInterval,
+			Logger:      log,
+		})
+		if err != nil {
+			log.Fatal("failed to initialize SLO engine", zap.Error(err))
+		}
+		go sloEngine.Run(sloCtx)
+	}
 
 	log.Info("Observability systems initialized",
 		zap.String("health", "active"),
 		zap.String("metrics", "active"),
 		zap.String("user_metrics", "active"),
+		zap.String("grpc_metrics", "active"),
+		zap.String("pool_metrics", "active"),
+		zap.Bool("slo", sloEngine != nil),
 	)
 	// ========================================
 	// 5. INITIALIZE USERS MODULE (NUEVO)
 	// ========================================
 
 	// Dependency Injection: Repository → Service → Handler
-	userRepository := postgres.NewUserRepository(db.Pool, userMetrics)
-	userService := usecase.NewUserService(userRepository)
+	var repoOpts []postgres.Option
+	if cfg.Tenant.RLSEnabled {
+		repoOpts = append(repoOpts, postgres.WithRowLevelSecurity())
+	}
+	userRepository := postgres.NewUserRepository(db.Pool, repoOpts...)
+
+	// sessions of the user tokens, "log out everywhere"
+	sessionRepository := postgres.NewSessionRepository(db.Pool, repoOpts...)
+	sessionService := usecase.NewSessionService(userRepository, sessionRepository, log,
+		usecase.WithSessionCacheTTL(cfg.Security.SessionCacheTTL),
+	)
+	sessionHandler := http.NewSessionHandler(sessionService)
+
+	// deleted users are logged out everywhere
+	serviceOpts := []usecase.Option{usecase.WithSessionEnder(sessionService)}
+	if cfg.Users.AttributesSchemaPath != "" {
+		attributesValidator, err := jsonschema.LoadAttributesValidator(cfg.Users.AttributesSchemaPath)
+		if err != nil {
+			log.Fatal("failed to load users attributes schema", zap.Error(err))
+		}
+		serviceOpts = append(serviceOpts, usecase.WithAttributesValidator(attributesValidator))
+	}
+	userService := usecase.NewUserService(userRepository, serviceOpts...)
 	userHandler := http.NewUserHandler(userService, userMetrics)
+	userServer := usersgrpc.NewUserServer(userService, userMetrics)
+
+	// TOTP two-factor, the secrets are encrypted at rest
+	totpCipher, err := totpSecretBox(cfg.Security)
+	if err != nil {
+		log.Fatal("invalid totp encryption key", zap.Error(err))
+	}
+	twoFactorRepository := postgres.NewTwoFactorRepository(db.Pool, repoOpts...)
+	twoFactorService := usecase.NewTwoFactorService(userRepository, twoFactorRepository, totpCipher,
+		cfg.Security.TOTPIssuer, log)
+	twoFactorHandler := http.NewTwoFactorHandler(twoFactorService)
+
+	// login, issues the user tokens verified by the auth middleware
+	loginService := usecase.NewLoginService(userRepository, sessionService, twoFactorService,
+		auth.NewTokenIssuer([]byte(cfg.Security.JWTSecret), cfg.Tenant.Claim),
+		usecase.LoginConfig{
+			AccessTokenTTL:       cfg.Security.AccessTokenTTL,
+			ChallengeTTL:         cfg.Security.MFAChallengeTTL,
+			ChallengeMaxAttempts: cfg.Security.MFAChallengeMaxAttempts,
+			Scopes:               []string{domain.ScopeUsersRead, domain.ScopeUsersWrite},
+		},
+		log,
+	)
+	authHandler := http.NewAuthHandler(loginService)
+
+	// GDPR export and erasure, jobs share the tenant isolation of the users table
+	erasureJobRepository := postgres.NewErasureJobRepository(db.Pool, repoOpts...)
+	privacyService := usecase.NewPrivacyService(userRepository, erasureJobRepository, log,
+		usecase.WithExportSections(sessionService.ExportSection(), twoFactorService.ExportSection()),
+	)
+	privacyHandler := http.NewPrivacyHandler(privacyService)
+
+	// API keys, service to service access
+	apiKeyRepository := postgres.NewAPIKeyRepository(db.Pool, repoOpts...)
+	apiKeyService := usecase.NewAPIKeyService(apiKeyRepository, log)
+	apiKeyHandler := http.NewAPIKeyHandler(apiKeyService)
+	if plain := cfg.Security.BootstrapAPIKey; plain != "" {
+		// nothing grants api-keys:manage through the API, the operator seeds the first key
+		bootstrapCtx := tenant.WithID(ctx, cfg.Security.BootstrapAPIKeyTenant)
+		if _, err := apiKeyService.EnsureAPIKey(bootstrapCtx, "bootstrap", plain, cfg.Security.BootstrapAPIKeyScopes); err != nil {
+			log.Fatal("failed to seed bootstrap api key", zap.Error(err))
+		}
+	}
+
+	// retention purge of soft deleted users, a single replica runs it (advisory lock)
+	purgeCtx, stopPurge := context.WithCancel(ctx)
+	defer stopPurge()
+	purgeDone := make(chan struct{})
+	if cfg.Users.Purge.Enabled {
+		purgeMode, err := domain.ParsePurgeMode(cfg.Users.Purge.Mode)
+		if err != nil {
+			log.Fatal("invalid users purge mode", zap.Error(err))
+		}
+		purger := usecase.NewRetentionPurger(
+			userRepository,
+			database.NewAdvisoryLock(db.Pool, "users_retention_purge"),
+			usecase.RetentionConfig{
+				Interval:  cfg.Users.Purge.Interval,
+				Retention: cfg.Users.Purge.Retention,
+				BatchSize: cfg.Users.Purge.BatchSize,
+				Mode:      purgeMode,
+			},
+			metrics.NewRetentionMetrics(cfg.Service.Name, metricsOptions...),
+			log,
+		)
+		go func() {
+			defer close(purgeDone)
+			purger.Run(purgeCtx)
+		}()
+	} else {
+		close(purgeDone)
+	}
 
 	log.Info("Users module initialized",
 		zap.String("repository", "postgres"),
 		zap.String("service", "user_service"),
 		zap.String("handler", "user_handler"),
+		zap.String("grpc_server", "user_server"),
+		zap.String("privacy", "privacy_service"),
+		zap.Strings("tenant_resolvers", cfg.Tenant.Resolvers),
+		zap.Bool("tenant_rls", cfg.Tenant.RLSEnabled),
+		zap.String("attributes_schema", cfg.Users.AttributesSchemaPath),
+		zap.Bool("retention_purge", cfg.Users.Purge.Enabled),
+		zap.Bool("auth", cfg.Security.AuthEnabled),
 	)
 
 	// ========================================
@@ -104,15 +300,40 @@ func main() {
 	// ========================================
 	app := fiber.New(fiber.Config{
 		AppName:      "Factorit Platform v1.0.0",
-		ErrorHandler: customErrorHandler(log, metricsSystem, cfg.Service.Name),
+		ErrorHandler: customErrorHandler(log),
 	})
 
 	// Global Middlewares
-	app.Use(recover.New())
+	// request id first, every response and log line (panics included) carries it
+	app.Use(middleware.RequestID(middleware.RequestIDConfig{Logger: log}))
+	// server span of every request, the access log reads its trace id
+	app.Use(tracing.Middleware(tracing.MiddlewareConfig{
+		Next: func(c *fiber.Ctx) bool {
+			switch c.Path() {
+			case cfg.Observability.MetricsPath, cfg.Observability.HealthPath, cfg.Observability.ReadyPath, cfg.Observability.SLO.Path:
+				return true
+			}
+			return false
+		},
+	}))
+	if accessLog := cfg.Observability.AccessLog; accessLog.Enabled {
+		// before recover, panics are logged with the 500 sent
+		app.Use(middleware.AccessLog(middleware.AccessLogConfig{
+			Logger:        log.WithComponent("http"),
+			SampleRate:    accessLog.SampleRate,
+			SlowThreshold: accessLog.SlowThreshold,
+			ExcludePaths:  accessLog.ExcludePaths,
+		}))
+	}
+	// before recover too, panics are counted as the 500 sent
 	app.Use(metrics.Middleware(metrics.MetricsConfig{
-		ServiceName: cfg.Service.Name,
-		Metrics:     metricsSystem,
+		Metrics: metricsSystem,
+		SLO: metrics.LatencyThresholds{
+			Default: cfg.Observability.SLO.LatencyThreshold,
+			Routes:  cfg.Observability.SLO.LatencyRoutes,
+		},
 	}))
+	app.Use(recover.New())
 
 	// ========================================
 	// 7. REGISTER OBSERVABILITY ROUTES
@@ -123,17 +344,108 @@ func main() {
 		cfg.Observability.ReadyPath,
 	)
 	metricsHandler.RegisterRoutes(app)
+	if sloEngine != nil {
+		slo.NewHandler(sloEngine).RegisterRoutes(app, cfg.Observability.SLO.Path)
+	}
 
 	log.Info("Observability routes registered",
 		zap.String("health", cfg.Observability.HealthPath),
 		zap.String("ready", cfg.Observability.ReadyPath),
 		zap.String("metrics", "/metrics"),
+		zap.String("slo", cfg.Observability.SLO.Path),
 	)
 
 	// ========================================
 	// 8. REGISTER MODULE ROUTES
 	// ========================================
+	// auth before the tenant middleware, the tenant may come from the credential claims
+	// and a credential of one tenant is rejected for another
 	apiBasePath := cfg.API.BasePath
+	if cfg.Security.AuthEnabled {
+		app.Use(apiBasePath, middleware.Authenticate(middleware.AuthConfig{
+			APIKeys:      apiKeyService,
+			APIKeyHeader: cfg.Security.APIKeyHeader,
+			APIKeyPrefix: domain.APIKeyPrefix,
+			JWTSecret:    []byte(cfg.Security.JWTSecret),
+			TenantClaim:  cfg.Tenant.Claim,
+			Sessions:     sessionService,
+			Logger:       log.WithComponent("auth"),
+			// login is public
+			Next: func(c *fiber.Ctx) bool {
+				return strings.HasPrefix(c.Path(), apiBasePath+http.AuthPath+"/")
+			},
+		}))
+		app.Use(apiBasePath+"/users", middleware.ScopeByMethod(domain.ScopeUsersRead, domain.ScopeUsersWrite))
+		app.Use(apiBasePath+"/api-keys", middleware.RequireScope(domain.ScopeAPIKeysManage))
+	}
+
+	// operations of the users module, their routes are registered below and documented in
+	// the OpenAPI spec
+	apiOperations := slices.Concat(
+		http.Operations(),
+		http.PrivacyOperations(),
+		http.APIKeyOperations(),
+		http.SessionOperations(),
+		http.AuthOperations(),
+		http.TwoFactorOperations(),
+	)
+
+	// rate limits after auth so quotas can be per principal, the login routes get a
+	// stricter one per IP against password guessing
+	if cfg.RateLimit.Enabled {
+		algorithm, err := middleware.ParseRateLimitAlgorithm(cfg.RateLimit.Algorithm)
+		if err != nil {
+			log.Fatal("invalid rate limit algorithm", zap.Error(err))
+		}
+		var store middleware.RateLimitStore = middleware.NewMemoryRateLimitStore()
+		if cfg.RateLimit.Store == "postgres" {
+			store = middleware.NewPostgresRateLimitStore(db.Pool)
+		}
+		limiter := func(name string, requests int, key middleware.RateLimitKey) fiber.Handler {
+			handler, err := middleware.RateLimiter(middleware.RateLimitConfig{
+				Name:    name,
+				Limit:   middleware.RateLimit{Requests: requests, Window: cfg.RateLimit.Window, Algorithm: algorithm},
+				Key:     key,
+				Store:   store,
+				Metrics: metricsSystem,
+				Logger:  log.WithComponent("rate_limit"),
+			})
+			if err != nil {
+				log.Fatal("invalid rate limit", zap.String("limiter", name), zap.Error(err))
+			}
+			return handler
+		}
+
+		if cfg.RateLimit.LoginRequests > 0 {
+			app.Use(apiBasePath+http.AuthPath, limiter("login", cfg.RateLimit.LoginRequests, middleware.RateLimitByIP()))
+		}
+		api := limiter("api", cfg.RateLimit.Requests, rateLimitKey(cfg.RateLimit.Key))
+		if cfg.RateLimit.Key == "route" {
+			// the route key reads the template the router matched, so the limiter is
+			// registered on every route of the operations instead of the base path
+			for _, op := range apiOperations {
+				app.Add(op.Method, openapi.JoinPath(apiBasePath, op.Path), api)
+			}
+		} else {
+			app.Use(apiBasePath, api)
+		}
+
+		log.Info("Rate limiting enabled",
+			zap.Int("requests", cfg.RateLimit.Requests),
+			zap.Duration("window", cfg.RateLimit.Window),
+			zap.String("algorithm", cfg.RateLimit.Algorithm),
+			zap.String("key", cfg.RateLimit.Key),
+			zap.String("store", cfg.RateLimit.Store),
+		)
+	}
+
+	// tenant middleware registered after the observability routes so probes and
+	// scrapes never need a tenant, and after metrics so the tenant label is recorded
+	app.Use(middleware.Tenant(middleware.TenantConfig{
+		Resolvers: tenantResolvers(cfg.Tenant),
+		Default:   cfg.Tenant.Default,
+	}))
+
 	app.Group(apiBasePath) // Will be used when modules are ready
 	log.Info("API base path configured",
 		zap.String("base_path", apiBasePath),
@@ -141,6 +453,11 @@ func main() {
 
 	// ✅ USERS MODULE ROUTES
 	http.RegisterRoutes(app, userHandler, apiBasePath)
+	http.RegisterPrivacyRoutes(app, privacyHandler, apiBasePath)
+	http.RegisterAPIKeyRoutes(app, apiKeyHandler, apiBasePath)
+	http.RegisterSessionRoutes(app, sessionHandler, apiBasePath)
+	http.RegisterAuthRoutes(app, authHandler, apiBasePath)
+	http.RegisterTwoFactorRoutes(app, twoFactorHandler, apiBasePath)
 
 	log.Info("Users module routes registered",
 		zap.String("prefix", apiBasePath+"/users"),
@@ -150,9 +467,34 @@ func main() {
 			"GET " + apiBasePath + "/users/:id",
 			"PUT " + apiBasePath + "/users/:id",
 			"DELETE " + apiBasePath + "/users/:id",
+			"GET " + apiBasePath + "/users/:id/data-export",
+			"POST " + apiBasePath + "/users/:id/erasure-jobs",
+			"GET " + apiBasePath + "/users/:id/erasure-jobs/:jobId",
+			"POST " + apiBasePath + "/api-keys",
+			"GET " + apiBasePath + "/api-keys",
+			"DELETE " + apiBasePath + "/api-keys/:id",
 		}),
 	)
 
+	// ✅ OPENAPI SPEC + DOCS UI (generated from each module Operations)
+	apiDoc := openapi.New(openapi.Info{
+		Title:   "Factorit Platform API",
+		Version: "1.0.0",
+	}).
+		Add(apiBasePath, apiOperations...).
+		Document()
+
+	openapiHandler, err := openapi.NewHandler(apiDoc, "/openapi.json")
+	if err != nil {
+		log.Fatal("failed to build OpenAPI document", zap.Error(err))
+	}
+	openapiHandler.RegisterRoutes(app, "/openapi.json", "/docs")
+
+	log.Info("API docs registered",
+		zap.String("spec", "/openapi.json"),
+		zap.String("docs", "/docs"),
+	)
+
 	// TODO: Products Module Routes (Future)
 	// productsGroup := api.Group("/products")
 	// productsHandlers.RegisterRoutes(productsGroup)
@@ -193,7 +535,62 @@ func main() {
 	}()
 
 	// ========================================
-	// 10. GRACEFUL SHUTDOWN
+	// 11. START gRPC SERVER (separate port)
+	// ========================================
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled {
+		// interceptors order: tracing first so logs and metrics run inside the span, auth
+		// before tenant like the HTTP middlewares, the tenant is checked against the credential
+		interceptors := []grpc.UnaryServerInterceptor{tracing.UnaryServerInterceptor()}
+		if cfg.Security.AuthEnabled {
+			interceptors = append(interceptors, auth.UnaryServerInterceptor(auth.GRPCConfig{
+				Authenticator: auth.Authenticator{
+					APIKeys:      apiKeyService,
+					APIKeyPrefix: domain.APIKeyPrefix,
+					JWTSecret:    []byte(cfg.Security.JWTSecret),
+					TenantClaim:  cfg.Tenant.Claim,
+					Sessions:     sessionService,
+				},
+				APIKeyMetadata: cfg.Security.APIKeyHeader,
+				Scopes:         usersgrpc.MethodScopes(),
+				Logger:         log.WithComponent("auth"),
+			}))
+		}
+		interceptors = append(interceptors,
+			tenant.UnaryServerInterceptor("x-tenant-id", cfg.Tenant.Default),
+			logger.UnaryServerInterceptor(log.WithComponent("grpc")),
+			metrics.UnaryServerInterceptor(metrics.GRPCMetricsConfig{
+				Metrics: grpcMetrics,
+				// same objectives as the HTTP routes, RPCs keyed by full method name
+				SLO: metrics.LatencyThresholds{
+					Default: cfg.Observability.SLO.LatencyThreshold,
+					Routes:  cfg.Observability.SLO.LatencyRoutes,
+				},
+			}),
+		)
+		grpcServer = grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+		userServer.Register(grpcServer)
+
+		go func() {
+			addr := cfg.GetGRPCAddress()
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				log.Fatal("failed to listen for gRPC", zap.Error(err))
+			}
+
+			log.Info("Starting gRPC server",
+				zap.String("address", addr),
+				zap.Strings("services", []string{"users.v1.UserService"}),
+			)
+
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatal("failed to start gRPC server", zap.Error(err))
+			}
+		}()
+	}
+
+	// ========================================
+	// 12. GRACEFUL SHUTDOWN
 	// ========================================
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
@@ -205,15 +602,49 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if grpcServer != nil {
+		// GracefulStop waits for every call, streams and stuck calls would block the
+		// shutdown, past the timeout the remaining ones are cancelled
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			log.Error("gRPC server forced to stop")
+			grpcServer.Stop()
+		}
+	}
+
 	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
 		log.Error("❌ Server forced to shutdown", zap.Error(err))
 	}
 
+	// a purge batch in flight finishes, the next ones are not started
+	stopPurge()
+	select {
+	case <-purgeDone:
+	case <-shutdownCtx.Done():
+		log.Error("retention purge still running at shutdown")
+	}
+
+	// erasures already accepted should not be left half way (running forever)
+	if err := privacyService.Wait(shutdownCtx); err != nil {
+		log.Error("erasure jobs still running at shutdown", zap.Error(err))
+	}
+
+	// last, the spans of the requests above are flushed
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Error("failed to flush traces at shutdown", zap.Error(err))
+	}
+
 	log.Info("✅ Server stopped successfully")
 }
 
 // customErrorHandler handles errors globally
-func customErrorHandler(log *logger.Logger, metrics *metrics.Metrics, serviceName string) fiber.ErrorHandler {
+func customErrorHandler(log *logger.Logger) fiber.ErrorHandler {
 	return func(c *fiber.Ctx, err error) error {
 		code := fiber.StatusInternalServerError
 
@@ -221,28 +652,67 @@ func customErrorHandler(log *logger.Logger, metrics *metrics.Metrics, serviceNam
 			code = e.Code
 		}
 
-		//Register metrics for errors, especially 404s from catch-all
-		method := c.Method()
-		path := c.Path()
-		status := strconv.Itoa(code)
+		// error metrics are recorded by metrics.Middleware, it runs this handler to get
+		// the final status
 
-		// Record error metrics based on status code
-		if code >= 400 && code < 500 {
-			metrics.RecordHTTPClientError(serviceName, method, path, status)
-		} else if code >= 500 {
-			metrics.RecordHTTPServerError(serviceName, method, path, status)
+		principalType := "anonymous"
+		if principal, ok := auth.FromContext(c.UserContext()); ok {
+			principalType = string(principal.Type)
 		}
-
-		log.Error("HTTP error",
+		// the request logger carries request_id and tenant_id (once resolved), Ctx the trace
+		middleware.LoggerFrom(c, log).Ctx(c.UserContext()).Error("HTTP error",
 			zap.Error(err),
+			zap.String("principal_type", principalType),
 			zap.Int("status_code", code),
 			zap.String("method", c.Method()),
 			zap.String("path", c.Path()),
 		)
 
 		return c.Status(code).JSON(fiber.Map{
-			"error":   err.Error(),
-			"message": "An error occurred processing your request",
+			"error":      err.Error(),
+			"message":    "An error occurred processing your request",
+			"request_id": middleware.RequestIDFrom(c),
 		})
 	}
 }
+
+// tenantResolvers builds the tenant resolvers in the configured order
+func tenantResolvers(cfg config.TenantConfig) []middleware.TenantResolver {
+	resolvers := make([]middleware.TenantResolver, 0, len(cfg.Resolvers))
+	for _, name := range cfg.Resolvers {
+		switch name {
+		case "header":
+			resolvers = append(resolvers, middleware.TenantFromHeader(cfg.Header))
+		case "subdomain":
+			resolvers = append(resolvers, middleware.TenantFromSubdomain(cfg.BaseDomain))
+		case "claim":
+			resolvers = append(resolvers, middleware.TenantFromClaim(cfg.Claim))
+		}
+	}
+	return resolvers
+}
+
+// rateLimitKey maps RATE_LIMIT_KEY to the middleware key, validated by config.Validate
+func rateLimitKey(name string) middleware.RateLimitKey {
+	switch name {
+	case "ip":
+		return middleware.RateLimitByIP()
+	case "route":
+		return middleware.RateLimitByRoute()
+	default:
+		return middleware.RateLimitByPrincipal()
+	}
+}
+
+// totpSecretBox the cipher of the TOTP secrets, the key is derived from the JWT secret when
+// not configured (development only, config.Validate requires it in production)
+func totpSecretBox(cfg config.SecurityConfig) (*secretbox.Box, error) {
+	if cfg.TOTPEncryptionKey == "" {
+		return secretbox.New(secretbox.DeriveKey([]byte(cfg.JWTSecret), "totp-secret-encryption"))
+	}
+	key, err := base64.StdEncoding.DecodeString(cfg.TOTPEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return secretbox.New(key)
+}
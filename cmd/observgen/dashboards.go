@@ -0,0 +1,430 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+)
+
+// datasourceUID uid of the Prometheus datasource of the provisioning (generateProvisioning)
+const datasourceUID = "prometheus"
+
+// grid width of a Grafana dashboard
+const gridWidth = 24
+
+// the Grafana dashboard JSON model, only the fields the generated dashboards set
+type (
+	Dashboard struct {
+		UID           string    `json:"uid"`
+		Title         string    `json:"title"`
+		Tags          []string  `json:"tags"`
+		Timezone      string    `json:"timezone"`
+		Refresh       string    `json:"refresh"`
+		Time          TimeRange `json:"time"`
+		Editable      bool      `json:"editable"`
+		SchemaVersion int       `json:"schemaVersion"`
+		Version       int       `json:"version"`
+		Panels        []Panel   `json:"panels"`
+	}
+
+	TimeRange struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+
+	Panel struct {
+		ID          int            `json:"id"`
+		Type        string         `json:"type"`
+		Title       string         `json:"title"`
+		GridPos     GridPos        `json:"gridPos"`
+		Datasource  *Datasource    `json:"datasource,omitempty"`
+		Targets     []Target       `json:"targets,omitempty"`
+		FieldConfig *FieldConfig   `json:"fieldConfig,omitempty"`
+		Options     map[string]any `json:"options,omitempty"`
+	}
+
+	GridPos struct {
+		H int `json:"h"`
+		W int `json:"w"`
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+
+	Datasource struct {
+		Type string `json:"type"`
+		UID  string `json:"uid"`
+	}
+
+	Target struct {
+		RefID        string      `json:"refId"`
+		Datasource   *Datasource `json:"datasource"`
+		Expr         string      `json:"expr"`
+		LegendFormat string      `json:"legendFormat,omitempty"`
+		Instant      bool        `json:"instant,omitempty"`
+		Format       string      `json:"format,omitempty"`
+	}
+
+	FieldConfig struct {
+		Defaults  FieldDefaults `json:"defaults"`
+		Overrides []any         `json:"overrides"`
+	}
+
+	FieldDefaults struct {
+		Unit       string      `json:"unit,omitempty"`
+		Thresholds *Thresholds `json:"thresholds,omitempty"`
+	}
+
+	Thresholds struct {
+		Mode  string          `json:"mode"`
+		Steps []ThresholdStep `json:"steps"`
+	}
+
+	ThresholdStep struct {
+		Color string   `json:"color"`
+		Value *float64 `json:"value"`
+	}
+)
+
+// query a PromQL expression and its legend
+type query struct {
+	expr   string
+	legend string
+}
+
+// dashboardBuilder lays out the panels left to right in rows of the grid width
+type dashboardBuilder struct {
+	dashboard Dashboard
+	x, y      int
+	rowHeight int
+}
+
+func newDashboard(uid, title string, tags ...string) *dashboardBuilder {
+	return &dashboardBuilder{dashboard: Dashboard{
+		UID:           uid,
+		Title:         title,
+		Tags:          tags,
+		Timezone:      "browser",
+		Refresh:       "30s",
+		Time:          TimeRange{From: "now-1h", To: "now"},
+		SchemaVersion: 39,
+		Version:       1,
+	}}
+}
+
+// row starts a new row titled title
+func (b *dashboardBuilder) row(title string) *dashboardBuilder {
+	b.newLine()
+	b.add(Panel{Type: "row", Title: title}, gridWidth, 1)
+	b.newLine()
+	return b
+}
+
+// stat a single value panel of the last value of expr
+func (b *dashboardBuilder) stat(title, unit, expr string, thresholds ...float64) *dashboardBuilder {
+	fieldConfig := fieldConfig(unit)
+	if len(thresholds) > 0 {
+		steps := []ThresholdStep{{Color: "green"}}
+		for i, value := range thresholds {
+			steps = append(steps, ThresholdStep{Color: []string{"yellow", "red"}[min(i, 1)], Value: &value})
+		}
+		fieldConfig.Defaults.Thresholds = &Thresholds{Mode: "absolute", Steps: steps}
+	}
+	return b.add(Panel{
+		Type:        "stat",
+		Title:       title,
+		Targets:     targets(false, query{expr: expr}),
+		FieldConfig: fieldConfig,
+		Options: map[string]any{
+			"colorMode":     "background",
+			"graphMode":     "area",
+			"reduceOptions": map[string]any{"calcs": []string{"lastNotNull"}, "fields": "", "values": false},
+		},
+	}, 6, 4)
+}
+
+// timeseries a graph of the queries over time
+func (b *dashboardBuilder) timeseries(title, unit string, width int, queries ...query) *dashboardBuilder {
+	return b.add(Panel{
+		Type:        "timeseries",
+		Title:       title,
+		Targets:     targets(false, queries...),
+		FieldConfig: fieldConfig(unit),
+		Options: map[string]any{
+			"legend":  map[string]any{"displayMode": "list", "placement": "bottom", "showLegend": true},
+			"tooltip": map[string]any{"mode": "multi", "sort": "desc"},
+		},
+	}, width, 8)
+}
+
+// table the current value of the queries, one row per series
+func (b *dashboardBuilder) table(title, unit string, width int, queries ...query) *dashboardBuilder {
+	return b.add(Panel{
+		Type:        "table",
+		Title:       title,
+		Targets:     targets(true, queries...),
+		FieldConfig: fieldConfig(unit),
+		Options:     map[string]any{"showHeader": true},
+	}, width, 8)
+}
+
+// piechart the share of every series of the query
+func (b *dashboardBuilder) piechart(title string, width int, q query) *dashboardBuilder {
+	return b.add(Panel{
+		Type:        "piechart",
+		Title:       title,
+		Targets:     targets(true, q),
+		FieldConfig: fieldConfig("short"),
+		Options: map[string]any{
+			"pieType":       "pie",
+			"legend":        map[string]any{"displayMode": "table", "placement": "right", "showLegend": true, "values": []string{"value", "percent"}},
+			"reduceOptions": map[string]any{"calcs": []string{"lastNotNull"}, "fields": "", "values": false},
+		},
+	}, width, 8)
+}
+
+// add places panel after the previous one, on a new line when it does not fit
+func (b *dashboardBuilder) add(panel Panel, width, height int) *dashboardBuilder {
+	if b.x+width > gridWidth {
+		b.newLine()
+	}
+	panel.ID = len(b.dashboard.Panels) + 1
+	panel.GridPos = GridPos{H: height, W: width, X: b.x, Y: b.y}
+	if panel.Type != "row" {
+		panel.Datasource = prometheusDatasource()
+	}
+	b.dashboard.Panels = append(b.dashboard.Panels, panel)
+	b.x += width
+	b.rowHeight = max(b.rowHeight, height)
+	return b
+}
+
+func (b *dashboardBuilder) newLine() {
+	if b.x == 0 {
+		return
+	}
+	b.y += b.rowHeight
+	b.x, b.rowHeight = 0, 0
+}
+
+func prometheusDatasource() *Datasource {
+	return &Datasource{Type: "prometheus", UID: datasourceUID}
+}
+
+func fieldConfig(unit string) *FieldConfig {
+	return &FieldConfig{Defaults: FieldDefaults{Unit: unit}, Overrides: []any{}}
+}
+
+// targets the queries with refIds A, B..., instant ones are shown as tables
+func targets(instant bool, queries ...query) []Target {
+	result := make([]Target, 0, len(queries))
+	for i, q := range queries {
+		target := Target{
+			RefID:        string(rune('A' + i)),
+			Datasource:   prometheusDatasource(),
+			Expr:         q.expr,
+			LegendFormat: q.legend,
+		}
+		if instant {
+			target.Instant = true
+			target.Format = "table"
+		}
+		result = append(result, target)
+	}
+	return result
+}
+
+// dashboardsGenerator builds the dashboards from the metrics of the inventory, the queries
+// select the series of the service label
+type dashboardsGenerator struct {
+	*resolver
+	serviceName string
+}
+
+// generateDashboards the HTTP RED, errors, database and business dashboards of the
+// service, keyed by file name
+func generateDashboards(serviceName string, inventory metrics.Inventory) (map[string]Dashboard, error) {
+	g := &dashboardsGenerator{resolver: &resolver{inventory: inventory}, serviceName: serviceName}
+
+	dashboards := map[string]Dashboard{
+		serviceName + "-http.json":     g.httpDashboard(),
+		serviceName + "-errors.json":   g.errorsDashboard(),
+		serviceName + "-database.json": g.databaseDashboard(),
+		serviceName + "-business.json": g.businessDashboard(),
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	return dashboards, nil
+}
+
+// rate the per second rate of the metric of the service summed by labels
+func (g *dashboardsGenerator) rate(metric, by string) string {
+	return g.sum(fmt.Sprintf("rate(%s{service=%q}[$__rate_interval])", g.metric(metric), g.serviceName), by)
+}
+
+// countRate the per second rate of the observations of the histogram or summary metric
+func (g *dashboardsGenerator) countRate(metric, by string) string {
+	return g.sum(fmt.Sprintf("rate(%s_count{service=%q}[$__rate_interval])", g.metric(metric), g.serviceName), by)
+}
+
+// quantile the q quantile of the histogram metric of the service by labels
+func (g *dashboardsGenerator) quantile(q, metric, by string) string {
+	labels := "le"
+	if by != "" {
+		labels = by + ", le"
+	}
+	return fmt.Sprintf("histogram_quantile(%s, sum by (%s) (rate(%s_bucket{service=%q}[$__rate_interval])))",
+		q, labels, g.metric(metric), g.serviceName)
+}
+
+func (g *dashboardsGenerator) sum(expr, by string) string {
+	if by == "" {
+		return fmt.Sprintf("sum(%s)", expr)
+	}
+	return fmt.Sprintf("sum by (%s) (%s)", by, expr)
+}
+
+// httpDashboard rate, errors and duration of the HTTP requests
+func (g *dashboardsGenerator) httpDashboard() Dashboard {
+	requests := "http_request_total"
+	duration := "http_request_duration_seconds"
+
+	return newDashboard(g.serviceName+"-http", g.serviceName+" / HTTP RED", g.serviceName, "http", "red").
+		row("Overview").
+		stat("Requests/s", "reqps", g.rate(requests, "")).
+		stat("5xx ratio", "percentunit", g.rate("http_server_errors_total", "")+" / "+g.rate(requests, ""), 0.001, 0.01).
+		stat("P95 latency", "s", g.quantile("0.95", duration, ""), 0.5, 1).
+		stat("Active connections", "short", fmt.Sprintf("sum(%s{service=%q})", g.metric("http_active_connections"), g.serviceName)).
+		row("Rate").
+		timeseries("Requests by route", "reqps", 12, query{g.rate(requests, "method, path"), "{{method}} {{path}}"}).
+		timeseries("Requests by status", "reqps", 12, query{g.rate(requests, "status"), "{{status}}"}).
+		row("Errors").
+		timeseries("5xx ratio by route", "percentunit", 12,
+			query{g.rate("http_server_errors_total", "method, path") + " / " + g.rate(requests, "method, path"), "{{method}} {{path}}"}).
+		timeseries("4xx ratio by route", "percentunit", 12,
+			query{g.rate("http_client_errors_total", "method, path") + " / " + g.rate(requests, "method, path"), "{{method}} {{path}}"}).
+		row("Duration").
+		timeseries("Latency percentiles", "s", 12,
+			query{g.quantile("0.50", duration, ""), "p50"},
+			query{g.quantile("0.95", duration, ""), "p95"},
+			query{g.quantile("0.99", duration, ""), "p99"}).
+		timeseries("P99 latency by route", "s", 12, query{g.quantile("0.99", duration, "method, path"), "{{method}} {{path}}"}).
+		timeseries("Requests within the latency SLO", "percentunit", 12,
+			query{g.rate("http_requests_within_slo_total", "method, path") + " / " + g.rate(requests, "method, path"), "{{method}} {{path}}"}).
+		timeseries("Slow and rate limited requests", "reqps", 12,
+			query{g.rate("http_slow_requests_total", "method, path"), "slow {{method}} {{path}}"},
+			query{g.rate("rate_limited_requests_total", "method, path"), "limited {{method}} {{path}}"},
+			query{g.rate("rate_limit_store_failures_total", "limiter"), "store failures {{limiter}}"}).
+		row("Size").
+		timeseries("Average request size", "bytes", 12, query{g.summaryMean("http_request_size_bytes", "method, path"), "{{method}} {{path}}"}).
+		timeseries("Average response size", "bytes", 12, query{g.summaryMean("http_response_size_bytes", "method, path"), "{{method}} {{path}}"}).
+		dashboard
+}
+
+// summaryMean the mean of the observations of the summary metric by labels
+func (g *dashboardsGenerator) summaryMean(metric, by string) string {
+	selector := fmt.Sprintf("{service=%q}[$__rate_interval]", g.serviceName)
+	name := g.metric(metric)
+	return g.sum("rate("+name+"_sum"+selector+")", by) + " / " + g.sum("rate("+name+"_count"+selector+")", by)
+}
+
+// errorsDashboard the HTTP errors by status and route, and the gRPC and query errors
+func (g *dashboardsGenerator) errorsDashboard() Dashboard {
+	requests := "http_request_total"
+
+	return newDashboard(g.serviceName+"-errors", g.serviceName+" / Errors", g.serviceName, "errors", "status-codes").
+		row("Error breakdown by status code").
+		timeseries("4xx client errors by status", "reqps", 12, query{g.rate("http_client_errors_total", "status"), "{{status}}"}).
+		timeseries("5xx server errors by status", "reqps", 12, query{g.rate("http_server_errors_total", "status"), "{{status}}"}).
+		row("Error details").
+		table("Errors by route", "reqps", 24,
+			query{g.rate("http_client_errors_total", "status, method, path"), "4xx"},
+			query{g.rate("http_server_errors_total", "status, method, path"), "5xx"}).
+		row("Status code distribution").
+		piechart("Status codes (last hour)", 12, query{
+			fmt.Sprintf("sum by (status) (increase(%s{service=%q}[1h]))", g.metric(requests), g.serviceName), "{{status}}",
+		}).
+		table("Top endpoints with errors", "reqps", 12, query{
+			"topk(10, " + g.rate("http_client_errors_total", "method, path, status") + " or " + g.rate("http_server_errors_total", "method, path, status") + ")", "",
+		}).
+		row("gRPC and database").
+		timeseries("gRPC errors by code", "reqps", 12,
+			query{g.rate("grpc_server_client_errors_total", "method, code"), "client {{method}} {{code}}"},
+			query{g.rate("grpc_server_server_errors_total", "method, code"), "server {{method}} {{code}}"}).
+		timeseries("Query errors by SQLSTATE", "reqps", 12, query{g.rate("db_query_errors_total", "operation, sqlstate"), "{{operation}} {{sqlstate}}"}).
+		dashboard
+}
+
+// databaseDashboard the pgx pool statistics and the query metrics
+func (g *dashboardsGenerator) databaseDashboard() Dashboard {
+	pool := func(metric string) string {
+		return fmt.Sprintf("%s{service=%q}", g.metric(metric), g.serviceName)
+	}
+	poolRate := func(metric string) string {
+		return fmt.Sprintf("sum by (pool) (rate(%s[$__rate_interval]))", pool(metric))
+	}
+
+	return newDashboard(g.serviceName+"-database", g.serviceName+" / Database", g.serviceName, "database", "pgxpool").
+		row("Pool").
+		stat("Pool utilization", "percentunit",
+			fmt.Sprintf("sum(%s) / sum(%s)", pool("pgxpool_acquired_connections"), pool("pgxpool_max_connections")), 0.7, 0.9).
+		stat("Acquired connections", "short", "sum("+pool("pgxpool_acquired_connections")+")").
+		stat("Idle connections", "short", "sum("+pool("pgxpool_idle_connections")+")").
+		stat("Max connections", "short", "sum("+pool("pgxpool_max_connections")+")").
+		timeseries("Connections by state", "short", 12,
+			query{pool("pgxpool_acquired_connections"), "acquired {{pool}}"},
+			query{pool("pgxpool_idle_connections"), "idle {{pool}}"},
+			query{pool("pgxpool_constructing_connections"), "constructing {{pool}}"},
+			query{pool("pgxpool_max_connections"), "max {{pool}}"}).
+		timeseries("Average acquire wait", "s", 12, query{
+			poolRate("pgxpool_acquire_duration_seconds_total") + " / " + poolRate("pgxpool_acquires_total"), "{{pool}}",
+		}).
+		timeseries("Acquires", "ops", 12,
+			query{poolRate("pgxpool_acquires_total"), "acquired {{pool}}"},
+			query{poolRate("pgxpool_empty_acquires_total"), "waited {{pool}}"},
+			query{poolRate("pgxpool_canceled_acquires_total"), "canceled {{pool}}"}).
+		timeseries("Connection churn", "ops", 12,
+			query{poolRate("pgxpool_new_connections_total"), "opened {{pool}}"},
+			query{poolRate("pgxpool_max_lifetime_destroys_total"), "max lifetime {{pool}}"},
+			query{poolRate("pgxpool_max_idle_destroys_total"), "max idle {{pool}}"}).
+		row("Queries").
+		timeseries("Queries by operation", "ops", 12, query{g.countRate("db_query_duration_seconds", "operation"), "{{operation}}"}).
+		timeseries("P95 query duration by operation", "s", 12, query{g.quantile("0.95", "db_query_duration_seconds", "operation"), "{{operation}}"}).
+		dashboard
+}
+
+// businessDashboard the users events and the retention purge
+func (g *dashboardsGenerator) businessDashboard() Dashboard {
+	increase := func(metric string) string {
+		return fmt.Sprintf("sum(increase(%s{service=%q}[$__range]))", g.metric(metric), g.serviceName)
+	}
+
+	return newDashboard(g.serviceName+"-business", g.serviceName+" / Business", g.serviceName, "business", "users").
+		row("Users").
+		stat("Users created", "short", increase("users_created_total")).
+		stat("Users updated", "short", increase("users_updated_total")).
+		stat("Users deleted", "short", increase("users_deleted_total")).
+		stat("Purged users", "short", increase("users_purge_rows_total")).
+		timeseries("User events", "ops", 24,
+			query{g.rate("users_created_total", ""), "created"},
+			query{g.rate("users_updated_total", ""), "updated"},
+			query{g.rate("users_deleted_total", ""), "deleted"}).
+		row("Retention purge").
+		stat("Since the last successful purge", "s",
+			fmt.Sprintf("time() - max(%s{service=%q})", g.metric("users_purge_last_success_timestamp_seconds"), g.serviceName), 86400, 172800).
+		timeseries("Purged rows by mode", "short", 9, query{
+			fmt.Sprintf("sum by (mode) (increase(%s{service=%q}[$__rate_interval]))", g.metric("users_purge_rows_total"), g.serviceName), "{{mode}}",
+		}).
+		timeseries("P95 purge duration by result", "s", 9, query{g.quantile("0.95", "users_purge_run_duration_seconds", "result"), "{{result}}"}).
+		dashboard
+}
+
+// marshalDashboard the indented JSON of dashboard
+func marshalDashboard(dashboard Dashboard) ([]byte, error) {
+	content, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode dashboard %s: %w", dashboard.UID, err)
+	}
+	return append(content, '\n'), nil
+}
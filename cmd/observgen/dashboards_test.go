@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/config"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// the repository directories the generated Grafana files are checked against
+const (
+	dashboardsDir   = "../../config/grafana/dashboards"
+	provisioningDir = "../../config/grafana/provisioning"
+)
+
+func TestGenerateDashboards_ReferenceRegisteredMetrics(t *testing.T) {
+	for name, metricsConfig := range map[string]config.MetricsConfig{
+		"default":   {},
+		"namespace": {Namespace: "acme"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			inventory := describeService("factorit", metricsConfig, nil)
+			dashboards, err := generateDashboards("factorit", inventory)
+			require.NoError(t, err)
+			require.Len(t, dashboards, 4)
+
+			for file, dashboard := range dashboards {
+				assert.Equal(t, dashboard.UID+".json", file)
+				for _, panel := range dashboard.Panels {
+					for _, target := range panel.Targets {
+						// the series of other services in the same Prometheus are never summed in
+						assert.Contains(t, target.Expr, `service="factorit"`, panel.Title)
+						for _, metric := range metricNames(target.Expr) {
+							base := metric
+							for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+								if trimmed, ok := strings.CutSuffix(metric, suffix); ok && inventory.Has(trimmed) {
+									base = trimmed
+								}
+							}
+							assert.True(t, inventory.Has(base), "%s of %q in %s is not a registered metric", metric, target.Expr, panel.Title)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateDashboards_Layout(t *testing.T) {
+	inventory := describeService("factorit", config.MetricsConfig{}, nil)
+	dashboards, err := generateDashboards("factorit", inventory)
+	require.NoError(t, err)
+
+	for _, dashboard := range dashboards {
+		ids := map[int]bool{}
+		for i, panel := range dashboard.Panels {
+			assert.False(t, ids[panel.ID], "duplicated panel id %d in %s", panel.ID, dashboard.UID)
+			ids[panel.ID] = true
+			assert.LessOrEqual(t, panel.GridPos.X+panel.GridPos.W, gridWidth, panel.Title)
+
+			if panel.Type == "row" {
+				assert.Empty(t, panel.Targets)
+				continue
+			}
+			require.NotEmpty(t, panel.Targets, panel.Title)
+			assert.Equal(t, datasourceUID, panel.Datasource.UID)
+			// panels never overlap the previous one of the same line
+			if i > 0 && dashboard.Panels[i-1].GridPos.Y == panel.GridPos.Y {
+				previous := dashboard.Panels[i-1].GridPos
+				assert.Equal(t, previous.X+previous.W, panel.GridPos.X, panel.Title)
+			}
+		}
+	}
+}
+
+func TestGenerateDashboards_UnknownMetric(t *testing.T) {
+	_, err := generateDashboards("factorit", metrics.Inventory{{Name: "http_request_total"}})
+	assert.Error(t, err)
+}
+
+func TestGenerateProvisioning(t *testing.T) {
+	files := generateProvisioning("factorit", "http://prometheus:9090")
+
+	datasources, ok := files["datasources/prometheus.yml"].(DatasourceProvisioning)
+	require.True(t, ok)
+	require.Len(t, datasources.Datasources, 1)
+	// the uid the generated panels query
+	assert.Equal(t, datasourceUID, datasources.Datasources[0].UID)
+
+	dashboards, ok := files["dashboards/factorit.yml"].(DashboardProvisioning)
+	require.True(t, ok)
+	assert.Equal(t, grafanaDashboardsPath, dashboards.Providers[0].Options.Path)
+}
+
+// the committed dashboards and provisioning are the output of the generator, run make
+// observgen after changing the metrics
+func TestGrafanaFilesUpToDate(t *testing.T) {
+	inventory := describeService("factorit", config.MetricsConfig{}, nil)
+	dashboards, err := generateDashboards("factorit", inventory)
+	require.NoError(t, err)
+
+	for name, dashboard := range dashboards {
+		content, err := marshalDashboard(dashboard)
+		require.NoError(t, err)
+		committed, err := os.ReadFile(filepath.Join(dashboardsDir, name))
+		require.NoError(t, err, "run make observgen")
+		assert.JSONEq(t, string(content), string(committed), "run make observgen")
+
+		var model map[string]any
+		require.NoError(t, json.Unmarshal(committed, &model))
+		assert.Equal(t, dashboard.UID, model["uid"])
+	}
+
+	for name, provisioning := range generateProvisioning("factorit", "http://prometheus:9090") {
+		content, err := marshalYAML(provisioning)
+		require.NoError(t, err)
+		committed, err := os.ReadFile(filepath.Join(provisioningDir, name))
+		require.NoError(t, err, "run make observgen")
+		assert.Equal(t, string(content), string(committed), "run make observgen")
+
+		var parsed map[string]any
+		require.NoError(t, yaml.Unmarshal(committed, &parsed))
+		assert.Equal(t, 1, parsed["apiVersion"])
+	}
+}
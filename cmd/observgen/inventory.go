@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/cristianortiz/observ-monit-go/pkg/config"
+	"github.com/cristianortiz/observ-monit-go/pkg/database"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/slo"
+)
+
+// describeService the inventory of the metrics cmd/factorit registers with the same
+// options, from the descriptor tables of their constructors, so the generated files follow
+// the names in the code
+func describeService(serviceName string, metricsConfig config.MetricsConfig, objectives []slo.Objective) metrics.Inventory {
+	inventory := metrics.Describe(serviceName,
+		metrics.WithNamespace(metricsConfig.Namespace),
+		metrics.WithConstLabels(metricsConfig.ConstLabels),
+	).Add(metricsConfig.Namespace, database.PoolDescriptors...)
+
+	// the SLO gauges are only registered when there are objectives
+	if len(objectives) > 0 {
+		inventory = inventory.Add(metricsConfig.Namespace, slo.Descriptors...)
+	}
+	return inventory
+}
@@ -0,0 +1,90 @@
+// Command observgen generates the Prometheus rules and the Grafana dashboards of the
+// service from the metrics it registers and its SLO objectives, so both follow the names
+// in the code
+//
+// Usage (from the repository root, see make observgen):
+//
+//	go run ./cmd/observgen -objectives config/slo/objectives.json
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/config"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/slo"
+)
+
+func main() {
+	service := flag.String("service", "factorit", "service name, the service label of the metrics")
+	objectivesPath := flag.String("objectives", "", "JSON file of SLO objectives, SLO_OBJECTIVES when empty")
+	rulesPath := flag.String("rules", "pkg/config/prometheus/rules/factorit.rules.yml", "output Prometheus rule file")
+	dashboardsDir := flag.String("dashboards", "config/grafana/dashboards", "output directory of the Grafana dashboards")
+	provisioningDir := flag.String("provisioning", "config/grafana/provisioning", "output directory of the Grafana provisioning")
+	prometheusURL := flag.String("prometheus-url", "http://prometheus:9090", "URL of the provisioned Prometheus datasource")
+	flag.Parse()
+
+	cfg, err := config.Load(*service)
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	sloConfig := cfg.Observability.SLO
+	if *objectivesPath != "" {
+		sloConfig.ObjectivesPath = *objectivesPath
+	}
+	objectives, err := slo.ObjectivesFromConfig(sloConfig, cfg.API.BasePath)
+	if err != nil {
+		log.Fatalf("failed to load SLO objectives: %v", err)
+	}
+
+	inventory := describeService(*service, cfg.Observability.Metrics, objectives)
+
+	rules, err := generateRules(*service, inventory, objectives)
+	if err != nil {
+		log.Fatalf("failed to generate the rules: %v", err)
+	}
+	content, err := marshalYAML(rules)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := writeFile(*rulesPath, content); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d rule groups to %s", len(rules.Groups), *rulesPath)
+
+	dashboards, err := generateDashboards(*service, inventory)
+	if err != nil {
+		log.Fatalf("failed to generate the dashboards: %v", err)
+	}
+	for name, dashboard := range dashboards {
+		content, err := marshalDashboard(dashboard)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := writeFile(filepath.Join(*dashboardsDir, name), content); err != nil {
+			log.Fatal(err)
+		}
+	}
+	log.Printf("wrote %d dashboards to %s", len(dashboards), *dashboardsDir)
+
+	for name, provisioning := range generateProvisioning(*service, *prometheusURL) {
+		content, err := marshalYAML(provisioning)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := writeFile(filepath.Join(*provisioningDir, name), content); err != nil {
+			log.Fatal(err)
+		}
+	}
+	log.Printf("wrote the Grafana provisioning to %s", *provisioningDir)
+}
+
+// writeFile writes content to path creating its directory
+func writeFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
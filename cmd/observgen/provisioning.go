@@ -0,0 +1,74 @@
+package main
+
+import "path"
+
+// where docker-compose mounts config/grafana/dashboards in the Grafana container
+const grafanaDashboardsPath = "/var/lib/grafana/dashboards"
+
+// the Grafana provisioning files, read on startup from /etc/grafana/provisioning
+type (
+	DatasourceProvisioning struct {
+		APIVersion  int                  `yaml:"apiVersion"`
+		Datasources []DatasourceProvider `yaml:"datasources"`
+	}
+
+	DatasourceProvider struct {
+		Name      string `yaml:"name"`
+		Type      string `yaml:"type"`
+		UID       string `yaml:"uid"`
+		Access    string `yaml:"access"`
+		URL       string `yaml:"url"`
+		IsDefault bool   `yaml:"isDefault"`
+		Editable  bool   `yaml:"editable"`
+	}
+
+	DashboardProvisioning struct {
+		APIVersion int                 `yaml:"apiVersion"`
+		Providers  []DashboardProvider `yaml:"providers"`
+	}
+
+	DashboardProvider struct {
+		Name                  string                   `yaml:"name"`
+		Folder                string                   `yaml:"folder"`
+		Type                  string                   `yaml:"type"`
+		DisableDeletion       bool                     `yaml:"disableDeletion"`
+		AllowUIUpdates        bool                     `yaml:"allowUiUpdates"`
+		UpdateIntervalSeconds int                      `yaml:"updateIntervalSeconds"`
+		Options               DashboardProviderOptions `yaml:"options"`
+	}
+
+	DashboardProviderOptions struct {
+		Path string `yaml:"path"`
+	}
+)
+
+// generateProvisioning the Prometheus datasource (the uid the dashboards use) and the
+// provider of the generated dashboards, keyed by their path in the provisioning directory
+//
+// The dashboards are not editable in the UI, changes go through cmd/observgen
+func generateProvisioning(serviceName, prometheusURL string) map[string]any {
+	return map[string]any{
+		path.Join("datasources", "prometheus.yml"): DatasourceProvisioning{
+			APIVersion: 1,
+			Datasources: []DatasourceProvider{{
+				Name:      "Prometheus",
+				Type:      "prometheus",
+				UID:       datasourceUID,
+				Access:    "proxy",
+				URL:       prometheusURL,
+				IsDefault: true,
+			}},
+		},
+		path.Join("dashboards", serviceName+".yml"): DashboardProvisioning{
+			APIVersion: 1,
+			Providers: []DashboardProvider{{
+				Name:                  serviceName,
+				Folder:                serviceName,
+				Type:                  "file",
+				DisableDeletion:       true,
+				UpdateIntervalSeconds: 30,
+				Options:               DashboardProviderOptions{Path: grafanaDashboardsPath},
+			}},
+		},
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/slo"
+	"gopkg.in/yaml.v3"
+)
+
+// generatedHeader first lines of the generated files
+const generatedHeader = "# Code generated by cmd/observgen. DO NOT EDIT.\n"
+
+// RuleFile a Prometheus rule file (rule_files of prometheus.yml)
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule a recording rule (Record) or an alerting rule (Alert)
+type Rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// sliErrorRatio name of the recorded error ratio of the SLIs over window
+func sliErrorRatio(window string) string {
+	return "slo:sli_error:ratio_rate" + window
+}
+
+// resolver resolves the names of the registered metrics, a name that is no longer
+// registered fails the generation instead of producing a query that never matches
+type resolver struct {
+	inventory metrics.Inventory
+	err       error
+}
+
+// metric the registered name of name, namespace included
+func (r *resolver) metric(name string) string {
+	descriptor, err := r.inventory.Lookup(name)
+	if err != nil {
+		if r.err == nil {
+			r.err = err
+		}
+		return name
+	}
+	return descriptor.Name
+}
+
+// rulesGenerator builds the rules from the metrics of the inventory
+type rulesGenerator struct {
+	*resolver
+	serviceName string
+}
+
+// generateRules the recording rules of the HTTP, gRPC, database and business metrics and
+// the multi-window burn rate alerts (slo.BurnRateAlerts) of every objective
+func generateRules(serviceName string, inventory metrics.Inventory, objectives []slo.Objective) (RuleFile, error) {
+	g := &rulesGenerator{resolver: &resolver{inventory: inventory}, serviceName: serviceName}
+
+	file := RuleFile{Groups: []RuleGroup{
+		g.httpRules(),
+		g.grpcRules(),
+		g.databaseRules(),
+		g.businessRules(),
+	}}
+	if len(objectives) > 0 {
+		file.Groups = append(file.Groups, g.sloRules(objectives), g.sloAlerts(objectives))
+	}
+	if g.err != nil {
+		return RuleFile{}, g.err
+	}
+	return file, nil
+}
+
+func (g *rulesGenerator) httpRules() RuleGroup {
+	requests := g.metric("http_request_total")
+	duration := g.metric("http_request_duration_seconds")
+
+	rules := []Rule{
+		{
+			Record: "service:http_requests:rate5m",
+			Expr:   fmt.Sprintf("sum by (service, method, path, status) (rate(%s[5m]))", requests),
+		},
+		{
+			Record: "service:http_server_errors:ratio_rate5m",
+			Expr: fmt.Sprintf("sum by (service) (rate(%s[5m])) / sum by (service) (rate(%s[5m]))",
+				g.metric("http_server_errors_total"), requests),
+		},
+		{
+			Record: "service:http_client_errors:ratio_rate5m",
+			Expr: fmt.Sprintf("sum by (service) (rate(%s[5m])) / sum by (service) (rate(%s[5m]))",
+				g.metric("http_client_errors_total"), requests),
+		},
+	}
+	rules = append(rules, quantileRules("service:http_request_duration_seconds", duration, "service, method, path")...)
+	rules = append(rules,
+		Rule{
+			Record: "service:http_slow_requests:rate5m",
+			Expr:   fmt.Sprintf("sum by (service, method, path) (rate(%s[5m]))", g.metric("http_slow_requests_total")),
+		},
+		Rule{
+			Record: "service:http_rate_limited_requests:rate5m",
+			Expr:   fmt.Sprintf("sum by (service, method, path) (rate(%s[5m]))", g.metric("rate_limited_requests_total")),
+		},
+	)
+	return RuleGroup{Name: g.serviceName + ".http.rules", Rules: rules}
+}
+
+func (g *rulesGenerator) grpcRules() RuleGroup {
+	handled := g.metric("grpc_server_handled_total")
+
+	rules := []Rule{
+		{
+			Record: "service:grpc_server_handled:rate5m",
+			Expr:   fmt.Sprintf("sum by (service, method, code) (rate(%s[5m]))", handled),
+		},
+		{
+			Record: "service:grpc_server_errors:ratio_rate5m",
+			Expr: fmt.Sprintf("sum by (service) (rate(%s[5m])) / sum by (service) (rate(%s[5m]))",
+				g.metric("grpc_server_server_errors_total"), handled),
+		},
+	}
+	rules = append(rules, quantileRules("service:grpc_server_handling_seconds", g.metric("grpc_server_handling_seconds"), "service, method")...)
+	return RuleGroup{Name: g.serviceName + ".grpc.rules", Rules: rules}
+}
+
+func (g *rulesGenerator) databaseRules() RuleGroup {
+	rules := quantileRules("service:db_query_duration_seconds", g.metric("db_query_duration_seconds"), "service, operation")
+	rules = append(rules,
+		Rule{
+			Record: "service:db_query_errors:rate5m",
+			Expr:   fmt.Sprintf("sum by (service, operation, sqlstate) (rate(%s[5m]))", g.metric("db_query_errors_total")),
+		},
+		Rule{
+			Record: "pool:pgxpool_utilization:ratio",
+			Expr:   fmt.Sprintf("%s / %s", g.metric("pgxpool_acquired_connections"), g.metric("pgxpool_max_connections")),
+		},
+		Rule{
+			Record: "pool:pgxpool_acquire_duration_seconds:mean5m",
+			Expr: fmt.Sprintf("rate(%s[5m]) / rate(%s[5m])",
+				g.metric("pgxpool_acquire_duration_seconds_total"), g.metric("pgxpool_acquires_total")),
+		},
+		Rule{
+			Record: "pool:pgxpool_empty_acquires:rate5m",
+			Expr:   fmt.Sprintf("rate(%s[5m])", g.metric("pgxpool_empty_acquires_total")),
+		},
+	)
+	return RuleGroup{Name: g.serviceName + ".database.rules", Rules: rules}
+}
+
+func (g *rulesGenerator) businessRules() RuleGroup {
+	var rules []Rule
+	for _, event := range []string{"created", "updated", "deleted"} {
+		rules = append(rules, Rule{
+			Record: "service:users_" + event + ":rate1h",
+			Expr:   fmt.Sprintf("sum by (service) (rate(%s[1h]))", g.metric("users_"+event+"_total")),
+		})
+	}
+	rules = append(rules, Rule{
+		Record: "service:users_purge_rows:increase1d",
+		Expr:   fmt.Sprintf("sum by (service, mode) (increase(%s[1d]))", g.metric("users_purge_rows_total")),
+	})
+	return RuleGroup{Name: g.serviceName + ".business.rules", Rules: rules}
+}
+
+// sloRules the error ratio of every SLI of the objectives over every burn rate window,
+// computed like the slo.Engine does: 5xx responses for availability, requests slower than
+// their route threshold for latency
+func (g *rulesGenerator) sloRules(objectives []slo.Objective) RuleGroup {
+	requests := g.metric("http_request_total")
+	within := g.metric("http_requests_within_slo_total")
+
+	var rules []Rule
+	for _, objective := range objectives {
+		selector := g.selector(objective)
+		for _, sli := range slis(objective) {
+			for _, window := range slo.Windows() {
+				label := slo.WindowLabel(window)
+				total := fmt.Sprintf("sum by (service) (rate(%s{%s}[%s]))", requests, selector, label)
+
+				var expr string
+				switch sli {
+				case slo.SLIAvailability:
+					expr = fmt.Sprintf(`sum by (service) (rate(%s{%s,status=~"5.."}[%s])) / %s`, requests, selector, label, total)
+				case slo.SLILatency:
+					expr = fmt.Sprintf("1 - sum by (service) (rate(%s{%s}[%s])) / %s", within, selector, label, total)
+				}
+				rules = append(rules, Rule{
+					Record: sliErrorRatio(label),
+					Expr:   expr,
+					Labels: map[string]string{"slo": objective.Name, "sli": sli},
+				})
+			}
+		}
+	}
+	return RuleGroup{Name: g.serviceName + ".slo.rules", Rules: rules}
+}
+
+// sloAlerts an alert per objective, SLI and slo.BurnRateAlerts entry, firing when the
+// error ratio exceeds Factor times the error budget in both windows
+func (g *rulesGenerator) sloAlerts(objectives []slo.Objective) RuleGroup {
+	var rules []Rule
+	for _, objective := range objectives {
+		for _, sli := range slis(objective) {
+			target := formatFloat(objective.Targets()[sli])
+			series := fmt.Sprintf(`{slo=%q,sli=%q}`, objective.Name, sli)
+
+			for _, alert := range slo.BurnRateAlerts {
+				long, short := slo.WindowLabel(alert.LongWindow), slo.WindowLabel(alert.ShortWindow)
+				threshold := fmt.Sprintf("(%s * (1 - %s))", formatFloat(alert.Factor), target)
+				rules = append(rules, Rule{
+					Alert: "SLOErrorBudgetBurn",
+					Expr: fmt.Sprintf("%s%s > %s and %s%s > %s",
+						sliErrorRatio(long), series, threshold, sliErrorRatio(short), series, threshold),
+					Labels: map[string]string{
+						"severity":    alert.Severity,
+						"slo":         objective.Name,
+						"sli":         sli,
+						"long_window": long,
+					},
+					Annotations: map[string]string{
+						"summary": fmt.Sprintf("%s %s error budget burning %sx faster than allowed",
+							objective.Name, sli, formatFloat(alert.Factor)),
+						"description": fmt.Sprintf("The %s SLI of %s (target %s) spends the error budget at more than %sx in the last %s and %s.",
+							sli, objective.Name, target, formatFloat(alert.Factor), long, short),
+					},
+				})
+			}
+		}
+	}
+	return RuleGroup{Name: g.serviceName + ".slo.alerts", Rules: rules}
+}
+
+// selector the label matchers of the requests of objective
+func (g *rulesGenerator) selector(objective slo.Objective) string {
+	matchers := []string{fmt.Sprintf("service=%q", g.serviceName)}
+	if objective.Method != "" {
+		matchers = append(matchers, fmt.Sprintf("method=%q", strings.ToUpper(objective.Method)))
+	}
+	if prefix, ok := strings.CutSuffix(objective.Route, "*"); ok {
+		matchers = append(matchers, fmt.Sprintf("path=~%q", regexp.QuoteMeta(prefix)+".*"))
+	} else if objective.Route != "" {
+		matchers = append(matchers, fmt.Sprintf("path=%q", objective.Route))
+	}
+	return strings.Join(matchers, ",")
+}
+
+// slis the enabled SLIs of objective in a stable order
+func slis(objective slo.Objective) []string {
+	var enabled []string
+	for _, sli := range []string{slo.SLIAvailability, slo.SLILatency} {
+		if _, ok := objective.Targets()[sli]; ok {
+			enabled = append(enabled, sli)
+		}
+	}
+	return enabled
+}
+
+// quantileRules the p50, p95 and p99 of the histogram metric aggregated by labels
+func quantileRules(record, metric, labels string) []Rule {
+	var rules []Rule
+	for _, quantile := range []string{"50", "95", "99"} {
+		rules = append(rules, Rule{
+			Record: record + ":p" + quantile,
+			Expr:   fmt.Sprintf("histogram_quantile(0.%s, sum by (%s, le) (rate(%s_bucket[5m])))", quantile, labels, metric),
+		})
+	}
+	return rules
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// marshalYAML the YAML of value with the generated header
+func marshalYAML(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(generatedHeader)
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
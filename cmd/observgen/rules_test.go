@@ -0,0 +1,159 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/config"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/slo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// the repository files the generated rules are checked against
+const (
+	objectivesFile = "../../config/slo/objectives.json"
+	rulesFile      = "../../pkg/config/prometheus/rules/factorit.rules.yml"
+)
+
+var (
+	promqlStrings  = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+	promqlGroups   = regexp.MustCompile(`\{[^}]*\}|\[[^\]]*\]|\b(?:by|without|on|ignoring|group_left|group_right)\s*\([^)]*\)`)
+	promqlIdent    = regexp.MustCompile(`[a-zA-Z_:][a-zA-Z0-9_:]*(\s*\()?`)
+	promqlKeywords = []string{"and", "or", "unless", "bool", "offset"}
+)
+
+// metricNames the metric names an expression selects: the identifiers left once strings,
+// matchers, ranges and grouping clauses are removed that are neither functions nor keywords
+func metricNames(expr string) []string {
+	expr = promqlStrings.ReplaceAllString(expr, `""`)
+	expr = promqlGroups.ReplaceAllString(expr, "")
+
+	var names []string
+	for _, match := range promqlIdent.FindAllStringSubmatch(expr, -1) {
+		if match[1] != "" || slices.Contains(promqlKeywords, match[0]) {
+			continue
+		}
+		names = append(names, match[0])
+	}
+	return names
+}
+
+func TestMetricNames(t *testing.T) {
+	names := metricNames(`histogram_quantile(0.99, sum by (service, le) (rate(http_request_duration_seconds_bucket{path=~"/api.*"}[5m]))) / on (service) a:b > (14.4 * (1 - 0.999)) and c`)
+	assert.Equal(t, []string{"http_request_duration_seconds_bucket", "a:b", "c"}, names)
+}
+
+// generate the rules of the repository objectives with the default metric options
+func generate(t *testing.T, metricsConfig config.MetricsConfig) (metrics.Inventory, RuleFile) {
+	t.Helper()
+	objectives, err := slo.LoadObjectives(objectivesFile)
+	require.NoError(t, err)
+
+	inventory := describeService("factorit", metricsConfig, objectives)
+	rules, err := generateRules("factorit", inventory, objectives)
+	require.NoError(t, err)
+	return inventory, rules
+}
+
+func TestGenerateRules_ReferenceRegisteredMetrics(t *testing.T) {
+	for name, metricsConfig := range map[string]config.MetricsConfig{
+		"default":   {},
+		"namespace": {Namespace: "acme", ConstLabels: map[string]string{"env": "prod"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			inventory, rules := generate(t, metricsConfig)
+
+			// round trip through the YAML, what Prometheus reads
+			content, err := marshalYAML(rules)
+			require.NoError(t, err)
+			var file RuleFile
+			require.NoError(t, yaml.Unmarshal(content, &file))
+			require.NotEmpty(t, file.Groups)
+
+			recorded := map[string]bool{}
+			for _, group := range file.Groups {
+				for _, rule := range group.Rules {
+					if rule.Record != "" {
+						recorded[rule.Record] = true
+					}
+				}
+			}
+
+			for _, group := range file.Groups {
+				require.NotEmpty(t, group.Rules, group.Name)
+				for _, rule := range group.Rules {
+					names := metricNames(rule.Expr)
+					require.NotEmpty(t, names, rule.Expr)
+					for _, name := range names {
+						if recorded[name] {
+							continue
+						}
+						base := name
+						for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+							if trimmed, ok := strings.CutSuffix(name, suffix); ok && inventory.Has(trimmed) {
+								base = trimmed
+							}
+						}
+						assert.True(t, inventory.Has(base), "%s of %q is not a registered metric", name, rule.Expr)
+						if metricsConfig.Namespace != "" {
+							assert.True(t, strings.HasPrefix(base, metricsConfig.Namespace+"_"), name)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateRules_BurnRateAlerts(t *testing.T) {
+	_, rules := generate(t, config.MetricsConfig{})
+
+	objectives, err := slo.LoadObjectives(objectivesFile)
+	require.NoError(t, err)
+	want := 0
+	for _, objective := range objectives {
+		want += len(objective.Targets()) * len(slo.BurnRateAlerts)
+	}
+
+	alerts := rules.Groups[len(rules.Groups)-1]
+	require.Equal(t, "factorit.slo.alerts", alerts.Name)
+	require.Len(t, alerts.Rules, want)
+
+	page := alerts.Rules[0]
+	assert.Equal(t, "SLOErrorBudgetBurn", page.Alert)
+	assert.Equal(t, "page", page.Labels["severity"])
+	assert.Equal(t, objectives[0].Name, page.Labels["slo"])
+	assert.Contains(t, page.Expr, "slo:sli_error:ratio_rate1h{")
+	assert.Contains(t, page.Expr, "slo:sli_error:ratio_rate5m{")
+	assert.Contains(t, page.Expr, "(14.4 * (1 - 0.999))")
+
+	// the prefix objective selects its routes with a regex
+	slos := rules.Groups[len(rules.Groups)-2]
+	assert.True(t, slices.ContainsFunc(slos.Rules, func(r Rule) bool {
+		return r.Labels["slo"] == "api" && strings.Contains(r.Expr, `path=~"/api/v1/.*"`)
+	}))
+}
+
+func TestGenerateRules_UnknownMetric(t *testing.T) {
+	inventory := metrics.Inventory{{Name: "http_request_total"}}
+	_, err := generateRules("factorit", inventory, nil)
+	assert.Error(t, err)
+}
+
+// the committed rule file is the output of the generator, run make observgen after
+// changing the metrics or the objectives
+func TestRulesFileUpToDate(t *testing.T) {
+	_, rules := generate(t, config.MetricsConfig{})
+	content, err := marshalYAML(rules)
+	require.NoError(t, err)
+
+	committed, err := os.ReadFile(rulesFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(committed), "run make observgen")
+}
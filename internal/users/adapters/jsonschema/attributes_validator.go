@@ -0,0 +1,174 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	jsv "github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// schemaURL internal resource name, the schema is never fetched
+const schemaURL = "attributes.schema.json"
+
+// AttributesValidator implements domain.AttributesValidator with a JSON Schema
+// (draft 2020-12 by default, $schema selects another draft), one schema per deployment
+type AttributesValidator struct {
+	schema *jsv.Schema
+}
+
+// NewAttributesValidator compiles the JSON Schema document, it must describe an object
+func NewAttributesValidator(schema []byte) (*AttributesValidator, error) {
+	doc, err := jsv.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid attributes schema: %w", err)
+	}
+
+	compiler := jsv.NewCompiler()
+	// "format": "email" etc. are assertions, not annotations
+	compiler.AssertFormat()
+	if err := compiler.AddResource(schemaURL, doc); err != nil {
+		return nil, fmt.Errorf("invalid attributes schema: %w", err)
+	}
+
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile attributes schema: %w", err)
+	}
+
+	return &AttributesValidator{schema: compiled}, nil
+}
+
+// LoadAttributesValidator reads and compiles the schema file at path
+func LoadAttributesValidator(path string) (*AttributesValidator, error) {
+	schema, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attributes schema: %w", err)
+	}
+	return NewAttributesValidator(schema)
+}
+
+// Validate returns a *domain.AttributesError listing every violation
+func (v *AttributesValidator) Validate(attrs domain.Attributes) error {
+	// round trip so the instance has the types the validator expects (json.Number...)
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		return &domain.AttributesError{Violations: []string{err.Error()}}
+	}
+	instance, err := jsv.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return &domain.AttributesError{Violations: []string{err.Error()}}
+	}
+
+	err = v.schema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+
+	var ve *jsv.ValidationError
+	if !errors.As(err, &ve) {
+		return fmt.Errorf("failed to validate attributes: %w", err)
+	}
+
+	return &domain.AttributesError{Violations: violations(ve.BasicOutput())}
+}
+
+// CoerceFilter converts every string value of filter to the type of its property in the
+// schema, trying the types in the order integer, number, boolean, null unless the property
+// accepts strings, the keys the schema does not type stay strings
+func (v *AttributesValidator) CoerceFilter(filter domain.Attributes) (domain.Attributes, error) {
+	coerced := make(domain.Attributes, len(filter))
+	var list []string
+	for key, value := range filter {
+		raw, ok := value.(string)
+		if !ok {
+			coerced[key] = value
+			continue
+		}
+		types := propertyTypes(v.schema, key)
+		if len(types) == 0 || slices.Contains(types, "string") {
+			coerced[key] = raw
+			continue
+		}
+		typed, ok := coerce(raw, types)
+		if !ok {
+			list = append(list, fmt.Sprintf("/%s: got %q, want %s", key, raw, strings.Join(types, " or ")))
+			continue
+		}
+		coerced[key] = typed
+	}
+
+	if len(list) > 0 {
+		sort.Strings(list)
+		return nil, &domain.AttributesError{Violations: list}
+	}
+	return coerced, nil
+}
+
+// propertyTypes the types of the property key of the object schema, following $ref
+func propertyTypes(schema *jsv.Schema, key string) []string {
+	property := schema.Properties[key]
+	for property != nil && property.Types == nil && property.Ref != nil {
+		property = property.Ref
+	}
+	if property == nil || property.Types == nil {
+		return nil
+	}
+	return property.Types.ToStrings()
+}
+
+// coerce parses raw as the first of types it is valid for
+func coerce(raw string, types []string) (any, bool) {
+	for _, typ := range []string{"integer", "number", "boolean", "null"} {
+		if !slices.Contains(types, typ) {
+			continue
+		}
+		switch typ {
+		case "integer":
+			if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				return n, true
+			}
+		case "number":
+			if n, err := strconv.ParseFloat(raw, 64); err == nil {
+				return n, true
+			}
+		case "boolean":
+			if raw == "true" || raw == "false" {
+				return raw == "true", true
+			}
+		case "null":
+			if raw == "null" {
+				return nil, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// violations flattens the basic output into "<instance location>: <message>" lines
+func violations(out *jsv.OutputUnit) []string {
+	var list []string
+	for _, unit := range out.Errors {
+		if unit.Error == nil {
+			continue
+		}
+		location := unit.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		list = append(list, fmt.Sprintf("%s: %s", location, unit.Error.String()))
+	}
+
+	if len(list) == 0 && out.Error != nil {
+		list = append(list, out.Error.String())
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,141 @@
+package jsonschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compile-time check
+var _ domain.AttributesValidator = (*AttributesValidator)(nil)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"phone": {"type": "string", "pattern": "^\\+?[0-9 ]+$"},
+		"department": {"enum": ["sales", "hr", "engineering"]},
+		"locale": {"type": "string", "minLength": 2, "maxLength": 5},
+		"contact": {"type": "string", "format": "email"},
+		"age": {"type": "integer", "minimum": 0}
+	},
+	"additionalProperties": false
+}`
+
+func TestAttributesValidator_Validate(t *testing.T) {
+	v, err := NewAttributesValidator([]byte(testSchema))
+	require.NoError(t, err)
+
+	t.Run("valid attributes", func(t *testing.T) {
+		err := v.Validate(domain.Attributes{
+			"phone":      "+56 9 1234 5678",
+			"department": "sales",
+			"locale":     "es-CL",
+			"age":        30,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		assert.NoError(t, v.Validate(domain.Attributes{}))
+	})
+
+	tests := []struct {
+		name     string
+		attrs    domain.Attributes
+		location string
+	}{
+		{name: "wrong type", attrs: domain.Attributes{"phone": 123}, location: "/phone"},
+		{name: "pattern", attrs: domain.Attributes{"phone": "call me"}, location: "/phone"},
+		{name: "enum", attrs: domain.Attributes{"department": "finance"}, location: "/department"},
+		{name: "format", attrs: domain.Attributes{"contact": "not-an-email"}, location: "/contact"},
+		{name: "minimum", attrs: domain.Attributes{"age": -1}, location: "/age"},
+		{name: "integer", attrs: domain.Attributes{"age": 1.5}, location: "/age"},
+		{name: "unknown attribute", attrs: domain.Attributes{"shoe_size": 42}, location: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.attrs)
+
+			require.ErrorIs(t, err, domain.ErrInvalidAttributes)
+			var attrErr *domain.AttributesError
+			require.ErrorAs(t, err, &attrErr)
+			require.NotEmpty(t, attrErr.Violations)
+			assert.Contains(t, attrErr.Violations[0], tt.location)
+		})
+	}
+}
+
+func TestAttributesValidator_CoerceFilter(t *testing.T) {
+	v, err := NewAttributesValidator([]byte(`{
+		"type": "object",
+		"$defs": {"score": {"type": "number"}},
+		"properties": {
+			"phone": {"type": "string"},
+			"age": {"type": "integer"},
+			"score": {"$ref": "#/$defs/score"},
+			"verified": {"type": "boolean"},
+			"code": {"type": ["integer", "string"]}
+		}
+	}`))
+	require.NoError(t, err)
+
+	filter, err := v.CoerceFilter(domain.Attributes{
+		"phone":    "5551234",
+		"age":      "30",
+		"score":    "4.5",
+		"verified": "true",
+		"code":     "007",
+		"nickname": "42",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.Attributes{
+		"phone":    "5551234", // numeric looking, still a string
+		"age":      int64(30),
+		"score":    4.5,
+		"verified": true,
+		"code":     "007", // strings accepted
+		"nickname": "42",  // not in the schema
+	}, filter)
+
+	_, err = v.CoerceFilter(domain.Attributes{"age": "thirty", "verified": "yes"})
+	require.ErrorIs(t, err, domain.ErrInvalidAttributes)
+	var attrErr *domain.AttributesError
+	require.ErrorAs(t, err, &attrErr)
+	require.Len(t, attrErr.Violations, 2)
+	assert.Contains(t, attrErr.Violations[0], "/age")
+	assert.Contains(t, attrErr.Violations[1], "/verified")
+}
+
+func TestNewAttributesValidator_InvalidSchema(t *testing.T) {
+	_, err := NewAttributesValidator([]byte(`{"type": `))
+	assert.Error(t, err)
+
+	_, err = NewAttributesValidator([]byte(`{"type": "no-such-type"}`))
+	assert.Error(t, err)
+}
+
+func TestLoadAttributesValidator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "attributes.schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(testSchema), 0o600))
+
+	v, err := LoadAttributesValidator(path)
+	require.NoError(t, err)
+	assert.NoError(t, v.Validate(domain.Attributes{"locale": "es"}))
+
+	_, err = LoadAttributesValidator(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+// the example schema shipped in config/ must stay valid
+func TestExampleSchema(t *testing.T) {
+	v, err := LoadAttributesValidator("../../../../config/users/attributes.schema.json")
+	require.NoError(t, err)
+
+	assert.NoError(t, v.Validate(domain.Attributes{"phone": "+56 9 1234 5678", "locale": "es-CL"}))
+	assert.ErrorIs(t, v.Validate(domain.Attributes{"locale": "spanish"}), domain.ErrInvalidAttributes)
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+)
+
+// APIKeyRepository implements domain.APIKeyRepository in memory, tenant scoped like
+// the postgres adapter (except GetByPrefix, see the interface)
+type APIKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[string]*domain.APIKey
+}
+
+// NewAPIKeyRepository creates an empty in-memory repository
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{
+		keys: make(map[string]*domain.APIKey),
+	}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key.TenantID = tenant.IDFromContext(ctx)
+	r.keys[key.ID] = cloneAPIKey(key)
+
+	return nil
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id string) (*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.find(ctx, id)
+	if !ok {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	return cloneAPIKey(key), nil
+}
+
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			return cloneAPIKey(key), nil
+		}
+	}
+
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+func (r *APIKeyRepository) List(ctx context.Context) ([]*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := tenant.IDFromContext(ctx)
+	keys := make([]*domain.APIKey, 0)
+	for _, key := range r.keys {
+		if key.TenantID == tenantID {
+			keys = append(keys, cloneAPIKey(key))
+		}
+	}
+
+	// ORDER BY created_at DESC
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.find(ctx, id)
+	if !ok {
+		return domain.ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.find(ctx, id)
+	if !ok {
+		return domain.ErrAPIKeyNotFound
+	}
+	key.LastUsedAt = &at
+
+	return nil
+}
+
+// find returns the stored key only if it belongs to the tenant in ctx
+// caller must hold the lock
+func (r *APIKeyRepository) find(ctx context.Context, id string) (*domain.APIKey, bool) {
+	key, ok := r.keys[id]
+	if !ok || key.TenantID != tenant.IDFromContext(ctx) {
+		return nil, false
+	}
+	return key, true
+}
+
+// cloneAPIKey returns a copy so callers can not mutate the stored entity
+func cloneAPIKey(k *domain.APIKey) *domain.APIKey {
+	c := *k
+	c.Scopes = slices.Clone(k.Scopes)
+	c.ExpiresAt = cloneTime(k.ExpiresAt)
+	c.LastUsedAt = cloneTime(k.LastUsedAt)
+	c.RevokedAt = cloneTime(k.RevokedAt)
+	return &c
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
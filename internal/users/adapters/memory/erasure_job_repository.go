@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+)
+
+// ErasureJobRepository implements domain.ErasureJobRepository in memory, tenant
+// scoped like the postgres adapter
+type ErasureJobRepository struct {
+	mu   sync.RWMutex
+	jobs map[string]*domain.ErasureJob
+}
+
+// NewErasureJobRepository creates an empty in-memory repository
+func NewErasureJobRepository() *ErasureJobRepository {
+	return &ErasureJobRepository{
+		jobs: make(map[string]*domain.ErasureJob),
+	}
+}
+
+func (r *ErasureJobRepository) Create(ctx context.Context, job *domain.ErasureJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job.TenantID = tenant.IDFromContext(ctx)
+	r.jobs[job.ID] = cloneJob(job)
+
+	return nil
+}
+
+func (r *ErasureJobRepository) GetByID(ctx context.Context, id string) (*domain.ErasureJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.jobs[id]
+	if !ok || job.TenantID != tenant.IDFromContext(ctx) {
+		return nil, domain.ErrErasureJobNotFound
+	}
+
+	return cloneJob(job), nil
+}
+
+func (r *ErasureJobRepository) Update(ctx context.Context, job *domain.ErasureJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.jobs[job.ID]
+	if !ok || stored.TenantID != tenant.IDFromContext(ctx) {
+		return domain.ErrErasureJobNotFound
+	}
+
+	// only the columns the postgres UPDATE touches
+	updated := cloneJob(job)
+	stored.Status = updated.Status
+	stored.Error = updated.Error
+	stored.StartedAt = updated.StartedAt
+	stored.CompletedAt = updated.CompletedAt
+
+	return nil
+}
+
+func (r *ErasureJobRepository) ListByUser(ctx context.Context, userID string) ([]*domain.ErasureJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := tenant.IDFromContext(ctx)
+	var jobs []*domain.ErasureJob
+	for _, job := range r.jobs {
+		if job.TenantID == tenantID && job.UserID == userID {
+			jobs = append(jobs, cloneJob(job))
+		}
+	}
+
+	// ORDER BY requested_at DESC
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].RequestedAt.After(jobs[j].RequestedAt)
+	})
+
+	return jobs, nil
+}
+
+// cloneJob returns a copy, timestamps included, so callers can not mutate the stored job
+func cloneJob(job *domain.ErasureJob) *domain.ErasureJob {
+	c := *job
+	if job.StartedAt != nil {
+		startedAt := *job.StartedAt
+		c.StartedAt = &startedAt
+	}
+	if job.CompletedAt != nil {
+		completedAt := *job.CompletedAt
+		c.CompletedAt = &completedAt
+	}
+	return &c
+}
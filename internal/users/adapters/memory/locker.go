@@ -0,0 +1,24 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+// Locker implements usecase.Locker for a single process, the counterpart of
+// database.AdvisoryLock for tests and local development
+type Locker struct {
+	mu sync.Mutex
+}
+
+// NewLocker creates an unlocked locker
+func NewLocker() *Locker {
+	return &Locker{}
+}
+
+func (l *Locker) TryLock(ctx context.Context) (unlock func(), acquired bool, err error) {
+	if !l.mu.TryLock() {
+		return nil, false, nil
+	}
+	return l.mu.Unlock, true, nil
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+)
+
+// SessionRepository implements domain.SessionRepository in memory, tenant scoped like
+// the postgres adapter
+type SessionRepository struct {
+	mu       sync.RWMutex
+	sessions map[string]*domain.Session
+}
+
+// NewSessionRepository creates an empty in-memory repository
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{
+		sessions: make(map[string]*domain.Session),
+	}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session.TenantID = tenant.IDFromContext(ctx)
+	r.sessions[session.ID] = cloneSession(session)
+
+	return nil
+}
+
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.find(ctx, id)
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+
+	return cloneSession(session), nil
+}
+
+func (r *SessionRepository) ListByUser(ctx context.Context, userID string) ([]*domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := tenant.IDFromContext(ctx)
+	sessions := make([]*domain.Session, 0)
+	for _, session := range r.sessions {
+		if session.TenantID == tenantID && session.UserID == userID {
+			sessions = append(sessions, cloneSession(session))
+		}
+	}
+
+	// ORDER BY last_seen_at DESC
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.find(ctx, id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	if session.RevokedAt == nil {
+		session.RevokedAt = &at
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) RevokeAllByUser(ctx context.Context, userID string, at time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := tenant.IDFromContext(ctx)
+	var revoked int64
+	for _, session := range r.sessions {
+		if session.TenantID == tenantID && session.UserID == userID && !session.IsRevoked() {
+			revokedAt := at
+			session.RevokedAt = &revokedAt
+			revoked++
+		}
+	}
+
+	return revoked, nil
+}
+
+func (r *SessionRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := tenant.IDFromContext(ctx)
+	var deleted int64
+	for id, session := range r.sessions {
+		if session.TenantID == tenantID && session.UserID == userID {
+			delete(r.sessions, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (r *SessionRepository) TouchLastSeen(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.find(ctx, id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	session.LastSeenAt = at
+
+	return nil
+}
+
+// find returns the stored session only if it belongs to the tenant in ctx
+// caller must hold the lock
+func (r *SessionRepository) find(ctx context.Context, id string) (*domain.Session, bool) {
+	session, ok := r.sessions[id]
+	if !ok || session.TenantID != tenant.IDFromContext(ctx) {
+		return nil, false
+	}
+	return session, true
+}
+
+// cloneSession returns a copy so callers can not mutate the stored entity
+func cloneSession(s *domain.Session) *domain.Session {
+	c := *s
+	c.RevokedAt = cloneTime(s.RevokedAt)
+	return &c
+}
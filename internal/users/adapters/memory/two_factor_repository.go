@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+)
+
+// TwoFactorRepository implements domain.TwoFactorRepository in memory, tenant scoped like
+// the postgres adapter
+type TwoFactorRepository struct {
+	mu          sync.Mutex
+	enrollments map[twoFactorKey]*domain.TwoFactor
+	// challenges the current login challenge of the enrolled users, kept apart from the
+	// enrollment like the challenge columns of user_two_factor, Save does not touch it
+	challenges map[twoFactorKey]*twoFactorChallenge
+}
+
+type twoFactorChallenge struct {
+	id       string
+	attempts int
+}
+
+// twoFactorKey one enrollment per user of a tenant (primary key of user_two_factor)
+type twoFactorKey struct {
+	tenantID string
+	userID   string
+}
+
+// NewTwoFactorRepository creates an empty in-memory repository
+func NewTwoFactorRepository() *TwoFactorRepository {
+	return &TwoFactorRepository{
+		enrollments: make(map[twoFactorKey]*domain.TwoFactor),
+		challenges:  make(map[twoFactorKey]*twoFactorChallenge),
+	}
+}
+
+func (r *TwoFactorRepository) Get(ctx context.Context, userID string) (*domain.TwoFactor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment, ok := r.enrollments[r.key(ctx, userID)]
+	if !ok {
+		return nil, domain.ErrTwoFactorNotEnrolled
+	}
+
+	return cloneTwoFactor(enrollment), nil
+}
+
+func (r *TwoFactorRepository) Save(ctx context.Context, enrollment *domain.TwoFactor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment.TenantID = tenant.IDFromContext(ctx)
+	r.enrollments[r.key(ctx, enrollment.UserID)] = cloneTwoFactor(enrollment)
+
+	return nil
+}
+
+func (r *TwoFactorRepository) Delete(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := r.key(ctx, userID)
+	if _, ok := r.enrollments[key]; !ok {
+		return domain.ErrTwoFactorNotEnrolled
+	}
+	delete(r.enrollments, key)
+	delete(r.challenges, key)
+
+	return nil
+}
+
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userID string, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment, ok := r.enrollments[r.key(ctx, userID)]
+	if !ok {
+		return domain.ErrTwoFactorNotEnrolled
+	}
+	if step <= enrollment.LastUsedStep {
+		return domain.ErrInvalidOTP
+	}
+	enrollment.LastUsedStep = step
+
+	return nil
+}
+
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment, ok := r.enrollments[r.key(ctx, userID)]
+	if !ok {
+		return domain.ErrTwoFactorNotEnrolled
+	}
+	i := slices.Index(enrollment.RecoveryCodeHashes, hash)
+	if i < 0 {
+		return domain.ErrInvalidOTP
+	}
+	enrollment.RecoveryCodeHashes = slices.Delete(enrollment.RecoveryCodeHashes, i, i+1)
+
+	return nil
+}
+
+func (r *TwoFactorRepository) StartChallenge(ctx context.Context, userID, challengeID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := r.key(ctx, userID)
+	if _, ok := r.enrollments[key]; !ok {
+		return domain.ErrTwoFactorNotEnrolled
+	}
+	r.challenges[key] = &twoFactorChallenge{id: challengeID}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) AttemptChallenge(ctx context.Context, userID, challengeID string, maxAttempts int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge, ok := r.challenges[r.key(ctx, userID)]
+	if !ok || challenge.id != challengeID || challenge.attempts >= maxAttempts {
+		return domain.ErrInvalidChallenge
+	}
+	challenge.attempts++
+
+	return nil
+}
+
+func (r *TwoFactorRepository) EndChallenge(ctx context.Context, userID, challengeID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := r.key(ctx, userID)
+	if challenge, ok := r.challenges[key]; ok && challenge.id == challengeID {
+		delete(r.challenges, key)
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) key(ctx context.Context, userID string) twoFactorKey {
+	return twoFactorKey{tenantID: tenant.IDFromContext(ctx), userID: userID}
+}
+
+// cloneTwoFactor returns a copy so callers can not mutate the stored entity
+func cloneTwoFactor(t *domain.TwoFactor) *domain.TwoFactor {
+	c := *t
+	c.RecoveryCodeHashes = slices.Clone(t.RecoveryCodeHashes)
+	c.ConfirmedAt = cloneTime(t.ConfirmedAt)
+	return &c
+}
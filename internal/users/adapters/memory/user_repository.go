@@ -0,0 +1,322 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+)
+
+// UserRepository implements domain.UserRepository in memory
+// useful for tests and local development without PostgreSQL, it mirrors the
+// behaviour of the postgres adapter (same domain errors, same ordering, same
+// tenant scoping through tenant.IDFromContext)
+type UserRepository struct {
+	mu    sync.RWMutex
+	users map[string]*domain.User
+}
+
+// NewUserRepository creates an empty in-memory repository
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users: make(map[string]*domain.User),
+	}
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := tenant.IDFromContext(ctx)
+
+	// same rule as the users primary key, ids are unique across tenants
+	if _, ok := r.users[user.ID]; ok {
+		return fmt.Errorf("failed to create user: id %s already exists", user.ID)
+	}
+
+	// same rule as the users_tenant_email_key unique constraint
+	if r.emailTaken(tenantID, user.Email, "") {
+		return domain.ErrEmailAlreadyExists
+	}
+
+	attrs, err := cloneAttributes(user.Attributes)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	stored := *user
+	stored.TenantID = tenantID
+	stored.Attributes = attrs
+	// postgres adapter never persists deleted_at on insert
+	stored.DeletedAt = nil
+	r.users[user.ID] = &stored
+
+	user.TenantID = tenantID
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.findActive(ctx, id)
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	return clone(user), nil
+}
+
+func (r *UserRepository) GetIncludingDeleted(ctx context.Context, id string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.find(ctx, id)
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	return clone(user), nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := tenant.IDFromContext(ctx)
+	for _, user := range r.users {
+		if user.TenantID == tenantID && user.Email == email && !user.IsDeleted() {
+			return clone(user), nil
+		}
+	}
+
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.findActive(ctx, user.ID)
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+
+	if r.emailTaken(stored.TenantID, user.Email, user.ID) {
+		return domain.ErrEmailAlreadyExists
+	}
+
+	attrs, err := cloneAttributes(user.Attributes)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	// only the columns the postgres UPDATE touches
+	stored.Name = user.Name
+	stored.Email = user.Email
+	stored.PasswordHash = user.PasswordHash
+	stored.Attributes = attrs
+	// the update_users_updated_at trigger overrides the value sent by the caller
+	stored.UpdatedAt = time.Now()
+
+	user.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *UserRepository) Anonymize(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.find(ctx, user.ID)
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+
+	stored.Name = user.Name
+	stored.Email = user.Email
+	stored.PasswordHash = user.PasswordHash
+	stored.Attributes = domain.Attributes{}
+	// same trigger as Update
+	stored.UpdatedAt = time.Now()
+	if user.DeletedAt != nil {
+		deletedAt := *user.DeletedAt
+		stored.DeletedAt = &deletedAt
+	}
+
+	user.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *UserRepository) PurgeDeleted(ctx context.Context, mode domain.PurgeMode, cutoff time.Time, limit int) (int64, error) {
+	if _, err := domain.ParsePurgeMode(string(mode)); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// every tenant, like the purge_deleted_users function (migration 000006)
+	due := make([]*domain.User, 0)
+	for _, user := range r.users {
+		if !user.IsDeleted() || !user.DeletedAt.Before(cutoff) {
+			continue
+		}
+		if mode == domain.PurgeAnonymize && user.IsAnonymized() {
+			continue
+		}
+		due = append(due, user)
+	}
+
+	// ORDER BY deleted_at LIMIT limit
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].DeletedAt.Before(*due[j].DeletedAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	for _, user := range due {
+		if mode == domain.PurgeDelete {
+			delete(r.users, user.ID)
+			continue
+		}
+		// keeps the original deleted_at, the retention is counted from the deletion
+		deletedAt := *user.DeletedAt
+		user.Anonymize()
+		user.DeletedAt = &deletedAt
+	}
+
+	return int64(len(due)), nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.findActive(ctx, id)
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	// soft delete, PurgeDeleted removes the row later, updated_at like the trigger
+	now := time.Now()
+	stored.DeletedAt = &now
+	stored.UpdatedAt = now
+
+	return nil
+}
+
+func (r *UserRepository) List(ctx context.Context, filter domain.ListFilter, limit, offset int) ([]*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := r.filter(ctx, filter)
+
+	// ORDER BY created_at DESC
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].CreatedAt.After(all[j].CreatedAt)
+	})
+
+	if offset >= len(all) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > len(all) {
+		end = len(all)
+	}
+
+	users := make([]*domain.User, 0, end-offset)
+	for _, user := range all[offset:end] {
+		users = append(users, clone(user))
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) Count(ctx context.Context, filter domain.ListFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return int64(len(r.filter(ctx, filter))), nil
+}
+
+// ============================================================
+// HELPERS
+// ============================================================
+
+// find returns the stored user only if it belongs to the tenant in ctx
+// caller must hold the lock
+func (r *UserRepository) find(ctx context.Context, id string) (*domain.User, bool) {
+	user, ok := r.users[id]
+	if !ok || user.TenantID != tenant.IDFromContext(ctx) {
+		return nil, false
+	}
+	return user, true
+}
+
+// findActive like find, soft deleted users excluded
+// caller must hold the lock
+func (r *UserRepository) findActive(ctx context.Context, id string) (*domain.User, bool) {
+	user, ok := r.find(ctx, id)
+	if !ok || user.IsDeleted() {
+		return nil, false
+	}
+	return user, true
+}
+
+// filter returns the users of the tenant in ctx matching filter, soft deleted excluded, unordered
+// caller must hold the lock
+func (r *UserRepository) filter(ctx context.Context, filter domain.ListFilter) []*domain.User {
+	tenantID := tenant.IDFromContext(ctx)
+	users := make([]*domain.User, 0, len(r.users))
+	for _, user := range r.users {
+		if user.TenantID == tenantID && !user.IsDeleted() && user.Attributes.Contains(filter.Attributes) {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// emailTaken checks if another user of the tenant (different from exceptID) already uses the email
+// caller must hold the lock
+func (r *UserRepository) emailTaken(tenantID, email, exceptID string) bool {
+	for id, user := range r.users {
+		if id != exceptID && user.TenantID == tenantID && user.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a copy so callers can not mutate the stored entity
+func clone(u *domain.User) *domain.User {
+	c := *u
+	if u.DeletedAt != nil {
+		deletedAt := *u.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+	// stored attributes already went through cloneAttributes, can not fail
+	c.Attributes, _ = cloneAttributes(u.Attributes)
+	return &c
+}
+
+// cloneAttributes deep copies through JSON, the same round trip the JSONB column does,
+// nil becomes an empty map like the column default
+func cloneAttributes(attrs domain.Attributes) (domain.Attributes, error) {
+	out := domain.Attributes{}
+	if len(attrs) == 0 {
+		return out, nil
+	}
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
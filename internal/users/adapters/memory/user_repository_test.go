@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain/repotest"
+)
+
+// compile time check, memory adapter must satisfy the domain contract
+var _ domain.UserRepository = (*UserRepository)(nil)
+
+func TestUserRepository_Contract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) domain.UserRepository {
+		return NewUserRepository()
+	})
+}
+
+// compile time check
+var _ domain.ErasureJobRepository = (*ErasureJobRepository)(nil)
+
+func TestErasureJobRepository_Contract(t *testing.T) {
+	repotest.RunErasureJobs(t, func(t *testing.T) (domain.UserRepository, domain.ErasureJobRepository) {
+		return NewUserRepository(), NewErasureJobRepository()
+	})
+}
+
+// compile time check
+var _ domain.APIKeyRepository = (*APIKeyRepository)(nil)
+
+func TestAPIKeyRepository_Contract(t *testing.T) {
+	repotest.RunAPIKeys(t, func(t *testing.T) domain.APIKeyRepository {
+		return NewAPIKeyRepository()
+	})
+}
+
+// compile time check
+var _ domain.SessionRepository = (*SessionRepository)(nil)
+
+func TestSessionRepository_Contract(t *testing.T) {
+	repotest.RunSessions(t, func(t *testing.T) (domain.UserRepository, domain.SessionRepository) {
+		return NewUserRepository(), NewSessionRepository()
+	})
+}
+
+// compile time check
+var _ domain.TwoFactorRepository = (*TwoFactorRepository)(nil)
+
+func TestTwoFactorRepository_Contract(t *testing.T) {
+	repotest.RunTwoFactor(t, func(t *testing.T) (domain.UserRepository, domain.TwoFactorRepository) {
+		return NewUserRepository(), NewTwoFactorRepository()
+	})
+}
@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// apiKeyColumns selected by every read query, keep in sync with scanAPIKey
+const apiKeyColumns = `id, tenant_id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at`
+
+// APIKeyRepository implements domain.APIKeyRepository using PostgreSQL (migration 000007)
+type APIKeyRepository struct {
+	db *pgxpool.Pool
+	settings
+}
+
+// NewAPIKeyRepository creates a new instance of the repository, same options as NewUserRepository
+func NewAPIKeyRepository(db *pgxpool.Pool, opts ...Option) *APIKeyRepository {
+	return &APIKeyRepository{
+		db:       db,
+		settings: newSettings(opts),
+	}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	query := `
+        -- name: api_keys.Create
+        INSERT INTO api_keys (id, tenant_id, name, prefix, key_hash, scopes, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		_, err := q.Exec(ctx, query,
+			key.ID,
+			tenantID,
+			key.Name,
+			key.Prefix,
+			key.KeyHash,
+			key.Scopes,
+			key.CreatedAt,
+			key.ExpiresAt,
+		)
+		return err
+	})
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to create api key: %w", err))
+	}
+
+	key.TenantID = tenantID
+	return nil
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id string) (*domain.APIKey, error) {
+	query := `
+        -- name: api_keys.GetByID
+        SELECT ` + apiKeyColumns + `
+        FROM api_keys
+        WHERE tenant_id = $1 AND id = $2
+    `
+
+	var key *domain.APIKey
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		var err error
+		key, err = scanAPIKey(q.QueryRow(ctx, query, tenantID, id))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, logFailure(ctx, fmt.Errorf("failed to get api key: %w", err))
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	// not tenant scoped, the function runs as the table owner (migration 000007)
+	query := `
+        -- name: api_keys.GetByPrefix
+        SELECT ` + apiKeyColumns + ` FROM find_api_key_by_prefix($1)
+    `
+
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, prefix))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, logFailure(ctx, fmt.Errorf("failed to get api key by prefix: %w", err))
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) List(ctx context.Context) ([]*domain.APIKey, error) {
+	query := `
+        -- name: api_keys.List
+        SELECT ` + apiKeyColumns + `
+        FROM api_keys
+        WHERE tenant_id = $1
+        ORDER BY created_at DESC
+    `
+
+	keys := make([]*domain.APIKey, 0)
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		rows, err := q.Query(ctx, query, tenantID)
+		if err != nil {
+			return logFailure(ctx, fmt.Errorf("failed to list api keys: %w", err))
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			key, err := scanAPIKey(rows)
+			if err != nil {
+				return logFailure(ctx, fmt.Errorf("failed to scan api key: %w", err))
+			}
+			keys = append(keys, key)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating api keys: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	query := `
+        -- name: api_keys.Revoke
+        UPDATE api_keys
+        SET revoked_at = COALESCE(revoked_at, $3)
+        WHERE tenant_id = $1 AND id = $2
+    `
+
+	return r.exec(ctx, "revoke", query, id, at)
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	query := `
+        -- name: api_keys.TouchLastUsed
+        UPDATE api_keys
+        SET last_used_at = $3
+        WHERE tenant_id = $1 AND id = $2
+    `
+
+	return r.exec(ctx, "touch", query, id, at)
+}
+
+// exec runs an UPDATE of a single key of the tenant in ctx
+func (r *APIKeyRepository) exec(ctx context.Context, op, query, id string, at time.Time) error {
+	var result pgconn.CommandTag
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		var err error
+		result, err = q.Exec(ctx, query, tenantID, id, at)
+		return err
+	})
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to %s api key: %w", op, err))
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// scanAPIKey maps a row with apiKeyColumns to a domain.APIKey
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var key domain.APIKey
+	err := row.Scan(
+		&key.ID,
+		&key.TenantID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Scopes,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
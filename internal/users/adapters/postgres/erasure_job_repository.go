@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// erasureJobColumns selected by every read query, keep in sync with scanErasureJob
+const erasureJobColumns = `id, tenant_id, user_id, status, error, requested_at, started_at, completed_at`
+
+// ErasureJobRepository implements domain.ErasureJobRepository using PostgreSQL (migration 000005)
+type ErasureJobRepository struct {
+	db *pgxpool.Pool
+	settings
+}
+
+// NewErasureJobRepository creates a new instance of the repository, same options as NewUserRepository
+func NewErasureJobRepository(db *pgxpool.Pool, opts ...Option) *ErasureJobRepository {
+	return &ErasureJobRepository{
+		db:       db,
+		settings: newSettings(opts),
+	}
+}
+
+func (r *ErasureJobRepository) Create(ctx context.Context, job *domain.ErasureJob) error {
+	query := `
+        -- name: erasure_jobs.Create
+        INSERT INTO user_erasure_jobs (id, tenant_id, user_id, status, error, requested_at, started_at, completed_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		_, err := q.Exec(ctx, query,
+			job.ID,
+			tenantID,
+			job.UserID,
+			job.Status,
+			job.Error,
+			job.RequestedAt,
+			job.StartedAt,
+			job.CompletedAt,
+		)
+		return err
+	})
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to create erasure job: %w", err))
+	}
+
+	job.TenantID = tenantID
+	return nil
+}
+
+func (r *ErasureJobRepository) GetByID(ctx context.Context, id string) (*domain.ErasureJob, error) {
+	query := `
+        -- name: erasure_jobs.GetByID
+        SELECT ` + erasureJobColumns + `
+        FROM user_erasure_jobs
+        WHERE tenant_id = $1 AND id = $2
+    `
+
+	var job *domain.ErasureJob
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		var err error
+		job, err = scanErasureJob(q.QueryRow(ctx, query, tenantID, id))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrErasureJobNotFound
+		}
+		return nil, logFailure(ctx, fmt.Errorf("failed to get erasure job: %w", err))
+	}
+
+	return job, nil
+}
+
+func (r *ErasureJobRepository) Update(ctx context.Context, job *domain.ErasureJob) error {
+	query := `
+        -- name: erasure_jobs.Update
+        UPDATE user_erasure_jobs
+        SET status = $3, error = $4, started_at = $5, completed_at = $6
+        WHERE tenant_id = $1 AND id = $2
+    `
+
+	var result pgconn.CommandTag
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		var err error
+		result, err = q.Exec(ctx, query,
+			tenantID,
+			job.ID,
+			job.Status,
+			job.Error,
+			job.StartedAt,
+			job.CompletedAt,
+		)
+		return err
+	})
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to update erasure job: %w", err))
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrErasureJobNotFound
+	}
+
+	return nil
+}
+
+func (r *ErasureJobRepository) ListByUser(ctx context.Context, userID string) ([]*domain.ErasureJob, error) {
+	query := `
+        -- name: erasure_jobs.ListByUser
+        SELECT ` + erasureJobColumns + `
+        FROM user_erasure_jobs
+        WHERE tenant_id = $1 AND user_id = $2
+        ORDER BY requested_at DESC
+    `
+
+	var jobs []*domain.ErasureJob
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		rows, err := q.Query(ctx, query, tenantID, userID)
+		if err != nil {
+			return logFailure(ctx, fmt.Errorf("failed to list erasure jobs: %w", err))
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			job, err := scanErasureJob(rows)
+			if err != nil {
+				return logFailure(ctx, fmt.Errorf("failed to scan erasure job: %w", err))
+			}
+			jobs = append(jobs, job)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating erasure jobs: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// scanErasureJob maps a row with erasureJobColumns to a domain.ErasureJob
+func scanErasureJob(row pgx.Row) (*domain.ErasureJob, error) {
+	var job domain.ErasureJob
+	err := row.Scan(
+		&job.ID,
+		&job.TenantID,
+		&job.UserID,
+		&job.Status,
+		&job.Error,
+		&job.RequestedAt,
+		&job.StartedAt,
+		&job.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
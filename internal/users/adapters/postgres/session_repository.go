@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sessionColumns selected by every read query, keep in sync with scanSession
+const sessionColumns = `id, tenant_id, user_id, device, user_agent, ip, created_at, last_seen_at, revoked_at`
+
+// SessionRepository implements domain.SessionRepository using PostgreSQL (migration 000008)
+type SessionRepository struct {
+	db *pgxpool.Pool
+	settings
+}
+
+// NewSessionRepository creates a new instance of the repository, same options as NewUserRepository
+func NewSessionRepository(db *pgxpool.Pool, opts ...Option) *SessionRepository {
+	return &SessionRepository{
+		db:       db,
+		settings: newSettings(opts),
+	}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	query := `
+        -- name: sessions.Create
+        INSERT INTO user_sessions (id, tenant_id, user_id, device, user_agent, ip, created_at, last_seen_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		_, err := q.Exec(ctx, query,
+			session.ID,
+			tenantID,
+			session.UserID,
+			session.Device,
+			session.UserAgent,
+			session.IP,
+			session.CreatedAt,
+			session.LastSeenAt,
+		)
+		return err
+	})
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to create session: %w", err))
+	}
+
+	session.TenantID = tenantID
+	return nil
+}
+
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	query := `
+        -- name: sessions.GetByID
+        SELECT ` + sessionColumns + `
+        FROM user_sessions
+        WHERE tenant_id = $1 AND id = $2
+    `
+
+	var session *domain.Session
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		var err error
+		session, err = scanSession(q.QueryRow(ctx, query, tenantID, id))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, logFailure(ctx, fmt.Errorf("failed to get session: %w", err))
+	}
+
+	return session, nil
+}
+
+func (r *SessionRepository) ListByUser(ctx context.Context, userID string) ([]*domain.Session, error) {
+	query := `
+        -- name: sessions.ListByUser
+        SELECT ` + sessionColumns + `
+        FROM user_sessions
+        WHERE tenant_id = $1 AND user_id = $2
+        ORDER BY last_seen_at DESC
+    `
+
+	sessions := make([]*domain.Session, 0)
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		rows, err := q.Query(ctx, query, tenantID, userID)
+		if err != nil {
+			return logFailure(ctx, fmt.Errorf("failed to list sessions: %w", err))
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			session, err := scanSession(rows)
+			if err != nil {
+				return logFailure(ctx, fmt.Errorf("failed to scan session: %w", err))
+			}
+			sessions = append(sessions, session)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating sessions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	query := `
+        -- name: sessions.Revoke
+        UPDATE user_sessions
+        SET revoked_at = COALESCE(revoked_at, $3)
+        WHERE tenant_id = $1 AND id = $2
+    `
+
+	result, err := r.exec(ctx, query, id, at)
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to revoke session: %w", err))
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) RevokeAllByUser(ctx context.Context, userID string, at time.Time) (int64, error) {
+	query := `
+        -- name: sessions.RevokeAllByUser
+        UPDATE user_sessions
+        SET revoked_at = $3
+        WHERE tenant_id = $1 AND user_id = $2 AND revoked_at IS NULL
+    `
+
+	result, err := r.exec(ctx, query, userID, at)
+	if err != nil {
+		return 0, logFailure(ctx, fmt.Errorf("failed to revoke user sessions: %w", err))
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *SessionRepository) DeleteAllByUser(ctx context.Context, userID string) (int64, error) {
+	query := `
+        -- name: sessions.DeleteAllByUser
+        DELETE FROM user_sessions WHERE tenant_id = $1 AND user_id = $2
+    `
+
+	result, err := r.exec(ctx, query, userID)
+	if err != nil {
+		return 0, logFailure(ctx, fmt.Errorf("failed to delete user sessions: %w", err))
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *SessionRepository) TouchLastSeen(ctx context.Context, id string, at time.Time) error {
+	query := `
+        -- name: sessions.TouchLastSeen
+        UPDATE user_sessions
+        SET last_seen_at = $3
+        WHERE tenant_id = $1 AND id = $2
+    `
+
+	result, err := r.exec(ctx, query, id, at)
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to touch session: %w", err))
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// exec runs a statement of the tenant in ctx, $1 is the tenant and args follow
+func (r *SessionRepository) exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	var result pgconn.CommandTag
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		var err error
+		result, err = q.Exec(ctx, query, append([]any{tenantID}, args...)...)
+		return err
+	})
+	return result, err
+}
+
+// scanSession maps a row with sessionColumns to a domain.Session
+func scanSession(row pgx.Row) (*domain.Session, error) {
+	var session domain.Session
+	err := row.Scan(
+		&session.ID,
+		&session.TenantID,
+		&session.UserID,
+		&session.Device,
+		&session.UserAgent,
+		&session.IP,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,235 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TwoFactorRepository implements domain.TwoFactorRepository using PostgreSQL (migrations 000009
+// and 000011)
+type TwoFactorRepository struct {
+	db *pgxpool.Pool
+	settings
+}
+
+// NewTwoFactorRepository creates a new instance of the repository, same options as NewUserRepository
+func NewTwoFactorRepository(db *pgxpool.Pool, opts ...Option) *TwoFactorRepository {
+	return &TwoFactorRepository{
+		db:       db,
+		settings: newSettings(opts),
+	}
+}
+
+func (r *TwoFactorRepository) Get(ctx context.Context, userID string) (*domain.TwoFactor, error) {
+	query := `
+        -- name: two_factor.Get
+        SELECT user_id, tenant_id, secret, recovery_codes, last_used_step, created_at, confirmed_at
+        FROM user_two_factor
+        WHERE tenant_id = $1 AND user_id = $2
+    `
+
+	var enrollment domain.TwoFactor
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		return q.QueryRow(ctx, query, tenantID, userID).Scan(
+			&enrollment.UserID,
+			&enrollment.TenantID,
+			&enrollment.EncryptedSecret,
+			&enrollment.RecoveryCodeHashes,
+			&enrollment.LastUsedStep,
+			&enrollment.CreatedAt,
+			&enrollment.ConfirmedAt,
+		)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrTwoFactorNotEnrolled
+		}
+		return nil, logFailure(ctx, fmt.Errorf("failed to get two-factor enrollment: %w", err))
+	}
+
+	return &enrollment, nil
+}
+
+func (r *TwoFactorRepository) Save(ctx context.Context, enrollment *domain.TwoFactor) error {
+	// the primary key is the user, the tenant of an existing row never changes
+	query := `
+        -- name: two_factor.Save
+        INSERT INTO user_two_factor (user_id, tenant_id, secret, recovery_codes, last_used_step, created_at, confirmed_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (user_id) DO UPDATE SET
+            secret = EXCLUDED.secret,
+            recovery_codes = EXCLUDED.recovery_codes,
+            last_used_step = EXCLUDED.last_used_step,
+            created_at = EXCLUDED.created_at,
+            confirmed_at = EXCLUDED.confirmed_at
+        WHERE user_two_factor.tenant_id = EXCLUDED.tenant_id
+    `
+
+	hashes := enrollment.RecoveryCodeHashes
+	if hashes == nil {
+		// NOT NULL column
+		hashes = []string{}
+	}
+
+	var result pgconn.CommandTag
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		var err error
+		result, err = q.Exec(ctx, query,
+			enrollment.UserID,
+			tenantID,
+			enrollment.EncryptedSecret,
+			hashes,
+			enrollment.LastUsedStep,
+			enrollment.CreatedAt,
+			enrollment.ConfirmedAt,
+		)
+		return err
+	})
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to save two-factor enrollment: %w", err))
+	}
+	if result.RowsAffected() == 0 {
+		// the user belongs to another tenant
+		return domain.ErrUserNotFound
+	}
+
+	enrollment.TenantID = tenantID
+	return nil
+}
+
+func (r *TwoFactorRepository) Delete(ctx context.Context, userID string) error {
+	query := `
+        -- name: two_factor.Delete
+        DELETE FROM user_two_factor WHERE tenant_id = $1 AND user_id = $2
+    `
+
+	result, err := r.exec(ctx, query, userID)
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to delete two-factor enrollment: %w", err))
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrTwoFactorNotEnrolled
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userID string, step int64) error {
+	query := `
+        -- name: two_factor.UseStep
+        UPDATE user_two_factor
+        SET last_used_step = $3
+        WHERE tenant_id = $1 AND user_id = $2 AND last_used_step < $3
+    `
+
+	result, err := r.exec(ctx, query, userID, step)
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to record totp step: %w", err))
+	}
+	if result.RowsAffected() == 0 {
+		return r.notUsed(ctx, userID)
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID, hash string) error {
+	query := `
+        -- name: two_factor.UseRecoveryCode
+        UPDATE user_two_factor
+        SET recovery_codes = array_remove(recovery_codes, $3)
+        WHERE tenant_id = $1 AND user_id = $2 AND $3 = ANY(recovery_codes)
+    `
+
+	result, err := r.exec(ctx, query, userID, hash)
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to use recovery code: %w", err))
+	}
+	if result.RowsAffected() == 0 {
+		return r.notUsed(ctx, userID)
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) StartChallenge(ctx context.Context, userID, challengeID string) error {
+	query := `
+        -- name: two_factor.StartChallenge
+        UPDATE user_two_factor
+        SET challenge_id = $3, challenge_attempts = 0
+        WHERE tenant_id = $1 AND user_id = $2
+    `
+
+	result, err := r.exec(ctx, query, userID, challengeID)
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to start login challenge: %w", err))
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrTwoFactorNotEnrolled
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) AttemptChallenge(ctx context.Context, userID, challengeID string, maxAttempts int) error {
+	query := `
+        -- name: two_factor.AttemptChallenge
+        UPDATE user_two_factor
+        SET challenge_attempts = challenge_attempts + 1
+        WHERE tenant_id = $1 AND user_id = $2 AND challenge_id = $3 AND challenge_attempts < $4
+    `
+
+	result, err := r.exec(ctx, query, userID, challengeID, maxAttempts)
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to count login challenge attempt: %w", err))
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrInvalidChallenge
+	}
+
+	return nil
+}
+
+func (r *TwoFactorRepository) EndChallenge(ctx context.Context, userID, challengeID string) error {
+	query := `
+        -- name: two_factor.EndChallenge
+        UPDATE user_two_factor
+        SET challenge_id = NULL
+        WHERE tenant_id = $1 AND user_id = $2 AND challenge_id = $3
+    `
+
+	if _, err := r.exec(ctx, query, userID, challengeID); err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to end login challenge: %w", err))
+	}
+
+	return nil
+}
+
+// exec runs a statement of a user of the tenant in ctx, $1 is the tenant and $2 the user
+func (r *TwoFactorRepository) exec(ctx context.Context, query, userID string, args ...any) (pgconn.CommandTag, error) {
+	var result pgconn.CommandTag
+	tenantID := tenant.IDFromContext(ctx)
+	err := withTenant(ctx, r.db, r.rls, tenantID, func(q querier) error {
+		var err error
+		result, err = q.Exec(ctx, query, append([]any{tenantID, userID}, args...)...)
+		return err
+	})
+	return result, err
+}
+
+// notUsed tells why a conditional update matched no row: no enrollment or a rejected code
+func (r *TwoFactorRepository) notUsed(ctx context.Context, userID string) error {
+	if _, err := r.Get(ctx, userID); err != nil {
+		return err
+	}
+	return domain.ErrInvalidOTP
+}
@@ -7,10 +7,12 @@ import (
 	"time"
 
 	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
-	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
 )
 
 // PostgreSQL error codes that maps to domain errors
@@ -20,41 +22,84 @@ const (
 
 // Constraint names del schema
 const (
-	constraintUsersEmailKey = "users_email_key"
+	// email is unique per tenant (migration 000002)
+	constraintUsersEmailKey = "users_tenant_email_key"
 )
 
+// userColumns selected by every read query, keep in sync with scanUser
+const userColumns = `id, tenant_id, name, email, password_hash, attributes, created_at, updated_at, deleted_at`
+
+// querier is the subset of pgxpool.Pool / pgx.Tx used by the repository
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 // UserRepository implements domain.UserRepository using  PostgreSQL
+// every query is scoped by the tenant found in the context (tenant.IDFromContext)
+// queries are traced and measured by the pool tracer (database.QueryTracer)
 type UserRepository struct {
-	db      *pgxpool.Pool
-	metrics *metrics.UserMetrics
+	db *pgxpool.Pool
+	settings
+}
+
+// settings shared by every repository of the package
+type settings struct {
+	// rls enables row level security mode: each operation runs in a transaction
+	// with app.tenant_id set, so the tenant isolation policies apply too
+	rls bool
+}
+
+// Option configures optional repository behaviour
+type Option func(*settings)
+
+// WithRowLevelSecurity enables the Postgres RLS mode, requires migration 000003 and
+// connecting with a role that is not the table owner nor superuser (they bypass RLS)
+func WithRowLevelSecurity() Option {
+	return func(s *settings) {
+		s.rls = true
+	}
+}
+
+// newSettings applies the options
+func newSettings(opts []Option) settings {
+	var s settings
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
 }
 
 // NewUserRepository crea una nueva instancia del repository
-func NewUserRepository(db *pgxpool.Pool, metrics *metrics.UserMetrics) *UserRepository {
+func NewUserRepository(db *pgxpool.Pool, opts ...Option) *UserRepository {
 	return &UserRepository{
-		db:      db,
-		metrics: metrics}
+		db:       db,
+		settings: newSettings(opts),
+	}
 }
 
 func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
-	start := time.Now()
-	defer func() {
-		duration := time.Since(start)
-		r.metrics.DBQueryDuration.Observe(duration.Seconds())
-	}()
 	query := `
-        INSERT INTO users (id, name, email, password_hash, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        -- name: users.Create
+        INSERT INTO users (id, tenant_id, name, email, password_hash, attributes, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
     `
 
-	_, err := r.db.Exec(ctx, query,
-		user.ID,
-		user.Name,
-		user.Email,
-		user.PasswordHash,
-		user.CreatedAt,
-		user.UpdatedAt,
-	)
+	tenantID := tenant.IDFromContext(ctx)
+	err := r.withTenant(ctx, tenantID, func(q querier) error {
+		_, err := q.Exec(ctx, query,
+			user.ID,
+			tenantID,
+			user.Name,
+			user.Email,
+			user.PasswordHash,
+			attributesOrEmpty(user.Attributes),
+			user.CreatedAt,
+			user.UpdatedAt,
+		)
+		return err
+	})
 
 	if err != nil {
 		// duplicated email is a domain error
@@ -63,107 +108,175 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 		}
 
 		// any other error from postgres is a generic one
-		return fmt.Errorf("failed to create user: %w", err)
+		return logFailure(ctx, fmt.Errorf("failed to create user: %w", err))
 	}
 
+	user.TenantID = tenantID
 	return nil
 }
 
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
 	query := `
-        SELECT id, name, email, password_hash, created_at, updated_at
+        -- name: users.GetByID
+        SELECT ` + userColumns + `
         FROM users
-        WHERE id = $1
+        WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
     `
 
-	var user domain.User
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&user.ID,
-		&user.Name,
-		&user.Email,
-		&user.PasswordHash,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	return r.get(ctx, query, id)
+}
+
+func (r *UserRepository) GetIncludingDeleted(ctx context.Context, id string) (*domain.User, error) {
+	query := `
+        -- name: users.GetIncludingDeleted
+        SELECT ` + userColumns + `
+        FROM users
+        WHERE tenant_id = $1 AND id = $2
+    `
+
+	return r.get(ctx, query, id)
+}
+
+// get runs a single user query by id of the tenant in ctx, $1 is the tenant and $2 the id
+func (r *UserRepository) get(ctx context.Context, query, id string) (*domain.User, error) {
+	var user *domain.User
+	tenantID := tenant.IDFromContext(ctx)
+	err := r.withTenant(ctx, tenantID, func(q querier) error {
+		var err error
+		user, err = scanUser(q.QueryRow(ctx, query, tenantID, id))
+		return err
+	})
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrUserNotFound
 		}
 
-		return nil, fmt.Errorf("failed to get user by id: %w", err)
+		return nil, logFailure(ctx, fmt.Errorf("failed to get user by id: %w", err))
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-        SELECT id, name, email, password_hash, created_at, updated_at
+        -- name: users.GetByEmail
+        SELECT ` + userColumns + `
         FROM users
-        WHERE email = $1
+        WHERE tenant_id = $1 AND email = $2 AND deleted_at IS NULL
     `
 
-	var user domain.User
-	err := r.db.QueryRow(ctx, query, email).Scan(
-		&user.ID,
-		&user.Name,
-		&user.Email,
-		&user.PasswordHash,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	var user *domain.User
+	tenantID := tenant.IDFromContext(ctx)
+	err := r.withTenant(ctx, tenantID, func(q querier) error {
+		var err error
+		user, err = scanUser(q.QueryRow(ctx, query, tenantID, email))
+		return err
+	})
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrUserNotFound
 		}
 
-		return nil, fmt.Errorf("failed to get user by email: %w", err)
+		return nil, logFailure(ctx, fmt.Errorf("failed to get user by email: %w", err))
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	query := `
+        -- name: users.Update
         UPDATE users
-        SET name = $2, email = $3, password_hash = $4, updated_at = $5
-        WHERE id = $1
+        SET name = $3, email = $4, password_hash = $5, attributes = $6, updated_at = $7
+        WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+        RETURNING updated_at
     `
 
-	result, err := r.db.Exec(ctx, query,
-		user.ID,
-		user.Name,
-		user.Email,
-		user.PasswordHash,
-		user.UpdatedAt,
-	)
+	// the update_users_updated_at trigger sets updated_at to NOW(), the caller gets the stored value
+	var updatedAt time.Time
+	tenantID := tenant.IDFromContext(ctx)
+	err := r.withTenant(ctx, tenantID, func(q querier) error {
+		return q.QueryRow(ctx, query,
+			tenantID,
+			user.ID,
+			user.Name,
+			user.Email,
+			user.PasswordHash,
+			attributesOrEmpty(user.Attributes),
+			user.UpdatedAt,
+		).Scan(&updatedAt)
+	})
 
 	if err != nil {
+		// no row of the tenant matched
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrUserNotFound
+		}
 		if isUniqueViolation(err, constraintUsersEmailKey) {
 			return domain.ErrEmailAlreadyExists
 		}
 
-		return fmt.Errorf("failed to update user: %w", err)
+		return logFailure(ctx, fmt.Errorf("failed to update user: %w", err))
 	}
 
-	// ✅ Verificar que se actualizó al menos 1 fila
-	if result.RowsAffected() == 0 {
-		return domain.ErrUserNotFound
+	user.UpdatedAt = updatedAt
+	return nil
+}
+
+func (r *UserRepository) Anonymize(ctx context.Context, user *domain.User) error {
+	// the old values are overwritten in place, no copy is kept anywhere in the table
+	query := `
+        -- name: users.Anonymize
+        UPDATE users
+        SET name = $3, email = $4, password_hash = $5, attributes = '{}'::jsonb,
+            updated_at = $6, deleted_at = $7
+        WHERE tenant_id = $1 AND id = $2
+        RETURNING updated_at
+    `
+
+	var updatedAt time.Time
+	tenantID := tenant.IDFromContext(ctx)
+	err := r.withTenant(ctx, tenantID, func(q querier) error {
+		return q.QueryRow(ctx, query,
+			tenantID,
+			user.ID,
+			user.Name,
+			user.Email,
+			user.PasswordHash,
+			user.UpdatedAt,
+			user.DeletedAt,
+		).Scan(&updatedAt)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrUserNotFound
+		}
+		return logFailure(ctx, fmt.Errorf("failed to anonymize user: %w", err))
 	}
 
+	user.UpdatedAt = updatedAt
 	return nil
 }
 
 func (r *UserRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM users WHERE id = $1`
+	query := `
+        -- name: users.Delete
+        UPDATE users
+        SET deleted_at = NOW()
+        WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+    `
 
-	result, err := r.db.Exec(ctx, query, id)
+	var result pgconn.CommandTag
+	tenantID := tenant.IDFromContext(ctx)
+	err := r.withTenant(ctx, tenantID, func(q querier) error {
+		var err error
+		result, err = q.Exec(ctx, query, tenantID, id)
+		return err
+	})
 	if err != nil {
-		// ❌ No hay errores de dominio específicos para delete
-		// (podrías agregar foreign key violation si es necesario)
-		return fmt.Errorf("failed to delete user: %w", err)
+		return logFailure(ctx, fmt.Errorf("failed to delete user: %w", err))
 	}
 
 	// ✅ Verificar que se eliminó al menos 1 fila
@@ -174,66 +287,159 @@ func (r *UserRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*domain.User, error) {
-	start := time.Now()
-	defer func() {
-		duration := time.Since(start)
-		r.metrics.DBQueryDuration.Observe(duration.Seconds())
-	}()
+func (r *UserRepository) List(ctx context.Context, filter domain.ListFilter, limit, offset int) ([]*domain.User, error) {
 	query := `
-        SELECT id, name, email, password_hash, created_at, updated_at
+        -- name: users.List
+        SELECT ` + userColumns + `
         FROM users
+        WHERE tenant_id = $1 AND deleted_at IS NULL AND attributes @> $2
         ORDER BY created_at DESC
-        LIMIT $1 OFFSET $2
+        LIMIT $3 OFFSET $4
     `
 
-	rows, err := r.db.Query(ctx, query, limit, offset)
-	if err != nil {
-		//  Error genérico (no es de dominio)
-		return nil, fmt.Errorf("failed to list users: %w", err)
-	}
-	defer rows.Close()
-
 	var users []*domain.User
-	for rows.Next() {
-		var user domain.User
-		err := rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Email,
-			&user.PasswordHash,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+	tenantID := tenant.IDFromContext(ctx)
+	err := r.withTenant(ctx, tenantID, func(q querier) error {
+		rows, err := q.Query(ctx, query, tenantID, attributesOrEmpty(filter.Attributes), limit, offset)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan user: %w", err)
+			//  Error genérico (no es de dominio)
+			return logFailure(ctx, fmt.Errorf("failed to list users: %w", err))
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			user, err := scanUser(rows)
+			if err != nil {
+				return logFailure(ctx, fmt.Errorf("failed to scan user: %w", err))
+			}
+			users = append(users, user)
 		}
-		users = append(users, &user)
-	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating users: %w", err)
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating users: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return users, nil
 }
 
-func (r *UserRepository) Count(ctx context.Context) (int64, error) {
-	query := `SELECT COUNT(*) FROM users`
+func (r *UserRepository) Count(ctx context.Context, filter domain.ListFilter) (int64, error) {
+	query := `
+        -- name: users.Count
+        SELECT COUNT(*) FROM users WHERE tenant_id = $1 AND deleted_at IS NULL AND attributes @> $2
+    `
 
 	var count int64
-	err := r.db.QueryRow(ctx, query).Scan(&count)
+	tenantID := tenant.IDFromContext(ctx)
+	err := r.withTenant(ctx, tenantID, func(q querier) error {
+		return q.QueryRow(ctx, query, tenantID, attributesOrEmpty(filter.Attributes)).Scan(&count)
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to count users: %w", err)
+		return 0, logFailure(ctx, fmt.Errorf("failed to count users: %w", err))
 	}
 
 	return count, nil
 }
 
+func (r *UserRepository) PurgeDeleted(ctx context.Context, mode domain.PurgeMode, cutoff time.Time, limit int) (int64, error) {
+	if _, err := domain.ParsePurgeMode(string(mode)); err != nil {
+		return 0, err
+	}
+
+	// not tenant scoped, the function runs as the table owner (migration 000006)
+	query := `
+        -- name: users.PurgeDeleted
+        SELECT purge_deleted_users($1, $2, $3)
+    `
+
+	var purged int64
+	err := r.db.QueryRow(ctx, query, cutoff, limit, mode == domain.PurgeAnonymize).Scan(&purged)
+	if err != nil {
+		return 0, logFailure(ctx, fmt.Errorf("failed to purge deleted users: %w", err))
+	}
+
+	return purged, nil
+}
+
 // ============================================================
 // HELPERS - Funciones auxiliares privadas
 // ============================================================
 
+// withTenant runs fn with the tenant settings of the repository
+func (r *UserRepository) withTenant(ctx context.Context, tenantID string, fn func(q querier) error) error {
+	return withTenant(ctx, r.db, r.rls, tenantID, fn)
+}
+
+// withTenant runs fn against the pool, or in RLS mode inside a transaction where
+// app.tenant_id is set (SET LOCAL semantics, reset on commit/rollback)
+func withTenant(ctx context.Context, db *pgxpool.Pool, rls bool, tenantID string, fn func(q querier) error) error {
+	if !rls {
+		return fn(db)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to begin tenant transaction: %w", err))
+	}
+	defer tx.Rollback(ctx) // no-op after commit
+
+	if _, err := tx.Exec(ctx, `SELECT set_config('app.tenant_id', $1, true)`, tenantID); err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to set tenant: %w", err))
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return logFailure(ctx, fmt.Errorf("failed to commit tenant transaction: %w", err))
+	}
+	return nil
+}
+
+// logFailure logs a database failure with the request logger of ctx (request_id,
+// tenant_id), when there is one, and returns err
+func logFailure(ctx context.Context, err error) error {
+	if log, ok := logger.FromContext(ctx); ok {
+		log.Ctx(ctx).Error("database operation failed", zap.String("component", "postgres"), zap.Error(err))
+	}
+	return err
+}
+
+// scanUser maps a row with userColumns to a domain.User
+func scanUser(row pgx.Row) (*domain.User, error) {
+	var user domain.User
+	err := row.Scan(
+		&user.ID,
+		&user.TenantID,
+		&user.Name,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Attributes,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// attributesOrEmpty nil would be sent as SQL NULL, the column and the @> filter need '{}'
+// an empty filter matches every row, so List and Count share one query shape and the
+// GIN index (migration 000004) serves the filtered case
+func attributesOrEmpty(attrs domain.Attributes) domain.Attributes {
+	if attrs == nil {
+		return domain.Attributes{}
+	}
+	return attrs
+}
+
 // isUniqueViolation checks if the errir is unique constrain for a field, like email
 func isUniqueViolation(err error, constraintName string) bool {
 	var pgErr *pgconn.PgError
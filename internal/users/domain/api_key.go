@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// API keys: non interactive (service to service) access to the users API
+
+var (
+	// ErrAPIKeyNotFound the key does not exist or belongs to another tenant
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrInvalidAPIKey malformed, unknown, revoked or expired key
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrInvalidScope the scope is not one of Scopes
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// Scopes granted to API keys and user tokens
+const (
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeAPIKeysManage = "api-keys:manage"
+	// ScopeUsersAdmin creates and lists the users and acts on the profile, sessions,
+	// two-factor and personal data of any user, without it a principal only reaches its own
+	ScopeUsersAdmin = "users:admin"
+)
+
+// Scopes every known scope
+var Scopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeAPIKeysManage, ScopeUsersAdmin}
+
+// APIKeyPrefix starts every key, tells keys apart from tokens and helps secret scanners
+// key format: fk_<lookup prefix>_<secret>
+const APIKeyPrefix = "fk_"
+
+const (
+	apiKeyLookupBytes = 6  // 12 hex chars, indexed for the lookup
+	apiKeySecretBytes = 32 // 256 bits of entropy
+)
+
+// APIKey is stored without its secret, only a SHA-256 hash, a fast hash is enough
+// because the secret is random (no dictionary attacks, unlike passwords)
+type APIKey struct {
+	ID         string // UUID v4
+	TenantID   string // assigned by the repository from the request context
+	Name       string
+	Prefix     string // lookup prefix, unique, safe to display
+	KeyHash    string // hex SHA-256 of the whole key
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time // nil never expires
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// NewAPIKey creates a key, the returned plain key is shown once and never stored
+func NewAPIKey(name string, scopes []string, expiresAt *time.Time) (*APIKey, string, error) {
+	if err := ValidateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", fmt.Errorf("%w: expiry must be in the future", ErrInvalidAPIKey)
+	}
+
+	lookup := make([]byte, apiKeyLookupBytes)
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(lookup); err != nil {
+		return nil, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+
+	prefix := hex.EncodeToString(lookup)
+	plain := APIKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	return newAPIKey(name, prefix, plain, scopes, expiresAt), plain, nil
+}
+
+// ImportAPIKey creates a key from a plain key generated outside the service (bootstrap
+// key of the operator), the secret must be as long as the generated ones
+func ImportAPIKey(name, plain string, scopes []string) (*APIKey, error) {
+	if err := ValidateScopes(scopes); err != nil {
+		return nil, err
+	}
+	prefix, err := ParseAPIKey(plain)
+	if err != nil {
+		return nil, err
+	}
+	if _, secret, _ := strings.Cut(strings.TrimPrefix(plain, APIKeyPrefix), "_"); len(secret) < base64.RawURLEncoding.EncodedLen(apiKeySecretBytes) {
+		return nil, fmt.Errorf("%w: secret shorter than %d bytes", ErrInvalidAPIKey, apiKeySecretBytes)
+	}
+	if _, err := hex.DecodeString(prefix); err != nil {
+		return nil, fmt.Errorf("%w: lookup prefix must be hex", ErrInvalidAPIKey)
+	}
+
+	return newAPIKey(name, prefix, plain, scopes, nil), nil
+}
+
+func newAPIKey(name, prefix, plain string, scopes []string, expiresAt *time.Time) *APIKey {
+	return &APIKey{
+		ID:        uuid.New().String(),
+		Name:      strings.TrimSpace(name),
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(plain),
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(scopes))),
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// ParseAPIKey returns the lookup prefix of a plain key
+func ParseAPIKey(plain string) (string, error) {
+	rest, ok := strings.CutPrefix(plain, APIKeyPrefix)
+	if !ok {
+		return "", ErrInvalidAPIKey
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 2*apiKeyLookupBytes || secret == "" {
+		return "", ErrInvalidAPIKey
+	}
+	return prefix, nil
+}
+
+// Matches compares the plain key with the stored hash in constant time
+func (k *APIKey) Matches(plain string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashAPIKey(plain)), []byte(k.KeyHash)) == 1
+}
+
+// IsRevoked checks if the key was revoked
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// IsExpired checks if the key expired at now
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// IsActive the key can authenticate at now
+func (k *APIKey) IsActive(now time.Time) bool {
+	return !k.IsRevoked() && !k.IsExpired(now)
+}
+
+// ValidateScopes checks at least one scope is given and all of them are known
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+	return nil
+}
+
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyRepository persistence of API keys, scoped by tenant like UserRepository
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetByID(ctx context.Context, id string) (*APIKey, error)
+	// GetByPrefix is NOT tenant scoped, the tenant of a request is known only after
+	// its key is authenticated
+	GetByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	// List returns the keys of the tenant, newest first, revoked ones included
+	List(ctx context.Context) ([]*APIKey, error)
+	// Revoke sets revoked_at, revoking twice keeps the first time
+	Revoke(ctx context.Context, id string, at time.Time) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	key, plain, err := NewAPIKey(" billing job ", []string{ScopeUsersWrite, ScopeUsersRead, ScopeUsersRead}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "billing job", key.Name)
+	assert.Equal(t, []string{ScopeUsersRead, ScopeUsersWrite}, key.Scopes, "sorted, no duplicates")
+	assert.True(t, strings.HasPrefix(plain, APIKeyPrefix+key.Prefix+"_"))
+	assert.NotContains(t, key.KeyHash, plain)
+	assert.True(t, key.Matches(plain))
+	assert.False(t, key.Matches(plain+"x"))
+	assert.True(t, key.IsActive(time.Now()))
+
+	prefix, err := ParseAPIKey(plain)
+	require.NoError(t, err)
+	assert.Equal(t, key.Prefix, prefix)
+
+	_, other, err := NewAPIKey("other", []string{ScopeUsersRead}, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, plain, other)
+}
+
+func TestNewAPIKey_Errors(t *testing.T) {
+	_, _, err := NewAPIKey("job", nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidScope)
+
+	_, _, err = NewAPIKey("job", []string{"users:delete"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidScope)
+
+	past := time.Now().Add(-time.Hour)
+	_, _, err = NewAPIKey("job", []string{ScopeUsersRead}, &past)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+func TestImportAPIKey(t *testing.T) {
+	_, plain, err := NewAPIKey("generated", []string{ScopeUsersRead}, nil)
+	require.NoError(t, err)
+
+	key, err := ImportAPIKey(" bootstrap ", plain, []string{ScopeAPIKeysManage})
+	require.NoError(t, err)
+	assert.Equal(t, "bootstrap", key.Name)
+	assert.Equal(t, plain[len(APIKeyPrefix):len(APIKeyPrefix)+12], key.Prefix)
+	assert.True(t, key.Matches(plain))
+	assert.Nil(t, key.ExpiresAt)
+
+	for _, plain := range []string{"fk_0123456789ab_short", "fk_0123456789zz_" + strings.Repeat("a", 43), "secret"} {
+		_, err := ImportAPIKey("bootstrap", plain, []string{ScopeAPIKeysManage})
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, "key %q", plain)
+	}
+
+	_, err = ImportAPIKey("bootstrap", plain, []string{"users:delete"})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+}
+
+func TestParseAPIKey_Invalid(t *testing.T) {
+	for _, plain := range []string{"", "secret", "fk_short_secret", "fk_0123456789ab", "xx_0123456789ab_secret"} {
+		_, err := ParseAPIKey(plain)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, "key %q", plain)
+	}
+}
+
+func TestAPIKey_IsActive(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	key, _, err := NewAPIKey("job", []string{ScopeUsersRead}, &expiresAt)
+	require.NoError(t, err)
+
+	assert.True(t, key.IsActive(now))
+	assert.True(t, key.IsExpired(expiresAt))
+	assert.False(t, key.IsActive(expiresAt))
+
+	key.RevokedAt = &now
+	assert.True(t, key.IsRevoked())
+	assert.False(t, key.IsActive(now))
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Attributes custom per-user profile data (phone, department, locale...), the shape is
+// not fixed by the code but by the JSON Schema configured for the deployment, stored
+// as JSONB so values must be JSON compatible
+type Attributes map[string]any
+
+// ErrInvalidAttributes attributes do not match the deployment schema
+var ErrInvalidAttributes = errors.New("invalid attributes")
+
+// AttributesError carries the schema violations, errors.Is(err, ErrInvalidAttributes) is true
+type AttributesError struct {
+	Violations []string
+}
+
+func (e *AttributesError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidAttributes, strings.Join(e.Violations, "; "))
+}
+
+func (e *AttributesError) Unwrap() error {
+	return ErrInvalidAttributes
+}
+
+// AttributesValidator checks attributes against the deployment schema, the
+// implementation lives in adapters/jsonschema
+type AttributesValidator interface {
+	Validate(attrs Attributes) error
+	// CoerceFilter converts the string values of filter (query parameters) to the type of
+	// their property in the schema, a *AttributesError when a value is not of that type
+	CoerceFilter(filter Attributes) (Attributes, error)
+}
+
+// Contains reports whether every top level key of filter is present in a with an
+// equal value, same semantics as the postgres JSONB @> operator on flat objects
+func (a Attributes) Contains(filter Attributes) bool {
+	for key, want := range filter {
+		got, ok := a[key]
+		if !ok || !jsonEqual(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonEqual compares values by their JSON encoding, so 30 (int) equals 30.0 (float64)
+func jsonEqual(a, b any) bool {
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aj) == string(bj)
+}
+
+// ListFilter narrows List and Count results, the zero value matches every user
+type ListFilter struct {
+	// Attributes the user must contain, see Attributes.Contains, the values are strings
+	// unless the deployment schema types their property (AttributesValidator.CoerceFilter)
+	Attributes Attributes
+}
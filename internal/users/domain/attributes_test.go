@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttributes_Contains(t *testing.T) {
+	attrs := Attributes{
+		"department": "sales",
+		"age":        float64(30),
+		"active":     true,
+		"address":    map[string]any{"city": "Santiago"},
+	}
+
+	tests := []struct {
+		name     string
+		filter   Attributes
+		expected bool
+	}{
+		{name: "empty filter matches", filter: nil, expected: true},
+		{name: "string value", filter: Attributes{"department": "sales"}, expected: true},
+		{name: "int equals float", filter: Attributes{"age": 30}, expected: true},
+		{name: "several keys", filter: Attributes{"department": "sales", "active": true}, expected: true},
+		{name: "nested object", filter: Attributes{"address": map[string]any{"city": "Santiago"}}, expected: true},
+		{name: "different value", filter: Attributes{"department": "hr"}, expected: false},
+		{name: "different type", filter: Attributes{"age": "30"}, expected: false},
+		{name: "missing key", filter: Attributes{"locale": "es"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, attrs.Contains(tt.filter))
+		})
+	}
+}
+
+func TestAttributesError(t *testing.T) {
+	var err error = &AttributesError{Violations: []string{"/phone: expected string", "/age: minimum 0"}}
+
+	assert.True(t, errors.Is(err, ErrInvalidAttributes))
+	assert.Equal(t, "invalid attributes: /phone: expected string; /age: minimum 0", err.Error())
+}
@@ -11,4 +11,6 @@ var (
 	ErrUserNotFound = errors.New("user not found")
 	//login or maybe auth service will use this one
 	ErrInvalidCredentials = errors.New("invalid or password")
+	//the principal is neither the user nor an admin (ScopeUsersAdmin)
+	ErrForbidden = errors.New("forbidden")
 )
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GDPR data subject rights: access (export) and erasure
+
+var (
+	// ErrErasureJobNotFound the job does not exist or belongs to another user/tenant
+	ErrErasureJobNotFound = errors.New("erasure job not found")
+	// ErrUserErased the user personal data was already erased
+	ErrUserErased = errors.New("user already erased")
+)
+
+// ErasureStatus lifecycle of an erasure job: pending -> running -> completed | failed
+type ErasureStatus string
+
+const (
+	ErasurePending   ErasureStatus = "pending"
+	ErasureRunning   ErasureStatus = "running"
+	ErasureCompleted ErasureStatus = "completed"
+	ErasureFailed    ErasureStatus = "failed"
+)
+
+// ErasureJob tracks an erasure request, it never stores personal data of the user
+type ErasureJob struct {
+	ID          string // UUID v4
+	TenantID    string // assigned by the repository from the request context
+	UserID      string
+	Status      ErasureStatus
+	Error       string // failure reason, internal errors only, no PII
+	RequestedAt time.Time
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+// NewErasureJob creates a pending job for the user
+func NewErasureJob(userID string) *ErasureJob {
+	return &ErasureJob{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Status:      ErasurePending,
+		RequestedAt: time.Now(),
+	}
+}
+
+// Start moves the job to running
+func (j *ErasureJob) Start() {
+	now := time.Now()
+	j.Status = ErasureRunning
+	j.StartedAt = &now
+}
+
+// Complete moves the job to completed
+func (j *ErasureJob) Complete() {
+	now := time.Now()
+	j.Status = ErasureCompleted
+	j.CompletedAt = &now
+}
+
+// Fail moves the job to failed keeping the reason
+func (j *ErasureJob) Fail(err error) {
+	now := time.Now()
+	j.Status = ErasureFailed
+	j.Error = err.Error()
+	j.CompletedAt = &now
+}
+
+// IsActive pending or running, a new request for the same user reuses it
+func (j *ErasureJob) IsActive() bool {
+	return j.Status == ErasurePending || j.Status == ErasureRunning
+}
+
+// ErasureJobRepository persistence of erasure jobs, scoped by tenant like UserRepository
+type ErasureJobRepository interface {
+	Create(ctx context.Context, job *ErasureJob) error
+	GetByID(ctx context.Context, id string) (*ErasureJob, error)
+	Update(ctx context.Context, job *ErasureJob) error
+	// ListByUser newest first
+	ListByUser(ctx context.Context, userID string) ([]*ErasureJob, error)
+}
+
+// DataExport machine readable archive of everything stored about a user
+type DataExport struct {
+	FormatVersion int
+	GeneratedAt   time.Time
+	UserID        string
+	TenantID      string
+	// Sections by name (profile, erasure_jobs, sessions...), values are JSON serializable
+	Sections map[string]any
+}
+
+// DataExportSection contributes one section to the export and erases it, every store
+// holding user data registers one so the archive and the erasure stay complete as the
+// module grows
+type DataExportSection interface {
+	Name() string
+	Export(ctx context.Context, user *User) (any, error)
+	// Erase deletes or anonymizes the personal data of the section, must be idempotent so
+	// a failed erasure can be requested again
+	Erase(ctx context.Context, user *User) error
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErasureJob_Lifecycle(t *testing.T) {
+	job := NewErasureJob("user-id")
+	assert.Equal(t, ErasurePending, job.Status)
+	assert.True(t, job.IsActive())
+	assert.Nil(t, job.StartedAt)
+
+	job.Start()
+	assert.Equal(t, ErasureRunning, job.Status)
+	assert.True(t, job.IsActive())
+	assert.NotNil(t, job.StartedAt)
+
+	job.Complete()
+	assert.Equal(t, ErasureCompleted, job.Status)
+	assert.False(t, job.IsActive())
+	assert.NotNil(t, job.CompletedAt)
+}
+
+func TestErasureJob_Fail(t *testing.T) {
+	job := NewErasureJob("user-id")
+	job.Start()
+	job.Fail(errors.New("database unavailable"))
+
+	assert.Equal(t, ErasureFailed, job.Status)
+	assert.Equal(t, "database unavailable", job.Error)
+	assert.False(t, job.IsActive())
+	assert.NotNil(t, job.CompletedAt)
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository : Contract to access the user persistence layer
 // this is a CONTRACT, the real implementation will be in internal/infrastructure/persistence
@@ -8,12 +11,25 @@ import "context"
 // 1. decoupling domain layer from implmentation (Postgres, mongoDB, etc)
 // 2. Easy testing, mocks to simulate persistence layer
 // 3. Follows the Dependency Inversion Principle
+//
+// every implementation must pass the conformance suite in domain/repotest
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
+	// GetByID, GetByEmail, Update, List and Count only see users not soft deleted
 	GetByID(ctx context.Context, id string) (*User, error)
+	// GetIncludingDeleted also finds soft deleted users (erasure of a deleted user)
+	GetIncludingDeleted(ctx context.Context, id string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	Update(ctx context.Context, user *User) error
+	// Delete soft deletes the user (deleted_at), the row stays until PurgeDeleted removes
+	// or anonymizes it, its email stays taken until then
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, offset, limit int) ([]*User, error)
-	Count(ctx context.Context) (int64, error)
+	List(ctx context.Context, filter ListFilter, limit, offset int) ([]*User, error)
+	Count(ctx context.Context, filter ListFilter) (int64, error)
+	// Anonymize persists an anonymized user (see User.Anonymize), including deleted_at
+	Anonymize(ctx context.Context, user *User) error
+	// PurgeDeleted hard deletes or anonymizes (see PurgeMode) at most limit users soft deleted
+	// before cutoff, oldest first, returns how many were purged
+	// NOTE: it is a maintenance operation, it works across every tenant, ignoring the one in ctx
+	PurgeDeleted(ctx context.Context, mode PurgeMode, cutoff time.Time, limit int) (int64, error)
 }
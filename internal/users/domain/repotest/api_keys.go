@@ -0,0 +1,124 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// APIKeyFactory returns an EMPTY repository
+type APIKeyFactory func(t *testing.T) domain.APIKeyRepository
+
+// RunAPIKeys executes the domain.APIKeyRepository contract
+func RunAPIKeys(t *testing.T, newRepo APIKeyFactory) {
+	t.Helper()
+
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+
+	t.Run("Create and GetByID", func(t *testing.T) {
+		repo := newRepo(t)
+		expiresAt := time.Now().Add(24 * time.Hour)
+		key := newTestAPIKey(t, &expiresAt)
+		require.NoError(t, repo.Create(acme, key))
+		assert.Equal(t, "acme", key.TenantID)
+
+		found, err := repo.GetByID(acme, key.ID)
+		require.NoError(t, err)
+		AssertSameAPIKey(t, key, found)
+
+		_, err = repo.GetByID(acme, uuid.New().String())
+		assert.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+	})
+
+	t.Run("GetByPrefix spans tenants", func(t *testing.T) {
+		repo := newRepo(t)
+		key := newTestAPIKey(t, nil)
+		require.NoError(t, repo.Create(acme, key))
+
+		found, err := repo.GetByPrefix(globex, key.Prefix)
+		require.NoError(t, err)
+		AssertSameAPIKey(t, key, found)
+
+		_, err = repo.GetByPrefix(acme, "000000000000")
+		assert.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+	})
+
+	t.Run("List newest first per tenant", func(t *testing.T) {
+		repo := newRepo(t)
+		older := newTestAPIKey(t, nil)
+		older.CreatedAt = time.Now().Add(-time.Hour)
+		newer := newTestAPIKey(t, nil)
+		require.NoError(t, repo.Create(acme, older))
+		require.NoError(t, repo.Create(acme, newer))
+		require.NoError(t, repo.Create(globex, newTestAPIKey(t, nil)))
+
+		keys, err := repo.List(acme)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, newer.ID, keys[0].ID)
+		assert.Equal(t, older.ID, keys[1].ID)
+	})
+
+	t.Run("Revoke keeps the first time", func(t *testing.T) {
+		repo := newRepo(t)
+		key := newTestAPIKey(t, nil)
+		require.NoError(t, repo.Create(acme, key))
+
+		first := time.Now().Add(-time.Minute)
+		require.NoError(t, repo.Revoke(acme, key.ID, first))
+		require.NoError(t, repo.Revoke(acme, key.ID, time.Now()))
+
+		found, err := repo.GetByID(acme, key.ID)
+		require.NoError(t, err)
+		assertSameTime(t, &first, found.RevokedAt)
+
+		assert.ErrorIs(t, repo.Revoke(globex, key.ID, time.Now()), domain.ErrAPIKeyNotFound)
+	})
+
+	t.Run("TouchLastUsed", func(t *testing.T) {
+		repo := newRepo(t)
+		key := newTestAPIKey(t, nil)
+		require.NoError(t, repo.Create(acme, key))
+
+		usedAt := time.Now()
+		require.NoError(t, repo.TouchLastUsed(acme, key.ID, usedAt))
+
+		found, err := repo.GetByID(acme, key.ID)
+		require.NoError(t, err)
+		assertSameTime(t, &usedAt, found.LastUsedAt)
+
+		assert.ErrorIs(t, repo.TouchLastUsed(globex, key.ID, usedAt), domain.ErrAPIKeyNotFound)
+	})
+}
+
+// AssertSameAPIKey compares the persisted fields of two keys
+func AssertSameAPIKey(t *testing.T, want, got *domain.APIKey) {
+	t.Helper()
+
+	require.NotNil(t, got)
+	assert.Equal(t, want.ID, got.ID)
+	assert.Equal(t, want.TenantID, got.TenantID)
+	assert.Equal(t, want.Name, got.Name)
+	assert.Equal(t, want.Prefix, got.Prefix)
+	assert.Equal(t, want.KeyHash, got.KeyHash)
+	assert.Equal(t, want.Scopes, got.Scopes)
+	assert.WithinDuration(t, want.CreatedAt, got.CreatedAt, timestampTolerance)
+	assertSameTime(t, want.ExpiresAt, got.ExpiresAt)
+	assertSameTime(t, want.LastUsedAt, got.LastUsedAt)
+	assertSameTime(t, want.RevokedAt, got.RevokedAt)
+}
+
+func newTestAPIKey(t *testing.T, expiresAt *time.Time) *domain.APIKey {
+	t.Helper()
+
+	key, _, err := domain.NewAPIKey("test job", []string{domain.ScopeUsersRead}, expiresAt)
+	require.NoError(t, err)
+	return key
+}
@@ -0,0 +1,135 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ErasureJobFactory returns EMPTY repositories, jobs reference users so both are needed
+type ErasureJobFactory func(t *testing.T) (domain.UserRepository, domain.ErasureJobRepository)
+
+// RunErasureJobs executes the domain.ErasureJobRepository contract
+func RunErasureJobs(t *testing.T, newRepos ErasureJobFactory) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	t.Run("Create and GetByID", func(t *testing.T) {
+		users, jobs := newRepos(t)
+		user := createUser(t, users, ctx)
+
+		job := domain.NewErasureJob(user.ID)
+		require.NoError(t, jobs.Create(ctx, job))
+		assert.Equal(t, tenant.DefaultID, job.TenantID)
+
+		found, err := jobs.GetByID(ctx, job.ID)
+		require.NoError(t, err)
+		AssertSameErasureJob(t, job, found)
+	})
+
+	t.Run("GetByID not found", func(t *testing.T) {
+		_, jobs := newRepos(t)
+
+		_, err := jobs.GetByID(ctx, uuid.New().String())
+		assert.ErrorIs(t, err, domain.ErrErasureJobNotFound)
+	})
+
+	t.Run("Update status", func(t *testing.T) {
+		users, jobs := newRepos(t)
+		user := createUser(t, users, ctx)
+		job := domain.NewErasureJob(user.ID)
+		require.NoError(t, jobs.Create(ctx, job))
+
+		job.Start()
+		job.Fail(errors.New("boom"))
+		require.NoError(t, jobs.Update(ctx, job))
+
+		found, err := jobs.GetByID(ctx, job.ID)
+		require.NoError(t, err)
+		AssertSameErasureJob(t, job, found)
+
+		missing := domain.NewErasureJob(user.ID)
+		assert.ErrorIs(t, jobs.Update(ctx, missing), domain.ErrErasureJobNotFound)
+	})
+
+	t.Run("ListByUser newest first", func(t *testing.T) {
+		users, jobs := newRepos(t)
+		user := createUser(t, users, ctx)
+		other := createUser(t, users, ctx)
+
+		older := domain.NewErasureJob(user.ID)
+		older.RequestedAt = time.Now().Add(-time.Hour)
+		newer := domain.NewErasureJob(user.ID)
+		require.NoError(t, jobs.Create(ctx, older))
+		require.NoError(t, jobs.Create(ctx, newer))
+		require.NoError(t, jobs.Create(ctx, domain.NewErasureJob(other.ID)))
+
+		list, err := jobs.ListByUser(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, newer.ID, list[0].ID)
+		assert.Equal(t, older.ID, list[1].ID)
+	})
+
+	t.Run("TenantIsolation", func(t *testing.T) {
+		users, jobs := newRepos(t)
+		acme := tenant.WithID(ctx, "acme")
+		globex := tenant.WithID(ctx, "globex")
+		user := createUser(t, users, acme)
+
+		job := domain.NewErasureJob(user.ID)
+		require.NoError(t, jobs.Create(acme, job))
+
+		_, err := jobs.GetByID(globex, job.ID)
+		assert.ErrorIs(t, err, domain.ErrErasureJobNotFound)
+		assert.ErrorIs(t, jobs.Update(globex, job), domain.ErrErasureJobNotFound)
+
+		list, err := jobs.ListByUser(globex, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, list)
+	})
+}
+
+// AssertSameErasureJob compares the persisted fields of two jobs
+func AssertSameErasureJob(t *testing.T, want, got *domain.ErasureJob) {
+	t.Helper()
+
+	require.NotNil(t, got)
+	assert.Equal(t, want.ID, got.ID)
+	assert.Equal(t, want.TenantID, got.TenantID)
+	assert.Equal(t, want.UserID, got.UserID)
+	assert.Equal(t, want.Status, got.Status)
+	assert.Equal(t, want.Error, got.Error)
+	assert.WithinDuration(t, want.RequestedAt, got.RequestedAt, timestampTolerance)
+	assertSameTime(t, want.StartedAt, got.StartedAt)
+	assertSameTime(t, want.CompletedAt, got.CompletedAt)
+}
+
+func assertSameTime(t *testing.T, want, got *time.Time) {
+	t.Helper()
+
+	if want == nil {
+		assert.Nil(t, got)
+		return
+	}
+	if assert.NotNil(t, got) {
+		assert.WithinDuration(t, *want, *got, timestampTolerance)
+	}
+}
+
+// createUser stores a user with a unique email
+func createUser(t *testing.T, repo domain.UserRepository, ctx context.Context) *domain.User {
+	t.Helper()
+
+	user := NewTestUser(t, "John Doe", uuid.New().String()+"@example.com")
+	require.NoError(t, repo.Create(ctx, user))
+	return user
+}
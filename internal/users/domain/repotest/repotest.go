@@ -0,0 +1,677 @@
+// Package repotest is a conformance suite for domain.UserRepository implementations
+//
+// Any adapter (postgres, memory, future ones) can be validated with a single call:
+//
+//	func TestUserRepository_Contract(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) domain.UserRepository {
+//			return NewUserRepository(...)
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns an EMPTY repository, it is called once per test case so
+// every case starts from a clean state (truncate tables, new instance, etc)
+type Factory func(t *testing.T) domain.UserRepository
+
+// timestampTolerance absorbs precision loss on storage (postgres keeps microseconds)
+const timestampTolerance = time.Millisecond
+
+// Run executes the whole contract against the repository built by newRepo
+func Run(t *testing.T, newRepo Factory) {
+	t.Helper()
+
+	t.Run("Create", func(t *testing.T) { testCreate(t, newRepo) })
+	t.Run("GetByID", func(t *testing.T) { testGetByID(t, newRepo) })
+	t.Run("GetByEmail", func(t *testing.T) { testGetByEmail(t, newRepo) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newRepo) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newRepo) })
+	t.Run("List", func(t *testing.T) { testList(t, newRepo) })
+	t.Run("Count", func(t *testing.T) { testCount(t, newRepo) })
+	t.Run("TenantIsolation", func(t *testing.T) { testTenantIsolation(t, newRepo) })
+	t.Run("Attributes", func(t *testing.T) { testAttributes(t, newRepo) })
+	t.Run("Anonymize", func(t *testing.T) { testAnonymize(t, newRepo) })
+	t.Run("PurgeDeleted", func(t *testing.T) { testPurgeDeleted(t, newRepo) })
+}
+
+func testCreate(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("success - persists every field", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+
+		require.NoError(t, repo.Create(ctx, user))
+		assert.Equal(t, tenant.DefaultID, user.TenantID, "tenant must be assigned on create")
+
+		found, err := repo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		AssertSameUser(t, user, found)
+	})
+
+	t.Run("error - duplicate email", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		require.NoError(t, repo.Create(ctx, user))
+
+		duplicated := NewTestUser(t, "Jane Doe", user.Email)
+		err := repo.Create(ctx, duplicated)
+
+		assert.ErrorIs(t, err, domain.ErrEmailAlreadyExists)
+	})
+
+	t.Run("error - duplicate id", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		require.NoError(t, repo.Create(ctx, user))
+
+		duplicated := NewTestUser(t, "Jane Doe", "jane@example.com")
+		duplicated.ID = user.ID
+		assert.Error(t, repo.Create(ctx, duplicated))
+
+		// the first user is kept untouched
+		found, err := repo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		AssertSameUser(t, user, found)
+	})
+
+	t.Run("stored user is isolated from caller", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		require.NoError(t, repo.Create(ctx, user))
+
+		// mutating the caller copy must not change what is persisted
+		user.Name = "Mutated"
+
+		found, err := repo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "John Doe", found.Name)
+	})
+}
+
+func testGetByID(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("success - finds existing user", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		require.NoError(t, repo.Create(ctx, user))
+
+		found, err := repo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		AssertSameUser(t, user, found)
+	})
+
+	t.Run("error - user not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		found, err := repo.GetByID(ctx, uuid.New().String())
+
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		assert.Nil(t, found)
+	})
+}
+
+func testGetByEmail(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("success - finds user by email", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		require.NoError(t, repo.Create(ctx, user))
+
+		found, err := repo.GetByEmail(ctx, user.Email)
+		require.NoError(t, err)
+		AssertSameUser(t, user, found)
+	})
+
+	t.Run("error - email not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		found, err := repo.GetByEmail(ctx, "nonexistent@example.com")
+
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		assert.Nil(t, found)
+	})
+}
+
+func testUpdate(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("success - updates user", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		require.NoError(t, repo.Create(ctx, user))
+
+		previous := user.UpdatedAt
+		user.Name = "Jane Doe"
+		user.Email = "jane@example.com"
+		// the update_users_updated_at trigger owns updated_at, whatever the caller sends
+		user.UpdatedAt = previous.Add(-time.Hour)
+		require.NoError(t, repo.Update(ctx, user))
+		assert.False(t, user.UpdatedAt.Before(previous), "updated_at is refreshed by the store")
+
+		found, err := repo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		AssertSameUser(t, user, found)
+
+		// old email must be released
+		_, err = repo.GetByEmail(ctx, "john@example.com")
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+
+	t.Run("success - keeps own email", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		require.NoError(t, repo.Create(ctx, user))
+
+		user.Name = "Johnny"
+		assert.NoError(t, repo.Update(ctx, user))
+	})
+
+	t.Run("error - user not found", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+
+		err := repo.Update(ctx, user)
+
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+
+	t.Run("error - duplicate email on update", func(t *testing.T) {
+		repo := newRepo(t)
+		user1 := NewTestUser(t, "John Doe", "john@example.com")
+		user2 := NewTestUser(t, "Jane Doe", "jane@example.com")
+		require.NoError(t, repo.Create(ctx, user1))
+		require.NoError(t, repo.Create(ctx, user2))
+
+		user2.Email = user1.Email
+		err := repo.Update(ctx, user2)
+
+		assert.ErrorIs(t, err, domain.ErrEmailAlreadyExists)
+	})
+}
+
+func testDelete(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("success - deletes user", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		require.NoError(t, repo.Create(ctx, user))
+
+		require.NoError(t, repo.Delete(ctx, user.ID))
+
+		_, err := repo.GetByID(ctx, user.ID)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+
+	t.Run("success - soft deletes, the row is left for the purge", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		require.NoError(t, repo.Create(ctx, user))
+		before := time.Now().Add(-timestampTolerance)
+
+		require.NoError(t, repo.Delete(ctx, user.ID))
+
+		found, err := repo.GetIncludingDeleted(ctx, user.ID)
+		require.NoError(t, err)
+		require.True(t, found.IsDeleted())
+		assert.False(t, found.DeletedAt.Before(before))
+		assert.Equal(t, user.Email, found.Email, "personal data is kept until the purge")
+
+		_, err = repo.GetByEmail(ctx, user.Email)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		users, err := repo.List(ctx, domain.ListFilter{}, 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+		count, err := repo.Count(ctx, domain.ListFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+
+		// deleted users can not be edited
+		user.Name = "Jane Doe"
+		assert.ErrorIs(t, repo.Update(ctx, user), domain.ErrUserNotFound)
+
+		// the email stays taken until the purge
+		err = repo.Create(ctx, NewTestUser(t, "Jane Doe", user.Email))
+		assert.ErrorIs(t, err, domain.ErrEmailAlreadyExists)
+	})
+
+	t.Run("error - user not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.Delete(ctx, uuid.New().String())
+
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+
+	t.Run("error - deleting twice", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		require.NoError(t, repo.Create(ctx, user))
+		require.NoError(t, repo.Delete(ctx, user.ID))
+
+		err := repo.Delete(ctx, user.ID)
+
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+}
+
+func testList(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("success - returns empty list when no users", func(t *testing.T) {
+		repo := newRepo(t)
+
+		users, err := repo.List(ctx, domain.ListFilter{}, 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	})
+
+	t.Run("success - newest first with pagination", func(t *testing.T) {
+		repo := newRepo(t)
+		created := seedUsers(t, repo, 5)
+
+		// created_at DESC: last seeded is first
+		page1, err := repo.List(ctx, domain.ListFilter{}, 2, 0)
+		require.NoError(t, err)
+		require.Len(t, page1, 2)
+		assert.Equal(t, created[4].ID, page1[0].ID)
+		assert.Equal(t, created[3].ID, page1[1].ID)
+
+		page2, err := repo.List(ctx, domain.ListFilter{}, 2, 2)
+		require.NoError(t, err)
+		require.Len(t, page2, 2)
+		assert.Equal(t, created[2].ID, page2[0].ID)
+		assert.Equal(t, created[1].ID, page2[1].ID)
+
+		page3, err := repo.List(ctx, domain.ListFilter{}, 2, 4)
+		require.NoError(t, err)
+		require.Len(t, page3, 1)
+		assert.Equal(t, created[0].ID, page3[0].ID)
+	})
+
+	t.Run("success - offset beyond total", func(t *testing.T) {
+		repo := newRepo(t)
+		seedUsers(t, repo, 2)
+
+		users, err := repo.List(ctx, domain.ListFilter{}, 10, 10)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	})
+}
+
+func testCount(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("success - counts users", func(t *testing.T) {
+		repo := newRepo(t)
+
+		count, err := repo.Count(ctx, domain.ListFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+
+		seedUsers(t, repo, 3)
+
+		count, err = repo.Count(ctx, domain.ListFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("success - reflects deletes", func(t *testing.T) {
+		repo := newRepo(t)
+		created := seedUsers(t, repo, 2)
+		require.NoError(t, repo.Delete(ctx, created[0].ID))
+
+		count, err := repo.Count(ctx, domain.ListFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+}
+
+func testTenantIsolation(t *testing.T, newRepo Factory) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+
+	t.Run("same email allowed in different tenants", func(t *testing.T) {
+		repo := newRepo(t)
+
+		require.NoError(t, repo.Create(acme, NewTestUser(t, "John Acme", "john@example.com")))
+		require.NoError(t, repo.Create(globex, NewTestUser(t, "John Globex", "john@example.com")))
+
+		found, err := repo.GetByEmail(globex, "john@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "John Globex", found.Name)
+		assert.Equal(t, "globex", found.TenantID)
+	})
+
+	t.Run("users of other tenants are invisible", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Acme", "john@example.com")
+		require.NoError(t, repo.Create(acme, user))
+
+		_, err := repo.GetByID(globex, user.ID)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+		_, err = repo.GetByEmail(globex, user.Email)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+		users, err := repo.List(globex, domain.ListFilter{}, 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+
+		count, err := repo.Count(globex, domain.ListFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+
+		count, err = repo.Count(acme, domain.ListFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("users of other tenants can not be modified", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Acme", "john@example.com")
+		require.NoError(t, repo.Create(acme, user))
+
+		user.Name = "Hijacked"
+		assert.ErrorIs(t, repo.Update(globex, user), domain.ErrUserNotFound)
+		assert.ErrorIs(t, repo.Delete(globex, user.ID), domain.ErrUserNotFound)
+
+		found, err := repo.GetByID(acme, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "John Acme", found.Name)
+	})
+}
+
+func testAttributes(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("success - round trip", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		user.Attributes = domain.Attributes{
+			"phone":   "+56 9 1234 5678",
+			"age":     30,
+			"tags":    []any{"vip", "beta"},
+			"address": map[string]any{"city": "Santiago"},
+		}
+		require.NoError(t, repo.Create(ctx, user))
+
+		found, err := repo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		AssertSameUser(t, user, found)
+	})
+
+	t.Run("success - nil attributes are stored as empty", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		user.Attributes = nil
+		require.NoError(t, repo.Create(ctx, user))
+
+		found, err := repo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, found.Attributes)
+		assert.Empty(t, found.Attributes)
+	})
+
+	t.Run("success - update replaces attributes", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		user.Attributes = domain.Attributes{"department": "sales", "locale": "es"}
+		require.NoError(t, repo.Create(ctx, user))
+
+		user.Attributes = domain.Attributes{"department": "hr"}
+		require.NoError(t, repo.Update(ctx, user))
+
+		found, err := repo.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.Attributes{"department": "hr"}, found.Attributes)
+	})
+
+	t.Run("success - list and count filter by attributes", func(t *testing.T) {
+		repo := newRepo(t)
+		users := seedUsers(t, repo, 4)
+		setAttributes(t, repo, users[0], domain.Attributes{"department": "sales", "level": 1})
+		setAttributes(t, repo, users[1], domain.Attributes{"department": "sales", "level": 2})
+		setAttributes(t, repo, users[2], domain.Attributes{"department": "hr", "level": 1})
+
+		sales := domain.ListFilter{Attributes: domain.Attributes{"department": "sales"}}
+		found, err := repo.List(ctx, sales, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		// still newest first
+		assert.Equal(t, users[1].ID, found[0].ID)
+		assert.Equal(t, users[0].ID, found[1].ID)
+
+		count, err := repo.Count(ctx, sales)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+
+		// every key must match, numbers compare by value
+		salesLevel1 := domain.ListFilter{Attributes: domain.Attributes{"department": "sales", "level": 1}}
+		found, err = repo.List(ctx, salesLevel1, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, users[0].ID, found[0].ID)
+
+		none := domain.ListFilter{Attributes: domain.Attributes{"department": "finance"}}
+		count, err = repo.Count(ctx, none)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func testAnonymize(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("success - overwrites personal data and keeps the row", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		user.Attributes = domain.Attributes{"phone": "+56 9 1234 5678"}
+		require.NoError(t, repo.Create(ctx, user))
+
+		user.Anonymize()
+		require.NoError(t, repo.Anonymize(ctx, user))
+
+		found, err := repo.GetIncludingDeleted(ctx, user.ID)
+		require.NoError(t, err)
+		AssertSameUser(t, user, found)
+		assert.True(t, found.IsDeleted())
+
+		// an anonymized user is deleted
+		_, err = repo.GetByID(ctx, user.ID)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		_, err = repo.GetByEmail(ctx, "john@example.com")
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		count, err := repo.Count(ctx, domain.ListFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("error - user not found", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser(t, "John Doe", "john@example.com")
+		user.Anonymize()
+
+		assert.ErrorIs(t, repo.Anonymize(ctx, user), domain.ErrUserNotFound)
+	})
+}
+
+func testPurgeDeleted(t *testing.T, newRepo Factory) {
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+	now := time.Now()
+	cutoff := now.Add(-5 * 24 * time.Hour)
+
+	t.Run("success - deletes users past the retention of every tenant", func(t *testing.T) {
+		repo := newRepo(t)
+		old := createUser(t, repo, acme)
+		softDelete(t, repo, acme, old, now.Add(-10*24*time.Hour))
+		recent := createUser(t, repo, acme)
+		softDelete(t, repo, acme, recent, now.Add(-24*time.Hour))
+		active := createUser(t, repo, acme)
+		other := createUser(t, repo, globex)
+		softDelete(t, repo, globex, other, now.Add(-10*24*time.Hour))
+
+		purged, err := repo.PurgeDeleted(context.Background(), domain.PurgeDelete, cutoff, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), purged)
+
+		_, err = repo.GetIncludingDeleted(acme, old.ID)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		_, err = repo.GetIncludingDeleted(globex, other.ID)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+		_, err = repo.GetIncludingDeleted(acme, recent.ID)
+		assert.NoError(t, err)
+		_, err = repo.GetByID(acme, active.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("success - batches oldest first", func(t *testing.T) {
+		repo := newRepo(t)
+		users := make([]*domain.User, 3)
+		for i := range users {
+			users[i] = createUser(t, repo, acme)
+			softDelete(t, repo, acme, users[i], now.Add(-time.Duration(10-i)*24*time.Hour))
+		}
+
+		for _, want := range []int64{2, 1, 0} {
+			purged, err := repo.PurgeDeleted(acme, domain.PurgeDelete, cutoff, 2)
+			require.NoError(t, err)
+			assert.Equal(t, want, purged)
+
+			if want == 2 {
+				// the newest deletion is left for the next batch
+				_, err = repo.GetIncludingDeleted(acme, users[2].ID)
+				assert.NoError(t, err)
+			}
+		}
+	})
+
+	t.Run("success - anonymize mode keeps the row", func(t *testing.T) {
+		repo := newRepo(t)
+		user := createUser(t, repo, acme)
+		deletedAt := now.Add(-10 * 24 * time.Hour)
+		softDelete(t, repo, acme, user, deletedAt)
+
+		purged, err := repo.PurgeDeleted(acme, domain.PurgeAnonymize, cutoff, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		found, err := repo.GetIncludingDeleted(acme, user.ID)
+		require.NoError(t, err)
+		assert.True(t, found.IsAnonymized())
+		assert.Equal(t, domain.ErasedName, found.Name)
+		assert.Empty(t, found.PasswordHash)
+		assert.Empty(t, found.Attributes)
+		assertSameTime(t, &deletedAt, found.DeletedAt)
+
+		// already anonymized users are not purged again
+		purged, err = repo.PurgeDeleted(acme, domain.PurgeAnonymize, cutoff, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), purged)
+	})
+
+	t.Run("error - unknown mode", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.PurgeDeleted(acme, domain.PurgeMode("truncate"), cutoff, 10)
+		assert.Error(t, err)
+	})
+}
+
+// ============================================================
+// HELPERS - exported so adapters can reuse them in their own tests
+// ============================================================
+
+// NewTestUser builds a valid domain user, fails the test on error
+func NewTestUser(t *testing.T, name, email string) *domain.User {
+	t.Helper()
+
+	user, err := domain.NewUser(name, email, "SecurePass123!")
+	require.NoError(t, err)
+
+	return user
+}
+
+// AssertSameUser compares the persisted fields of two users
+func AssertSameUser(t *testing.T, want, got *domain.User) {
+	t.Helper()
+
+	require.NotNil(t, got)
+	assert.Equal(t, want.ID, got.ID)
+	assert.Equal(t, want.TenantID, got.TenantID)
+	assert.Equal(t, want.Name, got.Name)
+	assert.Equal(t, want.Email, got.Email)
+	assert.Equal(t, want.PasswordHash, got.PasswordHash)
+	assertSameAttributes(t, want.Attributes, got.Attributes)
+	assert.WithinDuration(t, want.CreatedAt, got.CreatedAt, timestampTolerance)
+	assert.WithinDuration(t, want.UpdatedAt, got.UpdatedAt, timestampTolerance)
+	assertSameTime(t, want.DeletedAt, got.DeletedAt)
+}
+
+// assertSameAttributes compares through JSON, storage returns numbers as float64 and
+// nil as an empty object
+func assertSameAttributes(t *testing.T, want, got domain.Attributes) {
+	t.Helper()
+
+	if want == nil {
+		want = domain.Attributes{}
+	}
+	wantJSON, err := json.Marshal(want)
+	require.NoError(t, err)
+	gotJSON, err := json.Marshal(got)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, string(wantJSON), string(gotJSON))
+}
+
+// setAttributes replaces the attributes of a stored user
+func setAttributes(t *testing.T, repo domain.UserRepository, user *domain.User, attrs domain.Attributes) {
+	t.Helper()
+
+	user.Attributes = attrs
+	require.NoError(t, repo.Update(context.Background(), user))
+}
+
+// softDelete flags a stored user as deleted at the given time, keeping its personal data
+// Delete stamps the current time, Anonymize persists the deleted_at of the user
+func softDelete(t *testing.T, repo domain.UserRepository, ctx context.Context, user *domain.User, at time.Time) {
+	t.Helper()
+
+	user.DeletedAt = &at
+	require.NoError(t, repo.Anonymize(ctx, user))
+}
+
+// seedUsers creates n users with strictly increasing created_at, oldest first
+func seedUsers(t *testing.T, repo domain.UserRepository, n int) []*domain.User {
+	t.Helper()
+
+	base := time.Now().Add(-time.Duration(n) * time.Minute)
+	users := make([]*domain.User, n)
+	for i := 0; i < n; i++ {
+		user := NewTestUser(t, fmt.Sprintf("User %d", i), fmt.Sprintf("user%d@example.com", i))
+		user.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		user.UpdatedAt = user.CreatedAt
+		require.NoError(t, repo.Create(context.Background(), user))
+		users[i] = user
+	}
+
+	return users
+}
@@ -0,0 +1,183 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// SessionFactory returns EMPTY repositories, sessions reference users so both are needed
+type SessionFactory func(t *testing.T) (domain.UserRepository, domain.SessionRepository)
+
+// RunSessions executes the domain.SessionRepository contract
+func RunSessions(t *testing.T, newRepos SessionFactory) {
+	t.Helper()
+
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+
+	t.Run("Create and GetByID", func(t *testing.T) {
+		users, sessions := newRepos(t)
+		user := createUser(t, users, acme)
+
+		session := domain.NewSession(user.ID, "Pixel 8", "Mozilla/5.0", "203.0.113.7")
+		require.NoError(t, sessions.Create(acme, session))
+		assert.Equal(t, "acme", session.TenantID)
+
+		found, err := sessions.GetByID(acme, session.ID)
+		require.NoError(t, err)
+		AssertSameSession(t, session, found)
+
+		_, err = sessions.GetByID(globex, session.ID)
+		assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+		_, err = sessions.GetByID(acme, uuid.New().String())
+		assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+	})
+
+	t.Run("ListByUser most recently seen first", func(t *testing.T) {
+		users, sessions := newRepos(t)
+		user := createUser(t, users, acme)
+		other := createUser(t, users, acme)
+
+		oldest := domain.NewSession(user.ID, "tablet", "", "")
+		oldest.LastSeenAt = time.Now().Add(-2 * time.Hour)
+		older := domain.NewSession(user.ID, "laptop", "", "")
+		older.LastSeenAt = time.Now().Add(-time.Hour)
+		newer := domain.NewSession(user.ID, "phone", "", "")
+		for _, s := range []*domain.Session{older, newer, oldest, domain.NewSession(other.ID, "", "", "")} {
+			require.NoError(t, sessions.Create(acme, s))
+		}
+		require.NoError(t, sessions.Revoke(acme, oldest.ID, time.Now()))
+
+		found, err := sessions.ListByUser(acme, user.ID)
+		require.NoError(t, err)
+		require.Len(t, found, 3)
+		assert.Equal(t, newer.ID, found[0].ID)
+		assert.Equal(t, older.ID, found[1].ID)
+		assert.Equal(t, oldest.ID, found[2].ID)
+		assert.True(t, found[2].IsRevoked(), "revoked sessions are listed too")
+
+		found, err = sessions.ListByUser(globex, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+	})
+
+	t.Run("Revoke keeps the first time", func(t *testing.T) {
+		users, sessions := newRepos(t)
+		user := createUser(t, users, acme)
+		session := domain.NewSession(user.ID, "", "", "")
+		require.NoError(t, sessions.Create(acme, session))
+
+		first := time.Now().Add(-time.Minute)
+		require.NoError(t, sessions.Revoke(acme, session.ID, first))
+		require.NoError(t, sessions.Revoke(acme, session.ID, time.Now()))
+
+		found, err := sessions.GetByID(acme, session.ID)
+		require.NoError(t, err)
+		assertSameTime(t, &first, found.RevokedAt)
+
+		assert.ErrorIs(t, sessions.Revoke(globex, session.ID, time.Now()), domain.ErrSessionNotFound)
+	})
+
+	t.Run("RevokeAllByUser", func(t *testing.T) {
+		users, sessions := newRepos(t)
+		user := createUser(t, users, acme)
+		other := createUser(t, users, acme)
+
+		first := domain.NewSession(user.ID, "", "", "")
+		second := domain.NewSession(user.ID, "", "", "")
+		already := domain.NewSession(user.ID, "", "", "")
+		untouched := domain.NewSession(other.ID, "", "", "")
+		for _, s := range []*domain.Session{first, second, already, untouched} {
+			require.NoError(t, sessions.Create(acme, s))
+		}
+		revokedAt := time.Now().Add(-time.Hour)
+		require.NoError(t, sessions.Revoke(acme, already.ID, revokedAt))
+
+		revoked, err := sessions.RevokeAllByUser(globex, user.ID, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, revoked, "other tenant")
+
+		revoked, err = sessions.RevokeAllByUser(acme, user.ID, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), revoked)
+
+		found, err := sessions.GetByID(acme, first.ID)
+		require.NoError(t, err)
+		assert.True(t, found.IsRevoked())
+
+		found, err = sessions.GetByID(acme, already.ID)
+		require.NoError(t, err)
+		assertSameTime(t, &revokedAt, found.RevokedAt)
+
+		found, err = sessions.GetByID(acme, untouched.ID)
+		require.NoError(t, err)
+		assert.False(t, found.IsRevoked())
+	})
+
+	t.Run("DeleteAllByUser", func(t *testing.T) {
+		users, sessions := newRepos(t)
+		user := createUser(t, users, acme)
+		other := createUser(t, users, acme)
+
+		active := domain.NewSession(user.ID, "", "", "")
+		revoked := domain.NewSession(user.ID, "", "", "")
+		untouched := domain.NewSession(other.ID, "", "", "")
+		for _, s := range []*domain.Session{active, revoked, untouched} {
+			require.NoError(t, sessions.Create(acme, s))
+		}
+		require.NoError(t, sessions.Revoke(acme, revoked.ID, time.Now()))
+
+		deleted, err := sessions.DeleteAllByUser(globex, user.ID)
+		require.NoError(t, err)
+		assert.Zero(t, deleted, "other tenant")
+
+		deleted, err = sessions.DeleteAllByUser(acme, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+
+		found, err := sessions.ListByUser(acme, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+		_, err = sessions.GetByID(acme, untouched.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("TouchLastSeen", func(t *testing.T) {
+		users, sessions := newRepos(t)
+		user := createUser(t, users, acme)
+		session := domain.NewSession(user.ID, "", "", "")
+		require.NoError(t, sessions.Create(acme, session))
+
+		seenAt := time.Now().Add(time.Minute)
+		require.NoError(t, sessions.TouchLastSeen(acme, session.ID, seenAt))
+
+		found, err := sessions.GetByID(acme, session.ID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, seenAt, found.LastSeenAt, timestampTolerance)
+
+		assert.ErrorIs(t, sessions.TouchLastSeen(globex, session.ID, seenAt), domain.ErrSessionNotFound)
+	})
+}
+
+// AssertSameSession compares the persisted fields of two sessions
+func AssertSameSession(t *testing.T, want, got *domain.Session) {
+	t.Helper()
+
+	require.NotNil(t, got)
+	assert.Equal(t, want.ID, got.ID)
+	assert.Equal(t, want.TenantID, got.TenantID)
+	assert.Equal(t, want.UserID, got.UserID)
+	assert.Equal(t, want.Device, got.Device)
+	assert.Equal(t, want.UserAgent, got.UserAgent)
+	assert.Equal(t, want.IP, got.IP)
+	assert.WithinDuration(t, want.CreatedAt, got.CreatedAt, timestampTolerance)
+	assert.WithinDuration(t, want.LastSeenAt, got.LastSeenAt, timestampTolerance)
+	assertSameTime(t, want.RevokedAt, got.RevokedAt)
+}
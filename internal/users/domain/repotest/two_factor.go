@@ -0,0 +1,159 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TwoFactorFactory returns EMPTY repositories, enrollments reference users so both are needed
+type TwoFactorFactory func(t *testing.T) (domain.UserRepository, domain.TwoFactorRepository)
+
+// RunTwoFactor executes the domain.TwoFactorRepository contract
+func RunTwoFactor(t *testing.T, newRepos TwoFactorFactory) {
+	t.Helper()
+
+	acme := tenant.WithID(context.Background(), "acme")
+	globex := tenant.WithID(context.Background(), "globex")
+
+	t.Run("Save and Get", func(t *testing.T) {
+		users, enrollments := newRepos(t)
+		user := createUser(t, users, acme)
+
+		_, err := enrollments.Get(acme, user.ID)
+		assert.ErrorIs(t, err, domain.ErrTwoFactorNotEnrolled)
+
+		pending := newTestTwoFactor(user.ID)
+		require.NoError(t, enrollments.Save(acme, pending))
+		assert.Equal(t, "acme", pending.TenantID)
+
+		found, err := enrollments.Get(acme, user.ID)
+		require.NoError(t, err)
+		AssertSameTwoFactor(t, pending, found)
+
+		_, err = enrollments.Get(globex, user.ID)
+		assert.ErrorIs(t, err, domain.ErrTwoFactorNotEnrolled)
+
+		// confirmation replaces the pending enrollment
+		confirmedAt := time.Now()
+		confirmed := newTestTwoFactor(user.ID)
+		confirmed.ConfirmedAt = &confirmedAt
+		confirmed.RecoveryCodeHashes = []string{domain.HashRecoveryCode("aaaaa-bbbbb")}
+		confirmed.LastUsedStep = 42
+		require.NoError(t, enrollments.Save(acme, confirmed))
+
+		found, err = enrollments.Get(acme, user.ID)
+		require.NoError(t, err)
+		AssertSameTwoFactor(t, confirmed, found)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		users, enrollments := newRepos(t)
+		user := createUser(t, users, acme)
+		require.NoError(t, enrollments.Save(acme, newTestTwoFactor(user.ID)))
+
+		assert.ErrorIs(t, enrollments.Delete(globex, user.ID), domain.ErrTwoFactorNotEnrolled)
+		require.NoError(t, enrollments.Delete(acme, user.ID))
+
+		_, err := enrollments.Get(acme, user.ID)
+		assert.ErrorIs(t, err, domain.ErrTwoFactorNotEnrolled)
+		assert.ErrorIs(t, enrollments.Delete(acme, user.ID), domain.ErrTwoFactorNotEnrolled)
+	})
+
+	t.Run("UseStep rejects replays", func(t *testing.T) {
+		users, enrollments := newRepos(t)
+		user := createUser(t, users, acme)
+		enrollment := newTestTwoFactor(user.ID)
+		enrollment.LastUsedStep = 100
+		require.NoError(t, enrollments.Save(acme, enrollment))
+
+		assert.ErrorIs(t, enrollments.UseStep(acme, user.ID, 100), domain.ErrInvalidOTP)
+		require.NoError(t, enrollments.UseStep(acme, user.ID, 101))
+		assert.ErrorIs(t, enrollments.UseStep(acme, user.ID, 101), domain.ErrInvalidOTP)
+		assert.ErrorIs(t, enrollments.UseStep(acme, user.ID, 100), domain.ErrInvalidOTP)
+
+		found, err := enrollments.Get(acme, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(101), found.LastUsedStep)
+
+		assert.ErrorIs(t, enrollments.UseStep(globex, user.ID, 102), domain.ErrTwoFactorNotEnrolled)
+	})
+
+	t.Run("UseRecoveryCode works once", func(t *testing.T) {
+		users, enrollments := newRepos(t)
+		user := createUser(t, users, acme)
+		first := domain.HashRecoveryCode("aaaaa-bbbbb")
+		second := domain.HashRecoveryCode("ccccc-ddddd")
+		enrollment := newTestTwoFactor(user.ID)
+		enrollment.RecoveryCodeHashes = []string{first, second}
+		require.NoError(t, enrollments.Save(acme, enrollment))
+
+		require.NoError(t, enrollments.UseRecoveryCode(acme, user.ID, first))
+		assert.ErrorIs(t, enrollments.UseRecoveryCode(acme, user.ID, first), domain.ErrInvalidOTP)
+		assert.ErrorIs(t, enrollments.UseRecoveryCode(acme, user.ID, domain.HashRecoveryCode("zzzzz-zzzzz")),
+			domain.ErrInvalidOTP)
+
+		found, err := enrollments.Get(acme, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{second}, found.RecoveryCodeHashes)
+
+		assert.ErrorIs(t, enrollments.UseRecoveryCode(globex, user.ID, second), domain.ErrTwoFactorNotEnrolled)
+	})
+
+	t.Run("challenge attempts are capped", func(t *testing.T) {
+		users, enrollments := newRepos(t)
+		user := createUser(t, users, acme)
+		first, second := uuid.NewString(), uuid.NewString()
+
+		assert.ErrorIs(t, enrollments.StartChallenge(acme, user.ID, first), domain.ErrTwoFactorNotEnrolled)
+		require.NoError(t, enrollments.Save(acme, newTestTwoFactor(user.ID)))
+		require.NoError(t, enrollments.StartChallenge(acme, user.ID, first))
+
+		require.NoError(t, enrollments.AttemptChallenge(acme, user.ID, first, 2))
+		require.NoError(t, enrollments.AttemptChallenge(acme, user.ID, first, 2))
+		assert.ErrorIs(t, enrollments.AttemptChallenge(acme, user.ID, first, 2), domain.ErrInvalidChallenge)
+		assert.ErrorIs(t, enrollments.AttemptChallenge(globex, user.ID, first, 2), domain.ErrInvalidChallenge)
+
+		// a new challenge replaces the previous one
+		require.NoError(t, enrollments.StartChallenge(acme, user.ID, second))
+		assert.ErrorIs(t, enrollments.AttemptChallenge(acme, user.ID, first, 2), domain.ErrInvalidChallenge)
+		require.NoError(t, enrollments.AttemptChallenge(acme, user.ID, second, 2))
+
+		// Save (confirmation, replays) keeps the challenge, ending it discards it
+		found, err := enrollments.Get(acme, user.ID)
+		require.NoError(t, err)
+		require.NoError(t, enrollments.Save(acme, found))
+		require.NoError(t, enrollments.EndChallenge(acme, user.ID, first))
+		require.NoError(t, enrollments.AttemptChallenge(acme, user.ID, second, 2))
+		require.NoError(t, enrollments.EndChallenge(acme, user.ID, second))
+		assert.ErrorIs(t, enrollments.AttemptChallenge(acme, user.ID, second, 2), domain.ErrInvalidChallenge)
+	})
+}
+
+// AssertSameTwoFactor compares the persisted fields of two enrollments
+func AssertSameTwoFactor(t *testing.T, want, got *domain.TwoFactor) {
+	t.Helper()
+
+	require.NotNil(t, got)
+	assert.Equal(t, want.UserID, got.UserID)
+	assert.Equal(t, want.TenantID, got.TenantID)
+	assert.Equal(t, want.EncryptedSecret, got.EncryptedSecret)
+	assert.ElementsMatch(t, want.RecoveryCodeHashes, got.RecoveryCodeHashes)
+	assert.Equal(t, want.LastUsedStep, got.LastUsedStep)
+	assert.WithinDuration(t, want.CreatedAt, got.CreatedAt, timestampTolerance)
+	assertSameTime(t, want.ConfirmedAt, got.ConfirmedAt)
+}
+
+func newTestTwoFactor(userID string) *domain.TwoFactor {
+	return &domain.TwoFactor{
+		UserID:          userID,
+		EncryptedSecret: "sealed-secret",
+		CreatedAt:       time.Now(),
+	}
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "fmt"
+
+// PurgeMode what the retention purge does with the users soft deleted longer than the retention
+type PurgeMode string
+
+const (
+	// PurgeDelete removes the row, rows referencing the user are deleted by cascade
+	PurgeDelete PurgeMode = "delete"
+	// PurgeAnonymize keeps the row but erases its personal data (see User.Anonymize),
+	// users already anonymized are skipped
+	PurgeAnonymize PurgeMode = "anonymize"
+)
+
+// ParsePurgeMode validates a mode coming from the configuration
+func ParsePurgeMode(mode string) (PurgeMode, error) {
+	switch m := PurgeMode(mode); m {
+	case PurgeDelete, PurgeAnonymize:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown purge mode %q", mode)
+	}
+}
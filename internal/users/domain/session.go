@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sessions: every user token belongs to a session (sid claim), revoking the session
+// invalidates its tokens before they expire
+
+var (
+	// ErrSessionNotFound the session does not exist or belongs to another user/tenant
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionRevoked the session was revoked (logout), its tokens are no longer valid
+	ErrSessionRevoked = errors.New("session revoked")
+)
+
+// maximum stored length of the client provided fields, same as the columns (migration 000008)
+const (
+	maxSessionDeviceLength    = 100
+	maxSessionUserAgentLength = 512
+)
+
+// Session is a login of a user from a client
+type Session struct {
+	ID         string // UUID v4, the sid claim of the tokens
+	TenantID   string // assigned by the repository from the request context
+	UserID     string
+	Device     string // client provided name, e.g. "Pixel 8"
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  *time.Time
+}
+
+// NewSession creates an active session of the user, the client provided fields are
+// truncated to the column sizes
+func NewSession(userID, device, userAgent, ip string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		Device:     truncate(strings.TrimSpace(device), maxSessionDeviceLength),
+		UserAgent:  truncate(userAgent, maxSessionUserAgentLength),
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+}
+
+// IsRevoked checks if the session was revoked
+func (s *Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+// truncate cuts s to max runes
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
+
+// SessionRepository persistence of sessions, scoped by tenant like UserRepository
+type SessionRepository interface {
+	Create(ctx context.Context, session *Session) error
+	GetByID(ctx context.Context, id string) (*Session, error)
+	// ListByUser returns the sessions of the user, most recently seen first, revoked ones included
+	ListByUser(ctx context.Context, userID string) ([]*Session, error)
+	// Revoke sets revoked_at, revoking twice keeps the first time
+	Revoke(ctx context.Context, id string, at time.Time) error
+	// RevokeAllByUser revokes every active session of the user ("log out everywhere"),
+	// returns how many were revoked
+	RevokeAllByUser(ctx context.Context, userID string, at time.Time) (int64, error)
+	// DeleteAllByUser removes every session of the user, revoked ones included (erasure),
+	// returns how many were deleted
+	DeleteAllByUser(ctx context.Context, userID string) (int64, error)
+	TouchLastSeen(ctx context.Context, id string, at time.Time) error
+}
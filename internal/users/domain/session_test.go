@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSession(t *testing.T) {
+	session := NewSession("user-1", " Pixel 8 ", "Mozilla/5.0", "203.0.113.7")
+
+	assert.NotEmpty(t, session.ID)
+	assert.Equal(t, "user-1", session.UserID)
+	assert.Equal(t, "Pixel 8", session.Device)
+	assert.Equal(t, "Mozilla/5.0", session.UserAgent)
+	assert.Equal(t, "203.0.113.7", session.IP)
+	assert.Equal(t, session.CreatedAt, session.LastSeenAt)
+	assert.False(t, session.IsRevoked())
+}
+
+func TestNewSession_TruncatesClientFields(t *testing.T) {
+	session := NewSession("user-1", strings.Repeat("é", 150), strings.Repeat("a", 1000), "")
+
+	assert.Len(t, []rune(session.Device), maxSessionDeviceLength)
+	assert.Len(t, session.UserAgent, maxSessionUserAgentLength)
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Two-factor authentication: RFC 6238 TOTP with one-time recovery codes
+
+var (
+	// ErrTwoFactorNotEnrolled the user has no TOTP enrollment (pending or confirmed)
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication not enrolled")
+	// ErrTwoFactorAlreadyEnabled the enrollment was already confirmed
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	// ErrInvalidOTP wrong, expired or already used TOTP or recovery code
+	ErrInvalidOTP = errors.New("invalid verification code")
+	// ErrInvalidChallenge the login challenge token is malformed or expired
+	ErrInvalidChallenge = errors.New("invalid or expired login challenge")
+)
+
+// RecoveryCodeCount recovery codes generated at confirmation, each one works once
+const RecoveryCodeCount = 10
+
+// recoveryCodeBytes random bytes of a code, the first 10 base32 chars (50 bits) are kept
+// and shown as xxxxx-xxxxx
+const recoveryCodeBytes = 7
+
+// SecretCipher encrypts the TOTP secrets at rest, implemented by pkg/secretbox
+type SecretCipher interface {
+	Seal(plaintext []byte) (string, error)
+	Open(sealed string) ([]byte, error)
+}
+
+// TwoFactor is the TOTP enrollment of a user, pending until the first code confirms the
+// authenticator app was set up
+type TwoFactor struct {
+	UserID          string
+	TenantID        string // assigned by the repository from the request context
+	EncryptedSecret string // sealed with SecretCipher, never stored in plain
+	// RecoveryCodeHashes hex SHA-256 of the unused recovery codes
+	RecoveryCodeHashes []string
+	// LastUsedStep TOTP time step of the last accepted code, a code works only once
+	LastUsedStep int64
+	CreatedAt    time.Time
+	ConfirmedAt  *time.Time
+}
+
+// IsEnabled the enrollment was confirmed, logins require a second step
+func (t *TwoFactor) IsEnabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+// NewRecoveryCodes returns RecoveryCodeCount codes and their hashes, only the hashes are
+// stored, the codes are shown once
+func NewRecoveryCodes() (codes []string, hashes []string, err error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes = make([]string, RecoveryCodeCount)
+	hashes = make([]string, RecoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(raw))
+		codes[i] = code[:5] + "-" + code[5:10]
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode hashes a code typed by the user, case, spaces and dashes are ignored,
+// a fast hash is enough because the codes are random (same as API keys)
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsRecoveryCode tells a recovery code apart from a TOTP code (digits only)
+func IsRecoveryCode(code string) bool {
+	return strings.ContainsFunc(code, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+}
+
+// TwoFactorRepository persistence of the TOTP enrollments, scoped by tenant like UserRepository
+type TwoFactorRepository interface {
+	// Get returns ErrTwoFactorNotEnrolled when the user has no enrollment
+	Get(ctx context.Context, userID string) (*TwoFactor, error)
+	// Save inserts or replaces the enrollment of the user
+	Save(ctx context.Context, enrollment *TwoFactor) error
+	Delete(ctx context.Context, userID string) error
+	// UseStep records an accepted TOTP code, ErrInvalidOTP when step is not after
+	// LastUsedStep (replayed code), atomic so concurrent logins can not reuse a code
+	UseStep(ctx context.Context, userID string, step int64) error
+	// UseRecoveryCode removes the code hash, ErrInvalidOTP when it is unknown or used, atomic
+	UseRecoveryCode(ctx context.Context, userID, hash string) error
+	// StartChallenge makes challengeID the only login challenge of the user that can be
+	// answered, with no attempts, ErrTwoFactorNotEnrolled when the user has no enrollment
+	StartChallenge(ctx context.Context, userID, challengeID string) error
+	// AttemptChallenge counts an answer to the challenge, ErrInvalidChallenge when it is
+	// not the current challenge of the user or maxAttempts answers were already counted,
+	// atomic so concurrent answers can not exceed maxAttempts
+	AttemptChallenge(ctx context.Context, userID, challengeID string, maxAttempts int) error
+	// EndChallenge discards the challenge once answered, no-op when it is not the current one
+	EndChallenge(ctx context.Context, userID, challengeID string) error
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, RecoveryCodeCount)
+	require.Len(t, hashes, RecoveryCodeCount)
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+		assert.Equal(t, HashRecoveryCode(code), hashes[i])
+		assert.True(t, IsRecoveryCode(code))
+		assert.False(t, seen[code], "codes are unique")
+		seen[code] = true
+	}
+}
+
+func TestHashRecoveryCode_Normalizes(t *testing.T) {
+	assert.Equal(t, HashRecoveryCode("abcde-fghij"), HashRecoveryCode(" ABCDE FGHIJ"))
+	assert.Equal(t, HashRecoveryCode("abcde-fghij"), HashRecoveryCode("abcdefghij"))
+	assert.NotEqual(t, HashRecoveryCode("abcde-fghij"), HashRecoveryCode("abcde-fghik"))
+}
+
+func TestIsRecoveryCode(t *testing.T) {
+	assert.False(t, IsRecoveryCode("123456"))
+	assert.True(t, IsRecoveryCode("abcde-fghij"))
+}
+
+func TestTwoFactor_IsEnabled(t *testing.T) {
+	enrollment := &TwoFactor{}
+	assert.False(t, enrollment.IsEnabled())
+
+	now := time.Now()
+	enrollment.ConfirmedAt = &now
+	assert.True(t, enrollment.IsEnabled())
+}
@@ -11,9 +11,11 @@ import (
 // User domain entity, represents a system user wih their bussiness rules
 type User struct {
 	ID           string // UUID v4
+	TenantID     string // owner organization, assigned by the repository from the request context
 	Name         string
 	Email        string
 	PasswordHash string
+	Attributes   Attributes // custom profile data, never nil once created with NewUser
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	DeletedAt    *time.Time // Pointer =nullable (soft delete)
@@ -37,6 +39,7 @@ func NewUser(name, email, password string) (*User, error) {
 		Name:         name,
 		Email:        email,
 		PasswordHash: string(passwordHash),
+		Attributes:   Attributes{},
 		CreatedAt:    now,
 		UpdatedAt:    now,
 		DeletedAt:    nil,
@@ -64,6 +67,30 @@ func (u *User) IsDeleted() bool {
 	return u.DeletedAt != nil
 }
 
+// ErasedName replaces the name of erased users
+const ErasedName = "Erased user"
+
+// ErasedEmailDomain domain of the placeholder email of erased users, reserved (RFC 2606)
+const ErasedEmailDomain = "erased.invalid"
+
+// Anonymize irreversibly replaces the personal data (GDPR erasure), nothing derived from
+// the original values is kept (no hashes), the ID stays so rows referencing the user keep
+// their integrity and aggregates (counts, created_at) are not altered
+func (u *User) Anonymize() {
+	u.Name = ErasedName
+	// unique per user and still a syntactically valid address (users.email_format)
+	u.Email = "erased-" + strings.ReplaceAll(u.ID, "-", "") + "@" + ErasedEmailDomain
+	// no hash matches an empty string, login is impossible
+	u.PasswordHash = ""
+	u.Attributes = Attributes{}
+	u.SoftDelete()
+}
+
+// IsAnonymized checks if the personal data was already erased
+func (u *User) IsAnonymized() bool {
+	return strings.HasSuffix(u.Email, "@"+ErasedEmailDomain)
+}
+
 // SoftDelete: flasg the user as "deleted", updates UpdateAt to reflex the change
 func (u *User) SoftDelete() {
 	now := time.Now()
@@ -169,3 +169,33 @@ func TestNewUser_PasswordHash_IsDifferent(t *testing.T) {
 	assert.True(t, user1.ValidatePassword(password))
 	assert.True(t, user2.ValidatePassword(password))
 }
+
+func TestUser_Anonymize(t *testing.T) {
+	user, err := NewUser("John Doe", "john@example.com", "password123")
+	require.NoError(t, err)
+	user.Attributes = Attributes{"phone": "+56 9 1234 5678"}
+	id, createdAt := user.ID, user.CreatedAt
+	assert.False(t, user.IsAnonymized())
+
+	user.Anonymize()
+
+	assert.Equal(t, id, user.ID, "ID is kept for referential integrity")
+	assert.Equal(t, createdAt, user.CreatedAt)
+	assert.Equal(t, ErasedName, user.Name)
+	assert.NotContains(t, user.Email, "john")
+	assert.Contains(t, user.Email, "@erased.invalid")
+	assert.Empty(t, user.PasswordHash)
+	assert.False(t, user.ValidatePassword("password123"))
+	assert.Empty(t, user.Attributes)
+	assert.True(t, user.IsDeleted())
+	assert.True(t, user.IsAnonymized())
+}
+
+func TestParsePurgeMode(t *testing.T) {
+	mode, err := ParsePurgeMode("anonymize")
+	require.NoError(t, err)
+	assert.Equal(t, PurgeAnonymize, mode)
+
+	_, err = ParsePurgeMode("truncate")
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/internal/users/ports/grpc/pb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// mapToUser converts domain.User to the protobuf message, password hash is never exposed
+func mapToUser(user *domain.User) *pb.User {
+	return &pb.User{
+		Id:         user.ID,
+		TenantId:   user.TenantID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Attributes: mapToStruct(user.Attributes),
+		CreatedAt:  timestamppb.New(user.CreatedAt),
+		UpdatedAt:  timestamppb.New(user.UpdatedAt),
+	}
+}
+
+// mapToStruct converts attributes to a protobuf Struct, values come from JSON so the
+// conversion only fails on corrupted data, an empty Struct is returned in that case
+func mapToStruct(attrs domain.Attributes) *structpb.Struct {
+	out, err := structpb.NewStruct(attrs)
+	if err != nil {
+		return &structpb.Struct{}
+	}
+	return out
+}
+
+// mapToAttributes nil when the field was not set, so the use case keeps the current value
+func mapToAttributes(s *structpb.Struct) domain.Attributes {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
+
+// mapToListUsersResponse builds the paginated response, same math as dto.MapToUserListResponse
+func mapToListUsersResponse(users []*domain.User, total int64, limit, offset int) *pb.ListUsersResponse {
+	items := make([]*pb.User, len(users))
+	for i, user := range users {
+		items[i] = mapToUser(user)
+	}
+
+	totalPages := 0
+	if total > 0 && limit > 0 {
+		totalPages = (int(total) + limit - 1) / limit // Ceiling division
+	}
+
+	return &pb.ListUsersResponse{
+		Users:      items,
+		TotalCount: total,
+		Page:       int32(offset/limit) + 1,
+		PageSize:   int32(limit),
+		TotalPages: int32(totalPages),
+	}
+}
@@ -0,0 +1,203 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/internal/users/ports/grpc/pb"
+	"github.com/cristianortiz/observ-monit-go/internal/users/ports/http/dto"
+	"github.com/cristianortiz/observ-monit-go/internal/users/usecase"
+	"github.com/cristianortiz/observ-monit-go/pkg/http-utils/middleware"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UserServer implements pb.UserServiceServer, it is the gRPC port of the users module
+// and reuses the same UserService (use cases) than the HTTP port
+type UserServer struct {
+	pb.UnimplementedUserServiceServer
+	service *usecase.UserService
+	metrics *metrics.UserMetrics
+}
+
+// NewUserServer creates a new gRPC user server
+func NewUserServer(service *usecase.UserService, metrics *metrics.UserMetrics) *UserServer {
+	return &UserServer{
+		service: service,
+		metrics: metrics,
+	}
+}
+
+// Register registers the users service in a gRPC server
+func (s *UserServer) Register(server *grpc.Server) {
+	pb.RegisterUserServiceServer(server, s)
+}
+
+// MethodScopes scopes of every method of users.v1.UserService for auth.GRPCConfig, same
+// rules as middleware.ScopeByMethod on the HTTP routes: write implies read
+func MethodScopes() map[string][]string {
+	read := []string{domain.ScopeUsersRead, domain.ScopeUsersWrite}
+	write := []string{domain.ScopeUsersWrite}
+
+	return map[string][]string{
+		pb.UserService_CreateUser_FullMethodName: write,
+		pb.UserService_GetUser_FullMethodName:    read,
+		pb.UserService_UpdateUser_FullMethodName: write,
+		pb.UserService_DeleteUser_FullMethodName: write,
+		pb.UserService_ListUsers_FullMethodName:  read,
+	}
+}
+
+// CreateUser handles users.v1.UserService/CreateUser
+func (s *UserServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	// same validation rules as POST /users
+	if err := validate(dto.CreateUserRequestDto{
+		Name:     req.GetName(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}); err != nil {
+		return nil, err
+	}
+
+	user, err := s.service.CreateUser(ctx, req.GetName(), req.GetEmail(), req.GetPassword(),
+		mapToAttributes(req.GetAttributes()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	s.metrics.UsersCreated.Inc()
+
+	return mapToUser(user), nil
+}
+
+// GetUser handles users.v1.UserService/GetUser
+func (s *UserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	user, err := s.service.GetUserByID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return mapToUser(user), nil
+}
+
+// UpdateUser handles users.v1.UserService/UpdateUser
+// fields not present in the request keep their current value
+func (s *UserServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+	if err := validate(dto.UpdateUserRequestDto{
+		Name:  req.Name,
+		Email: req.Email,
+	}); err != nil {
+		return nil, err
+	}
+
+	// unset name/email are "" and unset attributes nil, the use case keeps them
+	user, err := s.service.UpdateUser(ctx, req.GetId(), req.GetName(), req.GetEmail(),
+		mapToAttributes(req.GetAttributes()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	s.metrics.UsersUpdated.Inc()
+
+	return mapToUser(user), nil
+}
+
+// DeleteUser handles users.v1.UserService/DeleteUser
+func (s *UserServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	if err := validateID(req.GetId()); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.DeleteUser(ctx, req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	s.metrics.UsersDeleted.Inc()
+
+	return &pb.DeleteUserResponse{}, nil
+}
+
+// ListUsers handles users.v1.UserService/ListUsers
+func (s *UserServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	query := dto.ListUsersQueryDto{
+		Limit:      int(req.GetLimit()),
+		Offset:     int(req.GetOffset()),
+		Attributes: req.GetAttributeFilter(),
+	}
+	query.SetDefaults()
+	if err := validate(query); err != nil {
+		return nil, err
+	}
+
+	users, total, err := s.service.ListUsers(ctx, dto.MapToListFilter(query), query.Limit, query.Offset)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return mapToListUsersResponse(users, total, query.Limit, query.Offset), nil
+}
+
+// toStatus maps domain errors to gRPC status codes, the gRPC version of errorResponse in
+// the http port, keep both in sync
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+
+	case errors.Is(err, domain.ErrErasureJobNotFound), errors.Is(err, domain.ErrAPIKeyNotFound),
+		errors.Is(err, domain.ErrSessionNotFound), errors.Is(err, domain.ErrTwoFactorNotEnrolled):
+		return status.Error(codes.NotFound, err.Error())
+
+	case errors.Is(err, domain.ErrEmailAlreadyExists):
+		return status.Error(codes.AlreadyExists, "email already exists")
+
+	case errors.Is(err, domain.ErrUserErased), errors.Is(err, domain.ErrTwoFactorAlreadyEnabled):
+		// the state of the user, not the request, prevents the call
+		return status.Error(codes.FailedPrecondition, err.Error())
+
+	case errors.Is(err, domain.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "not allowed to act on this user")
+
+	case errors.Is(err, domain.ErrInvalidScope), errors.Is(err, domain.ErrInvalidAPIKey),
+		errors.Is(err, domain.ErrInvalidOTP):
+		return status.Error(codes.InvalidArgument, err.Error())
+
+	case errors.Is(err, domain.ErrInvalidAttributes):
+		// violations only describe the caller input, safe to return
+		return status.Error(codes.InvalidArgument, err.Error())
+
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, "invalid credentials")
+
+	case errors.Is(err, domain.ErrInvalidChallenge), errors.Is(err, domain.ErrSessionRevoked):
+		return status.Error(codes.Unauthenticated, err.Error())
+
+	default:
+		// internal details are not leaked to the caller
+		return status.Error(codes.Internal, "an unexpected error occurred")
+	}
+}
+
+// validate runs the DTO validation rules and returns InvalidArgument on failure
+func validate[T any](data T) error {
+	if fields, err := middleware.ValidateStruct(data); err != nil {
+		return status.Errorf(codes.InvalidArgument, "validation failed: %v", fields)
+	}
+	return nil
+}
+
+// validateID checks the id is a valid UUID, same rule as ValidateParam("id", "uuid")
+func validateID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return status.Errorf(codes.InvalidArgument, "id must be a valid UUID, got: %s", id)
+	}
+	return nil
+}
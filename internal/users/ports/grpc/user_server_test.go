@@ -0,0 +1,254 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/memory"
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/internal/users/ports/grpc/pb"
+	"github.com/cristianortiz/observ-monit-go/internal/users/usecase"
+	"github.com/cristianortiz/observ-monit-go/pkg/http-utils/middleware"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// setupTestClient starts an in-process gRPC server backed by the memory repository
+func setupTestClient(t *testing.T) pb.UserServiceClient {
+	t.Helper()
+	middleware.InitValidator()
+
+	// unregistered metrics, avoids duplicate registration in the default registry
+	userMetrics := &metrics.UserMetrics{
+		UsersCreated: prometheus.NewCounter(prometheus.CounterOpts{Name: "created"}),
+		UsersUpdated: prometheus.NewCounter(prometheus.CounterOpts{Name: "updated"}),
+		UsersDeleted: prometheus.NewCounter(prometheus.CounterOpts{Name: "deleted"}),
+	}
+	service := usecase.NewUserService(memory.NewUserRepository())
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	NewUserServer(service, userMetrics).Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewUserServiceClient(conn)
+}
+
+func TestUserServer_CRUD(t *testing.T) {
+	ctx := context.Background()
+	client := setupTestClient(t)
+
+	created, err := client.CreateUser(ctx, &pb.CreateUserRequest{
+		Name:     "John Doe",
+		Email:    "john@example.com",
+		Password: "SecurePass123!",
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.GetId())
+	assert.Equal(t, "john@example.com", created.GetEmail())
+
+	found, err := client.GetUser(ctx, &pb.GetUserRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, created.GetName(), found.GetName())
+
+	// only name is sent, email must be kept
+	updated, err := client.UpdateUser(ctx, &pb.UpdateUserRequest{
+		Id:   created.GetId(),
+		Name: proto.String("Jane Doe"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", updated.GetName())
+	assert.Equal(t, "john@example.com", updated.GetEmail())
+
+	list, err := client.ListUsers(ctx, &pb.ListUsersRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list.GetUsers(), 1)
+	assert.Equal(t, int64(1), list.GetTotalCount())
+	assert.Equal(t, int32(20), list.GetPageSize())
+	assert.Equal(t, int32(1), list.GetPage())
+
+	_, err = client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: created.GetId()})
+	require.NoError(t, err)
+
+	_, err = client.GetUser(ctx, &pb.GetUserRequest{Id: created.GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestUserServer_ErrorCodes(t *testing.T) {
+	ctx := context.Background()
+	client := setupTestClient(t)
+
+	_, err := client.CreateUser(ctx, &pb.CreateUserRequest{
+		Name:     "John Doe",
+		Email:    "john@example.com",
+		Password: "SecurePass123!",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		call     func() error
+		expected codes.Code
+	}{
+		{
+			name: "duplicate email",
+			call: func() error {
+				_, err := client.CreateUser(ctx, &pb.CreateUserRequest{
+					Name: "Other", Email: "john@example.com", Password: "SecurePass123!",
+				})
+				return err
+			},
+			expected: codes.AlreadyExists,
+		},
+		{
+			name: "invalid email",
+			call: func() error {
+				_, err := client.CreateUser(ctx, &pb.CreateUserRequest{
+					Name: "Other", Email: "not-an-email", Password: "SecurePass123!",
+				})
+				return err
+			},
+			expected: codes.InvalidArgument,
+		},
+		{
+			name: "invalid id",
+			call: func() error {
+				_, err := client.GetUser(ctx, &pb.GetUserRequest{Id: "123"})
+				return err
+			},
+			expected: codes.InvalidArgument,
+		},
+		{
+			name: "update unknown user",
+			call: func() error {
+				_, err := client.UpdateUser(ctx, &pb.UpdateUserRequest{Id: uuid.New().String()})
+				return err
+			},
+			expected: codes.NotFound,
+		},
+		{
+			name: "delete unknown user",
+			call: func() error {
+				_, err := client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: uuid.New().String()})
+				return err
+			},
+			expected: codes.NotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, status.Code(tt.call()))
+		})
+	}
+}
+
+func TestUserServer_Attributes(t *testing.T) {
+	ctx := context.Background()
+	client := setupTestClient(t)
+
+	attrs, err := structpb.NewStruct(map[string]any{"department": "sales", "level": 2})
+	require.NoError(t, err)
+
+	created, err := client.CreateUser(ctx, &pb.CreateUserRequest{
+		Name:       "John Doe",
+		Email:      "john@example.com",
+		Password:   "SecurePass123!",
+		Attributes: attrs,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "sales", created.GetAttributes().AsMap()["department"])
+
+	_, err = client.CreateUser(ctx, &pb.CreateUserRequest{
+		Name:     "Jane Doe",
+		Email:    "jane@example.com",
+		Password: "SecurePass123!",
+	})
+	require.NoError(t, err)
+
+	// without attributes schema the filter values are strings, "2" does not match level 2
+	list, err := client.ListUsers(ctx, &pb.ListUsersRequest{
+		AttributeFilter: map[string]string{"department": "sales", "level": "2"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, list.GetUsers())
+
+	list, err = client.ListUsers(ctx, &pb.ListUsersRequest{
+		AttributeFilter: map[string]string{"department": "sales"},
+	})
+	require.NoError(t, err)
+	require.Len(t, list.GetUsers(), 1)
+	assert.Equal(t, created.GetId(), list.GetUsers()[0].GetId())
+	assert.Equal(t, int64(1), list.GetTotalCount())
+
+	// name is kept, attributes replaced
+	replaced, err := structpb.NewStruct(map[string]any{"department": "hr"})
+	require.NoError(t, err)
+	updated, err := client.UpdateUser(ctx, &pb.UpdateUserRequest{
+		Id:         created.GetId(),
+		Attributes: replaced,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", updated.GetName())
+	assert.Equal(t, map[string]any{"department": "hr"}, updated.GetAttributes().AsMap())
+}
+
+func TestToStatus_HidesInternalErrors(t *testing.T) {
+	err := toStatus(assert.AnError)
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.NotContains(t, err.Error(), assert.AnError.Error())
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{domain.ErrUserNotFound, codes.NotFound},
+		{domain.ErrErasureJobNotFound, codes.NotFound},
+		{domain.ErrAPIKeyNotFound, codes.NotFound},
+		{domain.ErrSessionNotFound, codes.NotFound},
+		{domain.ErrTwoFactorNotEnrolled, codes.NotFound},
+		{domain.ErrEmailAlreadyExists, codes.AlreadyExists},
+		{domain.ErrUserErased, codes.FailedPrecondition},
+		{domain.ErrTwoFactorAlreadyEnabled, codes.FailedPrecondition},
+		{domain.ErrForbidden, codes.PermissionDenied},
+		{domain.ErrInvalidScope, codes.InvalidArgument},
+		{domain.ErrInvalidAPIKey, codes.InvalidArgument},
+		{domain.ErrInvalidOTP, codes.InvalidArgument},
+		{&domain.AttributesError{Violations: []string{"/phone: expected string"}}, codes.InvalidArgument},
+		{domain.ErrInvalidCredentials, codes.Unauthenticated},
+		{domain.ErrInvalidChallenge, codes.Unauthenticated},
+		{domain.ErrSessionRevoked, codes.Unauthenticated},
+		{fmt.Errorf("failed to update user: %w", domain.ErrForbidden), codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.code, status.Code(toStatus(tt.err)))
+		})
+	}
+}
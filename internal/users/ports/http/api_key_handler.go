@@ -0,0 +1,74 @@
+package http
+
+import (
+	"github.com/cristianortiz/observ-monit-go/internal/users/ports/http/dto"
+	"github.com/cristianortiz/observ-monit-go/internal/users/usecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHandler handles the API key management requests
+type APIKeyHandler struct {
+	service *usecase.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(service *usecase.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// CreateAPIKey handles POST /api/v1/api-keys
+// @Summary Issue an API key
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateAPIKeyRequestDto true "API key creation request"
+// @Success 201 {object} dto.CreatedAPIKeyResponseDto
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	req := c.Locals("validated_data").(dto.CreateAPIKeyRequestDto)
+
+	key, plain, err := h.service.IssueAPIKey(c.UserContext(), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.CreatedAPIKeyResponseDto{
+		APIKey: dto.MapToAPIKeyResponse(key),
+		Key:    plain,
+	})
+}
+
+// ListAPIKeys handles GET /api/v1/api-keys
+// @Summary List the API keys of the tenant
+// @Tags api-keys
+// @Produce json
+// @Success 200 {object} dto.APIKeyListResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.service.ListAPIKeys(c.UserContext())
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.MapToAPIKeyListResponse(keys))
+}
+
+// RevokeAPIKey handles DELETE /api/v1/api-keys/:id
+// @Summary Revoke an API key
+// @Tags api-keys
+// @Param id path string true "API key ID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 404 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	if err := h.service.RevokeAPIKey(c.UserContext(), c.Params("id")); err != nil {
+		return handleError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
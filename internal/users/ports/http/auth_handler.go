@@ -0,0 +1,90 @@
+package http
+
+import (
+	"github.com/cristianortiz/observ-monit-go/internal/users/ports/http/dto"
+	"github.com/cristianortiz/observ-monit-go/internal/users/usecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthHandler handles the login requests, these routes are public (see AuthPath)
+type AuthHandler struct {
+	service *usecase.LoginService
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(service *usecase.LoginService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+// Login handles POST /api/v1/auth/login
+// @Summary Log in with email and password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.LoginRequestDto true "Login request"
+// @Success 200 {object} dto.LoginResponseDto
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 401 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	req := c.Locals("validated_data").(dto.LoginRequestDto)
+
+	result, err := h.service.Login(c.UserContext(), req.Email, req.Password, clientInfo(c, req.Device))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	if result.Challenge != nil {
+		return c.Status(fiber.StatusOK).JSON(dto.LoginResponseDto{
+			MFARequired: true,
+			Challenge:   result.Challenge.Token,
+			ExpiresAt:   &result.Challenge.ExpiresAt,
+		})
+	}
+
+	user := dto.MapToUserResponse(result.User)
+	return c.Status(fiber.StatusOK).JSON(dto.LoginResponseDto{
+		User:      &user,
+		Token:     result.Token.Token,
+		ExpiresAt: &result.Token.ExpiresAt,
+	})
+}
+
+// LoginTOTP handles POST /api/v1/auth/login/totp
+// @Summary Second login step, exchanges the challenge and a one-time code for a token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.LoginTOTPRequestDto true "Challenge and TOTP or recovery code"
+// @Success 200 {object} dto.TokenResponseDto
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 401 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /auth/login/totp [post]
+func (h *AuthHandler) LoginTOTP(c *fiber.Ctx) error {
+	req := c.Locals("validated_data").(dto.LoginTOTPRequestDto)
+
+	token, err := h.service.CompleteLogin(c.UserContext(), req.ChallengeToken, req.Code, clientInfo(c, ""))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.TokenResponseDto{
+		Token:     token.Token,
+		ExpiresAt: token.ExpiresAt,
+	})
+}
+
+// clientInfo describes the caller for its session
+func clientInfo(c *fiber.Ctx, device string) usecase.ClientInfo {
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if device == "" {
+		device = userAgent
+	}
+	return usecase.ClientInfo{
+		Device:    device,
+		UserAgent: userAgent,
+		IP:        c.IP(),
+	}
+}
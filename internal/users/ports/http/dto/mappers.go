@@ -4,12 +4,20 @@ import "github.com/cristianortiz/observ-monit-go/internal/users/domain"
 
 // mapToUserResponse converts domain.User to UserResponseDto, ready to serialize to JSON
 func MapToUserResponse(user *domain.User) UserResponseDto {
+	attributes := map[string]any(user.Attributes)
+	if attributes == nil {
+		// always an object in the JSON, like the column default
+		attributes = map[string]any{}
+	}
+
 	return UserResponseDto{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:         user.ID,
+		TenantID:   user.TenantID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Attributes: attributes,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
 	}
 }
 
@@ -51,3 +59,89 @@ func MapToUserListResponse(users []*domain.User, total int64, limit, offset int)
 	}
 
 }
+
+// MapToListFilter converts the attr[...] query values to a domain.ListFilter, the values
+// are strings, the use case converts them to the type of their property in the attributes
+// schema (attr[level]=2 is a number when the schema says so)
+func MapToListFilter(query ListUsersQueryDto) domain.ListFilter {
+	if len(query.Attributes) == 0 {
+		return domain.ListFilter{}
+	}
+
+	attrs := make(domain.Attributes, len(query.Attributes))
+	for key, value := range query.Attributes {
+		attrs[key] = value
+	}
+
+	return domain.ListFilter{Attributes: attrs}
+}
+
+// MapToErasureJobResponse converts domain.ErasureJob to ErasureJobResponseDto
+func MapToErasureJobResponse(job *domain.ErasureJob) ErasureJobResponseDto {
+	return ErasureJobResponseDto{
+		ID:          job.ID,
+		UserID:      job.UserID,
+		Status:      string(job.Status),
+		Error:       job.Error,
+		RequestedAt: job.RequestedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+	}
+}
+
+// MapToDataExportResponse converts domain.DataExport to DataExportResponseDto
+func MapToDataExportResponse(export *domain.DataExport) DataExportResponseDto {
+	return DataExportResponseDto{
+		FormatVersion: export.FormatVersion,
+		GeneratedAt:   export.GeneratedAt,
+		UserID:        export.UserID,
+		TenantID:      export.TenantID,
+		Sections:      export.Sections,
+	}
+}
+
+// MapToAPIKeyResponse converts domain.APIKey to APIKeyResponseDto
+func MapToAPIKeyResponse(key *domain.APIKey) APIKeyResponseDto {
+	return APIKeyResponseDto{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+// MapToAPIKeyListResponse converts a slice of domain.APIKey to APIKeyListResponseDto
+func MapToAPIKeyListResponse(keys []*domain.APIKey) APIKeyListResponseDto {
+	responses := make([]APIKeyResponseDto, len(keys))
+	for i, key := range keys {
+		responses[i] = MapToAPIKeyResponse(key)
+	}
+	return APIKeyListResponseDto{APIKeys: responses}
+}
+
+// MapToSessionResponse converts domain.Session to SessionResponseDto, currentID is the
+// session of the caller (empty when unknown)
+func MapToSessionResponse(session *domain.Session, currentID string) SessionResponseDto {
+	return SessionResponseDto{
+		ID:         session.ID,
+		Device:     session.Device,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		Current:    currentID != "" && session.ID == currentID,
+	}
+}
+
+// MapToSessionListResponse converts a slice of domain.Session to SessionListResponseDto
+func MapToSessionListResponse(sessions []*domain.Session, currentID string) SessionListResponseDto {
+	responses := make([]SessionResponseDto, len(sessions))
+	for i, session := range sessions {
+		responses[i] = MapToSessionResponse(session, currentID)
+	}
+	return SessionListResponseDto{Sessions: responses}
+}
@@ -1,14 +1,20 @@
 package dto
 
+import "time"
+
 type CreateUserRequestDto struct {
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
+	// Attributes custom profile data, validated against the deployment JSON Schema
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
+// UpdateUserRequestDto fields not sent keep their value, attributes are replaced as a whole
 type UpdateUserRequestDto struct {
-	Name  *string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
-	Email *string `json:"email,omitempty" validate:"omitempty,email"`
+	Name       *string        `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Email      *string        `json:"email,omitempty" validate:"omitempty,email"`
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
 
 type UpdatePasswordRequestDto struct {
@@ -19,11 +25,32 @@ type UpdatePasswordRequestDto struct {
 type LoginRequestDto struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// Device name shown in the session list, the user agent is used when empty
+	Device string `json:"device,omitempty" validate:"omitempty,max=100"`
+}
+
+// LoginTOTPRequestDto second login step, code is a TOTP code or a recovery code
+type LoginTOTPRequestDto struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,min=6,max=20"`
+}
+
+// ConfirmTOTPRequestDto first code of the authenticator app
+type ConfirmTOTPRequestDto struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+// DisableTOTPRequestDto a TOTP code or a recovery code, proves the caller holds the second factor
+type DisableTOTPRequestDto struct {
+	Code string `json:"code" validate:"required,min=6,max=20"`
 }
 
 type ListUsersQueryDto struct {
 	Limit  int `query:"limit" validate:"omitempty,min=1,max=100"`
 	Offset int `query:"offset" validate:"omitempty,min=0"`
+	// Attributes filter, ?attr[department]=sales&attr[level]=2, strings unless the attributes
+	// schema types them, see MapToListFilter
+	Attributes map[string]string `query:"attr" validate:"omitempty,max=10,dive,keys,min=1,max=63,endkeys,max=256"`
 }
 
 func (q *ListUsersQueryDto) SetDefaults() {
@@ -37,3 +64,10 @@ func (q *ListUsersQueryDto) SetDefaults() {
 		q.Offset = 0
 	}
 }
+
+// CreateAPIKeyRequestDto scopes must be a subset of domain.Scopes
+type CreateAPIKeyRequestDto struct {
+	Name      string     `json:"name" validate:"required,min=1,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=users:read users:write api-keys:manage users:admin"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -4,11 +4,13 @@ import "time"
 
 // UserResponseDto to return info about user, no password considered by security
 type UserResponseDto struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         string         `json:"id"`
+	TenantID   string         `json:"tenant_id"`
+	Name       string         `json:"name"`
+	Email      string         `json:"email"`
+	Attributes map[string]any `json:"attributes"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
 }
 
 type UserListResponseDto struct {
@@ -19,18 +21,106 @@ type UserListResponseDto struct {
 	TotalPages int               `json:"total_pages"`
 }
 
+// LoginResponseDto users with two-factor enabled get a challenge instead of the token,
+// exchanged at POST /auth/login/totp
 type LoginResponseDto struct {
-	User  UserResponseDto `json:"user"`
-	Token string          `json:"token,omitempty"` // JWT token
+	User        *UserResponseDto `json:"user,omitempty"`
+	Token       string           `json:"token,omitempty"` // JWT token
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	MFARequired bool             `json:"mfa_required"`
+	Challenge   string           `json:"challenge_token,omitempty"`
+}
+
+// TokenResponseDto access token issued by the second login step
+type TokenResponseDto struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 type ErrorResponseDto struct {
 	Error   string            `json:"error"`
 	Message string            `json:"message"`
 	Fields  map[string]string `json:"fields,omitempty"`
+	// RequestID id of the failed request (X-Request-ID), to correlate with the logs
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // for succes operations, like a delete op
 type MessageResponse struct {
 	Message string `json:"message"`
 }
+
+// ErasureJobResponseDto status of an erasure (right to be forgotten) request
+type ErasureJobResponseDto struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	RequestedAt time.Time  `json:"requested_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// DataExportResponseDto machine readable archive with everything stored about a user,
+// each section comes from a different store (profile, sessions...)
+type DataExportResponseDto struct {
+	FormatVersion int            `json:"format_version"`
+	GeneratedAt   time.Time      `json:"generated_at"`
+	UserID        string         `json:"user_id"`
+	TenantID      string         `json:"tenant_id"`
+	Sections      map[string]any `json:"sections"`
+}
+
+// APIKeyResponseDto describes a key, never its secret
+type APIKeyResponseDto struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyListResponseDto struct {
+	APIKeys []APIKeyResponseDto `json:"api_keys"`
+}
+
+// CreatedAPIKeyResponseDto the plain key is returned only once, at creation
+type CreatedAPIKeyResponseDto struct {
+	APIKey APIKeyResponseDto `json:"api_key"`
+	Key    string            `json:"key"`
+}
+
+// SessionResponseDto describes a login of the user
+type SessionResponseDto struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Current the session of the token making the request
+	Current bool `json:"current"`
+}
+
+type SessionListResponseDto struct {
+	Sessions []SessionResponseDto `json:"sessions"`
+}
+
+// RevokedSessionsResponseDto result of "log out everywhere"
+type RevokedSessionsResponseDto struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// TOTPEnrollmentResponseDto the secret is returned only once, at enrollment
+type TOTPEnrollmentResponseDto struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// RecoveryCodesResponseDto the recovery codes are returned only once, at confirmation
+type RecoveryCodesResponseDto struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
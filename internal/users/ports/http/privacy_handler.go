@@ -0,0 +1,89 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/ports/http/dto"
+	"github.com/cristianortiz/observ-monit-go/internal/users/usecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PrivacyHandler handles the GDPR data subject requests of a user
+type PrivacyHandler struct {
+	service *usecase.PrivacyService
+}
+
+// NewPrivacyHandler creates a new privacy handler
+func NewPrivacyHandler(service *usecase.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{service: service}
+}
+
+// ExportUserData handles GET /api/v1/users/:id/data-export
+// @Summary Export everything stored about a user
+// @Tags privacy
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.DataExportResponseDto
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
+// @Failure 404 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /users/{id}/data-export [get]
+func (h *PrivacyHandler) ExportUserData(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	export, err := h.service.ExportUserData(c.UserContext(), id)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	// download as a file, the archive is meant to be handed to the data subject
+	c.Attachment(fmt.Sprintf("user-%s-data-export.json", id))
+	return c.Status(fiber.StatusOK).JSON(dto.MapToDataExportResponse(export))
+}
+
+// RequestErasure handles POST /api/v1/users/:id/erasure-jobs
+// @Summary Request the erasure of the user personal data
+// @Tags privacy
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 202 {object} dto.ErasureJobResponseDto
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
+// @Failure 404 {object} dto.ErrorResponseDto
+// @Failure 409 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /users/{id}/erasure-jobs [post]
+func (h *PrivacyHandler) RequestErasure(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	job, err := h.service.RequestErasure(c.UserContext(), id)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	// the job runs in background, poll the Location for its status
+	c.Location(c.Path() + "/" + job.ID)
+	return c.Status(fiber.StatusAccepted).JSON(dto.MapToErasureJobResponse(job))
+}
+
+// GetErasureJob handles GET /api/v1/users/:id/erasure-jobs/:jobId
+// @Summary Get the status of an erasure job
+// @Tags privacy
+// @Produce json
+// @Param id path string true "User ID"
+// @Param jobId path string true "Erasure job ID"
+// @Success 200 {object} dto.ErasureJobResponseDto
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
+// @Failure 404 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /users/{id}/erasure-jobs/{jobId} [get]
+func (h *PrivacyHandler) GetErasureJob(c *fiber.Ctx) error {
+	job, err := h.service.GetErasureJob(c.UserContext(), c.Params("id"), c.Params("jobId"))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.MapToErasureJobResponse(job))
+}
@@ -1,39 +1,508 @@
 package http
 
 import (
+	"strings"
+
 	"github.com/cristianortiz/observ-monit-go/internal/users/ports/http/dto"
 	"github.com/cristianortiz/observ-monit-go/pkg/http-utils/middleware"
+	"github.com/cristianortiz/observ-monit-go/pkg/http-utils/openapi"
 	"github.com/gofiber/fiber/v2"
 )
 
+// AuthPath prefix of the public login routes, relative to the basePath, the
+// authentication middleware must skip it
+const AuthPath = "/auth"
+
 // RegisterRoutes registers all user routes
 func RegisterRoutes(app *fiber.App, handler *UserHandler, basePath string) {
-	api := app.Group(basePath)
-	users := api.Group("/users")
-
-	// CRUD operations
-	users.Post("/",
-		middleware.ValidateBody[dto.CreateUserRequestDto](),
-		handler.CreateUser,
-	)
-
-	users.Get("/",
-		middleware.ValidateQuery[dto.ListUsersQueryDto](),
-		handler.ListUsers,
-	)
-
-	users.Get("/:id",
-		middleware.ValidateParam("id", "uuid"),
-		handler.GetUser)
-
-	users.Put("/:id",
-		middleware.ValidateParam("id", "uuid"),
-		middleware.ValidateBody[dto.UpdateUserRequestDto](),
-		handler.UpdateUser,
-	)
-
-	users.Delete("/:id",
-		middleware.ValidateParam("id", "uuid"),
-		handler.DeleteUser,
-	)
+	register(app, basePath, userRoutes(handler))
+}
+
+// Operations describes the routes registered by RegisterRoutes for the OpenAPI document,
+// paths are relative to the same basePath
+func Operations() []openapi.Operation {
+	return operations(userRoutes(nil))
+}
+
+// userRoutes the user CRUD routes
+func userRoutes(handler *UserHandler) []route {
+	idParam := map[string]string{"id": "uuid"}
+	tags := []string{"users"}
+
+	return []route{
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodPost,
+				Path:        "/users",
+				Summary:     "Create a new user",
+				Tags:        tags,
+				OperationID: "createUser",
+				Body:        dto.CreateUserRequestDto{},
+				Responses: map[int]any{
+					fiber.StatusCreated:             dto.UserResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusConflict:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.CreateUser,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodGet,
+				Path:        "/users",
+				Summary:     "List users with pagination",
+				Tags:        tags,
+				OperationID: "listUsers",
+				Query:       dto.ListUsersQueryDto{},
+				Responses: map[int]any{
+					fiber.StatusOK:                  dto.UserListResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.ListUsers,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodGet,
+				Path:        "/users/:id",
+				Summary:     "Get user by ID",
+				Tags:        tags,
+				OperationID: "getUser",
+				PathParams:  idParam,
+				Responses: map[int]any{
+					fiber.StatusOK:                  dto.UserResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.GetUser,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodPut,
+				Path:        "/users/:id",
+				Summary:     "Update user",
+				Tags:        tags,
+				OperationID: "updateUser",
+				PathParams:  idParam,
+				Body:        dto.UpdateUserRequestDto{},
+				Responses: map[int]any{
+					fiber.StatusOK:                  dto.UserResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusConflict:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.UpdateUser,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodDelete,
+				Path:        "/users/:id",
+				Summary:     "Delete user",
+				Tags:        tags,
+				OperationID: "deleteUser",
+				PathParams:  idParam,
+				Responses: map[int]any{
+					fiber.StatusNoContent:           nil,
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.DeleteUser,
+		},
+	}
+}
+
+// RegisterPrivacyRoutes registers the GDPR data subject routes of the users
+func RegisterPrivacyRoutes(app *fiber.App, handler *PrivacyHandler, basePath string) {
+	register(app, basePath, privacyRoutes(handler))
+}
+
+// PrivacyOperations describes the routes registered by RegisterPrivacyRoutes
+func PrivacyOperations() []openapi.Operation {
+	return operations(privacyRoutes(nil))
+}
+
+// privacyRoutes the GDPR data subject routes
+func privacyRoutes(handler *PrivacyHandler) []route {
+	idParam := map[string]string{"id": "uuid"}
+	tags := []string{"privacy"}
+
+	return []route{
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodGet,
+				Path:        "/users/:id/data-export",
+				Summary:     "Export everything stored about a user",
+				Tags:        tags,
+				OperationID: "exportUserData",
+				PathParams:  idParam,
+				Responses: map[int]any{
+					fiber.StatusOK:                  dto.DataExportResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.ExportUserData,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodPost,
+				Path:        "/users/:id/erasure-jobs",
+				Summary:     "Request the erasure of the user personal data",
+				Tags:        tags,
+				OperationID: "requestUserErasure",
+				PathParams:  idParam,
+				Responses: map[int]any{
+					fiber.StatusAccepted:            dto.ErasureJobResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusConflict:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.RequestErasure,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodGet,
+				Path:        "/users/:id/erasure-jobs/:jobId",
+				Summary:     "Get the status of an erasure job",
+				Tags:        tags,
+				OperationID: "getUserErasureJob",
+				PathParams:  map[string]string{"id": "uuid", "jobId": "uuid"},
+				Responses: map[int]any{
+					fiber.StatusOK:                  dto.ErasureJobResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.GetErasureJob,
+		},
+	}
+}
+
+// RegisterAPIKeyRoutes registers the API key management routes
+func RegisterAPIKeyRoutes(app *fiber.App, handler *APIKeyHandler, basePath string) {
+	register(app, basePath, apiKeyRoutes(handler))
+}
+
+// APIKeyOperations describes the routes registered by RegisterAPIKeyRoutes
+func APIKeyOperations() []openapi.Operation {
+	return operations(apiKeyRoutes(nil))
+}
+
+// apiKeyRoutes the API key management routes
+func apiKeyRoutes(handler *APIKeyHandler) []route {
+	tags := []string{"api-keys"}
+
+	return []route{
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodPost,
+				Path:        "/api-keys",
+				Summary:     "Issue an API key, the key is returned only once",
+				Tags:        tags,
+				OperationID: "createAPIKey",
+				Body:        dto.CreateAPIKeyRequestDto{},
+				Responses: map[int]any{
+					fiber.StatusCreated:             dto.CreatedAPIKeyResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.CreateAPIKey,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodGet,
+				Path:        "/api-keys",
+				Summary:     "List the API keys of the tenant",
+				Tags:        tags,
+				OperationID: "listAPIKeys",
+				Responses: map[int]any{
+					fiber.StatusOK:                  dto.APIKeyListResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.ListAPIKeys,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodDelete,
+				Path:        "/api-keys/:id",
+				Summary:     "Revoke an API key",
+				Tags:        tags,
+				OperationID: "revokeAPIKey",
+				PathParams:  map[string]string{"id": "uuid"},
+				Responses: map[int]any{
+					fiber.StatusNoContent:           nil,
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.RevokeAPIKey,
+		},
+	}
+}
+
+// RegisterSessionRoutes registers the session management routes of the users
+func RegisterSessionRoutes(app *fiber.App, handler *SessionHandler, basePath string) {
+	register(app, basePath, sessionRoutes(handler))
+}
+
+// SessionOperations describes the routes registered by RegisterSessionRoutes
+func SessionOperations() []openapi.Operation {
+	return operations(sessionRoutes(nil))
+}
+
+// sessionRoutes the session management routes
+func sessionRoutes(handler *SessionHandler) []route {
+	idParam := map[string]string{"id": "uuid"}
+	tags := []string{"sessions"}
+
+	return []route{
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodGet,
+				Path:        "/users/:id/sessions",
+				Summary:     "List the active sessions of a user",
+				Tags:        tags,
+				OperationID: "listUserSessions",
+				PathParams:  idParam,
+				Responses: map[int]any{
+					fiber.StatusOK:                  dto.SessionListResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.ListSessions,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodDelete,
+				Path:        "/users/:id/sessions",
+				Summary:     "Log the user out everywhere",
+				Tags:        tags,
+				OperationID: "revokeAllUserSessions",
+				PathParams:  idParam,
+				Responses: map[int]any{
+					fiber.StatusOK:                  dto.RevokedSessionsResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.RevokeAllSessions,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodDelete,
+				Path:        "/users/:id/sessions/:sid",
+				Summary:     "Revoke a session, its tokens stop being accepted",
+				Tags:        tags,
+				OperationID: "revokeUserSession",
+				PathParams:  map[string]string{"id": "uuid", "sid": "uuid"},
+				Responses: map[int]any{
+					fiber.StatusNoContent:           nil,
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.RevokeSession,
+		},
+	}
+}
+
+// RegisterAuthRoutes registers the public login routes under AuthPath
+func RegisterAuthRoutes(app *fiber.App, handler *AuthHandler, basePath string) {
+	register(app, basePath, authRoutes(handler))
+}
+
+// AuthOperations describes the routes registered by RegisterAuthRoutes
+func AuthOperations() []openapi.Operation {
+	return operations(authRoutes(nil))
+}
+
+// authRoutes the public login routes
+func authRoutes(handler *AuthHandler) []route {
+	tags := []string{"auth"}
+
+	return []route{
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodPost,
+				Path:        AuthPath + "/login",
+				Summary:     "Log in with email and password, two-factor users get a challenge",
+				Tags:        tags,
+				OperationID: "login",
+				Body:        dto.LoginRequestDto{},
+				Responses: map[int]any{
+					fiber.StatusOK:                  dto.LoginResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusUnauthorized:        dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.Login,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodPost,
+				Path:        AuthPath + "/login/totp",
+				Summary:     "Exchange a login challenge and a one-time code for a token",
+				Tags:        tags,
+				OperationID: "loginTOTP",
+				Body:        dto.LoginTOTPRequestDto{},
+				Responses: map[int]any{
+					fiber.StatusOK:                  dto.TokenResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusUnauthorized:        dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.LoginTOTP,
+		},
+	}
+}
+
+// RegisterTwoFactorRoutes registers the TOTP enrollment routes of the users
+func RegisterTwoFactorRoutes(app *fiber.App, handler *TwoFactorHandler, basePath string) {
+	register(app, basePath, twoFactorRoutes(handler))
+}
+
+// TwoFactorOperations describes the routes registered by RegisterTwoFactorRoutes
+func TwoFactorOperations() []openapi.Operation {
+	return operations(twoFactorRoutes(nil))
+}
+
+// twoFactorRoutes the TOTP enrollment routes
+func twoFactorRoutes(handler *TwoFactorHandler) []route {
+	idParam := map[string]string{"id": "uuid"}
+	tags := []string{"two-factor"}
+
+	return []route{
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodPost,
+				Path:        "/users/:id/totp",
+				Summary:     "Start the TOTP enrollment, the secret is returned only once",
+				Tags:        tags,
+				OperationID: "enrollUserTOTP",
+				PathParams:  idParam,
+				Responses: map[int]any{
+					fiber.StatusCreated:             dto.TOTPEnrollmentResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusConflict:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.EnrollTOTP,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodPost,
+				Path:        "/users/:id/totp/confirm",
+				Summary:     "Enable two-factor with the first code, returns the recovery codes once",
+				Tags:        tags,
+				OperationID: "confirmUserTOTP",
+				PathParams:  idParam,
+				Body:        dto.ConfirmTOTPRequestDto{},
+				Responses: map[int]any{
+					fiber.StatusOK:                  dto.RecoveryCodesResponseDto{},
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusConflict:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.ConfirmTOTP,
+		},
+		{
+			Operation: openapi.Operation{
+				Method:      fiber.MethodDelete,
+				Path:        "/users/:id/totp",
+				Summary:     "Disable two-factor authentication, requires a TOTP or recovery code",
+				Tags:        tags,
+				OperationID: "disableUserTOTP",
+				PathParams:  idParam,
+				Body:        dto.DisableTOTPRequestDto{},
+				Responses: map[int]any{
+					fiber.StatusNoContent:           nil,
+					fiber.StatusBadRequest:          dto.ErrorResponseDto{},
+					fiber.StatusForbidden:           dto.ErrorResponseDto{},
+					fiber.StatusNotFound:            dto.ErrorResponseDto{},
+					fiber.StatusInternalServerError: dto.ErrorResponseDto{},
+				},
+			},
+			Handler: handler.DisableTOTP,
+		},
+	}
+}
+
+// route a route of the API and its OpenAPI operation, Register* and *Operations read the
+// same table so the document can not drift from the routes, the validation middlewares
+// come from the operation (PathParams, Query and Body)
+type route struct {
+	openapi.Operation
+	Handler fiber.Handler
+}
+
+// register registers routes under basePath, each one behind the validation of its
+// operation
+func register(app *fiber.App, basePath string, routes []route) {
+	for _, r := range routes {
+		app.Add(r.Method, openapi.JoinPath(basePath, r.Path), append(validators(r.Operation), r.Handler)...)
+	}
+}
+
+// validators the path params (in path order), query and body validation of op
+func validators(op openapi.Operation) []fiber.Handler {
+	var handlers []fiber.Handler
+	for _, segment := range strings.Split(op.Path, "/") {
+		name, ok := strings.CutPrefix(segment, ":")
+		if format := op.PathParams[name]; ok && format != "" {
+			handlers = append(handlers, middleware.ValidateParam(name, format))
+		}
+	}
+	if op.Query != nil {
+		handlers = append(handlers, middleware.ValidateQueryOf(op.Query))
+	}
+	if op.Body != nil {
+		handlers = append(handlers, middleware.ValidateBodyOf(op.Body))
+	}
+	return handlers
+}
+
+// operations the OpenAPI operations of routes
+func operations(routes []route) []openapi.Operation {
+	ops := make([]openapi.Operation, len(routes))
+	for i, r := range routes {
+		ops[i] = r.Operation
+	}
+	return ops
 }
@@ -0,0 +1,626 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/jsonschema"
+	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/memory"
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/internal/users/ports/http/dto"
+	"github.com/cristianortiz/observ-monit-go/internal/users/usecase"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth/totp"
+	"github.com/cristianortiz/observ-monit-go/pkg/http-utils/middleware"
+	"github.com/cristianortiz/observ-monit-go/pkg/http-utils/openapi"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/cristianortiz/observ-monit-go/pkg/secretbox"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testBasePath = "/api/v1"
+
+// TestOpenAPI_MatchesRegisteredRoutes fails when RegisterRoutes and Operations diverge,
+// e.g. a new route without documentation or a documented route that does not exist
+func TestOpenAPI_MatchesRegisteredRoutes(t *testing.T) {
+	app := fiber.New()
+	RegisterRoutes(app, NewUserHandler(nil, &metrics.UserMetrics{}), testBasePath)
+	RegisterPrivacyRoutes(app, NewPrivacyHandler(nil), testBasePath)
+	RegisterAPIKeyRoutes(app, NewAPIKeyHandler(nil), testBasePath)
+	RegisterSessionRoutes(app, NewSessionHandler(nil), testBasePath)
+	RegisterAuthRoutes(app, NewAuthHandler(nil), testBasePath)
+	RegisterTwoFactorRoutes(app, NewTwoFactorHandler(nil), testBasePath)
+
+	registered := map[string]bool{}
+	for _, route := range app.GetRoutes(true) {
+		// HEAD is added by Fiber for every GET
+		if route.Method == fiber.MethodHead || !strings.HasPrefix(route.Path, testBasePath) {
+			continue
+		}
+		path := openapi.ToOpenAPIPath(openapi.JoinPath(route.Path, ""))
+		registered[route.Method+" "+path] = true
+	}
+
+	doc := openapi.New(openapi.Info{Title: "test", Version: "1.0.0"}).
+		Add(testBasePath, Operations()...).
+		Add(testBasePath, PrivacyOperations()...).
+		Add(testBasePath, APIKeyOperations()...).
+		Add(testBasePath, SessionOperations()...).
+		Add(testBasePath, AuthOperations()...).
+		Add(testBasePath, TwoFactorOperations()...).
+		Document()
+
+	documented := map[string]bool{}
+	for path, item := range doc.Paths {
+		for method := range *item {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	assert.Equal(t, sortedKeys(registered), sortedKeys(documented),
+		"registered routes and OpenAPI operations must match")
+}
+
+// TestRoutes_OperationMetadata the operations describe what the routes check: every path
+// param is documented and validated, validated operations document 400
+func TestRoutes_OperationMetadata(t *testing.T) {
+	middleware.InitValidator()
+	tables := [][]route{
+		userRoutes(nil),
+		privacyRoutes(nil),
+		apiKeyRoutes(nil),
+		sessionRoutes(nil),
+		authRoutes(nil),
+		twoFactorRoutes(nil),
+	}
+
+	app := fiber.New()
+	ids := map[string]bool{}
+	for _, routes := range tables {
+		register(app, testBasePath, routes)
+
+		for _, r := range routes {
+			assert.False(t, ids[r.OperationID], "duplicate operation id %s", r.OperationID)
+			ids[r.OperationID] = true
+
+			var params []string
+			for _, segment := range strings.Split(r.Path, "/") {
+				if name, ok := strings.CutPrefix(segment, ":"); ok {
+					params = append(params, name)
+				}
+			}
+			assert.ElementsMatch(t, params, paramNames(r.PathParams),
+				"%s %s path params must be documented", r.Method, r.Path)
+
+			if len(r.PathParams) > 0 || r.Query != nil || r.Body != nil {
+				assert.Contains(t, r.Responses, fiber.StatusBadRequest,
+					"%s %s is validated, it must document 400", r.Method, r.Path)
+			}
+		}
+	}
+
+	// the validators run before the (nil) handlers
+	for _, routes := range tables {
+		for _, r := range routes {
+			if len(r.PathParams) == 0 {
+				continue
+			}
+			path := testBasePath + r.Path
+			for name := range r.PathParams {
+				path = strings.Replace(path, ":"+name, "not-a-uuid", 1)
+			}
+			resp, err := app.Test(httptest.NewRequest(r.Method, path, nil))
+			require.NoError(t, err)
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, "%s %s", r.Method, r.Path)
+		}
+	}
+}
+
+func TestOpenAPI_ServedSpec(t *testing.T) {
+	doc := openapi.New(openapi.Info{Title: "Factorit", Version: "1.0.0"}).
+		Add(testBasePath, Operations()...).
+		Document()
+
+	handler, err := openapi.NewHandler(doc, "/openapi.json")
+	require.NoError(t, err)
+
+	app := fiber.New()
+	handler.RegisterRoutes(app, "/openapi.json", "/docs")
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/openapi.json", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, _ := io.ReadAll(resp.Body)
+	var spec map[string]any
+	require.NoError(t, json.Unmarshal(body, &spec))
+
+	paths := spec["paths"].(map[string]any)
+	assert.Contains(t, paths, "/api/v1/users")
+	assert.Contains(t, paths, "/api/v1/users/{id}")
+
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+	assert.Contains(t, schemas, "CreateUserRequestDto")
+	assert.Contains(t, schemas, "UserResponseDto")
+	assert.Contains(t, schemas, "ErrorResponseDto")
+}
+
+func TestListUsers_AttributesFilter(t *testing.T) {
+	middleware.InitValidator()
+	service := usecase.NewUserService(memory.NewUserRepository())
+	app := fiber.New()
+	RegisterRoutes(app, NewUserHandler(service, &metrics.UserMetrics{
+		UsersCreated: prometheus.NewCounter(prometheus.CounterOpts{Name: "created"}),
+	}), testBasePath)
+
+	create := func(body string) {
+		req := httptest.NewRequest("POST", testBasePath+"/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	}
+	create(`{"name":"John Doe","email":"john@example.com","password":"SecurePass123!","attributes":{"department":"sales","level":2}}`)
+	create(`{"name":"Jane Doe","email":"jane@example.com","password":"SecurePass123!","attributes":{"department":"sales","level":"2"}}`)
+	create(`{"name":"Jim Doe","email":"jim@example.com","password":"SecurePass123!"}`)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "no filter", query: "", expected: []string{"jim@example.com", "jane@example.com", "john@example.com"}},
+		{name: "string value", query: "?attr[department]=sales", expected: []string{"jane@example.com", "john@example.com"}},
+		// without schema the query values are strings
+		{name: "numeric looking string", query: "?attr[level]=2", expected: []string{"jane@example.com"}},
+		{name: "no match", query: "?attr[department]=hr", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", testBasePath+"/users"+tt.query, nil))
+			require.NoError(t, err)
+			require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+			var body dto.UserListResponseDto
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+			emails := []string{}
+			for _, user := range body.Users {
+				emails = append(emails, user.Email)
+				assert.NotNil(t, user.Attributes, "attributes is always an object")
+			}
+			assert.ElementsMatch(t, tt.expected, emails)
+			assert.Equal(t, int64(len(tt.expected)), body.TotalCount)
+		})
+	}
+}
+
+func TestListUsers_AttributesFilterTypedBySchema(t *testing.T) {
+	middleware.InitValidator()
+	validator, err := jsonschema.NewAttributesValidator([]byte(
+		`{"type":"object","properties":{"phone":{"type":"string"},"level":{"type":"integer"}}}`))
+	require.NoError(t, err)
+
+	users := memory.NewUserRepository()
+	service := usecase.NewUserService(users, usecase.WithAttributesValidator(validator))
+	_, err = service.CreateUser(t.Context(), "John Doe", "john@example.com", "SecurePass123!",
+		domain.Attributes{"phone": "5551234", "level": 2})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	RegisterRoutes(app, NewUserHandler(service, &metrics.UserMetrics{}), testBasePath)
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected int64
+	}{
+		{name: "numeric looking string", query: "?attr[phone]=5551234", status: fiber.StatusOK, expected: 1},
+		{name: "integer", query: "?attr[level]=2", status: fiber.StatusOK, expected: 1},
+		{name: "not an integer", query: "?attr[level]=two", status: fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", testBasePath+"/users"+tt.query, nil))
+			require.NoError(t, err)
+			require.Equal(t, tt.status, resp.StatusCode)
+			if tt.status != fiber.StatusOK {
+				return
+			}
+
+			var body dto.UserListResponseDto
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tt.expected, body.TotalCount)
+		})
+	}
+}
+
+func TestCreateUser_InvalidAttributes(t *testing.T) {
+	middleware.InitValidator()
+	validator, err := jsonschema.NewAttributesValidator([]byte(
+		`{"type":"object","properties":{"phone":{"type":"string"}},"additionalProperties":false}`))
+	require.NoError(t, err)
+
+	service := usecase.NewUserService(memory.NewUserRepository(), usecase.WithAttributesValidator(validator))
+	app := fiber.New()
+	app.Use(middleware.RequestID(middleware.RequestIDConfig{}))
+	RegisterRoutes(app, NewUserHandler(service, &metrics.UserMetrics{}), testBasePath)
+
+	req := httptest.NewRequest("POST", testBasePath+"/users", strings.NewReader(
+		`{"name":"John Doe","email":"john@example.com","password":"SecurePass123!","attributes":{"phone":123}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var body dto.ErrorResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Contains(t, body.Fields, "attributes/phone")
+	assert.Equal(t, "req-42", body.RequestID, "clients can report the failed request")
+}
+
+func TestPrivacyRoutes(t *testing.T) {
+	users := memory.NewUserRepository()
+	privacy := usecase.NewPrivacyService(users, memory.NewErasureJobRepository(),
+		&logger.Logger{Logger: zap.NewNop()})
+	app := fiber.New()
+	RegisterPrivacyRoutes(app, NewPrivacyHandler(privacy), testBasePath)
+
+	user, err := domain.NewUser("John Doe", "john@example.com", "SecurePass123!")
+	require.NoError(t, err)
+	require.NoError(t, users.Create(context.Background(), user))
+	userPath := testBasePath + "/users/" + user.ID
+
+	// export
+	resp, err := app.Test(httptest.NewRequest("GET", userPath+"/data-export", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get(fiber.HeaderContentDisposition), "attachment")
+
+	var export dto.DataExportResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&export))
+	assert.Equal(t, user.ID, export.UserID)
+	assert.Contains(t, export.Sections, "profile")
+
+	// erasure request
+	resp, err = app.Test(httptest.NewRequest("POST", userPath+"/erasure-jobs", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusAccepted, resp.StatusCode)
+
+	var job dto.ErasureJobResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	assert.Equal(t, userPath+"/erasure-jobs/"+job.ID, resp.Header.Get(fiber.HeaderLocation))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, privacy.Wait(ctx))
+
+	// job status
+	resp, err = app.Test(httptest.NewRequest("GET", resp.Header.Get(fiber.HeaderLocation), nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	assert.Equal(t, string(domain.ErasureCompleted), job.Status)
+
+	// a second request conflicts
+	resp, err = app.Test(httptest.NewRequest("POST", userPath+"/erasure-jobs", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+	// unknown job
+	resp, err = app.Test(httptest.NewRequest("GET", userPath+"/erasure-jobs/"+uuid.New().String(), nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestAPIKeyRoutes(t *testing.T) {
+	middleware.InitValidator()
+	keys := usecase.NewAPIKeyService(memory.NewAPIKeyRepository(), &logger.Logger{Logger: zap.NewNop()})
+	users := usecase.NewUserService(memory.NewUserRepository())
+
+	// management routes open, users routes behind the API key auth
+	app := fiber.New()
+	RegisterAPIKeyRoutes(app, NewAPIKeyHandler(keys), testBasePath)
+	app.Use(testBasePath+"/users",
+		middleware.Authenticate(middleware.AuthConfig{APIKeys: keys}),
+		middleware.ScopeByMethod(domain.ScopeUsersRead, domain.ScopeUsersWrite),
+	)
+	RegisterRoutes(app, NewUserHandler(users, &metrics.UserMetrics{}), testBasePath)
+
+	// issue
+	req := httptest.NewRequest("POST", testBasePath+"/api-keys", strings.NewReader(
+		`{"name":"reporting job","scopes":["users:read","users:admin"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	var created dto.CreatedAPIKeyResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.NotEmpty(t, created.Key)
+	assert.Equal(t, []string{"users:admin", "users:read"}, created.APIKey.Scopes)
+
+	// invalid scope
+	req = httptest.NewRequest("POST", testBasePath+"/api-keys", strings.NewReader(
+		`{"name":"reporting job","scopes":["users:delete"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	// the key reads users but can not write them
+	req = httptest.NewRequest("GET", testBasePath+"/users", nil)
+	req.Header.Set("X-API-Key", created.Key)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	req = httptest.NewRequest("POST", testBasePath+"/users", strings.NewReader(
+		`{"name":"John Doe","email":"john@example.com","password":"SecurePass123!"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", created.Key)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	// list never exposes the secret
+	resp, err = app.Test(httptest.NewRequest("GET", testBasePath+"/api-keys", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), created.APIKey.ID)
+	assert.NotContains(t, string(body), created.Key)
+
+	// revoked keys stop authenticating
+	resp, err = app.Test(httptest.NewRequest("DELETE", testBasePath+"/api-keys/"+created.APIKey.ID, nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+
+	req = httptest.NewRequest("GET", testBasePath+"/users", nil)
+	req.Header.Set("X-API-Key", created.Key)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("DELETE", testBasePath+"/api-keys/"+uuid.New().String(), nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestSessionRoutes(t *testing.T) {
+	secret := []byte("test-secret")
+	userRepo := memory.NewUserRepository()
+	sessions := usecase.NewSessionService(userRepo, memory.NewSessionRepository(),
+		&logger.Logger{Logger: zap.NewNop()})
+
+	app := fiber.New()
+	app.Use(testBasePath+"/users", middleware.Authenticate(middleware.AuthConfig{
+		JWTSecret: secret,
+		Sessions:  sessions,
+	}))
+	RegisterSessionRoutes(app, NewSessionHandler(sessions), testBasePath)
+
+	user, err := domain.NewUser("John Doe", "john@example.com", "SecurePass123!")
+	require.NoError(t, err)
+	require.NoError(t, userRepo.Create(context.Background(), user))
+	userPath := testBasePath + "/users/" + user.ID
+
+	laptop, err := sessions.StartSession(context.Background(), user.ID, "laptop", "Mozilla/5.0", "203.0.113.7")
+	require.NoError(t, err)
+	phone, err := sessions.StartSession(context.Background(), user.ID, "phone", "okhttp", "203.0.113.8")
+	require.NoError(t, err)
+
+	request := func(method, path string, session *domain.Session) *http.Response {
+		t.Helper()
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub": user.ID,
+			"sid": session.ID,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}).SignedString(secret)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// list, the caller session is flagged
+	resp := request("GET", userPath+"/sessions", laptop)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var list dto.SessionListResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Sessions, 2)
+	for _, session := range list.Sessions {
+		assert.Equal(t, session.ID == laptop.ID, session.Current)
+	}
+
+	// the revoked session token stops working immediately
+	resp = request("DELETE", userPath+"/sessions/"+phone.ID, laptop)
+	require.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+
+	resp = request("GET", userPath+"/sessions", phone)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp = request("DELETE", userPath+"/sessions/"+uuid.New().String(), laptop)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	// log out everywhere, the caller included
+	resp = request("DELETE", userPath+"/sessions", laptop)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var revoked dto.RevokedSessionsResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&revoked))
+	assert.Equal(t, int64(1), revoked.Revoked)
+
+	resp = request("GET", userPath+"/sessions", laptop)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestTwoFactorLoginRoutes(t *testing.T) {
+	middleware.InitValidator()
+	secret := []byte("test-secret")
+	log := &logger.Logger{Logger: zap.NewNop()}
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	box, err := secretbox.New(secretbox.DeriveKey(secret, "totp"))
+	require.NoError(t, err)
+	userRepo := memory.NewUserRepository()
+	sessions := usecase.NewSessionService(userRepo, memory.NewSessionRepository(), log)
+	twoFactor := usecase.NewTwoFactorService(userRepo, memory.NewTwoFactorRepository(), box, "FactorIT", log,
+		usecase.WithTwoFactorClock(clock))
+	login := usecase.NewLoginService(userRepo, sessions, twoFactor, auth.NewTokenIssuer(secret, ""),
+		usecase.LoginConfig{AccessTokenTTL: time.Hour, ChallengeTTL: 5 * time.Minute}, log)
+
+	// only the login routes are public
+	app := fiber.New()
+	app.Use(middleware.Authenticate(middleware.AuthConfig{
+		JWTSecret: secret,
+		Sessions:  sessions,
+		Next: func(c *fiber.Ctx) bool {
+			return strings.HasPrefix(c.Path(), testBasePath+AuthPath+"/")
+		},
+	}))
+	RegisterAuthRoutes(app, NewAuthHandler(login), testBasePath)
+	RegisterTwoFactorRoutes(app, NewTwoFactorHandler(twoFactor), testBasePath)
+
+	user, err := domain.NewUser("John Doe", "john@example.com", "SecurePass123!")
+	require.NoError(t, err)
+	require.NoError(t, userRepo.Create(context.Background(), user))
+	totpPath := testBasePath + "/users/" + user.ID + "/totp"
+
+	request := func(method, path, token, body string) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+	credentials := `{"email":"john@example.com","password":"SecurePass123!"}`
+
+	resp := request("POST", totpPath, "", "")
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp = request("POST", testBasePath+"/auth/login", "", `{"email":"john@example.com","password":"wrong"}`)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	resp = request("POST", testBasePath+"/auth/login", "", credentials)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var loggedIn dto.LoginResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&loggedIn))
+	assert.False(t, loggedIn.MFARequired)
+	require.NotEmpty(t, loggedIn.Token)
+	require.NotNil(t, loggedIn.User)
+	assert.Equal(t, user.ID, loggedIn.User.ID)
+
+	// enroll and confirm
+	resp = request("POST", totpPath, loggedIn.Token, "")
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	var enrollment dto.TOTPEnrollmentResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&enrollment))
+	assert.True(t, strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/"))
+
+	resp = request("POST", totpPath+"/confirm", loggedIn.Token, `{"code":"12345"}`)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	code, err := totp.Code(enrollment.Secret, now)
+	require.NoError(t, err)
+	resp = request("POST", totpPath+"/confirm", loggedIn.Token, `{"code":"`+code+`"}`)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var recovery dto.RecoveryCodesResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&recovery))
+	assert.Len(t, recovery.RecoveryCodes, domain.RecoveryCodeCount)
+
+	resp = request("POST", totpPath, loggedIn.Token, "")
+	assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+	// the password alone returns a challenge, not a token
+	resp = request("POST", testBasePath+"/auth/login", "", credentials)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var challenged dto.LoginResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&challenged))
+	assert.True(t, challenged.MFARequired)
+	assert.Empty(t, challenged.Token)
+	require.NotEmpty(t, challenged.Challenge)
+
+	resp = request("GET", totpPath, challenged.Challenge, "")
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode, "a challenge is not an access token")
+
+	// the confirmation code was used, the next one works
+	resp = request("POST", testBasePath+"/auth/login/totp", "",
+		`{"challenge_token":"`+challenged.Challenge+`","code":"`+code+`"}`)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	now = now.Add(totp.Period)
+	code, err = totp.Code(enrollment.Secret, now)
+	require.NoError(t, err)
+	resp = request("POST", testBasePath+"/auth/login/totp", "",
+		`{"challenge_token":"`+challenged.Challenge+`","code":"`+code+`"}`)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var token dto.TokenResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&token))
+	require.NotEmpty(t, token.Token)
+
+	// another user can not touch the enrollment
+	jane, err := domain.NewUser("Jane Doe", "jane@example.com", "SecurePass123!")
+	require.NoError(t, err)
+	require.NoError(t, userRepo.Create(context.Background(), jane))
+	resp = request("POST", testBasePath+"/auth/login", "", `{"email":"jane@example.com","password":"SecurePass123!"}`)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var janeLogin dto.LoginResponseDto
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&janeLogin))
+	resp = request("DELETE", totpPath, janeLogin.Token, `{"code":"`+recovery.RecoveryCodes[0]+`"}`)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	// disabling requires a code
+	resp = request("DELETE", totpPath, token.Token, "")
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	resp = request("DELETE", totpPath, token.Token, `{"code":"000000"}`)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	resp = request("DELETE", totpPath, token.Token, `{"code":"`+recovery.RecoveryCodes[0]+`"}`)
+	require.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+
+	resp = request("POST", testBasePath+"/auth/login/totp", "", `{"challenge_token":"garbage","code":"123456"}`)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func paramNames(params map[string]string) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"github.com/cristianortiz/observ-monit-go/internal/users/ports/http/dto"
+	"github.com/cristianortiz/observ-monit-go/internal/users/usecase"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionHandler handles the session management requests of the users
+type SessionHandler struct {
+	service *usecase.SessionService
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(service *usecase.SessionService) *SessionHandler {
+	return &SessionHandler{service: service}
+}
+
+// ListSessions handles GET /api/v1/users/:id/sessions
+// @Summary List the active sessions of a user
+// @Tags sessions
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.SessionListResponseDto
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
+// @Failure 404 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /users/{id}/sessions [get]
+func (h *SessionHandler) ListSessions(c *fiber.Ctx) error {
+	sessions, err := h.service.ListSessions(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.MapToSessionListResponse(sessions, currentSessionID(c)))
+}
+
+// RevokeSession handles DELETE /api/v1/users/:id/sessions/:sid
+// @Summary Revoke a session, its tokens stop being accepted
+// @Tags sessions
+// @Param id path string true "User ID"
+// @Param sid path string true "Session ID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
+// @Failure 404 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /users/{id}/sessions/{sid} [delete]
+func (h *SessionHandler) RevokeSession(c *fiber.Ctx) error {
+	if err := h.service.RevokeSession(c.UserContext(), c.Params("id"), c.Params("sid")); err != nil {
+		return handleError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RevokeAllSessions handles DELETE /api/v1/users/:id/sessions
+// @Summary Log the user out everywhere
+// @Tags sessions
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.RevokedSessionsResponseDto
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
+// @Failure 404 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /users/{id}/sessions [delete]
+func (h *SessionHandler) RevokeAllSessions(c *fiber.Ctx) error {
+	revoked, err := h.service.RevokeAllSessions(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.RevokedSessionsResponseDto{Revoked: revoked})
+}
+
+// currentSessionID the session of the caller token, empty for API keys and anonymous requests
+func currentSessionID(c *fiber.Ctx) string {
+	if principal, ok := auth.FromContext(c.UserContext()); ok {
+		return principal.SessionID
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"github.com/cristianortiz/observ-monit-go/internal/users/ports/http/dto"
+	"github.com/cristianortiz/observ-monit-go/internal/users/usecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TwoFactorHandler handles the TOTP enrollment requests of the users
+type TwoFactorHandler struct {
+	service *usecase.TwoFactorService
+}
+
+// NewTwoFactorHandler creates a new two-factor handler
+func NewTwoFactorHandler(service *usecase.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{service: service}
+}
+
+// EnrollTOTP handles POST /api/v1/users/:id/totp
+// @Summary Start the TOTP enrollment, the secret is returned only once
+// @Tags two-factor
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 201 {object} dto.TOTPEnrollmentResponseDto
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
+// @Failure 404 {object} dto.ErrorResponseDto
+// @Failure 409 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /users/{id}/totp [post]
+func (h *TwoFactorHandler) EnrollTOTP(c *fiber.Ctx) error {
+	enrollment, err := h.service.EnrollTOTP(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.TOTPEnrollmentResponseDto{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.URI,
+	})
+}
+
+// ConfirmTOTP handles POST /api/v1/users/:id/totp/confirm
+// @Summary Enable two-factor with the first code of the app, returns the recovery codes once
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body dto.ConfirmTOTPRequestDto true "TOTP code"
+// @Success 200 {object} dto.RecoveryCodesResponseDto
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
+// @Failure 404 {object} dto.ErrorResponseDto
+// @Failure 409 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /users/{id}/totp/confirm [post]
+func (h *TwoFactorHandler) ConfirmTOTP(c *fiber.Ctx) error {
+	req := c.Locals("validated_data").(dto.ConfirmTOTPRequestDto)
+
+	codes, err := h.service.ConfirmTOTP(c.UserContext(), c.Params("id"), req.Code)
+	if err != nil {
+		return handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.RecoveryCodesResponseDto{RecoveryCodes: codes})
+}
+
+// DisableTOTP handles DELETE /api/v1/users/:id/totp
+// @Summary Disable two-factor authentication, requires a TOTP or recovery code
+// @Tags two-factor
+// @Accept json
+// @Param id path string true "User ID"
+// @Param request body dto.DisableTOTPRequestDto true "TOTP or recovery code"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
+// @Failure 404 {object} dto.ErrorResponseDto
+// @Failure 500 {object} dto.ErrorResponseDto
+// @Router /users/{id}/totp [delete]
+func (h *TwoFactorHandler) DisableTOTP(c *fiber.Ctx) error {
+	req := c.Locals("validated_data").(dto.DisableTOTPRequestDto)
+
+	if err := h.service.DisableTOTP(c.UserContext(), c.Params("id"), req.Code); err != nil {
+		return handleError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
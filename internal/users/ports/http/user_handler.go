@@ -2,15 +2,21 @@ package http
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
 	"github.com/cristianortiz/observ-monit-go/internal/users/ports/http/dto"
 	"github.com/cristianortiz/observ-monit-go/internal/users/usecase"
+	"github.com/cristianortiz/observ-monit-go/pkg/http-utils/middleware"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
 	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
 	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
 )
 
 // UserHandler handles HTTP requests for user operations
+// annotations @Router are relative to the API base path (/api/v1), the served
+// OpenAPI document is generated from Operations() in routes.go
 type UserHandler struct {
 	service *usecase.UserService
 	metrics metrics.UserMetrics
@@ -24,7 +30,7 @@ func NewUserHandler(service *usecase.UserService, metrics *metrics.UserMetrics)
 	}
 }
 
-// CreateUser handles POST /api/users
+// CreateUser handles POST /api/v1/users
 // @Summary Create a new user
 // @Tags users
 // @Accept json
@@ -32,23 +38,25 @@ func NewUserHandler(service *usecase.UserService, metrics *metrics.UserMetrics)
 // @Param request body dto.CreateUserRequestDto true "User creation request"
 // @Success 201 {object} dto.UserResponseDto
 // @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
 // @Failure 409 {object} dto.ErrorResponseDto
 // @Failure 500 {object} dto.ErrorResponseDto
-// @Router /api/users [post]
+// @Router /users [post]
 func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 	// Get validated data from middleware
 	req := c.Locals("validated_data").(dto.CreateUserRequestDto)
 
 	// Call service
 	user, err := h.service.CreateUser(
-		c.Context(),
+		c.UserContext(),
 		req.Name,
 		req.Email,
 		req.Password,
+		req.Attributes,
 	)
 
 	if err != nil {
-		return h.handleError(c, err)
+		return handleError(c, err)
 	}
 	h.metrics.UsersCreated.Inc()
 
@@ -57,27 +65,28 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
-// GetUser handles GET /api/users/:id
+// GetUser handles GET /api/v1/users/:id
 // @Summary Get user by ID
 // @Tags users
 // @Produce json
 // @Param id path string true "User ID"
 // @Success 200 {object} dto.UserResponseDto
+// @Failure 400 {object} dto.ErrorResponseDto
 // @Failure 404 {object} dto.ErrorResponseDto
 // @Failure 500 {object} dto.ErrorResponseDto
-// @Router /api/users/{id} [get]
+// @Router /users/{id} [get]
 func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	user, err := h.service.GetUserByID(c.Context(), id)
+	user, err := h.service.GetUserByID(c.UserContext(), id)
 	if err != nil {
-		return h.handleError(c, err)
+		return handleError(c, err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(dto.MapToUserResponse(user))
 }
 
-// UpdateUser handles PUT /api/users/:id
+// UpdateUser handles PUT /api/v1/users/:id
 // @Summary Update user
 // @Tags users
 // @Accept json
@@ -86,69 +95,84 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 // @Param request body dto.UpdateUserRequestDto true "User update request"
 // @Success 200 {object} dto.UserResponseDto
 // @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
 // @Failure 404 {object} dto.ErrorResponseDto
 // @Failure 409 {object} dto.ErrorResponseDto
 // @Failure 500 {object} dto.ErrorResponseDto
-// @Router /api/users/{id} [put]
+// @Router /users/{id} [put]
 func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 
 	// Get validated data from middleware
 	req := c.Locals("validated_data").(dto.UpdateUserRequestDto)
 
+	// fields not sent keep their current value
+	var name, email string
+	if req.Name != nil {
+		name = *req.Name
+	}
+	if req.Email != nil {
+		email = *req.Email
+	}
+
 	// Call service
 	user, err := h.service.UpdateUser(
-		c.Context(),
+		c.UserContext(),
 		id,
-		*req.Name,
-		*req.Email,
+		name,
+		email,
+		req.Attributes,
 	)
 
 	if err != nil {
-		return h.handleError(c, err)
+		return handleError(c, err)
 	}
 	h.metrics.UsersUpdated.Inc()
 
 	return c.Status(fiber.StatusOK).JSON(dto.MapToUserResponse(user))
 }
 
-// DeleteUser handles DELETE /api/users/:id
+// DeleteUser handles DELETE /api/v1/users/:id
 // @Summary Delete user
 // @Tags users
 // @Param id path string true "User ID"
 // @Success 204
+// @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
 // @Failure 404 {object} dto.ErrorResponseDto
 // @Failure 500 {object} dto.ErrorResponseDto
-// @Router /api/users/{id} [delete]
+// @Router /users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	err := h.service.DeleteUser(c.Context(), id)
+	err := h.service.DeleteUser(c.UserContext(), id)
 	if err != nil {
-		return h.handleError(c, err)
+		return handleError(c, err)
 	}
 	h.metrics.UsersDeleted.Inc()
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// ListUsers handles GET /api/users
+// ListUsers handles GET /api/v1/users
 // @Summary List users with pagination
 // @Tags users
 // @Produce json
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
+// @Param attr query object false "Attribute filter, attr[department]=sales, values typed by the attributes schema"
 // @Success 200 {object} dto.UserListResponseDto
 // @Failure 400 {object} dto.ErrorResponseDto
+// @Failure 403 {object} dto.ErrorResponseDto
 // @Failure 500 {object} dto.ErrorResponseDto
-// @Router /api/users [get]
+// @Router /users [get]
 func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
 	// Get validated query params from middleware
 	query := c.Locals("validated_query").(dto.ListUsersQueryDto)
 
 	// Call service
-	users, total, err := h.service.ListUsers(c.Context(), query.Limit, query.Offset)
+	users, total, err := h.service.ListUsers(c.UserContext(), dto.MapToListFilter(query), query.Limit, query.Offset)
 	if err != nil {
-		return h.handleError(c, err)
+		return handleError(c, err)
 	}
 
 	// Build response
@@ -157,38 +181,125 @@ func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(response)
 }
 
-// handleError maps domain errors to HTTP responses
-func (h *UserHandler) handleError(c *fiber.Ctx, err error) error {
+// handleError maps domain errors to HTTP responses, shared by every handler of the module,
+// the body carries the request id so clients can report it
+func handleError(c *fiber.Ctx, err error) error {
+	status, response := errorResponse(err)
+	if status == fiber.StatusInternalServerError {
+		middleware.LoggerFrom(c, logger.Nop()).Ctx(c.UserContext()).Error("request failed",
+			zap.String("method", c.Method()),
+			zap.String("path", c.Path()),
+			zap.Error(err),
+		)
+	}
+
+	response.RequestID = middleware.RequestIDFrom(c)
+	return c.Status(status).JSON(response)
+}
+
+// errorResponse maps err to the status and body of the response, toStatus in the grpc port
+// maps the same errors, keep both in sync
+func errorResponse(err error) (int, dto.ErrorResponseDto) {
 	switch {
 	case errors.Is(err, domain.ErrUserNotFound):
-		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponseDto{
+		return fiber.StatusNotFound, dto.ErrorResponseDto{
 			Error:   "Not Found",
 			Message: "User not found",
-		})
+		}
+
+	case errors.Is(err, domain.ErrErasureJobNotFound):
+		return fiber.StatusNotFound, dto.ErrorResponseDto{
+			Error:   "Not Found",
+			Message: "Erasure job not found",
+		}
+
+	case errors.Is(err, domain.ErrUserErased):
+		return fiber.StatusConflict, dto.ErrorResponseDto{
+			Error:   "Conflict",
+			Message: "User data already erased",
+		}
+
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		return fiber.StatusNotFound, dto.ErrorResponseDto{
+			Error:   "Not Found",
+			Message: "API key not found",
+		}
+
+	case errors.Is(err, domain.ErrSessionNotFound):
+		return fiber.StatusNotFound, dto.ErrorResponseDto{
+			Error:   "Not Found",
+			Message: "Session not found",
+		}
+
+	case errors.Is(err, domain.ErrTwoFactorNotEnrolled):
+		return fiber.StatusNotFound, dto.ErrorResponseDto{
+			Error:   "Not Found",
+			Message: "Two-factor authentication not enrolled",
+		}
+
+	case errors.Is(err, domain.ErrTwoFactorAlreadyEnabled):
+		return fiber.StatusConflict, dto.ErrorResponseDto{
+			Error:   "Conflict",
+			Message: "Two-factor authentication already enabled",
+		}
+
+	case errors.Is(err, domain.ErrInvalidScope), errors.Is(err, domain.ErrInvalidAPIKey),
+		errors.Is(err, domain.ErrInvalidOTP):
+		return fiber.StatusBadRequest, dto.ErrorResponseDto{
+			Error:   "Bad Request",
+			Message: err.Error(),
+		}
+
+	case errors.Is(err, domain.ErrForbidden):
+		return fiber.StatusForbidden, dto.ErrorResponseDto{
+			Error:   "Forbidden",
+			Message: "Not allowed to act on this user",
+		}
 
 	case errors.Is(err, domain.ErrEmailAlreadyExists):
-		return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponseDto{
+		return fiber.StatusConflict, dto.ErrorResponseDto{
 			Error:   "Conflict",
 			Message: "Email already exists",
-		})
+		}
 
 	// case errors.Is(err, domain.ErrInvalidUserData):
-	// 	return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponseDto{
+	// 	return fiber.StatusBadRequest, dto.ErrorResponseDto{
 	// 		Error:   "Bad Request",
 	// 		Message: err.Error(),
-	// 	})
+	// 	}
+
+	case errors.Is(err, domain.ErrInvalidAttributes):
+		response := dto.ErrorResponseDto{
+			Error:   "Bad Request",
+			Message: "Attributes do not match the schema",
+		}
+		var attrErr *domain.AttributesError
+		if errors.As(err, &attrErr) {
+			response.Fields = make(map[string]string, len(attrErr.Violations))
+			for _, violation := range attrErr.Violations {
+				location, message, _ := strings.Cut(violation, ": ")
+				response.Fields["attributes"+strings.TrimSuffix(location, "/")] = message
+			}
+		}
+		return fiber.StatusBadRequest, response
 
 	case errors.Is(err, domain.ErrInvalidCredentials):
-		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponseDto{
+		return fiber.StatusUnauthorized, dto.ErrorResponseDto{
 			Error:   "Unauthorized",
 			Message: "Invalid credentials",
-		})
+		}
+
+	case errors.Is(err, domain.ErrInvalidChallenge):
+		return fiber.StatusUnauthorized, dto.ErrorResponseDto{
+			Error:   "Unauthorized",
+			Message: err.Error(),
+		}
 
 	default:
-		// Log internal error (TODO: add logger)
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponseDto{
+		// logged by handleError
+		return fiber.StatusInternalServerError, dto.ErrorResponseDto{
 			Error:   "Internal Server Error",
 			Message: "An unexpected error occurred",
-		})
+		}
 	}
 }
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"go.uber.org/zap"
+)
+
+// lastUsedResolution last_used_at is written at most once per key in this window,
+// avoids a write on every authenticated request
+const lastUsedResolution = time.Minute
+
+// APIKeyService issues, revokes and authenticates API keys
+// it implements auth.APIKeyAuthenticator
+type APIKeyService struct {
+	keys domain.APIKeyRepository
+	log  *logger.Logger
+	now  func() time.Time
+}
+
+// NewAPIKeyService creates a new API key service instance
+func NewAPIKeyService(keys domain.APIKeyRepository, log *logger.Logger) *APIKeyService {
+	return &APIKeyService{
+		keys: keys,
+		log:  log.WithComponent("api_keys"),
+		now:  time.Now,
+	}
+}
+
+// IssueAPIKey creates a key for the tenant in ctx, the plain key is returned only here
+func (s *APIKeyService) IssueAPIKey(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	key, plain, err := domain.NewAPIKey(name, scopes, expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.keys.Create(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to issue api key: %w", err)
+	}
+
+	logger.FromContextOr(ctx, s.log).Info("api key issued",
+		zap.String("api_key_id", key.ID),
+		zap.String("prefix", key.Prefix),
+		zap.String("tenant_id", key.TenantID),
+		zap.Strings("scopes", key.Scopes),
+	)
+
+	return key, plain, nil
+}
+
+// EnsureAPIKey stores a plain key generated by the operator for the tenant in ctx, it is
+// the bootstrap path of the first api-keys:manage key, nothing else can issue one.
+// Seeding the same key again is a no-op, a revoked key stays revoked
+func (s *APIKeyService) EnsureAPIKey(ctx context.Context, name, plain string, scopes []string) (*domain.APIKey, error) {
+	key, err := domain.ImportAPIKey(name, plain, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.keys.GetByPrefix(ctx, key.Prefix)
+	switch {
+	case err == nil:
+		if !existing.Matches(plain) {
+			return nil, fmt.Errorf("%w: lookup prefix already used by another key", domain.ErrInvalidAPIKey)
+		}
+		if existing.IsRevoked() {
+			logger.FromContextOr(ctx, s.log).Warn("bootstrap api key is revoked",
+				zap.String("api_key_id", existing.ID),
+				zap.String("tenant_id", existing.TenantID),
+			)
+		}
+		return existing, nil
+	case !errors.Is(err, domain.ErrAPIKeyNotFound):
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if err := s.keys.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to seed api key: %w", err)
+	}
+
+	logger.FromContextOr(ctx, s.log).Info("bootstrap api key seeded",
+		zap.String("api_key_id", key.ID),
+		zap.String("prefix", key.Prefix),
+		zap.String("tenant_id", key.TenantID),
+		zap.Strings("scopes", key.Scopes),
+	)
+
+	return key, nil
+}
+
+// ListAPIKeys returns the keys of the tenant in ctx
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	return s.keys.List(ctx)
+}
+
+// RevokeAPIKey revokes a key of the tenant in ctx, it stops authenticating immediately
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id string) error {
+	if err := s.keys.Revoke(ctx, id, s.now()); err != nil {
+		return err
+	}
+
+	logger.FromContextOr(ctx, s.log).Info("api key revoked",
+		zap.String("api_key_id", id),
+		zap.String("tenant_id", tenant.IDFromContext(ctx)),
+	)
+
+	return nil
+}
+
+// AuthenticateAPIKey validates a plain key and returns its principal, every failure is
+// reported as domain.ErrInvalidAPIKey so callers can not probe which keys exist
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, plain string) (*auth.Principal, error) {
+	prefix, err := domain.ParseAPIKey(plain)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.keys.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			return nil, domain.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	now := s.now()
+	if !key.Matches(plain) || !key.IsActive(now) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		// the key tenant, the request tenant is not resolved yet
+		keyCtx := tenant.WithID(ctx, key.TenantID)
+		if err := s.keys.TouchLastUsed(keyCtx, key.ID, now); err != nil {
+			// tracking only, the request is still authenticated
+			logger.FromContextOr(ctx, s.log).Warn("failed to record api key usage",
+				zap.String("api_key_id", key.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return &auth.Principal{
+		Type:     auth.PrincipalAPIKey,
+		ID:       key.ID,
+		TenantID: key.TenantID,
+		Scopes:   key.Scopes,
+	}, nil
+}
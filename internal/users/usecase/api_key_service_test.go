@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/memory"
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newAPIKeyTest() (*APIKeyService, *memory.APIKeyRepository) {
+	keys := memory.NewAPIKeyRepository()
+	return NewAPIKeyService(keys, &logger.Logger{Logger: zap.NewNop()}), keys
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	t.Run("success - principal of the key tenant", func(t *testing.T) {
+		service, keys := newAPIKeyTest()
+		key, plain, err := service.IssueAPIKey(ctx, "billing", []string{domain.ScopeUsersRead}, nil)
+		require.NoError(t, err)
+
+		// no tenant yet, authentication runs before the tenant middleware
+		principal, err := service.AuthenticateAPIKey(context.Background(), plain)
+		require.NoError(t, err)
+		assert.Equal(t, auth.PrincipalAPIKey, principal.Type)
+		assert.Equal(t, key.ID, principal.ID)
+		assert.Equal(t, "acme", principal.TenantID)
+		assert.Equal(t, []string{domain.ScopeUsersRead}, principal.Scopes)
+
+		stored, err := keys.GetByID(ctx, key.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, stored.LastUsedAt)
+	})
+
+	t.Run("last used is written once per resolution", func(t *testing.T) {
+		service, keys := newAPIKeyTest()
+		key, plain, err := service.IssueAPIKey(ctx, "billing", []string{domain.ScopeUsersRead}, nil)
+		require.NoError(t, err)
+
+		now := time.Now()
+		service.now = func() time.Time { return now }
+		_, err = service.AuthenticateAPIKey(ctx, plain)
+		require.NoError(t, err)
+
+		service.now = func() time.Time { return now.Add(10 * time.Second) }
+		_, err = service.AuthenticateAPIKey(ctx, plain)
+		require.NoError(t, err)
+
+		stored, err := keys.GetByID(ctx, key.ID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, now, *stored.LastUsedAt, time.Millisecond)
+	})
+
+	t.Run("error - revoked, expired, unknown or tampered", func(t *testing.T) {
+		service, _ := newAPIKeyTest()
+
+		revoked, revokedPlain, err := service.IssueAPIKey(ctx, "revoked", []string{domain.ScopeUsersRead}, nil)
+		require.NoError(t, err)
+		require.NoError(t, service.RevokeAPIKey(ctx, revoked.ID))
+
+		expiresAt := time.Now().Add(time.Minute)
+		_, expiredPlain, err := service.IssueAPIKey(ctx, "expired", []string{domain.ScopeUsersRead}, &expiresAt)
+		require.NoError(t, err)
+		service.now = func() time.Time { return expiresAt.Add(time.Second) }
+
+		_, unknownPlain, err := domain.NewAPIKey("unknown", []string{domain.ScopeUsersRead}, nil)
+		require.NoError(t, err)
+
+		for name, plain := range map[string]string{
+			"revoked":   revokedPlain,
+			"expired":   expiredPlain,
+			"unknown":   unknownPlain,
+			"tampered":  revokedPlain[:len(revokedPlain)-1] + "x",
+			"malformed": "not-a-key",
+		} {
+			_, err := service.AuthenticateAPIKey(ctx, plain)
+			assert.ErrorIs(t, err, domain.ErrInvalidAPIKey, name)
+		}
+	})
+}
+
+func TestAPIKeyService_Manage(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	service, _ := newAPIKeyTest()
+
+	_, _, err := service.IssueAPIKey(ctx, "bad", []string{"users:delete"}, nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidScope)
+
+	key, _, err := service.IssueAPIKey(ctx, "billing", []string{domain.ScopeUsersWrite}, nil)
+	require.NoError(t, err)
+
+	keys, err := service.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, key.ID, keys[0].ID)
+
+	// other tenants can not see nor revoke it
+	globex := tenant.WithID(context.Background(), "globex")
+	keys, err = service.ListAPIKeys(globex)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+	assert.ErrorIs(t, service.RevokeAPIKey(globex, key.ID), domain.ErrAPIKeyNotFound)
+
+	assert.ErrorIs(t, service.RevokeAPIKey(ctx, uuid.New().String()), domain.ErrAPIKeyNotFound)
+}
+
+func TestAPIKeyService_EnsureAPIKey(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	service, keys := newAPIKeyTest()
+	_, plain, err := domain.NewAPIKey("generated", []string{domain.ScopeUsersRead}, nil)
+	require.NoError(t, err)
+
+	key, err := service.EnsureAPIKey(ctx, "bootstrap", plain, []string{domain.ScopeAPIKeysManage})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", key.TenantID)
+
+	principal, err := service.AuthenticateAPIKey(context.Background(), plain)
+	require.NoError(t, err)
+	assert.Equal(t, []string{domain.ScopeAPIKeysManage}, principal.Scopes)
+
+	// seeding again at the next startup keeps the same key
+	again, err := service.EnsureAPIKey(ctx, "bootstrap", plain, []string{domain.ScopeAPIKeysManage})
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, again.ID)
+	stored, err := keys.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, stored, 1)
+
+	// a revoked bootstrap key is not brought back
+	require.NoError(t, service.RevokeAPIKey(ctx, key.ID))
+	_, err = service.EnsureAPIKey(ctx, "bootstrap", plain, []string{domain.ScopeAPIKeysManage})
+	require.NoError(t, err)
+	_, err = service.AuthenticateAPIKey(context.Background(), plain)
+	assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+
+	// same lookup prefix, other secret
+	clash := plain[:len(domain.APIKeyPrefix)+13] + strings.Repeat("a", 43)
+	_, err = service.EnsureAPIKey(ctx, "bootstrap", clash, []string{domain.ScopeAPIKeysManage})
+	assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+}
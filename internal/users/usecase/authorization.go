@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+)
+
+// authorizeUser checks the principal of ctx may act on the profile, sessions, two-factor
+// and personal data of userID: the user itself or a principal granted domain.ScopeUsersAdmin,
+// calls without principal (auth disabled, background jobs) are not restricted
+func authorizeUser(ctx context.Context, userID string) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if principal.HasScope(domain.ScopeUsersAdmin) {
+		return nil
+	}
+	if principal.Type == auth.PrincipalUser && principal.ID == userID {
+		return nil
+	}
+	return domain.ErrForbidden
+}
+
+// authorizeAdmin checks the principal of ctx may act on every user of the tenant (create,
+// list), only principals granted domain.ScopeUsersAdmin, calls without principal are not
+// restricted
+func authorizeAdmin(ctx context.Context) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok || principal.HasScope(domain.ScopeUsersAdmin) {
+		return nil
+	}
+	return domain.ErrForbidden
+}
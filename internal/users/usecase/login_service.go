@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LoginConfig lifetimes and grants of the tokens issued at login
+type LoginConfig struct {
+	AccessTokenTTL time.Duration
+	// ChallengeTTL time the user has to send the one-time code after the password
+	ChallengeTTL time.Duration
+	// ChallengeMaxAttempts codes a challenge takes before it is invalidated, the user has
+	// to log in again, default defaultChallengeMaxAttempts
+	ChallengeMaxAttempts int
+	// Scopes granted to the access tokens of the users
+	Scopes []string
+}
+
+// ClientInfo describes the client logging in, stored in its session
+type ClientInfo struct {
+	Device    string
+	UserAgent string
+	IP        string
+}
+
+// AccessToken is a user token bound to a session
+type AccessToken struct {
+	Token     string
+	ExpiresAt time.Time
+	SessionID string
+}
+
+// LoginChallenge is returned instead of an access token when the user has two-factor
+// enabled, CompleteLogin exchanges it (plus a code) for the access token
+type LoginChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// LoginResult exactly one of Token and Challenge is set, User only with Token
+type LoginResult struct {
+	User      *domain.User
+	Token     *AccessToken
+	Challenge *LoginChallenge
+}
+
+// defaultChallengeMaxAttempts codes tried per challenge when LoginConfig does not say,
+// a 6 digit code is then guessed once in 200000 challenges
+const defaultChallengeMaxAttempts = 5
+
+// LoginService authenticates users with their password and, when enabled, a one-time code
+type LoginService struct {
+	users     domain.UserRepository
+	sessions  *SessionService
+	twoFactor *TwoFactorService
+	tokens    *auth.TokenIssuer
+	config    LoginConfig
+	log       *logger.Logger
+}
+
+// NewLoginService creates a new login service instance
+func NewLoginService(users domain.UserRepository, sessions *SessionService, twoFactor *TwoFactorService, tokens *auth.TokenIssuer, config LoginConfig, log *logger.Logger) *LoginService {
+	if config.ChallengeMaxAttempts <= 0 {
+		config.ChallengeMaxAttempts = defaultChallengeMaxAttempts
+	}
+
+	return &LoginService{
+		users:     users,
+		sessions:  sessions,
+		twoFactor: twoFactor,
+		tokens:    tokens,
+		config:    config,
+		log:       log.WithComponent("login"),
+	}
+}
+
+// Login checks the password of the user of the tenant in ctx, users with two-factor
+// enabled get a challenge, the rest an access token, every failure is reported as
+// domain.ErrInvalidCredentials so callers can not probe which emails exist
+func (s *LoginService) Login(ctx context.Context, email, password string, client ClientInfo) (*LoginResult, error) {
+	user, err := s.users.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		if !errors.Is(err, domain.ErrUserNotFound) {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		// same cost as a wrong password, the response time does not reveal the email exists
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return nil, domain.ErrInvalidCredentials
+	}
+	if user.IsDeleted() || !user.ValidatePassword(password) {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	enabled, err := s.twoFactor.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check two-factor: %w", err)
+	}
+	if enabled {
+		challengeID, err := s.twoFactor.StartChallenge(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start login challenge: %w", err)
+		}
+		token, expiresAt, err := s.tokens.IssueChallenge(auth.Challenge{
+			ID:       challengeID,
+			UserID:   user.ID,
+			TenantID: user.TenantID,
+		}, s.config.ChallengeTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to issue login challenge: %w", err)
+		}
+		return &LoginResult{Challenge: &LoginChallenge{Token: token, ExpiresAt: expiresAt}}, nil
+	}
+
+	token, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{User: user, Token: token}, nil
+}
+
+// CompleteLogin second login step, exchanges a challenge and a TOTP or recovery code
+// for an access token, a challenge is answered once and takes ChallengeMaxAttempts codes
+func (s *LoginService) CompleteLogin(ctx context.Context, token, code string, client ClientInfo) (*AccessToken, error) {
+	challenge, err := s.tokens.ParseChallenge(token)
+	if err != nil {
+		return nil, domain.ErrInvalidChallenge
+	}
+	userID, tenantID := challenge.UserID, challenge.TenantID
+	// the tenant that checked the password
+	ctx = tenant.WithID(ctx, tenantID)
+
+	if err := s.twoFactor.VerifyChallenge(ctx, userID, challenge.ID, code, s.config.ChallengeMaxAttempts); err != nil {
+		if errors.Is(err, domain.ErrInvalidOTP) {
+			logger.FromContextOr(ctx, s.log).Warn("invalid two-factor code",
+				zap.String("user_id", userID),
+				zap.String("tenant_id", tenantID),
+			)
+		}
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDeleted() {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	return s.startSession(ctx, user, client)
+}
+
+// startSession creates the session of a login and its access token
+func (s *LoginService) startSession(ctx context.Context, user *domain.User, client ClientInfo) (*AccessToken, error) {
+	session, err := s.sessions.StartSession(ctx, user.ID, client.Device, client.UserAgent, client.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := s.tokens.IssueAccessToken(&auth.Principal{
+		Type:      auth.PrincipalUser,
+		ID:        user.ID,
+		TenantID:  user.TenantID,
+		Scopes:    s.config.Scopes,
+		SessionID: session.ID,
+	}, s.config.AccessTokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue access token: %w", err)
+	}
+
+	return &AccessToken{Token: token, ExpiresAt: expiresAt, SessionID: session.ID}, nil
+}
+
+// dummyPasswordHash is compared when the email is unknown, computed once on first use
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/memory"
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testLoginConfig = LoginConfig{
+	AccessTokenTTL: time.Hour,
+	ChallengeTTL:   5 * time.Minute,
+	Scopes:         []string{domain.ScopeUsersRead},
+}
+
+func newLoginTest(t *testing.T) (*LoginService, *twoFactorTest) {
+	t.Helper()
+
+	tt := newTwoFactorTest(t)
+	log := &logger.Logger{Logger: zap.NewNop()}
+	sessions := NewSessionService(tt.users, memory.NewSessionRepository(), log)
+	tokens := auth.NewTokenIssuer([]byte("test-secret"), "", auth.WithTokenClock(tt.clock.Now))
+
+	return NewLoginService(tt.users, sessions, tt.service, tokens, testLoginConfig, log), tt
+}
+
+func parseTestToken(t *testing.T, token string, now time.Time) jwt.MapClaims {
+	t.Helper()
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return []byte("test-secret"), nil
+	}, jwt.WithTimeFunc(func() time.Time { return now }))
+	require.NoError(t, err)
+	return claims
+}
+
+func TestLoginService_Login(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	service, tt := newLoginTest(t)
+	client := ClientInfo{Device: "laptop", UserAgent: "Mozilla/5.0", IP: "203.0.113.7"}
+
+	result, err := service.Login(ctx, " John@Example.com ", "password123", client)
+	require.NoError(t, err)
+	require.NotNil(t, result.Token)
+	assert.Nil(t, result.Challenge)
+	assert.WithinDuration(t, tt.clock.Now().Add(time.Hour), result.Token.ExpiresAt, 0)
+
+	claims := parseTestToken(t, result.Token.Token, tt.clock.Now())
+	assert.Equal(t, tt.user.ID, claims["sub"])
+	assert.Equal(t, "acme", claims["tenant"])
+	assert.Equal(t, result.Token.SessionID, claims["sid"])
+	assert.Equal(t, auth.TokenUseAccess, claims[auth.TokenUseClaim])
+	assert.Equal(t, domain.ScopeUsersRead, claims["scope"])
+
+	for name, login := range map[string]struct {
+		ctx             context.Context
+		email, password string
+	}{
+		"wrong password": {ctx, "john@example.com", "wrong-password"},
+		"unknown email":  {ctx, "jane@example.com", "password123"},
+		"other tenant":   {tenant.WithID(context.Background(), "globex"), "john@example.com", "password123"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.Login(login.ctx, login.email, login.password, client)
+			assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		})
+	}
+}
+
+func TestLoginService_TwoFactor(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	service, tt := newLoginTest(t)
+	secret, codes := tt.enable(t, ctx)
+	client := ClientInfo{Device: "phone"}
+
+	result, err := service.Login(ctx, "john@example.com", "password123", client)
+	require.NoError(t, err)
+	assert.Nil(t, result.Token, "no access token before the second step")
+	require.NotNil(t, result.Challenge)
+	assert.WithinDuration(t, tt.clock.Now().Add(5*time.Minute), result.Challenge.ExpiresAt, 0)
+
+	// the challenge carries the tenant, the second step does not need it in ctx
+	_, err = service.CompleteLogin(context.Background(), result.Challenge.Token, "000000", client)
+	assert.ErrorIs(t, err, domain.ErrInvalidOTP)
+
+	token, err := service.CompleteLogin(context.Background(), result.Challenge.Token, tt.code(t, secret), client)
+	require.NoError(t, err)
+	claims := parseTestToken(t, token.Token, tt.clock.Now())
+	assert.Equal(t, tt.user.ID, claims["sub"])
+	assert.Equal(t, "acme", claims["tenant"])
+	assert.Equal(t, token.SessionID, claims["sid"])
+
+	// a challenge is answered once
+	_, err = service.CompleteLogin(context.Background(), result.Challenge.Token, codes[1], client)
+	assert.ErrorIs(t, err, domain.ErrInvalidChallenge)
+
+	// recovery codes work as second step too
+	result, err = service.Login(ctx, "john@example.com", "password123", client)
+	require.NoError(t, err)
+	token, err = service.CompleteLogin(context.Background(), result.Challenge.Token, codes[0], client)
+	require.NoError(t, err)
+	assert.NotEmpty(t, token.Token)
+
+	// an access token is not a challenge
+	_, err = service.CompleteLogin(context.Background(), token.Token, tt.code(t, secret), client)
+	assert.ErrorIs(t, err, domain.ErrInvalidChallenge)
+
+	// expired challenge
+	tt.clock.Advance(6 * time.Minute)
+	_, err = service.CompleteLogin(context.Background(), result.Challenge.Token, tt.code(t, secret), client)
+	assert.ErrorIs(t, err, domain.ErrInvalidChallenge)
+}
+
+func TestLoginService_ChallengeAttempts(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	service, tt := newLoginTest(t)
+	secret, _ := tt.enable(t, ctx)
+	client := ClientInfo{Device: "phone"}
+
+	first, err := service.Login(ctx, "john@example.com", "password123", client)
+	require.NoError(t, err)
+	for range defaultChallengeMaxAttempts {
+		_, err = service.CompleteLogin(context.Background(), first.Challenge.Token, "000000", client)
+		assert.ErrorIs(t, err, domain.ErrInvalidOTP)
+	}
+	// the right code no longer helps, the user logs in again
+	_, err = service.CompleteLogin(context.Background(), first.Challenge.Token, tt.code(t, secret), client)
+	assert.ErrorIs(t, err, domain.ErrInvalidChallenge)
+
+	second, err := service.Login(ctx, "john@example.com", "password123", client)
+	require.NoError(t, err)
+	third, err := service.Login(ctx, "john@example.com", "password123", client)
+	require.NoError(t, err)
+	// only the latest challenge can be answered
+	_, err = service.CompleteLogin(context.Background(), second.Challenge.Token, tt.code(t, secret), client)
+	assert.ErrorIs(t, err, domain.ErrInvalidChallenge)
+	_, err = service.CompleteLogin(context.Background(), third.Challenge.Token, tt.code(t, secret), client)
+	require.NoError(t, err)
+}
@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+)
+
+// built-in data export sections, the JSON field names are part of the export format
+// (see dataExportFormatVersion)
+
+// profileSection the user row, the password hash is a credential not personal data
+// about the subject, it is never exported
+type profileSection struct {
+	users domain.UserRepository
+}
+
+type profileExport struct {
+	ID         string            `json:"id"`
+	TenantID   string            `json:"tenant_id"`
+	Name       string            `json:"name"`
+	Email      string            `json:"email"`
+	Attributes domain.Attributes `json:"attributes"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	DeletedAt  *time.Time        `json:"deleted_at,omitempty"`
+}
+
+func (profileSection) Name() string { return "profile" }
+
+func (profileSection) Export(_ context.Context, user *domain.User) (any, error) {
+	attributes := user.Attributes
+	if attributes == nil {
+		attributes = domain.Attributes{}
+	}
+
+	return profileExport{
+		ID:         user.ID,
+		TenantID:   user.TenantID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Attributes: attributes,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+		DeletedAt:  user.DeletedAt,
+	}, nil
+}
+
+// Erase anonymizes the row in place, it is kept so references and aggregates stay valid
+func (s profileSection) Erase(ctx context.Context, user *domain.User) error {
+	if user.IsAnonymized() {
+		// erased meanwhile by another job, nothing left to do
+		return nil
+	}
+
+	user.Anonymize()
+	if err := s.users.Anonymize(ctx, user); err != nil {
+		return fmt.Errorf("failed to anonymize user: %w", err)
+	}
+	return nil
+}
+
+// erasureJobsSection the erasure requests of the user, they are data about the subject too
+type erasureJobsSection struct {
+	jobs domain.ErasureJobRepository
+}
+
+type erasureJobExport struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+func (erasureJobsSection) Name() string { return "erasure_jobs" }
+
+func (s erasureJobsSection) Export(ctx context.Context, user *domain.User) (any, error) {
+	jobs, err := s.jobs.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]erasureJobExport, len(jobs))
+	for i, job := range jobs {
+		out[i] = erasureJobExport{
+			ID:          job.ID,
+			Status:      string(job.Status),
+			RequestedAt: job.RequestedAt,
+			StartedAt:   job.StartedAt,
+			CompletedAt: job.CompletedAt,
+		}
+	}
+
+	return out, nil
+}
+
+// Erase keeps the jobs, they hold no personal data and prove the request was honoured
+func (erasureJobsSection) Erase(context.Context, *domain.User) error {
+	return nil
+}
+
+// sessionsSection the logins of the user, revoked ones included (device, IP...)
+type sessionsSection struct {
+	sessions domain.SessionRepository
+	// forget drops the cached validations of the user sessions (SessionService cache)
+	forget func(ctx context.Context, userID string)
+}
+
+type sessionExport struct {
+	ID         string     `json:"id"`
+	Device     string     `json:"device"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (sessionsSection) Name() string { return "sessions" }
+
+func (s sessionsSection) Export(ctx context.Context, user *domain.User) (any, error) {
+	sessions, err := s.sessions.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]sessionExport, len(sessions))
+	for i, session := range sessions {
+		out[i] = sessionExport{
+			ID:         session.ID,
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			RevokedAt:  session.RevokedAt,
+		}
+	}
+
+	return out, nil
+}
+
+// Erase deletes the sessions, their tokens stop being accepted
+func (s sessionsSection) Erase(ctx context.Context, user *domain.User) error {
+	if _, err := s.sessions.DeleteAllByUser(ctx, user.ID); err != nil {
+		return err
+	}
+	s.forget(ctx, user.ID)
+	return nil
+}
+
+// twoFactorSection whether two-factor authentication is set up, the secret and the
+// recovery codes are credentials, they are never exported
+type twoFactorSection struct {
+	enrollments domain.TwoFactorRepository
+}
+
+type twoFactorExport struct {
+	Enabled                bool       `json:"enabled"`
+	CreatedAt              *time.Time `json:"created_at,omitempty"`
+	ConfirmedAt            *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+func (twoFactorSection) Name() string { return "two_factor" }
+
+func (s twoFactorSection) Export(ctx context.Context, user *domain.User) (any, error) {
+	enrollment, err := s.enrollments.Get(ctx, user.ID)
+	if errors.Is(err, domain.ErrTwoFactorNotEnrolled) {
+		return twoFactorExport{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return twoFactorExport{
+		Enabled:                enrollment.IsEnabled(),
+		CreatedAt:              &enrollment.CreatedAt,
+		ConfirmedAt:            enrollment.ConfirmedAt,
+		RecoveryCodesRemaining: len(enrollment.RecoveryCodeHashes),
+	}, nil
+}
+
+// Erase deletes the enrollment, the TOTP secret included
+func (s twoFactorSection) Erase(ctx context.Context, user *domain.User) error {
+	if err := s.enrollments.Delete(ctx, user.ID); err != nil && !errors.Is(err, domain.ErrTwoFactorNotEnrolled) {
+		return err
+	}
+	return nil
+}
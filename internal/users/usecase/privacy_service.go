@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"go.uber.org/zap"
+)
+
+// dataExportFormatVersion bump it when a section changes in a non backwards compatible way
+const dataExportFormatVersion = 1
+
+// PrivacyService handles the GDPR data subject requests: export (right of access)
+// and erasure (right to be forgotten)
+type PrivacyService struct {
+	users    domain.UserRepository
+	jobs     domain.ErasureJobRepository
+	sections []domain.DataExportSection
+	log      *logger.Logger
+
+	// running erasures, Wait blocks on it during graceful shutdown
+	wg sync.WaitGroup
+}
+
+// PrivacyOption configures optional privacy service behaviour
+type PrivacyOption func(*PrivacyService)
+
+// WithExportSections adds sections to the data export and the erasure, every store
+// holding user data (sessions, audit...) should contribute one
+func WithExportSections(sections ...domain.DataExportSection) PrivacyOption {
+	return func(s *PrivacyService) {
+		s.sections = append(s.sections, sections...)
+	}
+}
+
+// NewPrivacyService creates a new privacy service instance, profile and erasure jobs
+// sections are always included
+func NewPrivacyService(users domain.UserRepository, jobs domain.ErasureJobRepository, log *logger.Logger, opts ...PrivacyOption) *PrivacyService {
+	s := &PrivacyService{
+		users: users,
+		jobs:  jobs,
+		log:   log.WithComponent("privacy"),
+	}
+	s.sections = []domain.DataExportSection{
+		profileSection{users: users},
+		erasureJobsSection{jobs: jobs},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// ExportUserData builds the archive with everything stored about the user
+func (s *PrivacyService) ExportUserData(ctx context.Context, userID string) (*domain.DataExport, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	// the data of a soft deleted user is still held until the retention purge
+	user, err := s.users.GetIncludingDeleted(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &domain.DataExport{
+		FormatVersion: dataExportFormatVersion,
+		GeneratedAt:   time.Now().UTC(),
+		UserID:        user.ID,
+		TenantID:      user.TenantID,
+		Sections:      make(map[string]any, len(s.sections)),
+	}
+
+	for _, section := range s.sections {
+		data, err := section.Export(ctx, user)
+		if err != nil {
+			// an incomplete archive is not compliant, fail the whole export
+			return nil, fmt.Errorf("failed to export %s section: %w", section.Name(), err)
+		}
+		export.Sections[section.Name()] = data
+	}
+
+	logger.FromContextOr(ctx, s.log).Info("user data exported",
+		zap.String("user_id", user.ID),
+		zap.String("tenant_id", user.TenantID),
+		zap.Int("sections", len(export.Sections)),
+	)
+
+	return export, nil
+}
+
+// RequestErasure creates an erasure job and runs it in background, an active job
+// (pending or running) of the same user is returned instead of creating another one
+func (s *PrivacyService) RequestErasure(ctx context.Context, userID string) (*domain.ErasureJob, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	// a soft deleted user keeps the personal data until the retention purge, it can be
+	// erased before
+	user, err := s.users.GetIncludingDeleted(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsAnonymized() {
+		return nil, domain.ErrUserErased
+	}
+
+	existing, err := s.jobs.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list erasure jobs: %w", err)
+	}
+	for _, job := range existing {
+		if job.IsActive() {
+			return job, nil
+		}
+	}
+
+	job := domain.NewErasureJob(userID)
+	if err := s.jobs.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create erasure job: %w", err)
+	}
+
+	logger.FromContextOr(ctx, s.log).Info("erasure job requested",
+		zap.String("job_id", job.ID),
+		zap.String("user_id", userID),
+		zap.String("tenant_id", job.TenantID),
+	)
+
+	// detached from the request (it ends before the job) but keeps its values (tenant)
+	jobCtx := context.WithoutCancel(ctx)
+	// the caller gets a snapshot, the goroutine owns its own copy
+	snapshot := *job
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.runErasure(jobCtx, job)
+	}()
+
+	return &snapshot, nil
+}
+
+// GetErasureJob returns a job of the user
+func (s *PrivacyService) GetErasureJob(ctx context.Context, userID, jobID string) (*domain.ErasureJob, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	job, err := s.jobs.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, domain.ErrErasureJobNotFound
+	}
+
+	return job, nil
+}
+
+// Wait blocks until the running erasure jobs finish or ctx is done
+func (s *PrivacyService) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runErasure anonymizes the user and records every status change of the job
+func (s *PrivacyService) runErasure(ctx context.Context, job *domain.ErasureJob) {
+	log := s.log.WithFields(
+		zap.String("job_id", job.ID),
+		zap.String("user_id", job.UserID),
+		zap.String("tenant_id", tenant.IDFromContext(ctx)),
+	)
+
+	job.Start()
+	if err := s.jobs.Update(ctx, job); err != nil {
+		log.Error("failed to start erasure job", zap.Error(err))
+		return
+	}
+
+	if err := s.erase(ctx, job.UserID); err != nil {
+		job.Fail(err)
+		log.Error("erasure job failed",
+			zap.String("status", string(job.Status)),
+			zap.Error(err),
+		)
+	} else {
+		job.Complete()
+		log.Info("erasure job completed",
+			zap.String("status", string(job.Status)),
+			zap.Duration("duration", job.CompletedAt.Sub(*job.StartedAt)),
+		)
+	}
+
+	if err := s.jobs.Update(ctx, job); err != nil {
+		log.Error("failed to record erasure job status", zap.Error(err))
+	}
+}
+
+// erase runs the Erase of every section, the profile (first section) last: while the
+// row is not anonymized a failed erasure can be requested again
+func (s *PrivacyService) erase(ctx context.Context, userID string) error {
+	user, err := s.users.GetIncludingDeleted(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for i := len(s.sections) - 1; i >= 0; i-- {
+		section := s.sections[i]
+		if err := section.Erase(ctx, user); err != nil {
+			return fmt.Errorf("failed to erase %s section: %w", section.Name(), err)
+		}
+	}
+
+	return nil
+}
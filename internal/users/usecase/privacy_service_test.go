@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/memory"
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// staticSection exports a fixed value, stands in for sessions, audit...
+type staticSection struct {
+	name     string
+	data     any
+	err      error
+	eraseErr error
+	erased   *[]string
+}
+
+func (s *staticSection) Name() string { return s.name }
+
+func (s *staticSection) Export(context.Context, *domain.User) (any, error) {
+	return s.data, s.err
+}
+
+func (s *staticSection) Erase(_ context.Context, user *domain.User) error {
+	if s.erased != nil {
+		*s.erased = append(*s.erased, user.ID)
+	}
+	return s.eraseErr
+}
+
+func newPrivacyTest(t *testing.T, opts ...PrivacyOption) (*PrivacyService, *memory.UserRepository) {
+	t.Helper()
+
+	users := memory.NewUserRepository()
+	service := NewPrivacyService(users, memory.NewErasureJobRepository(),
+		&logger.Logger{Logger: zap.NewNop()}, opts...)
+	return service, users
+}
+
+func createTestUser(t *testing.T, repo domain.UserRepository, ctx context.Context) *domain.User {
+	t.Helper()
+
+	user, err := domain.NewUser("John Doe", "john@example.com", "SecurePass123!")
+	require.NoError(t, err)
+	user.Attributes = domain.Attributes{"phone": "+56 9 1234 5678"}
+	require.NoError(t, repo.Create(ctx, user))
+	return user
+}
+
+// waitErasure waits for the background jobs
+func waitErasure(t *testing.T, service *PrivacyService) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, service.Wait(ctx))
+}
+
+func TestPrivacyService_ExportUserData(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	t.Run("success - every section, no password hash", func(t *testing.T) {
+		service, users := newPrivacyTest(t, WithExportSections(
+			&staticSection{name: "sessions", data: []string{"session-1"}},
+		))
+		user := createTestUser(t, users, ctx)
+
+		export, err := service.ExportUserData(ctx, user.ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, dataExportFormatVersion, export.FormatVersion)
+		assert.Equal(t, user.ID, export.UserID)
+		assert.Equal(t, "acme", export.TenantID)
+		assert.Contains(t, export.Sections, "profile")
+		assert.Contains(t, export.Sections, "erasure_jobs")
+		assert.Equal(t, []string{"session-1"}, export.Sections["sessions"])
+
+		data, err := json.Marshal(export.Sections)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "john@example.com")
+		assert.Contains(t, string(data), "+56 9 1234 5678")
+		assert.NotContains(t, string(data), user.PasswordHash)
+	})
+
+	t.Run("error - failing section fails the export", func(t *testing.T) {
+		service, users := newPrivacyTest(t, WithExportSections(
+			&staticSection{name: "sessions", err: errors.New("store down")},
+		))
+		user := createTestUser(t, users, ctx)
+
+		export, err := service.ExportUserData(ctx, user.ID)
+		assert.Error(t, err)
+		assert.Nil(t, export)
+	})
+
+	t.Run("error - user not found", func(t *testing.T) {
+		service, _ := newPrivacyTest(t)
+
+		_, err := service.ExportUserData(ctx, uuid.New().String())
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+}
+
+func TestPrivacyService_RequestErasure(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	t.Run("success - anonymizes the user in background", func(t *testing.T) {
+		service, users := newPrivacyTest(t)
+		user := createTestUser(t, users, ctx)
+
+		job, err := service.RequestErasure(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ErasurePending, job.Status)
+		assert.Equal(t, "acme", job.TenantID)
+
+		waitErasure(t, service)
+
+		done, err := service.GetErasureJob(ctx, user.ID, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ErasureCompleted, done.Status)
+		assert.NotNil(t, done.StartedAt)
+		assert.NotNil(t, done.CompletedAt)
+
+		erased, err := users.GetIncludingDeleted(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, erased.IsDeleted())
+		assert.Equal(t, domain.ErasedName, erased.Name)
+		assert.Empty(t, erased.Attributes)
+
+		// the row stays for the retention purge, the API no longer sees the user
+		_, err = users.GetByID(ctx, user.ID)
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+		// the export reflects the erasure
+		export, err := service.ExportUserData(ctx, user.ID)
+		require.NoError(t, err)
+		data, err := json.Marshal(export.Sections)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "john@example.com")
+		assert.Contains(t, string(data), job.ID)
+	})
+
+	t.Run("success - erases the sessions and the two-factor enrollment", func(t *testing.T) {
+		log := &logger.Logger{Logger: zap.NewNop()}
+		users := memory.NewUserRepository()
+		sessionRepo := memory.NewSessionRepository()
+		sessions := NewSessionService(users, sessionRepo, log)
+		enrollments := memory.NewTwoFactorRepository()
+		twoFactor := NewTwoFactorService(users, enrollments, nil, "FactorIT", log)
+		service := NewPrivacyService(users, memory.NewErasureJobRepository(), log,
+			WithExportSections(sessions.ExportSection(), twoFactor.ExportSection()))
+
+		user := createTestUser(t, users, ctx)
+		session, err := sessions.StartSession(ctx, user.ID, "laptop", "Mozilla/5.0", "203.0.113.7")
+		require.NoError(t, err)
+		require.NoError(t, sessions.ValidateSession(ctx, userPrincipal(user, session)))
+		require.NoError(t, enrollments.Save(ctx, &domain.TwoFactor{UserID: user.ID, EncryptedSecret: "sealed"}))
+
+		_, err = service.RequestErasure(ctx, user.ID)
+		require.NoError(t, err)
+		waitErasure(t, service)
+
+		stored, err := sessionRepo.ListByUser(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, stored)
+		assert.ErrorIs(t, sessions.ValidateSession(ctx, userPrincipal(user, session)), domain.ErrSessionNotFound)
+		_, err = enrollments.Get(ctx, user.ID)
+		assert.ErrorIs(t, err, domain.ErrTwoFactorNotEnrolled)
+	})
+
+	t.Run("error - failed section leaves the user erasable", func(t *testing.T) {
+		var erased []string
+		failing := &staticSection{name: "audit", eraseErr: errors.New("store down"), erased: &erased}
+		service, users := newPrivacyTest(t, WithExportSections(failing))
+		user := createTestUser(t, users, ctx)
+
+		job, err := service.RequestErasure(ctx, user.ID)
+		require.NoError(t, err)
+		waitErasure(t, service)
+
+		failed, err := service.GetErasureJob(ctx, user.ID, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ErasureFailed, failed.Status)
+		assert.Contains(t, failed.Error, "audit")
+		found, err := users.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.False(t, found.IsAnonymized(), "the profile is erased last")
+
+		failing.eraseErr = nil
+		_, err = service.RequestErasure(ctx, user.ID)
+		require.NoError(t, err)
+		waitErasure(t, service)
+		assert.Equal(t, []string{user.ID, user.ID}, erased)
+		found, err = users.GetIncludingDeleted(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, found.IsAnonymized())
+	})
+
+	t.Run("success - erases a deleted user before the purge", func(t *testing.T) {
+		service, users := newPrivacyTest(t)
+		user := createTestUser(t, users, ctx)
+		require.NoError(t, users.Delete(ctx, user.ID))
+
+		_, err := service.RequestErasure(ctx, user.ID)
+		require.NoError(t, err)
+		waitErasure(t, service)
+
+		found, err := users.GetIncludingDeleted(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, found.IsAnonymized())
+	})
+
+	t.Run("error - already erased", func(t *testing.T) {
+		service, users := newPrivacyTest(t)
+		user := createTestUser(t, users, ctx)
+
+		_, err := service.RequestErasure(ctx, user.ID)
+		require.NoError(t, err)
+		waitErasure(t, service)
+
+		_, err = service.RequestErasure(ctx, user.ID)
+		assert.ErrorIs(t, err, domain.ErrUserErased)
+	})
+
+	t.Run("error - user not found", func(t *testing.T) {
+		service, _ := newPrivacyTest(t)
+
+		_, err := service.RequestErasure(ctx, uuid.New().String())
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+
+	t.Run("error - job of another user", func(t *testing.T) {
+		service, users := newPrivacyTest(t)
+		user := createTestUser(t, users, ctx)
+
+		job, err := service.RequestErasure(ctx, user.ID)
+		require.NoError(t, err)
+		waitErasure(t, service)
+
+		_, err = service.GetErasureJob(ctx, uuid.New().String(), job.ID)
+		assert.ErrorIs(t, err, domain.ErrErasureJobNotFound)
+	})
+}
+
+func TestPrivacyService_Ownership(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	service, users := newPrivacyTest(t)
+	user := createTestUser(t, users, acme)
+
+	other := auth.WithPrincipal(acme, &auth.Principal{Type: auth.PrincipalUser, ID: uuid.NewString(),
+		Scopes: []string{domain.ScopeUsersRead, domain.ScopeUsersWrite}})
+	_, err := service.ExportUserData(other, user.ID)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = service.RequestErasure(other, user.ID)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+
+	self := auth.WithPrincipal(acme, &auth.Principal{Type: auth.PrincipalUser, ID: user.ID})
+	_, err = service.ExportUserData(self, user.ID)
+	require.NoError(t, err)
+	job, err := service.RequestErasure(self, user.ID)
+	require.NoError(t, err)
+	waitErasure(t, service)
+
+	_, err = service.GetErasureJob(other, user.ID, job.ID)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	admin := auth.WithPrincipal(acme, &auth.Principal{Type: auth.PrincipalAPIKey, ID: "key-1",
+		Scopes: []string{domain.ScopeUsersAdmin}})
+	_, err = service.GetErasureJob(admin, user.ID, job.ID)
+	require.NoError(t, err)
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"go.uber.org/zap"
+)
+
+// Locker guarantees a job runs in a single replica at a time (database.AdvisoryLock)
+type Locker interface {
+	// TryLock does not wait, acquired is false when someone else holds the lock
+	TryLock(ctx context.Context) (unlock func(), acquired bool, err error)
+}
+
+// RetentionConfig settings of the retention purge
+type RetentionConfig struct {
+	Interval  time.Duration
+	Retention time.Duration
+	BatchSize int
+	Mode      domain.PurgeMode
+}
+
+// RetentionPurger periodically purges the users soft deleted longer than the retention
+type RetentionPurger struct {
+	users   domain.UserRepository
+	locker  Locker
+	config  RetentionConfig
+	metrics *metrics.RetentionMetrics
+	log     *logger.Logger
+	now     func() time.Time
+}
+
+// NewRetentionPurger creates a new purger, metrics is optional (nil)
+func NewRetentionPurger(users domain.UserRepository, locker Locker, config RetentionConfig, metrics *metrics.RetentionMetrics, log *logger.Logger) *RetentionPurger {
+	return &RetentionPurger{
+		users:   users,
+		locker:  locker,
+		config:  config,
+		metrics: metrics,
+		log:     log.WithComponent("retention_purger"),
+		now:     time.Now,
+	}
+}
+
+// Run purges on start and then every interval until ctx is done, errors are logged and
+// retried on the next tick
+func (p *RetentionPurger) Run(ctx context.Context) {
+	p.log.Info("retention purge scheduled",
+		zap.Duration("interval", p.config.Interval),
+		zap.Duration("retention", p.config.Retention),
+		zap.Int("batch_size", p.config.BatchSize),
+		zap.String("mode", string(p.config.Mode)),
+	)
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		// the error is already logged and measured by RunOnce
+		_, _ = p.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			p.log.Info("retention purge stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce purges in batches until nothing is due, it does nothing when another replica
+// holds the lock, returns how many users were purged
+func (p *RetentionPurger) RunOnce(ctx context.Context) (int64, error) {
+	start := p.now()
+
+	unlock, acquired, err := p.locker.TryLock(ctx)
+	if err != nil {
+		p.observeRun("error", start)
+		p.log.Error("retention purge failed to take the lock", zap.Error(err))
+		return 0, err
+	}
+	if !acquired {
+		p.observeRun("skipped", start)
+		p.log.Debug("retention purge skipped, running in another replica")
+		return 0, nil
+	}
+	defer unlock()
+
+	// a fixed cutoff, rows deleted while purging wait for the next run
+	cutoff := start.Add(-p.config.Retention)
+
+	var total int64
+	for {
+		purged, err := p.users.PurgeDeleted(ctx, p.config.Mode, cutoff, p.config.BatchSize)
+		total += purged
+		p.observeRows(purged)
+		if err != nil {
+			p.observeRun("error", start)
+			p.log.Error("retention purge failed",
+				zap.Int64("purged", total),
+				zap.Error(err),
+			)
+			return total, fmt.Errorf("failed to purge deleted users: %w", err)
+		}
+
+		// a short batch means nothing else is due
+		if purged < int64(p.config.BatchSize) || ctx.Err() != nil {
+			break
+		}
+	}
+
+	p.observeRun("success", start)
+	if p.metrics != nil {
+		p.metrics.LastSuccess.Set(float64(p.now().Unix()))
+	}
+	p.log.Info("retention purge completed",
+		zap.Int64("purged", total),
+		zap.Time("cutoff", cutoff),
+		zap.String("mode", string(p.config.Mode)),
+		zap.Duration("duration", p.now().Sub(start)),
+	)
+
+	return total, nil
+}
+
+func (p *RetentionPurger) observeRun(result string, start time.Time) {
+	if p.metrics == nil {
+		return
+	}
+	p.metrics.RunDuration.WithLabelValues(result).Observe(p.now().Sub(start).Seconds())
+}
+
+func (p *RetentionPurger) observeRows(purged int64) {
+	if p.metrics == nil || purged == 0 {
+		return
+	}
+	p.metrics.RowsPurged.WithLabelValues(string(p.config.Mode)).Add(float64(purged))
+}
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/memory"
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestRetentionMetrics unregistered metrics, avoids duplicate registration
+func newTestRetentionMetrics() *metrics.RetentionMetrics {
+	return &metrics.RetentionMetrics{
+		RunDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "run_duration_seconds"}, []string{"result"}),
+		RowsPurged:  prometheus.NewCounterVec(prometheus.CounterOpts{Name: "rows_total"}, []string{"mode"}),
+		LastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{Name: "last_success_timestamp_seconds"}),
+	}
+}
+
+func newTestPurger(users domain.UserRepository, locker Locker, m *metrics.RetentionMetrics) *RetentionPurger {
+	return NewRetentionPurger(users, locker, RetentionConfig{
+		Interval:  time.Hour,
+		Retention: 7 * 24 * time.Hour,
+		BatchSize: 2,
+		Mode:      domain.PurgeDelete,
+	}, m, &logger.Logger{Logger: zap.NewNop()})
+}
+
+// seedDeletedUsers creates n users soft deleted at the given time
+func seedDeletedUsers(t *testing.T, repo domain.UserRepository, n int, deletedAt time.Time) []*domain.User {
+	t.Helper()
+
+	ctx := context.Background()
+	users := make([]*domain.User, n)
+	for i := 0; i < n; i++ {
+		user, err := domain.NewUser("John Doe", fmt.Sprintf("john%d@example.com", i), "SecurePass123!")
+		require.NoError(t, err)
+		require.NoError(t, repo.Create(ctx, user))
+
+		// Delete stamps the current time, Anonymize persists the given deleted_at
+		user.DeletedAt = &deletedAt
+		require.NoError(t, repo.Anonymize(ctx, user))
+		users[i] = user
+	}
+	return users
+}
+
+// assertStored checks which users still have a row, soft deleted or not
+func assertStored(t *testing.T, repo domain.UserRepository, users []*domain.User, stored bool) {
+	t.Helper()
+
+	for _, user := range users {
+		_, err := repo.GetIncludingDeleted(context.Background(), user.ID)
+		if stored {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		}
+	}
+}
+
+func TestRetentionPurger_RunOnce(t *testing.T) {
+	t.Run("success - purges every batch", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		deleted := seedDeletedUsers(t, users, 5, time.Now().Add(-30*24*time.Hour))
+		m := newTestRetentionMetrics()
+
+		purged, err := newTestPurger(users, memory.NewLocker(), m).RunOnce(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), purged)
+		assertStored(t, users, deleted, false)
+
+		assert.Equal(t, float64(5), testutil.ToFloat64(m.RowsPurged.WithLabelValues("delete")))
+		assert.Equal(t, 1, testutil.CollectAndCount(m.RunDuration.WithLabelValues("success").(prometheus.Histogram)))
+		assert.Positive(t, testutil.ToFloat64(m.LastSuccess))
+	})
+
+	t.Run("success - keeps users within the retention", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		deleted := seedDeletedUsers(t, users, 2, time.Now().Add(-24*time.Hour))
+
+		purged, err := newTestPurger(users, memory.NewLocker(), nil).RunOnce(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), purged)
+		assertStored(t, users, deleted, true)
+	})
+
+	t.Run("success - purges a user deleted through the API", func(t *testing.T) {
+		ctx := context.Background()
+		users := memory.NewUserRepository()
+		service := NewUserService(users)
+		user, err := service.CreateUser(ctx, "John Doe", "john@example.com", "SecurePass123!", nil)
+		require.NoError(t, err)
+		require.NoError(t, service.DeleteUser(ctx, user.ID))
+
+		// soft deleted, kept until the retention ends
+		purger := newTestPurger(users, memory.NewLocker(), nil)
+		purged, err := purger.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), purged)
+		assertStored(t, users, []*domain.User{user}, true)
+
+		purger.now = func() time.Time { return time.Now().Add(8 * 24 * time.Hour) }
+		purged, err = purger.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		assertStored(t, users, []*domain.User{user}, false)
+	})
+
+	t.Run("skipped - lock held by another replica", func(t *testing.T) {
+		users := memory.NewUserRepository()
+		seedDeletedUsers(t, users, 1, time.Now().Add(-30*24*time.Hour))
+		locker := memory.NewLocker()
+		unlock, acquired, err := locker.TryLock(context.Background())
+		require.NoError(t, err)
+		require.True(t, acquired)
+		defer unlock()
+		m := newTestRetentionMetrics()
+
+		purged, err := newTestPurger(users, locker, m).RunOnce(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), purged)
+		assert.Equal(t, float64(0), testutil.ToFloat64(m.LastSuccess))
+	})
+
+	t.Run("error - repository fails", func(t *testing.T) {
+		users := new(MockUserRepository)
+		users.On("PurgeDeleted", mock.Anything, domain.PurgeDelete, mock.Anything, 2).
+			Return(int64(0), errors.New("connection refused"))
+		locker := memory.NewLocker()
+
+		_, err := newTestPurger(users, locker, newTestRetentionMetrics()).RunOnce(context.Background())
+		assert.Error(t, err)
+
+		// the lock is released on error
+		_, acquired, err := locker.TryLock(context.Background())
+		require.NoError(t, err)
+		assert.True(t, acquired)
+	})
+}
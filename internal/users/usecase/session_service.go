@@ -0,0 +1,317 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSessionCacheTTL how long a validated session is trusted without reading it
+	// again, a revocation done by another instance takes up to this long to be seen
+	DefaultSessionCacheTTL = 30 * time.Second
+
+	// maxCachedSessions bounds the validation cache, expired entries are dropped first
+	maxCachedSessions = 10000
+)
+
+// SessionService manages the sessions of the users and validates the session of every
+// user token, it implements auth.SessionValidator
+type SessionService struct {
+	users    domain.UserRepository
+	sessions domain.SessionRepository
+	log      *logger.Logger
+	now      func() time.Time
+	cacheTTL time.Duration
+
+	// validation results by tenant and session id, avoids a read per request
+	mu    sync.Mutex
+	cache map[sessionCacheKey]sessionCacheEntry
+}
+
+type sessionCacheKey struct {
+	tenantID  string
+	sessionID string
+}
+
+type sessionCacheEntry struct {
+	userID    string
+	err       error // nil for active sessions
+	expiresAt time.Time
+}
+
+// SessionOption configures optional session service behaviour
+type SessionOption func(*SessionService)
+
+// WithSessionCacheTTL overrides DefaultSessionCacheTTL, zero disables the cache
+func WithSessionCacheTTL(ttl time.Duration) SessionOption {
+	return func(s *SessionService) {
+		s.cacheTTL = ttl
+	}
+}
+
+// NewSessionService creates a new session service instance
+func NewSessionService(users domain.UserRepository, sessions domain.SessionRepository, log *logger.Logger, opts ...SessionOption) *SessionService {
+	s := &SessionService{
+		users:    users,
+		sessions: sessions,
+		log:      log.WithComponent("sessions"),
+		now:      time.Now,
+		cacheTTL: DefaultSessionCacheTTL,
+		cache:    make(map[sessionCacheKey]sessionCacheEntry),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// StartSession creates a session for a login of the user, its id is the sid claim of
+// the tokens issued for the login
+func (s *SessionService) StartSession(ctx context.Context, userID, device, userAgent, ip string) (*domain.Session, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDeleted() {
+		return nil, domain.ErrUserNotFound
+	}
+
+	session := domain.NewSession(user.ID, device, userAgent, ip)
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+
+	logger.FromContextOr(ctx, s.log).Info("session started",
+		zap.String("session_id", session.ID),
+		zap.String("user_id", user.ID),
+		zap.String("tenant_id", session.TenantID),
+	)
+
+	return session, nil
+}
+
+// ListSessions returns the active sessions of the user, most recently seen first
+func (s *SessionService) ListSessions(ctx context.Context, userID string) ([]*domain.Session, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.users.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessions.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	active := make([]*domain.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if !session.IsRevoked() {
+			active = append(active, session)
+		}
+	}
+
+	return active, nil
+}
+
+// RevokeSession logs the user out of one session
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return err
+	}
+
+	session, err := s.sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return domain.ErrSessionNotFound
+	}
+
+	if err := s.sessions.Revoke(ctx, sessionID, s.now()); err != nil {
+		return err
+	}
+	s.forget(func(key sessionCacheKey, _ sessionCacheEntry) bool {
+		return key.tenantID == session.TenantID && key.sessionID == sessionID
+	})
+
+	logger.FromContextOr(ctx, s.log).Info("session revoked",
+		zap.String("session_id", sessionID),
+		zap.String("user_id", userID),
+		zap.String("tenant_id", session.TenantID),
+	)
+
+	return nil
+}
+
+// RevokeAllSessions logs the user out everywhere, returns how many sessions were revoked
+func (s *SessionService) RevokeAllSessions(ctx context.Context, userID string) (int64, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return 0, err
+	}
+	if _, err := s.users.GetByID(ctx, userID); err != nil {
+		return 0, err
+	}
+
+	revoked, err := s.sessions.RevokeAllByUser(ctx, userID, s.now())
+	if err != nil {
+		return 0, err
+	}
+
+	tenantID := tenant.IDFromContext(ctx)
+	s.forgetUser(ctx, userID)
+
+	logger.FromContextOr(ctx, s.log).Info("all sessions revoked",
+		zap.String("user_id", userID),
+		zap.String("tenant_id", tenantID),
+		zap.Int64("sessions", revoked),
+	)
+
+	return revoked, nil
+}
+
+// EndSessions revokes every session of a user that is being deleted, no ownership check,
+// for internal callers only (see WithSessionEnder)
+func (s *SessionService) EndSessions(ctx context.Context, userID string) error {
+	s.forgetUser(ctx, userID)
+
+	if _, err := s.sessions.RevokeAllByUser(ctx, userID, s.now()); err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+	return nil
+}
+
+// ExportSection the data export section of the sessions, see WithExportSections, the
+// erasure deletes them (device, user agent and IP are personal data)
+func (s *SessionService) ExportSection() domain.DataExportSection {
+	return sessionsSection{sessions: s.sessions, forget: s.forgetUser}
+}
+
+// ValidateSession checks the session of a user token exists, belongs to the token subject,
+// was not revoked and its user was not deleted, results are cached for the cache TTL
+func (s *SessionService) ValidateSession(ctx context.Context, principal *auth.Principal) error {
+	// the token tenant, the request tenant is not resolved yet
+	tenantID := principal.TenantID
+	if tenantID == "" {
+		tenantID = tenant.DefaultID
+	}
+	key := sessionCacheKey{tenantID: tenantID, sessionID: principal.SessionID}
+	now := s.now()
+
+	if entry, ok := s.cached(key, now); ok {
+		if entry.err == nil && entry.userID != principal.ID {
+			return domain.ErrSessionNotFound
+		}
+		return entry.err
+	}
+
+	sessionCtx := tenant.WithID(ctx, tenantID)
+	session, err := s.sessions.GetByID(sessionCtx, principal.SessionID)
+	switch {
+	case errors.Is(err, domain.ErrSessionNotFound):
+		// negative results are cached too, a forged sid does not reach the database twice
+		s.store(key, sessionCacheEntry{err: err}, now)
+		return err
+	case err != nil:
+		return fmt.Errorf("failed to get session: %w", err)
+	case session.IsRevoked():
+		s.store(key, sessionCacheEntry{userID: session.UserID, err: domain.ErrSessionRevoked}, now)
+		return domain.ErrSessionRevoked
+	}
+
+	if session.UserID != principal.ID {
+		s.store(key, sessionCacheEntry{userID: session.UserID}, now)
+		return domain.ErrSessionNotFound
+	}
+
+	// deleted and erased users keep their sessions until the purge
+	user, err := s.users.GetByID(sessionCtx, session.UserID)
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound) || (err == nil && user.IsDeleted()):
+		s.store(key, sessionCacheEntry{userID: session.UserID, err: domain.ErrSessionRevoked}, now)
+		return domain.ErrSessionRevoked
+	case err != nil:
+		return fmt.Errorf("failed to get session user: %w", err)
+	}
+
+	s.store(key, sessionCacheEntry{userID: session.UserID}, now)
+
+	// last_seen_at is refreshed once per cache TTL, on the reads only
+	if err := s.sessions.TouchLastSeen(sessionCtx, session.ID, now); err != nil {
+		// tracking only, the request is still authenticated
+		logger.FromContextOr(ctx, s.log).Warn("failed to record session activity",
+			zap.String("session_id", session.ID),
+			zap.Error(err),
+		)
+	}
+
+	return nil
+}
+
+// cached returns the not expired entry of key
+func (s *SessionService) cached(key sessionCacheKey, now time.Time) (sessionCacheEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return sessionCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store caches entry for the cache TTL, when full the expired entries are dropped and,
+// if it is still full, the whole cache
+func (s *SessionService) store(key sessionCacheKey, entry sessionCacheEntry, now time.Time) {
+	if s.cacheTTL <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.cache) >= maxCachedSessions {
+		for k, e := range s.cache {
+			if !now.Before(e.expiresAt) {
+				delete(s.cache, k)
+			}
+		}
+		if len(s.cache) >= maxCachedSessions {
+			clear(s.cache)
+		}
+	}
+
+	entry.expiresAt = now.Add(s.cacheTTL)
+	s.cache[key] = entry
+}
+
+// forgetUser drops the cached sessions of a user of the tenant in ctx
+func (s *SessionService) forgetUser(ctx context.Context, userID string) {
+	tenantID := tenant.IDFromContext(ctx)
+	s.forget(func(key sessionCacheKey, entry sessionCacheEntry) bool {
+		return key.tenantID == tenantID && entry.userID == userID
+	})
+}
+
+// forget drops the cached entries matching, a revocation is seen immediately by this instance
+func (s *SessionService) forget(match func(sessionCacheKey, sessionCacheEntry) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.cache {
+		if match(key, entry) {
+			delete(s.cache, key)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/memory"
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// countingSessions counts the reads reaching the repository
+type countingSessions struct {
+	*memory.SessionRepository
+	reads int
+}
+
+func (r *countingSessions) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	r.reads++
+	return r.SessionRepository.GetByID(ctx, id)
+}
+
+func newSessionTest(t *testing.T, opts ...SessionOption) (*SessionService, *countingSessions, *domain.User) {
+	t.Helper()
+
+	service, sessions, _, user := newSessionUsersTest(t, opts...)
+	return service, sessions, user
+}
+
+// newSessionUsersTest newSessionTest plus the user repository
+func newSessionUsersTest(t *testing.T, opts ...SessionOption) (*SessionService, *countingSessions, *memory.UserRepository, *domain.User) {
+	t.Helper()
+
+	users := memory.NewUserRepository()
+	sessions := &countingSessions{SessionRepository: memory.NewSessionRepository()}
+	service := NewSessionService(users, sessions, &logger.Logger{Logger: zap.NewNop()}, opts...)
+
+	user, err := domain.NewUser("John Doe", "john@example.com", "password123")
+	require.NoError(t, err)
+	require.NoError(t, users.Create(tenant.WithID(context.Background(), "acme"), user))
+
+	return service, sessions, users, user
+}
+
+func userPrincipal(user *domain.User, session *domain.Session) *auth.Principal {
+	return &auth.Principal{
+		Type:      auth.PrincipalUser,
+		ID:        user.ID,
+		TenantID:  session.TenantID,
+		SessionID: session.ID,
+	}
+}
+
+func TestSessionService_ListAndRevoke(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	service, _, user := newSessionTest(t)
+
+	laptop, err := service.StartSession(ctx, user.ID, "laptop", "Mozilla/5.0", "203.0.113.7")
+	require.NoError(t, err)
+	phone, err := service.StartSession(ctx, user.ID, "phone", "okhttp", "203.0.113.8")
+	require.NoError(t, err)
+
+	sessions, err := service.ListSessions(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	require.NoError(t, service.RevokeSession(ctx, user.ID, laptop.ID))
+	sessions, err = service.ListSessions(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, phone.ID, sessions[0].ID)
+
+	assert.ErrorIs(t, service.RevokeSession(ctx, uuid.New().String(), phone.ID), domain.ErrSessionNotFound,
+		"session of another user")
+
+	revoked, err := service.RevokeAllSessions(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), revoked)
+
+	sessions, err = service.ListSessions(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	_, err = service.ListSessions(ctx, uuid.New().String())
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+}
+
+func TestSessionService_ValidateSession(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	t.Run("active sessions are cached", func(t *testing.T) {
+		service, sessions, user := newSessionTest(t)
+		session, err := service.StartSession(ctx, user.ID, "", "", "")
+		require.NoError(t, err)
+
+		now := time.Now()
+		service.now = func() time.Time { return now }
+		principal := userPrincipal(user, session)
+
+		// no tenant yet, authentication runs before the tenant middleware
+		require.NoError(t, service.ValidateSession(context.Background(), principal))
+		require.NoError(t, service.ValidateSession(context.Background(), principal))
+		assert.Equal(t, 1, sessions.reads)
+
+		service.now = func() time.Time { return now.Add(DefaultSessionCacheTTL) }
+		require.NoError(t, service.ValidateSession(context.Background(), principal))
+		assert.Equal(t, 2, sessions.reads, "read again once the entry expires")
+
+		stored, err := sessions.SessionRepository.GetByID(ctx, session.ID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, now.Add(DefaultSessionCacheTTL), stored.LastSeenAt, time.Millisecond)
+	})
+
+	t.Run("revocation is seen immediately", func(t *testing.T) {
+		service, _, user := newSessionTest(t)
+		first, err := service.StartSession(ctx, user.ID, "", "", "")
+		require.NoError(t, err)
+		second, err := service.StartSession(ctx, user.ID, "", "", "")
+		require.NoError(t, err)
+
+		require.NoError(t, service.ValidateSession(ctx, userPrincipal(user, first)))
+		require.NoError(t, service.ValidateSession(ctx, userPrincipal(user, second)))
+
+		require.NoError(t, service.RevokeSession(ctx, user.ID, first.ID))
+		assert.ErrorIs(t, service.ValidateSession(ctx, userPrincipal(user, first)), domain.ErrSessionRevoked)
+		require.NoError(t, service.ValidateSession(ctx, userPrincipal(user, second)))
+
+		_, err = service.RevokeAllSessions(ctx, user.ID)
+		require.NoError(t, err)
+		assert.ErrorIs(t, service.ValidateSession(ctx, userPrincipal(user, second)), domain.ErrSessionRevoked)
+	})
+
+	t.Run("error - unknown session, other subject or other tenant", func(t *testing.T) {
+		service, sessions, user := newSessionTest(t)
+		session, err := service.StartSession(ctx, user.ID, "", "", "")
+		require.NoError(t, err)
+
+		unknown := userPrincipal(user, session)
+		unknown.SessionID = uuid.New().String()
+		assert.ErrorIs(t, service.ValidateSession(ctx, unknown), domain.ErrSessionNotFound)
+		assert.ErrorIs(t, service.ValidateSession(ctx, unknown), domain.ErrSessionNotFound)
+		assert.Equal(t, 1, sessions.reads, "negative results are cached")
+
+		other := userPrincipal(user, session)
+		other.ID = uuid.New().String()
+		assert.ErrorIs(t, service.ValidateSession(ctx, other), domain.ErrSessionNotFound)
+
+		otherTenant := userPrincipal(user, session)
+		otherTenant.TenantID = "globex"
+		assert.ErrorIs(t, service.ValidateSession(ctx, otherTenant), domain.ErrSessionNotFound)
+
+		require.NoError(t, service.ValidateSession(ctx, userPrincipal(user, session)))
+	})
+
+	t.Run("sessions of deleted users are rejected", func(t *testing.T) {
+		service, _, users, user := newSessionUsersTest(t)
+		session, err := service.StartSession(ctx, user.ID, "", "", "")
+		require.NoError(t, err)
+		require.NoError(t, service.ValidateSession(ctx, userPrincipal(user, session)))
+
+		// the cached validation does not outlive the deletion
+		require.NoError(t, NewUserService(users, WithSessionEnder(service)).DeleteUser(ctx, user.ID))
+		assert.ErrorIs(t, service.ValidateSession(ctx, userPrincipal(user, session)), domain.ErrSessionRevoked)
+
+		// deleted without ending the sessions, e.g. by another instance
+		other, err := domain.NewUser("Jane Doe", "jane@example.com", "password123")
+		require.NoError(t, err)
+		require.NoError(t, users.Create(ctx, other))
+		session, err = service.StartSession(ctx, other.ID, "", "", "")
+		require.NoError(t, err)
+		other.Anonymize()
+		require.NoError(t, users.Anonymize(ctx, other))
+		assert.ErrorIs(t, service.ValidateSession(ctx, userPrincipal(other, session)), domain.ErrSessionRevoked)
+	})
+
+	t.Run("cache disabled", func(t *testing.T) {
+		service, sessions, user := newSessionTest(t, WithSessionCacheTTL(0))
+		session, err := service.StartSession(ctx, user.ID, "", "", "")
+		require.NoError(t, err)
+
+		require.NoError(t, service.ValidateSession(ctx, userPrincipal(user, session)))
+		require.NoError(t, service.ValidateSession(ctx, userPrincipal(user, session)))
+		assert.Equal(t, 2, sessions.reads)
+	})
+}
+
+func TestSessionService_Ownership(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	service, _, user := newSessionTest(t)
+	session, err := service.StartSession(acme, user.ID, "", "", "")
+	require.NoError(t, err)
+
+	other := auth.WithPrincipal(acme, &auth.Principal{Type: auth.PrincipalUser, ID: uuid.NewString(),
+		Scopes: []string{domain.ScopeUsersWrite}})
+	_, err = service.ListSessions(other, user.ID)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	assert.ErrorIs(t, service.RevokeSession(other, user.ID, session.ID), domain.ErrForbidden)
+	_, err = service.RevokeAllSessions(other, user.ID)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+
+	self := auth.WithPrincipal(acme, userPrincipal(user, session))
+	sessions, err := service.ListSessions(self, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	admin := auth.WithPrincipal(acme, &auth.Principal{Type: auth.PrincipalAPIKey, ID: "key-1",
+		Scopes: []string{domain.ScopeUsersAdmin}})
+	require.NoError(t, service.RevokeSession(admin, user.ID, session.ID))
+}
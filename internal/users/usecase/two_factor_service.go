@@ -0,0 +1,276 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth/totp"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// totpSkew steps accepted before and after the current one, absorbs the clock drift of
+// the phone and the time the user takes to type the code
+const totpSkew = 1
+
+// TOTPEnrollment what the authenticator app needs, returned once at enrollment
+type TOTPEnrollment struct {
+	Secret string // base32, for manual entry
+	URI    string // otpauth://, usually rendered as a QR code
+}
+
+// TwoFactorService handles the TOTP enrollment of the users and verifies their codes
+type TwoFactorService struct {
+	users       domain.UserRepository
+	enrollments domain.TwoFactorRepository
+	cipher      domain.SecretCipher
+	issuer      string
+	log         *logger.Logger
+	now         func() time.Time
+}
+
+// TwoFactorOption configures optional two-factor service behaviour
+type TwoFactorOption func(*TwoFactorService)
+
+// WithTwoFactorClock replaces time.Now, codes are validated against it
+func WithTwoFactorClock(now func() time.Time) TwoFactorOption {
+	return func(s *TwoFactorService) {
+		s.now = now
+	}
+}
+
+// NewTwoFactorService creates a new two-factor service instance, issuer is the name shown
+// by the authenticator apps
+func NewTwoFactorService(users domain.UserRepository, enrollments domain.TwoFactorRepository, cipher domain.SecretCipher, issuer string, log *logger.Logger, opts ...TwoFactorOption) *TwoFactorService {
+	s := &TwoFactorService{
+		users:       users,
+		enrollments: enrollments,
+		cipher:      cipher,
+		issuer:      issuer,
+		log:         log.WithComponent("two_factor"),
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// EnrollTOTP generates a new secret for the user, the enrollment stays pending until
+// ConfirmTOTP, enrolling again replaces a pending enrollment
+func (s *TwoFactorService) EnrollTOTP(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.enrollments.Get(ctx, userID)
+	switch {
+	case err == nil && existing.IsEnabled():
+		return nil, domain.ErrTwoFactorAlreadyEnabled
+	case err != nil && !errors.Is(err, domain.ErrTwoFactorNotEnrolled):
+		return nil, fmt.Errorf("failed to get two-factor enrollment: %w", err)
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	sealed, err := s.cipher.Seal([]byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+
+	enrollment := &domain.TwoFactor{
+		UserID:          user.ID,
+		EncryptedSecret: sealed,
+		CreatedAt:       s.now(),
+	}
+	if err := s.enrollments.Save(ctx, enrollment); err != nil {
+		return nil, fmt.Errorf("failed to save two-factor enrollment: %w", err)
+	}
+
+	logger.FromContextOr(ctx, s.log).Info("totp enrollment started",
+		zap.String("user_id", user.ID),
+		zap.String("tenant_id", enrollment.TenantID),
+	)
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication with the first code of the app, the
+// recovery codes are returned only here
+func (s *TwoFactorService) ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	enrollment, err := s.enrollments.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.IsEnabled() {
+		return nil, domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	step, err := s.validate(enrollment, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := domain.NewRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	now := s.now()
+	enrollment.ConfirmedAt = &now
+	enrollment.RecoveryCodeHashes = hashes
+	enrollment.LastUsedStep = step
+	if err := s.enrollments.Save(ctx, enrollment); err != nil {
+		return nil, fmt.Errorf("failed to confirm two-factor enrollment: %w", err)
+	}
+
+	logger.FromContextOr(ctx, s.log).Info("totp enabled",
+		zap.String("user_id", userID),
+		zap.String("tenant_id", enrollment.TenantID),
+	)
+
+	return codes, nil
+}
+
+// DisableTOTP removes the enrollment (pending or confirmed) of the user, code proves the
+// caller holds the authenticator app (or a recovery code of a confirmed enrollment), a
+// stolen access token alone can not turn two-factor off
+func (s *TwoFactorService) DisableTOTP(ctx context.Context, userID, code string) error {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return err
+	}
+
+	enrollment, err := s.enrollments.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if enrollment.IsEnabled() {
+		err = s.VerifyCode(ctx, userID, code)
+	} else {
+		_, err = s.validate(enrollment, code)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.enrollments.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	logger.FromContextOr(ctx, s.log).Info("totp disabled", zap.String("user_id", userID))
+	return nil
+}
+
+// IsEnabled checks if logins of the user require a second step
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	enrollment, err := s.enrollments.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTwoFactorNotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+	return enrollment.IsEnabled(), nil
+}
+
+// VerifyCode checks a TOTP code or a recovery code of a user with two-factor enabled,
+// both work only once
+func (s *TwoFactorService) VerifyCode(ctx context.Context, userID, code string) error {
+	enrollment, err := s.enrollments.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enrollment.IsEnabled() {
+		return domain.ErrTwoFactorNotEnrolled
+	}
+
+	if domain.IsRecoveryCode(code) {
+		if err := s.enrollments.UseRecoveryCode(ctx, userID, domain.HashRecoveryCode(code)); err != nil {
+			return err
+		}
+		logger.FromContextOr(ctx, s.log).Warn("recovery code used",
+			zap.String("user_id", userID),
+			zap.Int("remaining", len(enrollment.RecoveryCodeHashes)-1),
+		)
+		return nil
+	}
+
+	step, err := s.validate(enrollment, code)
+	if err != nil {
+		return err
+	}
+	return s.enrollments.UseStep(ctx, userID, step)
+}
+
+// StartChallenge opens a login challenge of a user with two-factor enabled, returns its
+// id (jti of the challenge token), a previous challenge can no longer be answered
+func (s *TwoFactorService) StartChallenge(ctx context.Context, userID string) (string, error) {
+	challengeID := uuid.NewString()
+	if err := s.enrollments.StartChallenge(ctx, userID, challengeID); err != nil {
+		return "", err
+	}
+	return challengeID, nil
+}
+
+// VerifyChallenge answers a login challenge with a TOTP or recovery code (VerifyCode),
+// the challenge takes maxAttempts codes at most and none once one was accepted
+func (s *TwoFactorService) VerifyChallenge(ctx context.Context, userID, challengeID, code string, maxAttempts int) error {
+	// counted before the code is checked, concurrent guesses can not exceed the cap
+	if err := s.enrollments.AttemptChallenge(ctx, userID, challengeID, maxAttempts); err != nil {
+		if errors.Is(err, domain.ErrInvalidChallenge) {
+			logger.FromContextOr(ctx, s.log).Warn("login challenge rejected",
+				zap.String("user_id", userID),
+				zap.String("challenge_id", challengeID),
+			)
+		}
+		return err
+	}
+
+	if err := s.VerifyCode(ctx, userID, code); err != nil {
+		return err
+	}
+
+	if err := s.enrollments.EndChallenge(ctx, userID, challengeID); err != nil {
+		return fmt.Errorf("failed to end login challenge: %w", err)
+	}
+	return nil
+}
+
+// ExportSection the data export section of the enrollment, see WithExportSections
+func (s *TwoFactorService) ExportSection() domain.DataExportSection {
+	return twoFactorSection{enrollments: s.enrollments}
+}
+
+// validate checks a TOTP code against the enrollment secret, returns its time step
+func (s *TwoFactorService) validate(enrollment *domain.TwoFactor, code string) (int64, error) {
+	secret, err := s.cipher.Open(enrollment.EncryptedSecret)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	step, ok := totp.Validate(string(secret), code, s.now(), totpSkew)
+	if !ok || step <= enrollment.LastUsedStep {
+		return 0, domain.ErrInvalidOTP
+	}
+	return step, nil
+}
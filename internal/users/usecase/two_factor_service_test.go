@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/memory"
+	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth/totp"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/secretbox"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testClock a settable clock shared by the services of a test
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+type twoFactorTest struct {
+	users       *memory.UserRepository
+	enrollments *memory.TwoFactorRepository
+	service     *TwoFactorService
+	clock       *testClock
+	user        *domain.User
+}
+
+func newTwoFactorTest(t *testing.T) *twoFactorTest {
+	t.Helper()
+
+	box, err := secretbox.New(secretbox.DeriveKey([]byte("test-secret"), "totp"))
+	require.NoError(t, err)
+
+	clock := &testClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	users := memory.NewUserRepository()
+	enrollments := memory.NewTwoFactorRepository()
+	service := NewTwoFactorService(users, enrollments, box, "FactorIT", &logger.Logger{Logger: zap.NewNop()},
+		WithTwoFactorClock(clock.Now))
+
+	user, err := domain.NewUser("John Doe", "john@example.com", "password123")
+	require.NoError(t, err)
+	require.NoError(t, users.Create(tenant.WithID(context.Background(), "acme"), user))
+
+	return &twoFactorTest{users: users, enrollments: enrollments, service: service, clock: clock, user: user}
+}
+
+// enable enrolls and confirms the test user, returns the secret and the recovery codes
+func (tt *twoFactorTest) enable(t *testing.T, ctx context.Context) (string, []string) {
+	t.Helper()
+
+	enrollment, err := tt.service.EnrollTOTP(ctx, tt.user.ID)
+	require.NoError(t, err)
+	codes, err := tt.service.ConfirmTOTP(ctx, tt.user.ID, tt.code(t, enrollment.Secret))
+	require.NoError(t, err)
+
+	// the confirmation code can not be reused
+	tt.clock.Advance(totp.Period)
+	return enrollment.Secret, codes
+}
+
+func (tt *twoFactorTest) code(t *testing.T, secret string) string {
+	t.Helper()
+
+	code, err := totp.Code(secret, tt.clock.Now())
+	require.NoError(t, err)
+	return code
+}
+
+func TestTwoFactorService_Enrollment(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	tt := newTwoFactorTest(t)
+
+	enrollment, err := tt.service.EnrollTOTP(ctx, tt.user.ID)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/FactorIT:john@example.com?")
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	stored, err := tt.enrollments.Get(ctx, tt.user.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, stored.EncryptedSecret, enrollment.Secret, "secret stored encrypted")
+
+	enabled, err := tt.service.IsEnabled(ctx, tt.user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled, "pending until confirmed")
+
+	_, err = tt.service.ConfirmTOTP(ctx, tt.user.ID, "000000")
+	assert.ErrorIs(t, err, domain.ErrInvalidOTP)
+
+	codes, err := tt.service.ConfirmTOTP(ctx, tt.user.ID, tt.code(t, enrollment.Secret))
+	require.NoError(t, err)
+	assert.Len(t, codes, domain.RecoveryCodeCount)
+
+	enabled, err = tt.service.IsEnabled(ctx, tt.user.ID)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	_, err = tt.service.EnrollTOTP(ctx, tt.user.ID)
+	assert.ErrorIs(t, err, domain.ErrTwoFactorAlreadyEnabled)
+
+	// disabling requires a code of the app or a recovery code
+	tt.clock.Advance(totp.Period)
+	assert.ErrorIs(t, tt.service.DisableTOTP(ctx, tt.user.ID, "000000"), domain.ErrInvalidOTP)
+	require.NoError(t, tt.service.DisableTOTP(ctx, tt.user.ID, codes[0]))
+	enabled, err = tt.service.IsEnabled(ctx, tt.user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	assert.ErrorIs(t, tt.service.DisableTOTP(ctx, tt.user.ID, codes[1]), domain.ErrTwoFactorNotEnrolled)
+
+	// a pending enrollment is checked against its own secret
+	enrollment, err = tt.service.EnrollTOTP(ctx, tt.user.ID)
+	require.NoError(t, err)
+	assert.ErrorIs(t, tt.service.DisableTOTP(ctx, tt.user.ID, "000000"), domain.ErrInvalidOTP)
+	require.NoError(t, tt.service.DisableTOTP(ctx, tt.user.ID, tt.code(t, enrollment.Secret)))
+}
+
+func TestTwoFactorService_Ownership(t *testing.T) {
+	acme := tenant.WithID(context.Background(), "acme")
+	tt := newTwoFactorTest(t)
+
+	other := auth.WithPrincipal(acme, &auth.Principal{Type: auth.PrincipalUser, ID: "other-user"})
+	_, err := tt.service.EnrollTOTP(other, tt.user.ID)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = tt.service.ConfirmTOTP(other, tt.user.ID, "000000")
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+	assert.ErrorIs(t, tt.service.DisableTOTP(other, tt.user.ID, "000000"), domain.ErrForbidden)
+
+	// an API key is not the user, even with the same id
+	key := auth.WithPrincipal(acme, &auth.Principal{Type: auth.PrincipalAPIKey, ID: tt.user.ID})
+	_, err = tt.service.EnrollTOTP(key, tt.user.ID)
+	assert.ErrorIs(t, err, domain.ErrForbidden)
+
+	self := auth.WithPrincipal(acme, &auth.Principal{Type: auth.PrincipalUser, ID: tt.user.ID})
+	_, err = tt.service.EnrollTOTP(self, tt.user.ID)
+	require.NoError(t, err)
+
+	admin := auth.WithPrincipal(acme, &auth.Principal{Type: auth.PrincipalAPIKey, ID: "key-1",
+		Scopes: []string{domain.ScopeUsersAdmin}})
+	_, err = tt.service.EnrollTOTP(admin, tt.user.ID)
+	require.NoError(t, err)
+}
+
+func TestTwoFactorService_EnrollUnknownUser(t *testing.T) {
+	tt := newTwoFactorTest(t)
+
+	_, err := tt.service.EnrollTOTP(tenant.WithID(context.Background(), "globex"), tt.user.ID)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+}
+
+func TestTwoFactorService_VerifyCode(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	tt := newTwoFactorTest(t)
+
+	assert.ErrorIs(t, tt.service.VerifyCode(ctx, tt.user.ID, "123456"), domain.ErrTwoFactorNotEnrolled)
+
+	secret, _ := tt.enable(t, ctx)
+
+	code := tt.code(t, secret)
+	require.NoError(t, tt.service.VerifyCode(ctx, tt.user.ID, code))
+	assert.ErrorIs(t, tt.service.VerifyCode(ctx, tt.user.ID, code), domain.ErrInvalidOTP, "replayed code")
+
+	// the previous step is within the skew but older than the last used one
+	tt.clock.Advance(totp.Period)
+	previous, err := totp.Code(secret, tt.clock.Now().Add(-totp.Period))
+	require.NoError(t, err)
+	assert.ErrorIs(t, tt.service.VerifyCode(ctx, tt.user.ID, previous), domain.ErrInvalidOTP)
+	require.NoError(t, tt.service.VerifyCode(ctx, tt.user.ID, tt.code(t, secret)))
+
+	// too old
+	stale := tt.code(t, secret)
+	tt.clock.Advance(3 * totp.Period)
+	assert.ErrorIs(t, tt.service.VerifyCode(ctx, tt.user.ID, stale), domain.ErrInvalidOTP)
+}
+
+func TestTwoFactorService_RecoveryCodes(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "acme")
+	tt := newTwoFactorTest(t)
+	_, codes := tt.enable(t, ctx)
+
+	require.NoError(t, tt.service.VerifyCode(ctx, tt.user.ID, strings.ToUpper(codes[0])))
+	assert.ErrorIs(t, tt.service.VerifyCode(ctx, tt.user.ID, codes[0]), domain.ErrInvalidOTP, "used once")
+	assert.ErrorIs(t, tt.service.VerifyCode(ctx, tt.user.ID, "aaaaa-aaaaa"), domain.ErrInvalidOTP)
+
+	stored, err := tt.enrollments.Get(ctx, tt.user.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored.RecoveryCodeHashes, domain.RecoveryCodeCount-1)
+}
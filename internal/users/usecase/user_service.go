@@ -11,17 +11,61 @@ import (
 // UserService handles user business logic
 type UserService struct {
 	repo domain.UserRepository
+	// attributes validates custom profile attributes, nil accepts any JSON object
+	attributes domain.AttributesValidator
+	// sessions ends the sessions of deleted users, nil leaves them to ValidateSession
+	sessions SessionEnder
+}
+
+// SessionEnder ends the sessions of a user, implemented by SessionService
+type SessionEnder interface {
+	EndSessions(ctx context.Context, userID string) error
+}
+
+// Option configures optional service behaviour
+type Option func(*UserService)
+
+// WithAttributesValidator validates custom attributes on create and update, usually
+// against the JSON Schema configured for the deployment
+func WithAttributesValidator(v domain.AttributesValidator) Option {
+	return func(s *UserService) {
+		s.attributes = v
+	}
+}
+
+// WithSessionEnder revokes the sessions of the deleted users, their tokens are rejected
+// at once instead of when the cached session validation expires
+func WithSessionEnder(sessions SessionEnder) Option {
+	return func(s *UserService) {
+		s.sessions = sessions
+	}
 }
 
 // NewUserService creates a new user service instance
-func NewUserService(repo domain.UserRepository) *UserService {
-	return &UserService{
+func NewUserService(repo domain.UserRepository, opts ...Option) *UserService {
+	s := &UserService{
 		repo: repo,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
-// CreateUser creates a new user with validation
-func (s *UserService) CreateUser(ctx context.Context, name, email, password string) (*domain.User, error) {
+// CreateUser creates a new user with validation, attributes may be nil, requires
+// domain.ScopeUsersAdmin
+func (s *UserService) CreateUser(ctx context.Context, name, email, password string, attributes domain.Attributes) (*domain.User, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	// 0. Validate custom attributes before touching the repository
+	if err := s.validateAttributes(attributes); err != nil {
+		return nil, err
+	}
+
 	// 1. Validate email uniqueness (business rule)
 	existing, err := s.repo.GetByEmail(ctx, email)
 	if err != nil && err != domain.ErrUserNotFound {
@@ -37,6 +81,9 @@ func (s *UserService) CreateUser(ctx context.Context, name, email, password stri
 	if err != nil {
 		return nil, err // domain validation error
 	}
+	if attributes != nil {
+		user.Attributes = attributes
+	}
 
 	// 3. Persist to repository
 	if err := s.repo.Create(ctx, user); err != nil {
@@ -66,14 +113,30 @@ func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*domain
 	return user, nil
 }
 
-// UpdateUser updates an existing user
-func (s *UserService) UpdateUser(ctx context.Context, id, name, email string) (*domain.User, error) {
+// UpdateUser updates an existing user, empty name/email and nil attributes keep the
+// current value, attributes are replaced as a whole (not merged), only the user itself or
+// a domain.ScopeUsersAdmin principal
+func (s *UserService) UpdateUser(ctx context.Context, id, name, email string, attributes domain.Attributes) (*domain.User, error) {
+	if err := authorizeUser(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := s.validateAttributes(attributes); err != nil {
+		return nil, err
+	}
+
 	// 1. Get existing user
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if name == "" {
+		name = user.Name
+	}
+	if email == "" {
+		email = user.Email
+	}
+
 	// 2. Check if email is being changed to an existing one
 	if user.Email != email {
 		existing, err := s.repo.GetByEmail(ctx, email)
@@ -89,6 +152,9 @@ func (s *UserService) UpdateUser(ctx context.Context, id, name, email string) (*
 	// 3. Update fields
 	user.Name = name
 	user.Email = email
+	if attributes != nil {
+		user.Attributes = attributes
+	}
 	user.UpdatedAt = time.Now()
 
 	// 4. Persist changes
@@ -99,8 +165,13 @@ func (s *UserService) UpdateUser(ctx context.Context, id, name, email string) (*
 	return user, nil
 }
 
-// DeleteUser deletes a user by ID
+// DeleteUser soft deletes a user by ID, the retention purge removes or anonymizes it later,
+// only the user itself or a domain.ScopeUsersAdmin principal
 func (s *UserService) DeleteUser(ctx context.Context, id string) error {
+	if err := authorizeUser(ctx, id); err != nil {
+		return err
+	}
+
 	// Optional: verify user exists before attempting delete
 	if _, err := s.repo.GetByID(ctx, id); err != nil {
 		return err
@@ -110,11 +181,31 @@ func (s *UserService) DeleteUser(ctx context.Context, id string) error {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
+	if s.sessions != nil {
+		if err := s.sessions.EndSessions(ctx, id); err != nil {
+			return fmt.Errorf("failed to end sessions of deleted user: %w", err)
+		}
+	}
+
 	return nil
 }
 
-// ListUsers retrieves paginated users
-func (s *UserService) ListUsers(ctx context.Context, limit, offset int) ([]*domain.User, int64, error) {
+// ListUsers retrieves paginated users matching filter, total counts with the same filter,
+// requires domain.ScopeUsersAdmin
+func (s *UserService) ListUsers(ctx context.Context, filter domain.ListFilter, limit, offset int) ([]*domain.User, int64, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	// the filter values are typed by the schema, strings without one
+	if len(filter.Attributes) > 0 && s.attributes != nil {
+		attributes, err := s.attributes.CoerceFilter(filter.Attributes)
+		if err != nil {
+			return nil, 0, err
+		}
+		filter.Attributes = attributes
+	}
+
 	// Validate pagination parameters
 	if limit <= 0 || limit > 100 {
 		limit = 20 // default
@@ -125,13 +216,13 @@ func (s *UserService) ListUsers(ctx context.Context, limit, offset int) ([]*doma
 	}
 
 	// Get users
-	users, err := s.repo.List(ctx, limit, offset)
+	users, err := s.repo.List(ctx, filter, limit, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list users: %w", err)
 	}
 
 	// Get total count for pagination metadata
-	total, err := s.repo.Count(ctx)
+	total, err := s.repo.Count(ctx, filter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count users: %w", err)
 	}
@@ -139,6 +230,14 @@ func (s *UserService) ListUsers(ctx context.Context, limit, offset int) ([]*doma
 	return users, total, nil
 }
 
+// validateAttributes runs the configured validator, nil attributes mean "not provided"
+func (s *UserService) validateAttributes(attributes domain.Attributes) error {
+	if attributes == nil || s.attributes == nil {
+		return nil
+	}
+	return s.attributes.Validate(attributes)
+}
+
 // // AuthenticateUser validates user credentials
 // func (s *UserService) AuthenticateUser(ctx context.Context, email, password string) (*domain.User, error) {
 // 	// 1. Get user by email
@@ -3,8 +3,11 @@ package usecase
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/cristianortiz/observ-monit-go/internal/users/adapters/memory"
 	"github.com/cristianortiz/observ-monit-go/internal/users/domain"
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -28,6 +31,14 @@ func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*domain.Us
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetIncludingDeleted(ctx context.Context, id string) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
 func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
@@ -41,24 +52,48 @@ func (m *MockUserRepository) Update(ctx context.Context, user *domain.User) erro
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) Anonymize(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) PurgeDeleted(ctx context.Context, mode domain.PurgeMode, cutoff time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, mode, cutoff, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) List(ctx context.Context, limit, offset int) ([]*domain.User, error) {
-	args := m.Called(ctx, limit, offset)
+func (m *MockUserRepository) List(ctx context.Context, filter domain.ListFilter, limit, offset int) ([]*domain.User, error) {
+	args := m.Called(ctx, filter, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Count(ctx context.Context) (int64, error) {
-	args := m.Called(ctx)
+func (m *MockUserRepository) Count(ctx context.Context, filter domain.ListFilter) (int64, error) {
+	args := m.Called(ctx, filter)
 	return int64(args.Int(0)), args.Error(1)
 }
 
+// stubAttributesValidator rejects attributes containing the "invalid" key
+type stubAttributesValidator struct{}
+
+func (stubAttributesValidator) Validate(attrs domain.Attributes) error {
+	if _, ok := attrs["invalid"]; ok {
+		return &domain.AttributesError{Violations: []string{"/invalid: not allowed"}}
+	}
+	return nil
+}
+
+func (stubAttributesValidator) CoerceFilter(filter domain.Attributes) (domain.Attributes, error) {
+	return filter, nil
+}
+
 // cloneUser creates a copy of a user (to avoid reference issues in tests)
 func cloneUser(u *domain.User) *domain.User {
 	clone := *u
@@ -84,7 +119,7 @@ func TestUserService_CreateUser(t *testing.T) {
 		mockRepo.On("Create", ctx, mock.AnythingOfType("*domain.User")).
 			Return(nil)
 
-		user, err := service.CreateUser(ctx, "John Doe", "john@example.com", "SecurePass123!")
+		user, err := service.CreateUser(ctx, "John Doe", "john@example.com", "SecurePass123!", nil)
 
 		require.NoError(t, err)
 		assert.NotNil(t, user)
@@ -103,13 +138,42 @@ func TestUserService_CreateUser(t *testing.T) {
 		mockRepo.On("GetByEmail", ctx, "john@example.com").
 			Return(existingUser, nil)
 
-		user, err := service.CreateUser(ctx, "John Doe", "john@example.com", "SecurePass123!")
+		user, err := service.CreateUser(ctx, "John Doe", "john@example.com", "SecurePass123!", nil)
 
 		assert.ErrorIs(t, err, domain.ErrEmailAlreadyExists)
 		assert.Nil(t, user)
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("success - stores valid attributes", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, WithAttributesValidator(stubAttributesValidator{}))
+
+		mockRepo.On("GetByEmail", ctx, "john@example.com").
+			Return(nil, domain.ErrUserNotFound)
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*domain.User")).
+			Return(nil)
+
+		attrs := domain.Attributes{"department": "sales"}
+		user, err := service.CreateUser(ctx, "John Doe", "john@example.com", "SecurePass123!", attrs)
+
+		require.NoError(t, err)
+		assert.Equal(t, attrs, user.Attributes)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error - invalid attributes", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, WithAttributesValidator(stubAttributesValidator{}))
+
+		user, err := service.CreateUser(ctx, "John Doe", "john@example.com", "SecurePass123!",
+			domain.Attributes{"invalid": true})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidAttributes)
+		assert.Nil(t, user)
+		// validation happens before any repository call
+		mockRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+	})
 }
 
 func TestUserService_GetUserByID(t *testing.T) {
@@ -167,7 +231,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		mockRepo.On("Update", ctx, mock.AnythingOfType("*domain.User")).
 			Return(nil)
 
-		user, err := service.UpdateUser(ctx, existingUser.ID, "Jane Doe", "newemail@example.com")
+		user, err := service.UpdateUser(ctx, existingUser.ID, "Jane Doe", "newemail@example.com", nil)
 
 		require.NoError(t, err)
 		assert.Equal(t, "Jane Doe", user.Name)
@@ -182,7 +246,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		mockRepo.On("GetByID", ctx, "non-existent-id").
 			Return(nil, domain.ErrUserNotFound)
 
-		user, err := service.UpdateUser(ctx, "non-existent-id", "Jane Doe", "jane@example.com")
+		user, err := service.UpdateUser(ctx, "non-existent-id", "Jane Doe", "jane@example.com", nil)
 
 		assert.ErrorIs(t, err, domain.ErrUserNotFound)
 		assert.Nil(t, user)
@@ -202,12 +266,45 @@ func TestUserService_UpdateUser(t *testing.T) {
 		mockRepo.On("GetByEmail", ctx, "jane@example.com").
 			Return(otherUser, nil)
 
-		user, err := service.UpdateUser(ctx, existingUser.ID, "John Doe", "jane@example.com")
+		user, err := service.UpdateUser(ctx, existingUser.ID, "John Doe", "jane@example.com", nil)
 
 		assert.ErrorIs(t, err, domain.ErrEmailAlreadyExists)
 		assert.Nil(t, user)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("success - only attributes, keeps name and email", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, WithAttributesValidator(stubAttributesValidator{}))
+
+		existingUser, _ := domain.NewUser("John Doe", "john@example.com", "Pass123!")
+		existingUser.Attributes = domain.Attributes{"department": "sales", "locale": "es"}
+
+		mockRepo.On("GetByID", ctx, existingUser.ID).
+			Return(cloneUser(existingUser), nil)
+		mockRepo.On("Update", ctx, mock.AnythingOfType("*domain.User")).
+			Return(nil)
+
+		user, err := service.UpdateUser(ctx, existingUser.ID, "", "", domain.Attributes{"department": "hr"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "John Doe", user.Name)
+		assert.Equal(t, "john@example.com", user.Email)
+		// replaced, not merged
+		assert.Equal(t, domain.Attributes{"department": "hr"}, user.Attributes)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error - invalid attributes", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, WithAttributesValidator(stubAttributesValidator{}))
+
+		user, err := service.UpdateUser(ctx, "any-id", "", "", domain.Attributes{"invalid": 1})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidAttributes)
+		assert.Nil(t, user)
+		mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
 }
 
 func TestUserService_DeleteUser(t *testing.T) {
@@ -256,13 +353,13 @@ func TestUserService_ListUsers(t *testing.T) {
 		user2, _ := domain.NewUser("User 2", "user2@example.com", "Pass123!")
 		expectedUsers := []*domain.User{user1, user2}
 
-		mockRepo.On("List", ctx, 20, 0).
+		mockRepo.On("List", ctx, domain.ListFilter{}, 20, 0).
 			Return(expectedUsers, nil)
 
-		mockRepo.On("Count", ctx).
+		mockRepo.On("Count", ctx, domain.ListFilter{}).
 			Return(2, nil)
 
-		users, total, err := service.ListUsers(ctx, 20, 0)
+		users, total, err := service.ListUsers(ctx, domain.ListFilter{}, 20, 0)
 
 		require.NoError(t, err)
 		assert.Equal(t, 2, len(users))
@@ -274,13 +371,13 @@ func TestUserService_ListUsers(t *testing.T) {
 		mockRepo := new(MockUserRepository)
 		service := NewUserService(mockRepo)
 
-		mockRepo.On("List", ctx, 20, 0).
+		mockRepo.On("List", ctx, domain.ListFilter{}, 20, 0).
 			Return([]*domain.User{}, nil)
 
-		mockRepo.On("Count", ctx).
+		mockRepo.On("Count", ctx, domain.ListFilter{}).
 			Return(0, nil)
 
-		users, total, err := service.ListUsers(ctx, 0, -1) // Invalid params
+		users, total, err := service.ListUsers(ctx, domain.ListFilter{}, 0, -1) // Invalid params
 
 		require.NoError(t, err)
 		assert.Equal(t, 0, len(users))
@@ -288,3 +385,60 @@ func TestUserService_ListUsers(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+// TestUserService_Authorization a user reaches only its own profile, creating, listing
+// and acting on the other users takes domain.ScopeUsersAdmin
+func TestUserService_Authorization(t *testing.T) {
+	repo := memory.NewUserRepository()
+	service := NewUserService(repo)
+
+	john, err := domain.NewUser("John Doe", "john@example.com", "SecurePass123!")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(context.Background(), john))
+	jane, err := domain.NewUser("Jane Doe", "jane@example.com", "SecurePass123!")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(context.Background(), jane))
+
+	// the scopes of a password login
+	asJohn := auth.WithPrincipal(context.Background(), &auth.Principal{Type: auth.PrincipalUser, ID: john.ID,
+		Scopes: []string{domain.ScopeUsersRead, domain.ScopeUsersWrite}})
+	admin := auth.WithPrincipal(context.Background(), &auth.Principal{Type: auth.PrincipalAPIKey, ID: "key-1",
+		Scopes: []string{domain.ScopeUsersRead, domain.ScopeUsersWrite, domain.ScopeUsersAdmin}})
+
+	t.Run("error - update of another user", func(t *testing.T) {
+		_, err := service.UpdateUser(asJohn, jane.ID, "Mallory", "mallory@example.com", nil)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+
+		stored, err := repo.GetByID(context.Background(), jane.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", stored.Email, "unchanged")
+	})
+
+	t.Run("error - delete of another user", func(t *testing.T) {
+		assert.ErrorIs(t, service.DeleteUser(asJohn, jane.ID), domain.ErrForbidden)
+		_, err := repo.GetByID(context.Background(), jane.ID)
+		assert.NoError(t, err, "not deleted")
+	})
+
+	t.Run("error - create and list without users:admin", func(t *testing.T) {
+		_, err := service.CreateUser(asJohn, "Jim Doe", "jim@example.com", "SecurePass123!", nil)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		_, _, err = service.ListUsers(asJohn, domain.ListFilter{}, 20, 0)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("success - the user updates itself", func(t *testing.T) {
+		updated, err := service.UpdateUser(asJohn, john.ID, "Johnny Doe", "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Johnny Doe", updated.Name)
+	})
+
+	t.Run("success - users:admin acts on every user", func(t *testing.T) {
+		_, err := service.UpdateUser(admin, jane.ID, "Jane Roe", "", nil)
+		require.NoError(t, err)
+		_, total, err := service.ListUsers(admin, domain.ListFilter{}, 20, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		require.NoError(t, service.DeleteUser(admin, jane.ID))
+	})
+}
@@ -0,0 +1,18 @@
+-- migrations/000002_add_tenant_to_users.down.sql
+-- NOTE: fails if the same email exists in more than one tenant
+
+DROP INDEX IF EXISTS idx_users_tenant_created_at;
+DROP INDEX IF EXISTS idx_users_tenant_email;
+
+ALTER TABLE IF EXISTS users DROP CONSTRAINT IF EXISTS users_tenant_email_key;
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS tenant_id;
+
+DO $$
+BEGIN
+    IF to_regclass('users') IS NOT NULL THEN
+        ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+        ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+        CREATE INDEX IF NOT EXISTS idx_users_email ON users(email) WHERE deleted_at IS NULL;
+        CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at DESC);
+    END IF;
+END $$;
@@ -0,0 +1,18 @@
+-- migrations/000002_add_tenant_to_users.up.sql
+
+-- every user belongs to a tenant (customer organization), existing rows go to 'default'
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+
+COMMENT ON COLUMN users.tenant_id IS 'Owner tenant (organization) identifier';
+
+-- email is unique per tenant, no longer global
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_tenant_email_key UNIQUE (tenant_id, email);
+
+COMMENT ON COLUMN users.email IS 'User email address (unique per tenant)';
+
+-- every query is filtered by tenant first
+DROP INDEX IF EXISTS idx_users_email;
+DROP INDEX IF EXISTS idx_users_created_at;
+CREATE INDEX idx_users_tenant_email ON users(tenant_id, email) WHERE deleted_at IS NULL;
+CREATE INDEX idx_users_tenant_created_at ON users(tenant_id, created_at DESC);
@@ -0,0 +1,4 @@
+-- migrations/000003_users_row_level_security.down.sql
+
+DROP POLICY IF EXISTS users_tenant_isolation ON users;
+ALTER TABLE IF EXISTS users DISABLE ROW LEVEL SECURITY;
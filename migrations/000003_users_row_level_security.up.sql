@@ -0,0 +1,18 @@
+-- migrations/000003_users_row_level_security.up.sql
+
+-- Optional Postgres row level security (TENANT_RLS_ENABLED=true)
+-- the application sets app.tenant_id per transaction, rows of other tenants are
+-- invisible even if a query forgets the tenant_id filter
+-- NOTE: table owner and superusers bypass RLS, in RLS mode the service must connect
+-- with a dedicated role, e.g:
+--   CREATE ROLE factorit_app LOGIN PASSWORD '...';
+--   GRANT SELECT, INSERT, UPDATE, DELETE ON users TO factorit_app;
+-- with the mode off the service must connect as the table owner or a BYPASSRLS role, the
+-- policy is strict and hides every row from sessions that do not set app.tenant_id
+
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS users_tenant_isolation ON users;
+CREATE POLICY users_tenant_isolation ON users
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,6 @@
+-- migrations/000004_add_user_attributes.down.sql
+
+DROP INDEX IF EXISTS idx_users_attributes;
+
+ALTER TABLE IF EXISTS users DROP CONSTRAINT IF EXISTS users_attributes_object_check;
+ALTER TABLE IF EXISTS users DROP COLUMN IF EXISTS attributes;
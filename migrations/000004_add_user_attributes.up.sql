@@ -0,0 +1,13 @@
+-- migrations/000004_add_user_attributes.up.sql
+
+-- custom profile attributes (phone, department, locale...), shape validated by the
+-- JSON Schema configured in USERS_ATTRIBUTES_SCHEMA, not by the database
+ALTER TABLE users ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+COMMENT ON COLUMN users.attributes IS 'Custom profile attributes (JSON object)';
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_attributes_object_check;
+ALTER TABLE users ADD CONSTRAINT users_attributes_object_check CHECK (jsonb_typeof(attributes) = 'object');
+
+-- List filters with attributes @> '{...}', jsonb_path_ops is smaller and faster for containment
+CREATE INDEX IF NOT EXISTS idx_users_attributes ON users USING GIN (attributes jsonb_path_ops);
@@ -0,0 +1,3 @@
+-- migrations/000005_create_user_erasure_jobs.down.sql
+
+DROP TABLE IF EXISTS user_erasure_jobs;
@@ -0,0 +1,32 @@
+-- migrations/000005_create_user_erasure_jobs.up.sql
+
+-- GDPR erasure requests, the user row is anonymized (not deleted) so this table and any
+-- other referencing users(id) keep their integrity
+CREATE TABLE IF NOT EXISTS user_erasure_jobs (
+    id UUID PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP WITH TIME ZONE NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NULL,
+
+    CONSTRAINT user_erasure_jobs_status_check CHECK (
+        status IN ('pending', 'running', 'completed', 'failed')
+    )
+);
+
+COMMENT ON TABLE user_erasure_jobs IS 'GDPR erasure requests and their status';
+COMMENT ON COLUMN user_erasure_jobs.error IS 'Failure reason, never contains personal data';
+
+CREATE INDEX IF NOT EXISTS idx_user_erasure_jobs_tenant_user
+    ON user_erasure_jobs(tenant_id, user_id, requested_at DESC);
+
+-- same isolation as users (migration 000003)
+ALTER TABLE user_erasure_jobs ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS user_erasure_jobs_tenant_isolation ON user_erasure_jobs;
+CREATE POLICY user_erasure_jobs_tenant_isolation ON user_erasure_jobs
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,3 @@
+-- migrations/000006_create_purge_deleted_users.down.sql
+
+DROP FUNCTION IF EXISTS purge_deleted_users(TIMESTAMPTZ, INTEGER, BOOLEAN);
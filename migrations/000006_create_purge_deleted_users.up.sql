@@ -0,0 +1,50 @@
+-- migrations/000006_create_purge_deleted_users.up.sql
+
+-- Retention purge of soft deleted users (USERS_PURGE_*), one batch per call so each
+-- statement holds its row locks briefly, rows locked by a running request are skipped
+-- and picked up by the next batch
+-- SECURITY DEFINER: the purge spans every tenant, it runs as the table owner so the
+-- tenant isolation policies (migration 000003) do not hide rows in RLS mode, grant it
+-- explicitly to the application role, e.g:
+--   GRANT EXECUTE ON FUNCTION purge_deleted_users(TIMESTAMPTZ, INTEGER, BOOLEAN) TO factorit_app;
+CREATE OR REPLACE FUNCTION purge_deleted_users(p_cutoff TIMESTAMPTZ, p_limit INTEGER, p_anonymize BOOLEAN)
+RETURNS BIGINT
+LANGUAGE plpgsql
+SECURITY DEFINER
+SET search_path = public
+AS $$
+DECLARE
+    purged BIGINT;
+BEGIN
+    IF p_anonymize THEN
+        -- same values as domain.User.Anonymize, deleted_at is kept
+        UPDATE users
+        SET name = 'Erased user',
+            email = 'erased-' || replace(id::text, '-', '') || '@erased.invalid',
+            password_hash = '',
+            attributes = '{}'::jsonb
+        WHERE id IN (
+            SELECT id FROM users
+            WHERE deleted_at < p_cutoff AND email NOT LIKE '%@erased.invalid'
+            ORDER BY deleted_at
+            LIMIT p_limit
+            FOR UPDATE SKIP LOCKED
+        );
+    ELSE
+        -- user_erasure_jobs rows go away by cascade
+        DELETE FROM users
+        WHERE id IN (
+            SELECT id FROM users
+            WHERE deleted_at < p_cutoff
+            ORDER BY deleted_at
+            LIMIT p_limit
+            FOR UPDATE SKIP LOCKED
+        );
+    END IF;
+
+    GET DIAGNOSTICS purged = ROW_COUNT;
+    RETURN purged;
+END;
+$$;
+
+REVOKE ALL ON FUNCTION purge_deleted_users(TIMESTAMPTZ, INTEGER, BOOLEAN) FROM PUBLIC;
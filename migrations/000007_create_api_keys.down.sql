@@ -0,0 +1,4 @@
+-- migrations/000007_create_api_keys.down.sql
+
+DROP FUNCTION IF EXISTS find_api_key_by_prefix(VARCHAR);
+DROP TABLE IF EXISTS api_keys;
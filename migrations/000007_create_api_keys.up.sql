@@ -0,0 +1,47 @@
+-- migrations/000007_create_api_keys.up.sql
+
+-- API keys for service to service access, the secret is never stored, only its SHA-256
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE NULL,
+
+    -- every authenticated request looks the key up by prefix
+    CONSTRAINT api_keys_prefix_key UNIQUE (prefix)
+);
+
+COMMENT ON TABLE api_keys IS 'API keys for service to service authentication';
+COMMENT ON COLUMN api_keys.prefix IS 'Lookup prefix, part of the key, safe to display';
+COMMENT ON COLUMN api_keys.key_hash IS 'Hex SHA-256 of the whole key';
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_created_at ON api_keys(tenant_id, created_at DESC);
+
+-- same isolation as users (migration 000003)
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS api_keys_tenant_isolation ON api_keys;
+CREATE POLICY api_keys_tenant_isolation ON api_keys
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- the tenant of a request is known only after its key is authenticated, the lookup runs
+-- as the table owner so the isolation policy does not hide the key in RLS mode, e.g:
+--   GRANT EXECUTE ON FUNCTION find_api_key_by_prefix(VARCHAR) TO factorit_app;
+CREATE OR REPLACE FUNCTION find_api_key_by_prefix(p_prefix VARCHAR)
+RETURNS SETOF api_keys
+LANGUAGE sql
+STABLE
+SECURITY DEFINER
+SET search_path = public
+AS $$
+    SELECT * FROM api_keys WHERE prefix = p_prefix;
+$$;
+
+REVOKE ALL ON FUNCTION find_api_key_by_prefix(VARCHAR) FROM PUBLIC;
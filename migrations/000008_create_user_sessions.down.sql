@@ -0,0 +1,3 @@
+-- migrations/000008_create_user_sessions.down.sql
+
+DROP TABLE IF EXISTS user_sessions;
@@ -0,0 +1,30 @@
+-- migrations/000008_create_user_sessions.up.sql
+
+-- logins of the users, every user token carries the id of its session (sid claim) and
+-- stops being accepted once the session is revoked
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id UUID PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device VARCHAR(100) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+COMMENT ON TABLE user_sessions IS 'User logins, revoking a session invalidates its tokens';
+COMMENT ON COLUMN user_sessions.device IS 'Client provided device name';
+COMMENT ON COLUMN user_sessions.ip IS 'Client IP at login, IPv4 or IPv6 text form';
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_tenant_user
+    ON user_sessions(tenant_id, user_id, last_seen_at DESC);
+
+-- same isolation as users (migration 000003)
+ALTER TABLE user_sessions ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS user_sessions_tenant_isolation ON user_sessions;
+CREATE POLICY user_sessions_tenant_isolation ON user_sessions
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
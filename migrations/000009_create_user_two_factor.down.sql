@@ -0,0 +1,3 @@
+-- migrations/000009_create_user_two_factor.down.sql
+
+DROP TABLE IF EXISTS user_two_factor;
@@ -0,0 +1,25 @@
+-- migrations/000009_create_user_two_factor.up.sql
+
+-- TOTP (RFC 6238) enrollments, one per user, pending until confirmed_at is set
+CREATE TABLE IF NOT EXISTS user_two_factor (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    tenant_id VARCHAR(63) NOT NULL,
+    secret TEXT NOT NULL,
+    recovery_codes TEXT[] NOT NULL DEFAULT '{}',
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    confirmed_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+COMMENT ON TABLE user_two_factor IS 'TOTP two-factor enrollments of the users';
+COMMENT ON COLUMN user_two_factor.secret IS 'TOTP secret sealed with AES-256-GCM, never in plain';
+COMMENT ON COLUMN user_two_factor.recovery_codes IS 'Hex SHA-256 of the unused recovery codes';
+COMMENT ON COLUMN user_two_factor.last_used_step IS 'Time step of the last accepted code, rejects replays';
+
+-- same isolation as users (migration 000003)
+ALTER TABLE user_two_factor ENABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS user_two_factor_tenant_isolation ON user_two_factor;
+CREATE POLICY user_two_factor_tenant_isolation ON user_two_factor
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,18 @@
+-- migrations/000010_create_rate_limit_buckets.up.sql
+
+-- state of the rate limiter keys shared by the instances (middleware.PostgresRateLimitStore),
+-- not tenant scoped, the keys are client IPs, principals or routes
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key VARCHAR(512) PRIMARY KEY,
+    count DOUBLE PRECISION NOT NULL DEFAULT 0,
+    previous DOUBLE PRECISION NOT NULL DEFAULT 0,
+    stamp TIMESTAMP WITH TIME ZONE NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+COMMENT ON TABLE rate_limit_buckets IS 'Rate limiter state per key, rows expire when the key is idle';
+COMMENT ON COLUMN rate_limit_buckets.count IS 'Tokens left (token bucket) or requests of the current window (sliding window)';
+COMMENT ON COLUMN rate_limit_buckets.stamp IS 'Last refill or start of the current window, NULL until first used';
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_expires_at
+    ON rate_limit_buckets(expires_at);
@@ -0,0 +1,4 @@
+-- migrations/000011_add_two_factor_challenge.down.sql
+
+ALTER TABLE IF EXISTS user_two_factor DROP COLUMN IF EXISTS challenge_attempts;
+ALTER TABLE IF EXISTS user_two_factor DROP COLUMN IF EXISTS challenge_id;
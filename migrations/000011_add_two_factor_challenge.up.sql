@@ -0,0 +1,9 @@
+-- migrations/000011_add_two_factor_challenge.up.sql
+
+-- current login challenge of the user (jti of the challenge token) and the codes tried
+-- against it, the challenge is discarded once answered or after too many attempts
+ALTER TABLE user_two_factor ADD COLUMN IF NOT EXISTS challenge_id UUID NULL;
+ALTER TABLE user_two_factor ADD COLUMN IF NOT EXISTS challenge_attempts INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN user_two_factor.challenge_id IS 'jti of the only login challenge that can be answered, NULL when none';
+COMMENT ON COLUMN user_two_factor.challenge_attempts IS 'Codes tried against challenge_id, capped by AUTH_MFA_CHALLENGE_MAX_ATTEMPTS';
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// PrincipalType who is calling: an interactive user (token) or a service (API key)
+type PrincipalType string
+
+const (
+	PrincipalUser   PrincipalType = "user"
+	PrincipalAPIKey PrincipalType = "api_key"
+)
+
+// LocalsKey is the Fiber locals key where the authenticated principal is stored
+const LocalsKey = "principal"
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Type PrincipalType
+	// ID user id (token subject) or API key id
+	ID string
+	// TenantID the principal belongs to, empty when the credential does not carry one
+	TenantID string
+	Scopes   []string
+	// SessionID session of a user token (sid claim), empty for API keys
+	SessionID string
+}
+
+// HasScope checks if the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx, false for anonymous requests
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok && p != nil
+}
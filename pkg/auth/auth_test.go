@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+
+	_, ok := FromContext(ctx)
+	assert.False(t, ok)
+
+	ctx = WithPrincipal(ctx, &Principal{Type: PrincipalAPIKey, ID: "key-1"})
+	p, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, PrincipalAPIKey, p.Type)
+	assert.Equal(t, "key-1", p.ID)
+}
+
+func TestPrincipal_HasScope(t *testing.T) {
+	p := &Principal{Scopes: []string{"users:read"}}
+
+	assert.True(t, p.HasScope("users:read"))
+	assert.False(t, p.HasScope("users:write"))
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APIKeyAuthenticator validates a raw API key, implemented by the module owning the keys
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// SessionValidator checks the session of a user token was not revoked, implemented by
+// the module owning the sessions
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, principal *Principal) error
+}
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidAPIKey      = errors.New("invalid API key")
+	// ErrInvalidSession wraps the cause, which may be internal (database), callers
+	// should show this message only
+	ErrInvalidSession = errors.New("invalid or revoked session")
+)
+
+// Authenticator validates the credentials of a request, an API key or a user token
+// (HS256 JWT), shared by the HTTP middleware and the gRPC interceptor
+type Authenticator struct {
+	// APIKeys validates API keys, nil disables them
+	APIKeys APIKeyAuthenticator
+	// APIKeyPrefix tells API keys apart from user tokens in the bearer credential, empty
+	// accepts API keys only in their own header (metadata key)
+	APIKeyPrefix string
+	// JWTSecret HS256 secret of the user tokens, empty disables them
+	JWTSecret []byte
+	// TenantClaim user token claim with the tenant, default "tenant"
+	TenantClaim string
+	// Sessions validates the session (sid claim) of the user tokens, tokens without a
+	// session are rejected, nil accepts every token until it expires
+	Sessions SessionValidator
+}
+
+// Authenticate validates the API key or the bearer token, whichever is given, and returns
+// the principal and the token claims (the claims equivalent of an API key)
+func (a Authenticator) Authenticate(ctx context.Context, apiKey, bearer string) (*Principal, map[string]any, error) {
+	tenantClaim := a.TenantClaim
+	if tenantClaim == "" {
+		tenantClaim = "tenant"
+	}
+	if apiKey == "" && a.APIKeyPrefix != "" && strings.HasPrefix(bearer, a.APIKeyPrefix) {
+		apiKey, bearer = bearer, ""
+	}
+
+	switch {
+	case apiKey != "" && a.APIKeys != nil:
+		principal, err := a.APIKeys.AuthenticateAPIKey(ctx, apiKey)
+		if err != nil {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return principal, principalClaims(principal, tenantClaim), nil
+
+	case bearer != "" && len(a.JWTSecret) > 0:
+		principal, claims, err := a.authenticateToken(bearer, tenantClaim)
+		if err != nil {
+			return nil, nil, err
+		}
+		if a.Sessions != nil {
+			if principal.SessionID == "" {
+				return nil, nil, ErrInvalidSession
+			}
+			if err := a.Sessions.ValidateSession(ctx, principal); err != nil {
+				return nil, nil, fmt.Errorf("%w: %w", ErrInvalidSession, err)
+			}
+		}
+		return principal, claims, nil
+
+	default:
+		return nil, nil, ErrMissingCredentials
+	}
+}
+
+// authenticateToken validates a HS256 user token, sub is the user, scope the space
+// separated scopes (RFC 8693) and sid the session
+func (a Authenticator) authenticateToken(token, tenantClaim string) (*Principal, map[string]any, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return a.JWTSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, nil, ErrInvalidToken
+	}
+	// tokens without token_use predate it, challenge tokens never reach the API
+	if use, ok := claims[TokenUseClaim]; ok && use != TokenUseAccess {
+		return nil, nil, ErrInvalidToken
+	}
+
+	principal := &Principal{
+		Type: PrincipalUser,
+		ID:   subject,
+	}
+	principal.TenantID, _ = claims[tenantClaim].(string)
+	if scope, ok := claims["scope"].(string); ok {
+		principal.Scopes = strings.Fields(scope)
+	}
+	principal.SessionID, _ = claims["sid"].(string)
+
+	return principal, map[string]any(claims), nil
+}
+
+// principalClaims the claims equivalent of an API key, same shape as a user token
+func principalClaims(p *Principal, tenantClaim string) map[string]any {
+	return map[string]any{
+		"sub":       p.ID,
+		tenantClaim: p.TenantID,
+		"scope":     strings.Join(p.Scopes, " "),
+	}
+}
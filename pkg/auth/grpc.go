@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type GRPCConfig struct {
+	Authenticator Authenticator
+	// APIKeyMetadata metadata key carrying the API key, default x-api-key, user tokens and
+	// prefixed API keys go in authorization (Bearer <credential>)
+	APIKeyMetadata string
+	// Scopes any of the scopes required by a method (full method name), methods missing
+	// from the map only require an authenticated principal
+	Scopes map[string][]string
+	// Logger records the authentication failures, optional
+	Logger *logger.Logger
+}
+
+// UnaryServerInterceptor authenticates every call with the same rules as
+// middleware.Authenticate and stores the principal in the context, it must run before
+// tenant.UnaryServerInterceptor so the tenant is checked against the credential
+// calls without valid credentials get Unauthenticated, missing scopes PermissionDenied
+func UnaryServerInterceptor(config GRPCConfig) grpc.UnaryServerInterceptor {
+	if config.APIKeyMetadata == "" {
+		config.APIKeyMetadata = "x-api-key"
+	}
+	config.APIKeyMetadata = strings.ToLower(config.APIKeyMetadata)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key, token := "", ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(config.APIKeyMetadata); len(values) > 0 {
+				key = values[0]
+			}
+			if values := md.Get("authorization"); len(values) > 0 {
+				if scheme, value, ok := strings.Cut(values[0], " "); ok && strings.EqualFold(scheme, "Bearer") {
+					token = strings.TrimSpace(value)
+				}
+			}
+		}
+
+		principal, _, err := config.Authenticator.Authenticate(ctx, key, token)
+		if err != nil {
+			if config.Logger != nil {
+				config.Logger.Warn("authentication failed",
+					zap.String("grpc_method", info.FullMethod),
+					zap.Error(err),
+				)
+			}
+			message := err.Error()
+			if errors.Is(err, ErrInvalidSession) {
+				// the cause may be internal (database), it is logged only
+				message = ErrInvalidSession.Error()
+			}
+			return nil, status.Error(codes.Unauthenticated, message)
+		}
+
+		if scopes, ok := config.Scopes[info.FullMethod]; ok && !hasAnyScope(principal, scopes) {
+			return nil, status.Errorf(codes.PermissionDenied, "missing scope: %s", strings.Join(scopes, " or "))
+		}
+
+		return handler(WithPrincipal(ctx, principal), req)
+	}
+}
+
+func hasAnyScope(p *Principal, scopes []string) bool {
+	for _, scope := range scopes {
+		if p.HasScope(scope) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// staticAPIKeys accepts a single key
+type staticAPIKeys struct {
+	key       string
+	principal *Principal
+}
+
+func (s staticAPIKeys) AuthenticateAPIKey(_ context.Context, key string) (*Principal, error) {
+	if key != s.key {
+		return nil, errors.New("unknown key")
+	}
+	return s.principal, nil
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	secret := []byte("test-secret")
+	interceptor := UnaryServerInterceptor(GRPCConfig{
+		Authenticator: Authenticator{
+			APIKeys: staticAPIKeys{
+				key:       "fk_abc_secret",
+				principal: &Principal{Type: PrincipalAPIKey, ID: "key-1", TenantID: "acme", Scopes: []string{"users:read"}},
+			},
+			APIKeyPrefix: "fk_",
+			JWTSecret:    secret,
+		},
+		Scopes: map[string][]string{"/users.v1.UserService/CreateUser": {"users:write"}},
+	})
+	getUser := &grpc.UnaryServerInfo{FullMethod: "/users.v1.UserService/GetUser"}
+
+	var got *Principal
+	handler := func(ctx context.Context, req any) (any, error) {
+		got, _ = FromContext(ctx)
+		return nil, nil
+	}
+	call := func(info *grpc.UnaryServerInfo, pairs ...string) error {
+		got = nil
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+		_, err := interceptor(ctx, nil, info, handler)
+		return err
+	}
+
+	t.Run("api key in its own metadata key", func(t *testing.T) {
+		require.NoError(t, call(getUser, "x-api-key", "fk_abc_secret"))
+		require.NotNil(t, got)
+		assert.Equal(t, "key-1", got.ID)
+		assert.Equal(t, "acme", got.TenantID)
+	})
+
+	t.Run("api key as bearer", func(t *testing.T) {
+		require.NoError(t, call(getUser, "authorization", "Bearer fk_abc_secret"))
+		assert.Equal(t, PrincipalAPIKey, got.Type)
+	})
+
+	t.Run("user token", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":    "user-1",
+			"tenant": "acme",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+		}).SignedString(secret)
+		require.NoError(t, err)
+
+		require.NoError(t, call(getUser, "authorization", "Bearer "+token))
+		assert.Equal(t, PrincipalUser, got.Type)
+		assert.Equal(t, "user-1", got.ID)
+	})
+
+	t.Run("rejected credentials", func(t *testing.T) {
+		assert.Equal(t, codes.Unauthenticated, status.Code(call(getUser)))
+		assert.Equal(t, codes.Unauthenticated, status.Code(call(getUser, "x-api-key", "fk_abc_wrong")))
+		assert.Equal(t, codes.Unauthenticated, status.Code(call(getUser, "authorization", "Bearer not-a-token")))
+		assert.Nil(t, got, "the handler is not called")
+	})
+
+	t.Run("missing scope", func(t *testing.T) {
+		createUser := &grpc.UnaryServerInfo{FullMethod: "/users.v1.UserService/CreateUser"}
+		err := call(createUser, "x-api-key", "fk_abc_secret")
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
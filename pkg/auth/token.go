@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenUseClaim tells the kind of a user token apart, only access tokens authenticate
+// API requests (see middleware.Authenticate)
+const TokenUseClaim = "token_use"
+
+const (
+	TokenUseAccess = "access"
+	// TokenUseChallenge proves the first login step (password), only the second step
+	// (one-time code) accepts it
+	TokenUseChallenge = "mfa_challenge"
+)
+
+// ErrInvalidToken the token is malformed, expired, signed with another key or of another use
+var ErrInvalidToken = errors.New("invalid token")
+
+// TokenIssuer signs the HS256 user tokens verified by middleware.Authenticate
+type TokenIssuer struct {
+	secret      []byte
+	tenantClaim string
+	now         func() time.Time
+}
+
+// TokenOption configures optional issuer behaviour
+type TokenOption func(*TokenIssuer)
+
+// WithTokenClock replaces time.Now, for tests
+func WithTokenClock(now func() time.Time) TokenOption {
+	return func(i *TokenIssuer) {
+		i.now = now
+	}
+}
+
+// NewTokenIssuer creates an issuer, tenantClaim must match the one of the tenant and
+// auth middlewares (default "tenant")
+func NewTokenIssuer(secret []byte, tenantClaim string, opts ...TokenOption) *TokenIssuer {
+	if tenantClaim == "" {
+		tenantClaim = "tenant"
+	}
+	i := &TokenIssuer{
+		secret:      secret,
+		tenantClaim: tenantClaim,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+// IssueAccessToken signs an access token of a user principal, sid is its session
+func (i *TokenIssuer) IssueAccessToken(p *Principal, ttl time.Duration) (string, time.Time, error) {
+	claims := i.claims(p.ID, p.TenantID, TokenUseAccess, ttl)
+	claims["scope"] = strings.Join(p.Scopes, " ")
+	if p.SessionID != "" {
+		claims["sid"] = p.SessionID
+	}
+
+	return i.sign(claims)
+}
+
+// Challenge the first login step (password) of a user, ID (jti claim) tells the
+// challenges of the user apart so each one can be answered a limited number of times
+type Challenge struct {
+	ID       string
+	UserID   string
+	TenantID string
+}
+
+// IssueChallenge signs a challenge token, the user passed the password step but still
+// has to send a one-time code
+func (i *TokenIssuer) IssueChallenge(challenge Challenge, ttl time.Duration) (string, time.Time, error) {
+	claims := i.claims(challenge.UserID, challenge.TenantID, TokenUseChallenge, ttl)
+	claims["jti"] = challenge.ID
+
+	return i.sign(claims)
+}
+
+// ParseChallenge validates a challenge token and returns its challenge
+func (i *TokenIssuer) ParseChallenge(token string) (*Challenge, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return i.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(i.now),
+	)
+	if err != nil || claims[TokenUseClaim] != TokenUseChallenge {
+		return nil, ErrInvalidToken
+	}
+
+	var challenge Challenge
+	challenge.UserID, err = claims.GetSubject()
+	if err != nil || challenge.UserID == "" {
+		return nil, ErrInvalidToken
+	}
+	challenge.ID, _ = claims["jti"].(string)
+	if challenge.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	challenge.TenantID, _ = claims[i.tenantClaim].(string)
+
+	return &challenge, nil
+}
+
+func (i *TokenIssuer) claims(subject, tenantID, use string, ttl time.Duration) jwt.MapClaims {
+	now := i.now()
+	claims := jwt.MapClaims{
+		"sub":         subject,
+		"iat":         now.Unix(),
+		"exp":         now.Add(ttl).Unix(),
+		TokenUseClaim: use,
+	}
+	if tenantID != "" {
+		claims[i.tenantClaim] = tenantID
+	}
+	return claims
+}
+
+func (i *TokenIssuer) sign(claims jwt.MapClaims) (string, time.Time, error) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, time.Unix(claims["exp"].(int64), 0), nil
+}
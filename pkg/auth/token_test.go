@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenIssuer_AccessToken(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	issuer := NewTokenIssuer([]byte("secret"), "org", WithTokenClock(func() time.Time { return now }))
+
+	token, expiresAt, err := issuer.IssueAccessToken(&Principal{
+		ID:        "user-1",
+		TenantID:  "acme",
+		Scopes:    []string{"users:read", "users:write"},
+		SessionID: "session-1",
+	}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), expiresAt)
+
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(token, claims)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims["sub"])
+	assert.Equal(t, "acme", claims["org"])
+	assert.Equal(t, "users:read users:write", claims["scope"])
+	assert.Equal(t, "session-1", claims["sid"])
+	assert.Equal(t, TokenUseAccess, claims[TokenUseClaim])
+
+	_, err = issuer.ParseChallenge(token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "an access token is not a challenge")
+}
+
+func TestTokenIssuer_Challenge(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	issuer := NewTokenIssuer([]byte("secret"), "", WithTokenClock(func() time.Time { return now }))
+
+	want := Challenge{ID: "challenge-1", UserID: "user-1", TenantID: "acme"}
+	token, _, err := issuer.IssueChallenge(want, 5*time.Minute)
+	require.NoError(t, err)
+
+	challenge, err := issuer.ParseChallenge(token)
+	require.NoError(t, err)
+	assert.Equal(t, want, *challenge)
+
+	// every challenge has an id, the attempts are counted by it
+	token, _, err = issuer.IssueChallenge(Challenge{UserID: "user-1"}, 5*time.Minute)
+	require.NoError(t, err)
+	_, err = issuer.ParseChallenge(token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "without jti")
+
+	token, _, err = issuer.IssueChallenge(want, 5*time.Minute)
+	require.NoError(t, err)
+	now = now.Add(5 * time.Minute)
+	_, err = issuer.ParseChallenge(token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "expired")
+
+	other := NewTokenIssuer([]byte("other"), "")
+	_, err = other.ParseChallenge(token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "other secret")
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
 	"strconv"
@@ -17,6 +18,10 @@ type Config struct {
 	Observability ObservabilityConfig
 	Security      SecurityConfig
 	API           ApiConfig
+	GRPC          GRPCConfig
+	Tenant        TenantConfig
+	Users         UsersConfig
+	RateLimit     RateLimitConfig
 }
 
 type ApiConfig struct {
@@ -29,6 +34,63 @@ type ServiceConfig struct {
 	Port int
 }
 
+// GRPCConfig gRPC server settings, it listens on its own port next to the HTTP one
+type GRPCConfig struct {
+	Enabled bool
+	Host    string
+	Port    int
+}
+
+// TenantConfig how the tenant of each request is resolved
+type TenantConfig struct {
+	// Resolvers tried in order: header, subdomain, claim
+	Resolvers  []string
+	Header     string
+	BaseDomain string
+	Claim      string
+	// Default tenant when none is resolved, empty makes the tenant mandatory
+	Default string
+	// RLSEnabled turns on the postgres row level security mode (migration 000003), off
+	// the service must connect as the table owner or a BYPASSRLS role, the policies are
+	// always enabled and hide every row from a session without app.tenant_id
+	RLSEnabled bool
+}
+
+// UsersConfig users module settings
+type UsersConfig struct {
+	// AttributesSchemaPath JSON Schema for the custom profile attributes, empty accepts any object
+	AttributesSchemaPath string
+	Purge                PurgeConfig
+}
+
+// PurgeConfig retention purge of the soft deleted users
+type PurgeConfig struct {
+	Enabled bool
+	// Interval between runs, each run purges in batches until nothing is due
+	Interval time.Duration
+	// Retention how long a soft deleted user is kept before being purged
+	Retention time.Duration
+	BatchSize int
+	// Mode delete (hard delete) or anonymize
+	Mode string
+}
+
+// RateLimitConfig limits of the API routes, probes and metrics are never limited
+type RateLimitConfig struct {
+	Enabled bool
+	// Requests allowed per Window and key
+	Requests int
+	Window   time.Duration
+	// Algorithm token_bucket or sliding_window
+	Algorithm string
+	// Key ip, principal (authenticated principal, IP for anonymous requests) or route
+	Key string
+	// Store memory (per instance) or postgres (shared by the instances, migration 000010)
+	Store string
+	// LoginRequests stricter limit per IP and Window of the login routes, 0 disables it
+	LoginRequests int
+}
+
 type DatabaseConfig struct {
 	Host     string
 	Port     int
@@ -51,6 +113,53 @@ type ObservabilityConfig struct {
 	HealthPath  string
 	ReadyPath   string
 	Tracing     TracingConfig
+	AccessLog   AccessLogConfig
+	Metrics     MetricsConfig
+	SLO         SLOConfig
+}
+
+// SLOConfig service level objectives of the HTTP routes
+type SLOConfig struct {
+	// LatencyThreshold latency objective of the routes without their own
+	LatencyThreshold time.Duration
+	// LatencyRoutes objective per route, keyed by "METHOD /route/template" or
+	// "/route/template" for every method
+	LatencyRoutes map[string]time.Duration
+
+	// Enabled evaluates the objectives in process and serves them on Path
+	Enabled bool
+	Path    string
+	// ObjectivesPath JSON file of objectives per route, empty uses one objective for
+	// every API route with the targets below
+	ObjectivesPath     string
+	AvailabilityTarget float64
+	LatencyTarget      float64
+	// Period of the error budget, EvaluationInterval between evaluations
+	Period             time.Duration
+	EvaluationInterval time.Duration
+}
+
+// MetricsConfig names and labels of the Prometheus metrics, the service label is always
+// added from the service name
+type MetricsConfig struct {
+	// Namespace prefix of the metric names, none by default
+	Namespace string
+	// ConstLabels added to every metric, e.g. env=prod,region=eu
+	ConstLabels map[string]string
+	// LatencyBuckets of the HTTP and gRPC latency histograms (seconds), the Prometheus
+	// defaults when empty
+	LatencyBuckets []float64
+}
+
+// AccessLogConfig one log entry per HTTP request, errors and slow requests are always logged
+type AccessLogConfig struct {
+	Enabled bool
+	// SampleRate fraction (0..1) of the successful (< 400) requests logged
+	SampleRate float64
+	// SlowThreshold requests slower than this are always logged, 0 disables it
+	SlowThreshold time.Duration
+	// ExcludePaths never logged, by default the metrics and probe paths
+	ExcludePaths []string
 }
 
 type TracingConfig struct {
@@ -59,10 +168,58 @@ type TracingConfig struct {
 	ServiceVersion string
 	OTLPEndpoint   string
 	Environment    string
+	// Exporter otlp (collector), stdout (pretty or json) or file (JSON lines rotated by size)
+	Exporter       string
+	StdoutFormat   string
+	FilePath       string
+	FileMaxSizeMB  int
+	FileMaxBackups int
+	// OTLPProtocol grpc (4317) or http (4318)
+	OTLPProtocol string
+	// OTLPInsecure plain text, TLS with the CA/client files below otherwise
+	OTLPInsecure    bool
+	OTLPCAFile      string
+	OTLPCertFile    string
+	OTLPKeyFile     string
+	OTLPHeaders     map[string]string
+	OTLPCompression string // gzip or none
+	// SampleRatio fraction of the new traces sampled, child spans follow the parent
+	SampleRatio float64
+	// batch span processor
+	BatchTimeout       time.Duration
+	ExportTimeout      time.Duration
+	MaxExportBatchSize int
+	MaxQueueSize       int
 }
 
 type SecurityConfig struct {
 	JWTSecret string
+	// AuthEnabled requires an API key or a user token (HS256 JWT signed with JWTSecret)
+	// on the API routes, probes, metrics and docs stay public
+	AuthEnabled  bool
+	APIKeyHeader string
+	// SessionCacheTTL how long a validated user token session is trusted before reading
+	// it again, bounds how late another instance sees a revocation, 0 reads every time
+	SessionCacheTTL time.Duration
+	// AccessTokenTTL lifetime of the tokens issued at login
+	AccessTokenTTL time.Duration
+	// MFAChallengeTTL time a user with two-factor enabled has to send the code after the password
+	MFAChallengeTTL time.Duration
+	// MFAChallengeMaxAttempts codes a login challenge takes before the user has to send
+	// the password again
+	MFAChallengeMaxAttempts int
+	// TOTPIssuer name shown by the authenticator apps
+	TOTPIssuer string
+	// TOTPEncryptionKey base64 of the 32 byte key of the TOTP secrets at rest, derived from
+	// JWTSecret when empty (required in production when auth is enabled)
+	TOTPEncryptionKey string
+	// BootstrapAPIKey plain key (fk_<12 hex>_<secret>) seeded at startup when set, the first
+	// api-keys:manage key, the others are issued through the API with it
+	BootstrapAPIKey string
+	// BootstrapAPIKeyTenant tenant of the bootstrap key
+	BootstrapAPIKeyTenant string
+	// BootstrapAPIKeyScopes scopes of the bootstrap key
+	BootstrapAPIKeyScopes []string
 }
 
 // Load reads configuration from environment variables
@@ -95,19 +252,108 @@ func Load(serviceName string) (*Config, error) {
 			HealthPath:  getEnv("HEALTH_PATH", "/health"),
 			ReadyPath:   getEnv("READY_PATH", "/ready"),
 			Tracing: TracingConfig{
-				Enabled:        getEnvBool("TRACING_ENABLED", true),
-				ServiceName:    getEnv("TRACING_SERVICE_NAME", serviceName),
-				ServiceVersion: getEnv("TRACING_SERVICE_VERSION", "1.0.0"),
-				OTLPEndpoint:   getEnv("TRACING_OTLP_ENDPOINT", "localhost:4317"),
-				Environment:    getEnv("TRACING_ENVIRONMENT", "development"),
+				Enabled:            getEnvBool("TRACING_ENABLED", true),
+				ServiceName:        getEnv("TRACING_SERVICE_NAME", serviceName),
+				ServiceVersion:     getEnv("TRACING_SERVICE_VERSION", "1.0.0"),
+				OTLPEndpoint:       getEnv("TRACING_OTLP_ENDPOINT", otlpDefaultEndpoint(getEnv("TRACING_OTLP_PROTOCOL", "grpc"))),
+				Environment:        getEnv("TRACING_ENVIRONMENT", "development"),
+				Exporter:           getEnv("TRACING_EXPORTER", "otlp"),
+				StdoutFormat:       getEnv("TRACING_STDOUT_FORMAT", "pretty"),
+				FilePath:           getEnv("TRACING_FILE_PATH", "traces/spans.jsonl"),
+				FileMaxSizeMB:      getEnvInt("TRACING_FILE_MAX_SIZE_MB", 100),
+				FileMaxBackups:     getEnvInt("TRACING_FILE_MAX_BACKUPS", 3),
+				OTLPProtocol:       getEnv("TRACING_OTLP_PROTOCOL", "grpc"),
+				OTLPInsecure:       getEnvBool("TRACING_OTLP_INSECURE", true),
+				OTLPCAFile:         getEnv("TRACING_OTLP_CA_FILE", ""),
+				OTLPCertFile:       getEnv("TRACING_OTLP_CERT_FILE", ""),
+				OTLPKeyFile:        getEnv("TRACING_OTLP_KEY_FILE", ""),
+				OTLPHeaders:        getEnvMap("TRACING_OTLP_HEADERS"),
+				OTLPCompression:    getEnv("TRACING_OTLP_COMPRESSION", "none"),
+				SampleRatio:        getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+				BatchTimeout:       getEnvDuration("TRACING_BATCH_TIMEOUT", 5*time.Second),
+				ExportTimeout:      getEnvDuration("TRACING_EXPORT_TIMEOUT", 10*time.Second),
+				MaxExportBatchSize: getEnvInt("TRACING_MAX_EXPORT_BATCH_SIZE", 512),
+				MaxQueueSize:       getEnvInt("TRACING_MAX_QUEUE_SIZE", 2048),
+			},
+			Metrics: MetricsConfig{
+				Namespace:      getEnv("METRICS_NAMESPACE", ""),
+				ConstLabels:    getEnvMap("METRICS_CONST_LABELS"),
+				LatencyBuckets: getEnvFloatList("METRICS_LATENCY_BUCKETS"),
+			},
+			SLO: SLOConfig{
+				LatencyThreshold: getEnvDuration("SLO_LATENCY_THRESHOLD", 1*time.Second),
+				LatencyRoutes:    getEnvDurationMap("SLO_LATENCY_ROUTES"),
+
+				Enabled:            getEnvBool("SLO_ENABLED", true),
+				Path:               getEnv("SLO_PATH", "/slo"),
+				ObjectivesPath:     getEnv("SLO_OBJECTIVES", ""),
+				AvailabilityTarget: getEnvFloat("SLO_AVAILABILITY_TARGET", 0.999),
+				LatencyTarget:      getEnvFloat("SLO_LATENCY_TARGET", 0.99),
+				Period:             getEnvDuration("SLO_PERIOD", 30*24*time.Hour),
+				EvaluationInterval: getEnvDuration("SLO_EVALUATION_INTERVAL", 1*time.Minute),
+			},
+			AccessLog: AccessLogConfig{
+				Enabled:       getEnvBool("ACCESS_LOG_ENABLED", true),
+				SampleRate:    getEnvFloat("ACCESS_LOG_SAMPLE_RATE", 1),
+				SlowThreshold: getEnvDuration("ACCESS_LOG_SLOW_THRESHOLD", 1*time.Second),
+				ExcludePaths: getEnvList("ACCESS_LOG_EXCLUDE_PATHS", []string{
+					getEnv("METRICS_PATH", "/metrics"),
+					getEnv("HEALTH_PATH", "/health"),
+					getEnv("READY_PATH", "/ready"),
+				}),
 			},
 		},
 		Security: SecurityConfig{
-			JWTSecret: getEnv("JWT_SECRET", "change-me-in-production"),
+			JWTSecret:    getEnv("JWT_SECRET", "change-me-in-production"),
+			AuthEnabled:  getEnvBool("AUTH_ENABLED", false),
+			APIKeyHeader: getEnv("AUTH_API_KEY_HEADER", "X-API-Key"),
+
+			SessionCacheTTL:         getEnvDuration("AUTH_SESSION_CACHE_TTL", 30*time.Second),
+			AccessTokenTTL:          getEnvDuration("JWT_EXPIRATION", 24*time.Hour),
+			MFAChallengeTTL:         getEnvDuration("AUTH_MFA_CHALLENGE_TTL", 5*time.Minute),
+			MFAChallengeMaxAttempts: getEnvInt("AUTH_MFA_CHALLENGE_MAX_ATTEMPTS", 5),
+			TOTPIssuer:              getEnv("AUTH_TOTP_ISSUER", "FactorIT"),
+			TOTPEncryptionKey:       getEnv("AUTH_TOTP_ENCRYPTION_KEY", ""),
+
+			BootstrapAPIKey:       getEnv("AUTH_BOOTSTRAP_API_KEY", ""),
+			BootstrapAPIKeyTenant: getEnv("AUTH_BOOTSTRAP_API_KEY_TENANT", "default"),
+			BootstrapAPIKeyScopes: getEnvList("AUTH_BOOTSTRAP_API_KEY_SCOPES", []string{"api-keys:manage"}),
 		},
 		API: ApiConfig{
 			BasePath: getEnv("API_BASE_PATH", "/api/v1"),
 		},
+		GRPC: GRPCConfig{
+			Enabled: getEnvBool("GRPC_ENABLED", true),
+			Host:    getEnv("GRPC_HOST", "0.0.0.0"),
+			Port:    getEnvInt("GRPC_PORT", 50051),
+		},
+		Tenant: TenantConfig{
+			Resolvers:  getEnvList("TENANT_RESOLVERS", []string{"header"}),
+			Header:     getEnv("TENANT_HEADER", "X-Tenant-ID"),
+			BaseDomain: getEnv("TENANT_BASE_DOMAIN", ""),
+			Claim:      getEnv("TENANT_CLAIM", "tenant"),
+			Default:    getEnvAllowEmpty("TENANT_DEFAULT", "default"),
+			RLSEnabled: getEnvBool("TENANT_RLS_ENABLED", false),
+		},
+		Users: UsersConfig{
+			AttributesSchemaPath: getEnv("USERS_ATTRIBUTES_SCHEMA", ""),
+			Purge: PurgeConfig{
+				Enabled:   getEnvBool("USERS_PURGE_ENABLED", false),
+				Interval:  getEnvDuration("USERS_PURGE_INTERVAL", 1*time.Hour),
+				Retention: getEnvDuration("USERS_PURGE_RETENTION", 30*24*time.Hour),
+				BatchSize: getEnvInt("USERS_PURGE_BATCH_SIZE", 500),
+				Mode:      getEnv("USERS_PURGE_MODE", "delete"),
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:       getEnvBool("RATE_LIMIT_ENABLED", false),
+			Requests:      getEnvInt("RATE_LIMIT_REQUESTS", 100),
+			Window:        getEnvDuration("RATE_LIMIT_WINDOW", 1*time.Minute),
+			Algorithm:     getEnv("RATE_LIMIT_ALGORITHM", "token_bucket"),
+			Key:           getEnv("RATE_LIMIT_KEY", "principal"),
+			Store:         getEnv("RATE_LIMIT_STORE", "memory"),
+			LoginRequests: getEnvInt("RATE_LIMIT_LOGIN_REQUESTS", 10),
+		},
 	}
 
 	// Service-specific port override
@@ -148,6 +394,82 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFloatList reads comma separated numbers, e.g. "0.05,0.1,0.5", values that are not
+// numbers are ignored
+func getEnvFloatList(key string) []float64 {
+	var values []float64
+	for _, item := range getEnvList(key, nil) {
+		if value, err := strconv.ParseFloat(item, 64); err == nil {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvDurationMap reads comma separated key=duration pairs, e.g.
+// "GET /api/v1/users/:id=200ms,/api/v1/users=500ms", invalid durations are kept as 0 so
+// Validate reports them
+func getEnvDurationMap(key string) map[string]time.Duration {
+	values := map[string]time.Duration{}
+	for k, v := range getEnvMap(key) {
+		duration, _ := time.ParseDuration(v)
+		values[k] = duration
+	}
+	return values
+}
+
+// getEnvMap reads comma separated key=value pairs, e.g. "authorization=Bearer x,team=core"
+func getEnvMap(key string) map[string]string {
+	values := map[string]string{}
+	for _, item := range getEnvList(key, nil) {
+		if k, v, ok := strings.Cut(item, "="); ok && strings.TrimSpace(k) != "" {
+			values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return values
+}
+
+// otlpDefaultEndpoint the local collector port of the OTLP protocol
+func otlpDefaultEndpoint(protocol string) string {
+	if protocol == "http" {
+		return "localhost:4318"
+	}
+	return "localhost:4317"
+}
+
+// getEnvAllowEmpty like getEnv but a variable set to "" overrides the default
+func getEnvAllowEmpty(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvList reads a comma separated list, empty items are dropped
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func (c *Config) Validate() error {
 	if c.Service.Name == "" {
 		return fmt.Errorf("service name is required")
@@ -155,6 +477,120 @@ func (c *Config) Validate() error {
 	if c.Database.Host == "" {
 		return fmt.Errorf("database host is required")
 	}
+	if c.GRPC.Enabled && c.GRPC.Port == c.Service.Port {
+		return fmt.Errorf("grpc port must be different from the http port (%d)", c.Service.Port)
+	}
+	for _, resolver := range c.Tenant.Resolvers {
+		switch resolver {
+		case "header", "claim":
+		case "subdomain":
+			if c.Tenant.BaseDomain == "" {
+				return fmt.Errorf("tenant subdomain resolver requires TENANT_BASE_DOMAIN")
+			}
+		default:
+			return fmt.Errorf("unknown tenant resolver %q", resolver)
+		}
+	}
+	if c.Security.AuthEnabled && c.IsProduction() && c.Security.JWTSecret == "change-me-in-production" {
+		return fmt.Errorf("JWT_SECRET must be set when auth is enabled in production")
+	}
+	if c.Security.SessionCacheTTL < 0 {
+		return fmt.Errorf("session cache TTL can not be negative")
+	}
+	if c.Security.AccessTokenTTL <= 0 || c.Security.MFAChallengeTTL <= 0 {
+		return fmt.Errorf("access token and mfa challenge TTLs must be positive")
+	}
+	if c.Security.MFAChallengeMaxAttempts <= 0 {
+		return fmt.Errorf("mfa challenge max attempts must be positive")
+	}
+	if key := c.Security.TOTPEncryptionKey; key != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 32 {
+			return fmt.Errorf("AUTH_TOTP_ENCRYPTION_KEY must be the base64 of 32 bytes")
+		}
+	} else if c.Security.AuthEnabled && c.IsProduction() {
+		return fmt.Errorf("AUTH_TOTP_ENCRYPTION_KEY must be set when auth is enabled in production")
+	}
+	if c.Security.BootstrapAPIKey != "" && len(c.Security.BootstrapAPIKeyScopes) == 0 {
+		return fmt.Errorf("AUTH_BOOTSTRAP_API_KEY_SCOPES can not be empty when a bootstrap key is set")
+	}
+	if purge := c.Users.Purge; purge.Enabled {
+		if purge.Interval <= 0 || purge.Retention <= 0 || purge.BatchSize <= 0 {
+			return fmt.Errorf("users purge interval, retention and batch size must be positive")
+		}
+		if purge.Mode != "delete" && purge.Mode != "anonymize" {
+			return fmt.Errorf("unknown users purge mode %q", purge.Mode)
+		}
+	}
+	if tracing := c.Observability.Tracing; tracing.Enabled {
+		switch tracing.Exporter {
+		case "otlp":
+		case "stdout":
+			if tracing.StdoutFormat != "pretty" && tracing.StdoutFormat != "json" {
+				return fmt.Errorf("unknown tracing stdout format %q", tracing.StdoutFormat)
+			}
+		case "file":
+			if tracing.FilePath == "" || tracing.FileMaxSizeMB <= 0 || tracing.FileMaxBackups < 0 {
+				return fmt.Errorf("TRACING_FILE_PATH and a positive TRACING_FILE_MAX_SIZE_MB are required by the file exporter")
+			}
+		default:
+			return fmt.Errorf("unknown tracing exporter %q", tracing.Exporter)
+		}
+		if tracing.OTLPProtocol != "grpc" && tracing.OTLPProtocol != "http" {
+			return fmt.Errorf("unknown tracing OTLP protocol %q", tracing.OTLPProtocol)
+		}
+		if tracing.OTLPCompression != "gzip" && tracing.OTLPCompression != "none" {
+			return fmt.Errorf("unknown tracing OTLP compression %q", tracing.OTLPCompression)
+		}
+		if tracing.SampleRatio < 0 || tracing.SampleRatio > 1 {
+			return fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1")
+		}
+		if (tracing.OTLPCertFile == "") != (tracing.OTLPKeyFile == "") {
+			return fmt.Errorf("TRACING_OTLP_CERT_FILE and TRACING_OTLP_KEY_FILE must be set together")
+		}
+		if tracing.OTLPInsecure && (tracing.OTLPCAFile != "" || tracing.OTLPCertFile != "") {
+			return fmt.Errorf("TRACING_OTLP_INSECURE can not be used with TLS certificates")
+		}
+	}
+	slo := c.Observability.SLO
+	if slo.LatencyThreshold <= 0 {
+		return fmt.Errorf("SLO_LATENCY_THRESHOLD must be positive")
+	}
+	for route, threshold := range slo.LatencyRoutes {
+		if threshold <= 0 {
+			return fmt.Errorf("SLO_LATENCY_ROUTES: %q needs a positive duration", route)
+		}
+	}
+	if slo.Enabled {
+		if slo.AvailabilityTarget < 0 || slo.AvailabilityTarget >= 1 || slo.LatencyTarget < 0 || slo.LatencyTarget >= 1 {
+			return fmt.Errorf("SLO_AVAILABILITY_TARGET and SLO_LATENCY_TARGET must be between 0 and 1")
+		}
+		if slo.Period <= 0 || slo.EvaluationInterval <= 0 || slo.EvaluationInterval > slo.Period {
+			return fmt.Errorf("SLO_PERIOD and SLO_EVALUATION_INTERVAL must be positive, the interval shorter")
+		}
+	}
+	buckets := c.Observability.Metrics.LatencyBuckets
+	for i, bucket := range buckets {
+		if bucket <= 0 || (i > 0 && bucket <= buckets[i-1]) {
+			return fmt.Errorf("METRICS_LATENCY_BUCKETS must be increasing positive numbers")
+		}
+	}
+	if accessLog := c.Observability.AccessLog; accessLog.SampleRate < 0 || accessLog.SampleRate > 1 {
+		return fmt.Errorf("ACCESS_LOG_SAMPLE_RATE must be between 0 and 1")
+	}
+	if limit := c.RateLimit; limit.Enabled {
+		if limit.Requests <= 0 || limit.Window <= 0 || limit.LoginRequests < 0 {
+			return fmt.Errorf("rate limit requests and window must be positive")
+		}
+		if limit.Algorithm != "token_bucket" && limit.Algorithm != "sliding_window" {
+			return fmt.Errorf("unknown rate limit algorithm %q", limit.Algorithm)
+		}
+		if limit.Key != "ip" && limit.Key != "principal" && limit.Key != "route" {
+			return fmt.Errorf("unknown rate limit key %q", limit.Key)
+		}
+		if limit.Store != "memory" && limit.Store != "postgres" {
+			return fmt.Errorf("unknown rate limit store %q", limit.Store)
+		}
+	}
 	return nil
 }
 
@@ -174,6 +610,10 @@ func (c *Config) GetServiceAddress() string {
 	return fmt.Sprintf("%s:%d", c.Service.Host, c.Service.Port)
 }
 
+func (c *Config) GetGRPCAddress() string {
+	return fmt.Sprintf("%s:%d", c.GRPC.Host, c.GRPC.Port)
+}
+
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
 }
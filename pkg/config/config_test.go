@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -52,3 +53,287 @@ func TestDefaults(t *testing.T) {
 		t.Errorf("Expected default DB host 'localhost', got '%s'", config.Database.Host)
 	}
 }
+
+func TestGRPCConfig(t *testing.T) {
+	os.Setenv("GRPC_PORT", "6000")
+	defer os.Unsetenv("GRPC_PORT")
+
+	config, err := Load("test-service")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if !config.GRPC.Enabled {
+		t.Error("Expected gRPC enabled by default")
+	}
+
+	if config.GetGRPCAddress() != "0.0.0.0:6000" {
+		t.Errorf("Expected gRPC address '0.0.0.0:6000', got '%s'", config.GetGRPCAddress())
+	}
+}
+
+func TestGRPCPortMustDifferFromHTTP(t *testing.T) {
+	os.Setenv("GRPC_PORT", "8080")
+	defer os.Unsetenv("GRPC_PORT")
+
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error when gRPC and HTTP share the same port")
+	}
+}
+
+func TestTenantConfig(t *testing.T) {
+	os.Setenv("TENANT_RESOLVERS", "subdomain, header")
+	os.Setenv("TENANT_BASE_DOMAIN", "factorit.io")
+	defer os.Unsetenv("TENANT_RESOLVERS")
+	defer os.Unsetenv("TENANT_BASE_DOMAIN")
+
+	config, err := Load("test-service")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if len(config.Tenant.Resolvers) != 2 || config.Tenant.Resolvers[0] != "subdomain" || config.Tenant.Resolvers[1] != "header" {
+		t.Errorf("Expected resolvers [subdomain header], got %v", config.Tenant.Resolvers)
+	}
+
+	if config.Tenant.Header != "X-Tenant-ID" {
+		t.Errorf("Expected default tenant header 'X-Tenant-ID', got '%s'", config.Tenant.Header)
+	}
+}
+
+func TestTenantSubdomainRequiresBaseDomain(t *testing.T) {
+	os.Setenv("TENANT_RESOLVERS", "subdomain")
+	defer os.Unsetenv("TENANT_RESOLVERS")
+
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error when subdomain resolver has no base domain")
+	}
+}
+
+func TestUsersPurgeConfig(t *testing.T) {
+	os.Setenv("USERS_PURGE_ENABLED", "true")
+	os.Setenv("USERS_PURGE_RETENTION", "168h")
+	defer os.Unsetenv("USERS_PURGE_ENABLED")
+	defer os.Unsetenv("USERS_PURGE_RETENTION")
+
+	config, err := Load("test-service")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if config.Users.Purge.Retention != 168*time.Hour {
+		t.Errorf("Expected purge retention 168h, got %v", config.Users.Purge.Retention)
+	}
+	if config.Users.Purge.Mode != "delete" || config.Users.Purge.BatchSize != 500 {
+		t.Errorf("Expected default purge mode 'delete' and batch 500, got '%s' and %d",
+			config.Users.Purge.Mode, config.Users.Purge.BatchSize)
+	}
+
+	os.Setenv("USERS_PURGE_MODE", "truncate")
+	defer os.Unsetenv("USERS_PURGE_MODE")
+
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for an unknown purge mode")
+	}
+}
+
+func TestAuthConfig(t *testing.T) {
+	os.Setenv("AUTH_ENABLED", "true")
+	defer os.Unsetenv("AUTH_ENABLED")
+
+	config, err := Load("test-service")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !config.Security.AuthEnabled || config.Security.APIKeyHeader != "X-API-Key" {
+		t.Errorf("Expected auth enabled with header X-API-Key, got %v and '%s'",
+			config.Security.AuthEnabled, config.Security.APIKeyHeader)
+	}
+	if config.Security.SessionCacheTTL != 30*time.Second {
+		t.Errorf("Expected session cache TTL 30s, got %v", config.Security.SessionCacheTTL)
+	}
+	if config.Security.AccessTokenTTL != 24*time.Hour || config.Security.MFAChallengeTTL != 5*time.Minute {
+		t.Errorf("Expected token TTLs 24h and 5m, got %v and %v",
+			config.Security.AccessTokenTTL, config.Security.MFAChallengeTTL)
+	}
+
+	os.Setenv("AUTH_TOTP_ENCRYPTION_KEY", "c2hvcnQ=")
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for a TOTP encryption key that is not 32 bytes")
+	}
+	os.Unsetenv("AUTH_TOTP_ENCRYPTION_KEY")
+
+	// the default secret is rejected in production
+	os.Setenv("ENVIRONMENT", "production")
+	defer os.Unsetenv("ENVIRONMENT")
+
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for the default JWT secret in production")
+	}
+
+	// an explicit TOTP key is required in production too
+	os.Setenv("JWT_SECRET", "production-secret")
+	defer os.Unsetenv("JWT_SECRET")
+
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for a derived TOTP encryption key in production")
+	}
+
+	os.Setenv("AUTH_TOTP_ENCRYPTION_KEY", "MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE=")
+	defer os.Unsetenv("AUTH_TOTP_ENCRYPTION_KEY")
+
+	if _, err := Load("test-service"); err != nil {
+		t.Errorf("Expected production config to load, got %v", err)
+	}
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	os.Setenv("RATE_LIMIT_ENABLED", "true")
+	defer os.Unsetenv("RATE_LIMIT_ENABLED")
+
+	config, err := Load("test-service")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	limit := config.RateLimit
+	if limit.Requests != 100 || limit.Window != time.Minute || limit.LoginRequests != 10 {
+		t.Errorf("Expected 100 requests per minute and 10 logins, got %d per %v and %d",
+			limit.Requests, limit.Window, limit.LoginRequests)
+	}
+	if limit.Algorithm != "token_bucket" || limit.Key != "principal" || limit.Store != "memory" {
+		t.Errorf("Expected token_bucket by principal in memory, got %s by %s in %s",
+			limit.Algorithm, limit.Key, limit.Store)
+	}
+
+	os.Setenv("RATE_LIMIT_STORE", "redis")
+	defer os.Unsetenv("RATE_LIMIT_STORE")
+
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for an unknown rate limit store")
+	}
+}
+
+func TestAccessLogConfig(t *testing.T) {
+	os.Setenv("ACCESS_LOG_SAMPLE_RATE", "0.1")
+	defer os.Unsetenv("ACCESS_LOG_SAMPLE_RATE")
+
+	config, err := Load("test-service")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	accessLog := config.Observability.AccessLog
+	if !accessLog.Enabled || accessLog.SampleRate != 0.1 || accessLog.SlowThreshold != time.Second {
+		t.Errorf("Expected enabled with 0.1 sample rate and 1s threshold, got %v %v %v",
+			accessLog.Enabled, accessLog.SampleRate, accessLog.SlowThreshold)
+	}
+	if len(accessLog.ExcludePaths) != 3 || accessLog.ExcludePaths[0] != "/metrics" {
+		t.Errorf("Expected metrics and probe paths excluded, got %v", accessLog.ExcludePaths)
+	}
+
+	os.Setenv("ACCESS_LOG_SAMPLE_RATE", "2")
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for a sample rate above 1")
+	}
+}
+
+func TestTracingConfig(t *testing.T) {
+	os.Setenv("TRACING_OTLP_PROTOCOL", "http")
+	os.Setenv("TRACING_OTLP_HEADERS", "api-key=secret,x-team=payments")
+	os.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+	defer os.Unsetenv("TRACING_OTLP_PROTOCOL")
+	defer os.Unsetenv("TRACING_OTLP_HEADERS")
+	defer os.Unsetenv("TRACING_SAMPLE_RATIO")
+
+	config, err := Load("test-service")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	tracing := config.Observability.Tracing
+	if tracing.OTLPEndpoint != "localhost:4318" {
+		t.Errorf("Expected the OTLP HTTP port by default, got %s", tracing.OTLPEndpoint)
+	}
+	if tracing.OTLPHeaders["api-key"] != "secret" || tracing.OTLPHeaders["x-team"] != "payments" {
+		t.Errorf("Expected the OTLP headers, got %v", tracing.OTLPHeaders)
+	}
+	if tracing.SampleRatio != 0.25 || tracing.MaxQueueSize != 2048 {
+		t.Errorf("Expected 0.25 sample ratio and 2048 queue size, got %v %v", tracing.SampleRatio, tracing.MaxQueueSize)
+	}
+
+	os.Setenv("TRACING_OTLP_CERT_FILE", "client.pem")
+	defer os.Unsetenv("TRACING_OTLP_CERT_FILE")
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for a client certificate without key")
+	}
+	os.Unsetenv("TRACING_OTLP_CERT_FILE")
+
+	os.Setenv("TRACING_OTLP_PROTOCOL", "thrift")
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for an unknown OTLP protocol")
+	}
+	os.Setenv("TRACING_OTLP_PROTOCOL", "grpc")
+
+	os.Setenv("TRACING_EXPORTER", "stdout")
+	os.Setenv("TRACING_STDOUT_FORMAT", "yaml")
+	defer os.Unsetenv("TRACING_EXPORTER")
+	defer os.Unsetenv("TRACING_STDOUT_FORMAT")
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for an unknown stdout format")
+	}
+}
+
+func TestMetricsConfig(t *testing.T) {
+	os.Setenv("METRICS_CONST_LABELS", "env=prod,region=eu")
+	os.Setenv("METRICS_LATENCY_BUCKETS", "0.05, 0.1,0.5")
+	defer os.Unsetenv("METRICS_CONST_LABELS")
+	defer os.Unsetenv("METRICS_LATENCY_BUCKETS")
+
+	config, err := Load("test-service")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	metrics := config.Observability.Metrics
+	if metrics.Namespace != "" || metrics.ConstLabels["env"] != "prod" || metrics.ConstLabels["region"] != "eu" {
+		t.Errorf("Expected no namespace and env/region labels, got %q %v", metrics.Namespace, metrics.ConstLabels)
+	}
+	if len(metrics.LatencyBuckets) != 3 || metrics.LatencyBuckets[2] != 0.5 {
+		t.Errorf("Expected 3 latency buckets, got %v", metrics.LatencyBuckets)
+	}
+
+	os.Setenv("METRICS_LATENCY_BUCKETS", "0.5,0.1")
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for decreasing latency buckets")
+	}
+}
+
+func TestSLOConfig(t *testing.T) {
+	os.Setenv("SLO_LATENCY_ROUTES", "GET /api/v1/users/:id=200ms,/api/v1/users=500ms")
+	defer os.Unsetenv("SLO_LATENCY_ROUTES")
+
+	config, err := Load("test-service")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	slo := config.Observability.SLO
+	if slo.LatencyThreshold != time.Second {
+		t.Errorf("Expected 1s default latency threshold, got %s", slo.LatencyThreshold)
+	}
+	if slo.LatencyRoutes["GET /api/v1/users/:id"] != 200*time.Millisecond || slo.LatencyRoutes["/api/v1/users"] != 500*time.Millisecond {
+		t.Errorf("Expected the route latency thresholds, got %v", slo.LatencyRoutes)
+	}
+
+	if !slo.Enabled || slo.Path != "/slo" || slo.AvailabilityTarget != 0.999 || slo.Period != 30*24*time.Hour {
+		t.Errorf("Expected SLOs enabled on /slo with 0.999 availability over 30 days, got %+v", slo)
+	}
+
+	os.Setenv("SLO_LATENCY_ROUTES", "/api/v1/users=fast")
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for an invalid route threshold")
+	}
+	os.Unsetenv("SLO_LATENCY_ROUTES")
+
+	os.Setenv("SLO_AVAILABILITY_TARGET", "99.9")
+	defer os.Unsetenv("SLO_AVAILABILITY_TARGET")
+	if _, err := Load("test-service"); err == nil {
+		t.Error("Expected error for a target above 1")
+	}
+}
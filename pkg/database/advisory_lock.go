@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AdvisoryLock is a Postgres session advisory lock, used to run a job in a single replica
+// the lock belongs to the connection, so it is held on a dedicated pool connection until
+// unlock, and Postgres releases it by itself if the replica dies
+type AdvisoryLock struct {
+	pool *pgxpool.Pool
+	key  int64
+	name string
+}
+
+// NewAdvisoryLock creates a lock identified by name, every replica must use the same name
+func NewAdvisoryLock(pool *pgxpool.Pool, name string) *AdvisoryLock {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+
+	return &AdvisoryLock{
+		pool: pool,
+		key:  int64(h.Sum64()),
+		name: name,
+	}
+}
+
+// TryLock takes the lock without waiting, acquired is false when another session holds it
+// IMPORTANT: when acquired, always call unlock, it returns the connection to the pool
+func (l *AdvisoryLock) TryLock(ctx context.Context) (unlock func(), acquired bool, err error) {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection for lock %s: %w", l.name, err)
+	}
+
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&acquired); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("failed to take lock %s: %w", l.name, err)
+	}
+	if !acquired {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock = func() {
+		// not bound to the caller ctx, it may be already cancelled (shutdown)
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if _, err := conn.Exec(unlockCtx, `SELECT pg_advisory_unlock($1)`, l.key); err != nil {
+			// a connection still holding the lock must not go back to the pool
+			conn.Conn().Close(unlockCtx)
+		}
+		conn.Release()
+	}
+
+	return unlock, true, nil
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector is a prometheus.Collector of the pgxpool statistics, read on every scrape
+// so the values are never stale, every metric has a pool label besides the const labels of
+// the service
+type PoolCollector struct {
+	pools map[string]*pgxpool.Pool
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+	lifetimeDestroyCount *prometheus.Desc
+	idleDestroyCount     *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*PoolCollector)(nil)
+
+// PoolDescriptors the metrics of PoolCollector without the namespace, see metrics.Inventory
+var PoolDescriptors = []metrics.Descriptor{
+	{Name: "pgxpool_acquired_connections", Labels: []string{"pool"}},
+	{Name: "pgxpool_idle_connections", Labels: []string{"pool"}},
+	{Name: "pgxpool_constructing_connections", Labels: []string{"pool"}},
+	{Name: "pgxpool_total_connections", Labels: []string{"pool"}},
+	{Name: "pgxpool_max_connections", Labels: []string{"pool"}},
+	{Name: "pgxpool_acquires_total", Labels: []string{"pool"}},
+	{Name: "pgxpool_acquire_duration_seconds_total", Labels: []string{"pool"}},
+	{Name: "pgxpool_empty_acquires_total", Labels: []string{"pool"}},
+	{Name: "pgxpool_canceled_acquires_total", Labels: []string{"pool"}},
+	{Name: "pgxpool_new_connections_total", Labels: []string{"pool"}},
+	{Name: "pgxpool_max_lifetime_destroys_total", Labels: []string{"pool"}},
+	{Name: "pgxpool_max_idle_destroys_total", Labels: []string{"pool"}},
+}
+
+// NewPoolCollector creates the collector of pools by name and registers it, with the same
+// options as the other metrics of the service it gets their registry, namespace and labels
+//
+// Usage:
+//
+//	database.NewPoolCollector(cfg.Service.Name, map[string]*pgxpool.Pool{"main": db.Pool}, metrics.WithRegistry(registry))
+func NewPoolCollector(serviceName string, pools map[string]*pgxpool.Pool, opts ...metrics.Option) *PoolCollector {
+	settings := metrics.Resolve(serviceName, opts...)
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(settings.Namespace, "pgxpool", name), help,
+			[]string{"pool"}, settings.ConstLabels)
+	}
+
+	collector := &PoolCollector{
+		pools: pools,
+
+		acquiredConns:        desc("acquired_connections", "Number of connections currently acquired from the pool"),
+		idleConns:            desc("idle_connections", "Number of idle connections in the pool"),
+		constructingConns:    desc("constructing_connections", "Number of connections being established"),
+		totalConns:           desc("total_connections", "Total number of connections in the pool (acquired, idle and constructing)"),
+		maxConns:             desc("max_connections", "Maximum size of the pool"),
+		acquireCount:         desc("acquires_total", "Total number of successful acquires from the pool"),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Total time spent waiting for successful acquires in seconds"),
+		emptyAcquireCount:    desc("empty_acquires_total", "Total number of successful acquires that waited for a connection, the pool was empty"),
+		canceledAcquireCount: desc("canceled_acquires_total", "Total number of acquires canceled by the context"),
+		newConnsCount:        desc("new_connections_total", "Total number of connections opened"),
+		lifetimeDestroyCount: desc("max_lifetime_destroys_total", "Total number of connections closed by MaxConnLifetime"),
+		idleDestroyCount:     desc("max_idle_destroys_total", "Total number of connections closed by MaxConnIdleTime"),
+	}
+	settings.Registry.MustRegister(collector)
+	return collector
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.constructingConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+	ch <- c.canceledAcquireCount
+	ch <- c.newConnsCount
+	ch <- c.lifetimeDestroyCount
+	ch <- c.idleDestroyCount
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	// sorted, stable output
+	for _, name := range slices.Sorted(maps.Keys(c.pools)) {
+		stat := c.pools[name].Stat()
+
+		gauge := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, name)
+		}
+		counter := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, name)
+		}
+
+		gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+		gauge(c.idleConns, float64(stat.IdleConns()))
+		gauge(c.constructingConns, float64(stat.ConstructingConns()))
+		gauge(c.totalConns, float64(stat.TotalConns()))
+		gauge(c.maxConns, float64(stat.MaxConns()))
+		counter(c.acquireCount, float64(stat.AcquireCount()))
+		counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+		counter(c.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+		counter(c.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+		counter(c.newConnsCount, float64(stat.NewConnsCount()))
+		counter(c.lifetimeDestroyCount, float64(stat.MaxLifetimeDestroyCount()))
+		counter(c.idleDestroyCount, float64(stat.MaxIdleDestroyCount()))
+	}
+}
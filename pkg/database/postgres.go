@@ -18,8 +18,18 @@ type PostgresDB struct {
 	Logger *zap.Logger
 }
 
+// Option configures optional pool behaviour
+type Option func(*pgxpool.Config)
+
+// WithTracer sets the pgx tracer of every connection of the pool, e.g. a QueryTracer
+func WithTracer(tracer pgx.QueryTracer) Option {
+	return func(c *pgxpool.Config) {
+		c.ConnConfig.Tracer = tracer
+	}
+}
+
 // NewPostgresDB creates and configure a new db pool with pgxpool
-func NewPostgresDB(ctx context.Context, cfg *config.Config, logger *zap.Logger) (*PostgresDB, error) {
+func NewPostgresDB(ctx context.Context, cfg *config.Config, logger *zap.Logger, opts ...Option) (*PostgresDB, error) {
 	logger.Info("initializing PostgreSQL connection pool",
 		zap.String("host", cfg.Database.Host),
 		zap.Int("port", cfg.Database.Port),
@@ -44,6 +54,9 @@ func NewPostgresDB(ctx context.Context, cfg *config.Config, logger *zap.Logger)
 	poolConfig.MaxConnLifetime = cfg.Database.MaxConnLifetime
 	poolConfig.MaxConnIdleTime = cfg.Database.MaxConnIdleTime
 	poolConfig.HealthCheckPeriod = cfg.Database.HealthCheckInterval
+	for _, opt := range opts {
+		opt(poolConfig)
+	}
 
 	logger.Info("pgxpool configuration set",
 		zap.Int32("max_conns", poolConfig.MaxConns),
@@ -0,0 +1,242 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/cristianortiz/observ-monit-go/pkg/database"
+
+// queryNamePrefix of the SQL comment naming a query, e.g. "-- name: users.GetByID"
+const queryNamePrefix = "-- name:"
+
+// QueryRecorder records the query metrics, implemented by *metrics.DatabaseMetrics
+type QueryRecorder interface {
+	RecordQueryDuration(ctx context.Context, operation, query string, duration float64)
+	RecordQueryError(operation, query, sqlstate string)
+}
+
+type QueryTracerConfig struct {
+	// Metrics nil records no metrics
+	Metrics QueryRecorder
+	// TracerProvider default the global one
+	TracerProvider trace.TracerProvider
+	// Queries names of queries without a name comment, by SQL
+	Queries map[string]string
+}
+
+// QueryTracer is a pgx tracer (queries and batches) that creates a child span of the
+// request span for every query, with the db.* attributes, and records its duration and
+// errors, so repositories are observed without code of their own
+//
+// Queries are named by a leading "-- name: <name>" comment, the Queries registry or, as
+// last resort, by the operation and the table ("select users")
+//
+// Usage:
+//
+//	database.NewPostgresDB(ctx, cfg, log, database.WithTracer(database.NewQueryTracer(database.QueryTracerConfig{
+//		Metrics: metrics.NewDatabaseMetrics(cfg.Service.Name, metrics.WithRegistry(registry)),
+//	})))
+type QueryTracer struct {
+	config QueryTracerConfig
+	tracer trace.Tracer
+}
+
+var (
+	_ pgx.QueryTracer = (*QueryTracer)(nil)
+	_ pgx.BatchTracer = (*QueryTracer)(nil)
+)
+
+// NewQueryTracer creates the tracer, set it in pgx.ConnConfig.Tracer (see WithTracer)
+func NewQueryTracer(config QueryTracerConfig) *QueryTracer {
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+	return &QueryTracer{
+		config: config,
+		tracer: config.TracerProvider.Tracer(tracerName),
+	}
+}
+
+// queryState the query in flight, stored in the context returned by the start hooks
+type queryState struct {
+	start     time.Time
+	operation string
+	name      string
+	span      trace.Span
+}
+
+type queryStateKey struct{}
+
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation, name := t.queryName(data.SQL)
+	return t.start(ctx, conn, operation, name, semconv.DBStatement(data.SQL))
+}
+
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	t.end(ctx, data.CommandTag, data.Err)
+}
+
+func (t *QueryTracer) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	return t.start(ctx, conn, "BATCH", "batch", attribute.Int("db.batch.size", data.Batch.Len()))
+}
+
+// TraceBatchQuery adds every query of the batch as an event of the batch span, its
+// errors are counted under its own name
+func (t *QueryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	state, ok := ctx.Value(queryStateKey{}).(*queryState)
+	if !ok {
+		return
+	}
+
+	operation, name := t.queryName(data.SQL)
+	attributes := []attribute.KeyValue{
+		semconv.DBOperation(operation),
+		attribute.String("db.query.name", name),
+		semconv.DBStatement(data.SQL),
+	}
+	if data.Err != nil {
+		attributes = append(attributes, attribute.String("db.sqlstate", sqlState(data.Err)))
+		t.recordError(operation, name, data.Err)
+	}
+	state.span.AddEvent("query", trace.WithAttributes(attributes...))
+}
+
+func (t *QueryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	t.end(ctx, pgconn.CommandTag{}, data.Err)
+}
+
+// start opens the span of a query and keeps its state in the returned context
+func (t *QueryTracer) start(ctx context.Context, conn *pgx.Conn, operation, name string, extra ...attribute.KeyValue) context.Context {
+	attributes := []attribute.KeyValue{
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperation(operation),
+		attribute.String("db.query.name", name),
+	}
+	if conn != nil {
+		config := conn.Config()
+		attributes = append(attributes,
+			semconv.DBName(config.Database),
+			semconv.DBUser(config.User),
+			semconv.ServerAddress(config.Host),
+			semconv.ServerPort(int(config.Port)),
+		)
+	}
+
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attributes, extra...)...),
+	)
+
+	return context.WithValue(ctx, queryStateKey{}, &queryState{
+		start:     time.Now(),
+		operation: operation,
+		name:      name,
+		span:      span,
+	})
+}
+
+// end closes the span of the query of ctx and records its metrics
+func (t *QueryTracer) end(ctx context.Context, tag pgconn.CommandTag, err error) {
+	state, ok := ctx.Value(queryStateKey{}).(*queryState)
+	if !ok {
+		return
+	}
+	defer state.span.End()
+
+	if t.config.Metrics != nil {
+		t.config.Metrics.RecordQueryDuration(ctx, state.operation, state.name, time.Since(state.start).Seconds())
+	}
+
+	if err != nil {
+		state.span.RecordError(err)
+		state.span.SetStatus(codes.Error, err.Error())
+		state.span.SetAttributes(attribute.String("db.sqlstate", sqlState(err)))
+		t.recordError(state.operation, state.name, err)
+		return
+	}
+	state.span.SetAttributes(attribute.Int64("db.rows_affected", tag.RowsAffected()))
+}
+
+func (t *QueryTracer) recordError(operation, name string, err error) {
+	if t.config.Metrics != nil {
+		t.config.Metrics.RecordQueryError(operation, name, sqlState(err))
+	}
+}
+
+// queryName the operation (SELECT, INSERT...) and the name of a query
+func (t *QueryTracer) queryName(sql string) (operation, name string) {
+	body := strings.TrimSpace(sql)
+
+	// leading comments: the name one wins, the others are skipped
+	for strings.HasPrefix(body, "--") {
+		line, rest, _ := strings.Cut(body, "\n")
+		if value, ok := strings.CutPrefix(line, queryNamePrefix); ok && name == "" {
+			name = strings.TrimSpace(value)
+		}
+		body = strings.TrimSpace(rest)
+	}
+
+	fields := strings.Fields(body)
+	if len(fields) == 0 {
+		return "UNKNOWN", "unknown"
+	}
+	operation = strings.ToUpper(fields[0])
+
+	if name == "" {
+		name = t.config.Queries[sql]
+	}
+	if name == "" {
+		name = deriveQueryName(operation, fields)
+	}
+	return operation, name
+}
+
+// deriveQueryName "<operation> <table>" of a query without a name, the table is the one
+// after INTO, UPDATE or the first FROM, or the function of SELECT fn(...)
+func deriveQueryName(operation string, fields []string) string {
+	table := ""
+	switch operation {
+	case "UPDATE":
+		if len(fields) > 1 {
+			table = fields[1]
+		}
+	default:
+		for i, field := range fields[:len(fields)-1] {
+			if keyword := strings.ToUpper(field); keyword == "INTO" || keyword == "FROM" {
+				table = fields[i+1]
+				break
+			}
+		}
+		if table == "" && operation == "SELECT" && len(fields) > 1 {
+			table, _, _ = strings.Cut(fields[1], "(")
+		}
+	}
+
+	table = strings.Trim(table, `"();,`)
+	if table == "" {
+		return strings.ToLower(operation)
+	}
+	// find_api_key_by_prefix($1) -> find_api_key_by_prefix
+	table, _, _ = strings.Cut(table, "(")
+	return strings.ToLower(operation) + " " + strings.ToLower(table)
+}
+
+// sqlState the SQLSTATE of a Postgres error, "other" for any other error
+func sqlState(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return "other"
+}
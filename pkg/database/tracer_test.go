@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestQueryTracer_QueryName(t *testing.T) {
+	tracer := NewQueryTracer(QueryTracerConfig{
+		Queries: map[string]string{"SELECT 1": "ping"},
+	})
+
+	tests := []struct {
+		sql       string
+		operation string
+		name      string
+	}{
+		{"\n        -- name: users.GetByID\n        SELECT id FROM users WHERE id = $1\n    ", "SELECT", "users.GetByID"},
+		{"-- tenant scoped\n-- name: users.Count\nSELECT COUNT(*) FROM users", "SELECT", "users.Count"},
+		{"SELECT 1", "SELECT", "ping"},
+		{"SELECT COUNT(*) FROM users WHERE tenant_id = $1", "SELECT", "select users"},
+		{"insert into sessions (id) values ($1)", "INSERT", "insert sessions"},
+		{"UPDATE api_keys SET revoked_at = $1", "UPDATE", "update api_keys"},
+		{"DELETE FROM users WHERE id = $1", "DELETE", "delete users"},
+		{"SELECT set_config('app.tenant_id', $1, true)", "SELECT", "select set_config"},
+		{"SELECT id FROM find_api_key_by_prefix($1)", "SELECT", "select find_api_key_by_prefix"},
+		{"BEGIN", "BEGIN", "begin"},
+		{"", "UNKNOWN", "unknown"},
+	}
+
+	for _, tt := range tests {
+		operation, name := tracer.queryName(tt.sql)
+		assert.Equal(t, tt.operation, operation, tt.sql)
+		assert.Equal(t, tt.name, name, tt.sql)
+	}
+}
+
+// recordedQueries a QueryRecorder in memory
+type recordedQueries struct {
+	mu        sync.Mutex
+	durations map[string]int
+	errors    map[string]int
+}
+
+func (r *recordedQueries) RecordQueryDuration(_ context.Context, operation, query string, _ float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.durations[operation+" "+query]++
+}
+
+func (r *recordedQueries) RecordQueryError(_, query, sqlstate string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors[query+" "+sqlstate]++
+}
+
+func TestQueryTracer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	spans := tracetest.NewSpanRecorder()
+	recorder := &recordedQueries{durations: map[string]int{}, errors: map[string]int{}}
+	tracer := NewQueryTracer(QueryTracerConfig{
+		Metrics:        recorder,
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+	})
+
+	config, err := pgxpool.ParseConfig(dsn)
+	require.NoError(t, err)
+	WithTracer(tracer)(config)
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	// child of the request span
+	ctx, parent := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "GET /users")
+	defer parent.End()
+
+	var one int
+	require.NoError(t, pool.QueryRow(ctx, "-- name: test.One\nSELECT 1").Scan(&one))
+	_, err = pool.Exec(ctx, "SELECT 1/0")
+	require.Error(t, err)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 2)
+	assert.Equal(t, "test.One", ended[0].Name())
+	assert.Equal(t, trace.SpanKindClient, ended[0].SpanKind())
+	assert.Equal(t, parent.SpanContext().TraceID(), ended[0].SpanContext().TraceID())
+	assert.Equal(t, codes.Error, ended[1].Status().Code)
+
+	assert.Equal(t, map[string]int{"SELECT test.One": 1, "SELECT select 1/0": 1}, recorder.durations)
+	assert.Equal(t, map[string]int{"select 1/0 22012": 1}, recorder.errors, "division_by_zero SQLSTATE")
+}
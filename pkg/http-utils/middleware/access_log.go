@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type AccessLogConfig struct {
+	// Next skips the middleware when it returns true
+	Next func(c *fiber.Ctx) bool
+	// Logger required
+	Logger *logger.Logger
+	// SampleRate fraction (0..1) of the successful (< 400) requests logged, 0 logs none
+	SampleRate float64
+	// SlowThreshold requests slower than this are always logged (warn), 0 disables it
+	SlowThreshold time.Duration
+	// ExcludePaths never logged (exact match), e.g. /metrics, /health and /ready
+	ExcludePaths []string
+}
+
+// AccessLog is a Fiber middleware that writes one entry per request with its route
+// template, status, latency and sizes, the request id (see RequestID) and the trace id of
+// the active span. Client errors are logged as warn, server errors as error, both always,
+// successful requests are sampled
+//
+// Errors returned by the handlers are passed to the app error handler here, so the logged
+// status is the one sent to the client, register it after RequestID and before recover
+//
+// Usage:
+//
+//	app.Use(middleware.AccessLog(middleware.AccessLogConfig{
+//		Logger:        log.WithComponent("http"),
+//		SampleRate:    0.1,
+//		SlowThreshold: time.Second,
+//		ExcludePaths:  []string{"/metrics", "/health", "/ready"},
+//	}))
+func AccessLog(config AccessLogConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if config.Next != nil && config.Next(c) {
+			return c.Next()
+		}
+		if slices.Contains(config.ExcludePaths, c.Path()) {
+			return c.Next()
+		}
+
+		start := time.Now()
+
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		latency := time.Since(start)
+		status := c.Response().StatusCode()
+		slow := config.SlowThreshold > 0 && latency >= config.SlowThreshold
+
+		if status < fiber.StatusBadRequest && !slow && !sampled(config.SampleRate) {
+			return nil
+		}
+
+		fields := []zap.Field{
+			zap.String("method", c.Method()),
+			zap.String("route", routePath(c)),
+			zap.String("path", c.Path()),
+			zap.Int("status", status),
+			zap.Duration("latency", latency),
+			zap.Int("request_size", len(c.Request().Body())),
+			zap.Int("response_size", len(c.Response().Body())),
+			zap.String("client_ip", c.IP()),
+			zap.String("user_agent", c.Get(fiber.HeaderUserAgent)),
+		}
+		if tenantID, ok := tenant.FromContext(c.UserContext()); ok {
+			fields = append(fields, zap.String("tenant_id", tenantID))
+		}
+
+		// request_id, trace_id and span_id
+		log := withRequestID(c, config.Logger)
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			log.Error("request failed", fields...)
+		case status >= fiber.StatusBadRequest:
+			log.Warn("request rejected", fields...)
+		case slow:
+			log.Warn("slow request", fields...)
+		default:
+			log.Info("request", fields...)
+		}
+
+		return nil
+	}
+}
+
+// sampled reports whether a successful request is logged with the given rate
+func sampled(rate float64) bool {
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
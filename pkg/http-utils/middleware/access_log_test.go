@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLog(t *testing.T) {
+	newApp := func(config AccessLogConfig) (*fiber.App, *observer.ObservedLogs) {
+		core, logs := observer.New(zap.DebugLevel)
+		config.Logger = &logger.Logger{Logger: zap.New(core)}
+
+		app := fiber.New()
+		app.Use(RequestID(RequestIDConfig{}))
+		app.Use(AccessLog(config))
+		app.Get("/users/:id", func(c *fiber.Ctx) error {
+			switch c.Params("id") {
+			case "missing":
+				return fiber.NewError(fiber.StatusNotFound, "user not found")
+			case "broken":
+				return c.Status(fiber.StatusInternalServerError).SendString("broken")
+			case "slow":
+				time.Sleep(20 * time.Millisecond)
+			}
+			return c.SendString("ok")
+		})
+		app.Get("/health", func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusInternalServerError)
+		})
+		return app, logs
+	}
+
+	request := func(t *testing.T, app *fiber.App, path string) int {
+		t.Helper()
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set(RequestIDHeader, "req-1")
+		req.Header.Set(fiber.HeaderUserAgent, "test-agent")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("one entry per request", func(t *testing.T) {
+		app, logs := newApp(AccessLogConfig{SampleRate: 1})
+		request(t, app, "/users/42")
+
+		require.Equal(t, 1, logs.Len())
+		entry := logs.All()[0]
+		assert.Equal(t, zapcore.InfoLevel, entry.Level)
+		fields := entry.ContextMap()
+		assert.Equal(t, "GET", fields["method"])
+		assert.Equal(t, "/users/:id", fields["route"], "route template, not the path")
+		assert.Equal(t, int64(200), fields["status"])
+		assert.Equal(t, int64(2), fields["response_size"])
+		assert.Equal(t, "test-agent", fields["user_agent"])
+		assert.Equal(t, "req-1", fields["request_id"])
+		assert.Contains(t, fields, "latency")
+		assert.Contains(t, fields, "client_ip")
+	})
+
+	t.Run("errors are always logged with the status sent", func(t *testing.T) {
+		app, logs := newApp(AccessLogConfig{SampleRate: 0})
+		assert.Equal(t, fiber.StatusOK, request(t, app, "/users/42"))
+		assert.Equal(t, fiber.StatusNotFound, request(t, app, "/users/missing"))
+		assert.Equal(t, fiber.StatusInternalServerError, request(t, app, "/users/broken"))
+
+		require.Equal(t, 2, logs.Len(), "successful request not sampled")
+		assert.Equal(t, zapcore.WarnLevel, logs.All()[0].Level)
+		assert.Equal(t, int64(404), logs.All()[0].ContextMap()["status"])
+		assert.Equal(t, zapcore.ErrorLevel, logs.All()[1].Level)
+	})
+
+	t.Run("slow requests are always logged", func(t *testing.T) {
+		app, logs := newApp(AccessLogConfig{SlowThreshold: 10 * time.Millisecond})
+		request(t, app, "/users/42")
+		request(t, app, "/users/slow")
+
+		require.Equal(t, 1, logs.Len())
+		assert.Equal(t, "slow request", logs.All()[0].Message)
+	})
+
+	t.Run("excluded paths", func(t *testing.T) {
+		app, logs := newApp(AccessLogConfig{SampleRate: 1, ExcludePaths: []string{"/health"}})
+		request(t, app, "/health")
+		assert.Equal(t, 0, logs.Len())
+	})
+}
+
+func TestAccessLog_TraceID(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+	app := fiber.New()
+	app.Use(AccessLog(AccessLogConfig{Logger: &logger.Logger{Logger: zap.New(core)}, SampleRate: 1}))
+	// what the tracing middleware does, the span context is read after the handlers
+	app.Use(func(c *fiber.Ctx) error {
+		span := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}})
+		c.SetUserContext(trace.ContextWithSpanContext(c.UserContext(), span))
+		return c.Next()
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, traceID.String(), logs.All()[0].ContextMap()["trace_id"])
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// APIKeyAuthenticator validates a raw API key, implemented by the module owning the keys
+type APIKeyAuthenticator = auth.APIKeyAuthenticator
+
+// SessionValidator checks the session of a user token was not revoked, implemented by
+// the module owning the sessions
+type SessionValidator = auth.SessionValidator
+
+type AuthConfig struct {
+	// Next skips the middleware when it returns true (public routes like login), optional
+	Next func(c *fiber.Ctx) bool
+	// APIKeys validates API keys, nil disables them
+	APIKeys APIKeyAuthenticator
+	// APIKeyHeader header carrying the API key, default X-API-Key
+	APIKeyHeader string
+	// APIKeyPrefix tells API keys apart from user tokens in the Authorization header
+	// (Bearer <api key>), empty accepts API keys only in APIKeyHeader
+	APIKeyPrefix string
+	// JWTSecret HS256 secret of the user tokens, empty disables them
+	JWTSecret []byte
+	// TenantClaim user token claim with the tenant, default "tenant"
+	TenantClaim string
+	// Sessions validates the session (sid claim) of the user tokens, tokens without a
+	// session are rejected, nil accepts every token until it expires
+	Sessions SessionValidator
+	// Logger records the authentication failures, optional
+	Logger *logger.Logger
+}
+
+// Authenticate is a Fiber middleware that accepts either an API key or a user token (JWT),
+// the principal is stored in c.Locals(auth.LocalsKey) and in c.UserContext(), the token
+// claims in c.Locals(ClaimsLocalsKey) so TenantFromClaim can read them
+// requests without valid credentials get 401
+//
+// Usage:
+//
+//	app.Use(middleware.Authenticate(middleware.AuthConfig{
+//		APIKeys:   apiKeyService,
+//		JWTSecret: []byte(cfg.Security.JWTSecret),
+//	}))
+func Authenticate(config AuthConfig) fiber.Handler {
+	if config.APIKeyHeader == "" {
+		config.APIKeyHeader = "X-API-Key"
+	}
+	if config.TenantClaim == "" {
+		config.TenantClaim = "tenant"
+	}
+
+	return func(c *fiber.Ctx) error {
+		if config.Next != nil && config.Next(c) {
+			return c.Next()
+		}
+
+		principal, claims, err := authenticate(c, config)
+		if err != nil {
+			if config.Logger != nil {
+				withRequestID(c, config.Logger).Warn("authentication failed",
+					zap.String("method", c.Method()),
+					zap.String("path", c.Path()),
+					zap.Error(err),
+				)
+			}
+			message := err.Error()
+			if errors.Is(err, auth.ErrInvalidSession) {
+				// the cause may be internal (database), it is logged only
+				message = auth.ErrInvalidSession.Error()
+			}
+			c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return errorJSON(c, fiber.StatusUnauthorized, fiber.Map{
+				"error":   "unauthorized",
+				"message": message,
+			})
+		}
+
+		if config.Logger != nil {
+			config.Logger.Debug("request authenticated",
+				zap.String("request_id", RequestIDFrom(c)),
+				zap.String("principal_type", string(principal.Type)),
+				zap.String("principal_id", principal.ID),
+				zap.String("path", c.Path()),
+			)
+		}
+
+		c.Locals(auth.LocalsKey, principal)
+		c.Locals(ClaimsLocalsKey, claims)
+		c.SetUserContext(auth.WithPrincipal(c.UserContext(), principal))
+
+		return c.Next()
+	}
+}
+
+// RequireScope rejects (403) principals without any of the scopes, must run after Authenticate
+func RequireScope(scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := auth.FromContext(c.UserContext())
+		if !ok {
+			return errorJSON(c, fiber.StatusUnauthorized, fiber.Map{
+				"error":   "unauthorized",
+				"message": "Authentication required",
+			})
+		}
+
+		for _, scope := range scopes {
+			if principal.HasScope(scope) {
+				return c.Next()
+			}
+		}
+
+		return errorJSON(c, fiber.StatusForbidden, fiber.Map{
+			"error":   "forbidden",
+			"message": "Missing scope: " + strings.Join(scopes, " or "),
+		})
+	}
+}
+
+// ScopeByMethod requires readScope for safe methods (GET, HEAD, OPTIONS) and writeScope
+// for the rest, write implies read
+func ScopeByMethod(readScope, writeScope string) fiber.Handler {
+	read := RequireScope(readScope, writeScope)
+	write := RequireScope(writeScope)
+
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return read(c)
+		default:
+			return write(c)
+		}
+	}
+}
+
+// authenticate finds the credential of the request and validates it
+func authenticate(c *fiber.Ctx, config AuthConfig) (*auth.Principal, map[string]any, error) {
+	token := ""
+	if scheme, value, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		token = strings.TrimSpace(value)
+	}
+
+	return config.authenticator().Authenticate(c.UserContext(), c.Get(config.APIKeyHeader), token)
+}
+
+func (config AuthConfig) authenticator() auth.Authenticator {
+	return auth.Authenticator{
+		APIKeys:      config.APIKeys,
+		APIKeyPrefix: config.APIKeyPrefix,
+		JWTSecret:    config.JWTSecret,
+		TenantClaim:  config.TenantClaim,
+		Sessions:     config.Sessions,
+	}
+}
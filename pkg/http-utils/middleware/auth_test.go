@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testJWTSecret = []byte("test-secret")
+
+// staticAPIKeys accepts a single key
+type staticAPIKeys struct {
+	key       string
+	principal *auth.Principal
+}
+
+func (s staticAPIKeys) AuthenticateAPIKey(_ context.Context, key string) (*auth.Principal, error) {
+	if key != s.key {
+		return nil, errors.New("unknown key")
+	}
+	return s.principal, nil
+}
+
+// newAuthApp returns an app that echoes the principal and the claims
+func newAuthApp(after ...fiber.Handler) *fiber.App {
+	app := fiber.New()
+	app.Use(Authenticate(AuthConfig{
+		APIKeys: staticAPIKeys{
+			key: "fk_abc_secret",
+			principal: &auth.Principal{
+				Type:     auth.PrincipalAPIKey,
+				ID:       "key-1",
+				TenantID: "acme",
+				Scopes:   []string{"users:read"},
+			},
+		},
+		APIKeyPrefix: "fk_",
+		JWTSecret:    testJWTSecret,
+	}))
+	for _, h := range after {
+		app.Use(h)
+	}
+	app.All("/", func(c *fiber.Ctx) error {
+		principal, _ := auth.FromContext(c.UserContext())
+		claims, _ := c.Locals(ClaimsLocalsKey).(map[string]any)
+		return c.JSON(fiber.Map{
+			"type":   principal.Type,
+			"id":     principal.ID,
+			"tenant": principal.TenantID,
+			"claim":  claims["tenant"],
+		})
+	})
+	return app
+}
+
+func signToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testJWTSecret)
+	require.NoError(t, err)
+	return token
+}
+
+func doAuthRequest(t *testing.T, app *fiber.App, method string, headers map[string]string) (int, map[string]any) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, "/", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	var body map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	app := newAuthApp()
+
+	for name, headers := range map[string]map[string]string{
+		"header": {"X-API-Key": "fk_abc_secret"},
+		"bearer": {"Authorization": "Bearer fk_abc_secret"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			status, body := doAuthRequest(t, app, "GET", headers)
+
+			assert.Equal(t, fiber.StatusOK, status)
+			assert.Equal(t, "api_key", body["type"])
+			assert.Equal(t, "key-1", body["id"])
+			assert.Equal(t, "acme", body["claim"], "claims expose the tenant to TenantFromClaim")
+		})
+	}
+
+	status, body := doAuthRequest(t, app, "GET", map[string]string{"X-API-Key": "fk_abc_wrong"})
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Equal(t, "unauthorized", body["error"])
+}
+
+func TestAuthenticate_UserToken(t *testing.T) {
+	app := newAuthApp()
+
+	token := signToken(t, jwt.MapClaims{
+		"sub":    "user-1",
+		"tenant": "globex",
+		"scope":  "users:read users:write",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	})
+	status, body := doAuthRequest(t, app, "GET", map[string]string{"Authorization": "Bearer " + token})
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "user", body["type"])
+	assert.Equal(t, "user-1", body["id"])
+	assert.Equal(t, "globex", body["tenant"])
+
+	tests := map[string]string{
+		"expired":    signToken(t, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(-time.Minute).Unix()}),
+		"no expiry":  signToken(t, jwt.MapClaims{"sub": "user-1"}),
+		"no subject": signToken(t, jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()}),
+		"challenge": signToken(t, jwt.MapClaims{
+			"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix(), auth.TokenUseClaim: auth.TokenUseChallenge,
+		}),
+		"bad signature": func() string {
+			token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix(),
+			}).SignedString([]byte("other-secret"))
+			return token
+		}(),
+		"alg none": func() string {
+			token, _ := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+				"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix(),
+			}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return token
+		}(),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			status, _ := doAuthRequest(t, app, "GET", map[string]string{"Authorization": "Bearer " + token})
+			assert.Equal(t, fiber.StatusUnauthorized, status)
+		})
+	}
+}
+
+// revokedSessions rejects the sessions in the set
+type revokedSessions map[string]bool
+
+func (r revokedSessions) ValidateSession(_ context.Context, p *auth.Principal) error {
+	if r[p.SessionID] {
+		return errors.New("session revoked")
+	}
+	return nil
+}
+
+func TestAuthenticate_Sessions(t *testing.T) {
+	app := fiber.New()
+	app.Use(Authenticate(AuthConfig{
+		JWTSecret: testJWTSecret,
+		Sessions:  revokedSessions{"revoked": true},
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		principal, _ := auth.FromContext(c.UserContext())
+		return c.JSON(fiber.Map{"sid": principal.SessionID})
+	})
+
+	tokenFor := func(sid string) map[string]string {
+		claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+		if sid != "" {
+			claims["sid"] = sid
+		}
+		return map[string]string{"Authorization": "Bearer " + signToken(t, claims)}
+	}
+
+	status, body := doAuthRequest(t, app, "GET", tokenFor("active"))
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "active", body["sid"])
+
+	status, body = doAuthRequest(t, app, "GET", tokenFor("revoked"))
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	assert.Equal(t, "invalid or revoked session", body["message"])
+
+	status, _ = doAuthRequest(t, app, "GET", tokenFor(""))
+	assert.Equal(t, fiber.StatusUnauthorized, status, "tokens without a session")
+}
+
+func TestAuthenticate_MissingCredentials(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	resp, err := newAuthApp().Test(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "Bearer", resp.Header.Get(fiber.HeaderWWWAuthenticate))
+}
+
+func TestAuthenticate_Next(t *testing.T) {
+	app := fiber.New()
+	app.Use(Authenticate(AuthConfig{
+		JWTSecret: testJWTSecret,
+		Next: func(c *fiber.Ctx) bool {
+			return c.Path() == "/login"
+		},
+	}))
+	app.All("/*", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	status, _ := doAuthRequest(t, app, "GET", nil)
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestScopeByMethod(t *testing.T) {
+	app := newAuthApp(ScopeByMethod("users:read", "users:write"))
+	readOnly := map[string]string{"X-API-Key": "fk_abc_secret"}
+
+	status, _ := doAuthRequest(t, app, "GET", readOnly)
+	assert.Equal(t, fiber.StatusOK, status)
+
+	status, body := doAuthRequest(t, app, "POST", readOnly)
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Equal(t, "forbidden", body["error"])
+
+	writer := signToken(t, jwt.MapClaims{
+		"sub":   "user-1",
+		"scope": "users:write",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	status, _ = doAuthRequest(t, app, "GET", map[string]string{"Authorization": "Bearer " + writer})
+	assert.Equal(t, fiber.StatusOK, status, "write implies read")
+}
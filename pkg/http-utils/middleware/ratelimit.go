@@ -0,0 +1,314 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// RateLimitAlgorithm decides how the requests of a window are spread
+type RateLimitAlgorithm string
+
+const (
+	// TokenBucket allows bursts of up to Requests, refilled evenly over the Window
+	TokenBucket RateLimitAlgorithm = "token_bucket"
+	// SlidingWindow weights the count of the previous window by its overlap with the
+	// last Window, no burst at the window boundaries
+	SlidingWindow RateLimitAlgorithm = "sliding_window"
+)
+
+// ParseRateLimitAlgorithm converts a config value into a RateLimitAlgorithm
+func ParseRateLimitAlgorithm(s string) (RateLimitAlgorithm, error) {
+	switch algorithm := RateLimitAlgorithm(s); algorithm {
+	case TokenBucket, SlidingWindow:
+		return algorithm, nil
+	default:
+		return "", fmt.Errorf("unknown rate limit algorithm %q", s)
+	}
+}
+
+// RateLimit allows Requests per Window and key
+type RateLimit struct {
+	Requests  int
+	Window    time.Duration
+	Algorithm RateLimitAlgorithm
+}
+
+// RateLimitState what the stores persist per key, its meaning depends on the algorithm
+type RateLimitState struct {
+	// Count tokens left (token bucket) or requests of the current window (sliding window)
+	Count float64
+	// Previous requests of the previous window (sliding window)
+	Previous float64
+	// Stamp last refill (token bucket) or start of the current window (sliding window)
+	Stamp time.Time
+}
+
+// RateLimitResult outcome of a request, feeds the RateLimit-* headers
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset time until the quota is fully available again
+	Reset time.Duration
+	// RetryAfter time until the next request is allowed, zero when Allowed
+	RetryAfter time.Duration
+}
+
+// Take applies one request to the state of a key, found is false for unknown (or expired)
+// keys, stores call it while holding the key so it runs once per request
+func (l RateLimit) Take(state RateLimitState, found bool, now time.Time) (RateLimitState, RateLimitResult) {
+	if l.Algorithm == SlidingWindow {
+		return l.slidingWindow(state, found, now)
+	}
+	return l.tokenBucket(state, found, now)
+}
+
+// TTL how long the state of an idle key matters, after that it is as if never seen
+func (l RateLimit) TTL() time.Duration {
+	return 2 * l.Window
+}
+
+func (l RateLimit) tokenBucket(state RateLimitState, found bool, now time.Time) (RateLimitState, RateLimitResult) {
+	capacity := float64(l.Requests)
+	rate := capacity / l.Window.Seconds() // tokens per second
+
+	tokens := capacity
+	if found {
+		elapsed := max(now.Sub(state.Stamp).Seconds(), 0)
+		tokens = min(capacity, state.Count+elapsed*rate)
+	}
+
+	result := RateLimitResult{Allowed: tokens >= 1}
+	if result.Allowed {
+		tokens--
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	result.Remaining = int(tokens)
+	result.Reset = seconds((capacity - tokens) / rate)
+
+	return RateLimitState{Count: tokens, Stamp: now}, result
+}
+
+func (l RateLimit) slidingWindow(state RateLimitState, found bool, now time.Time) (RateLimitState, RateLimitResult) {
+	start := now.Truncate(l.Window)
+	switch {
+	case !found || start.Sub(state.Stamp) > l.Window:
+		state = RateLimitState{Stamp: start}
+	case state.Stamp.Before(start):
+		state = RateLimitState{Previous: state.Count, Stamp: start}
+	}
+
+	limit := float64(l.Requests)
+	elapsed := now.Sub(start)
+	weight := 1 - elapsed.Seconds()/l.Window.Seconds()
+	estimated := state.Previous*weight + state.Count
+
+	result := RateLimitResult{
+		Allowed: estimated+1 <= limit,
+		// the previous window stops counting at the end of the current one
+		Reset: l.Window - elapsed,
+	}
+	if result.Allowed {
+		state.Count++
+		estimated++
+	} else {
+		result.RetryAfter = l.retryAfter(state, elapsed)
+	}
+	result.Remaining = max(int(limit-math.Ceil(estimated)), 0)
+
+	return state, result
+}
+
+// retryAfter time until the weighted count leaves room for one request
+func (l RateLimit) retryAfter(state RateLimitState, elapsed time.Duration) time.Duration {
+	limit := float64(l.Requests)
+	if state.Count+1 > limit {
+		// full by itself, in the next window it is the previous one:
+		// count*(1-t/window) + 1 <= limit
+		return l.Window - elapsed + seconds((1-(limit-1)/state.Count)*l.Window.Seconds())
+	}
+	// previous*(1-t/window) + count + 1 <= limit
+	at := seconds((1 - (limit-1-state.Count)/state.Previous) * l.Window.Seconds())
+	return max(at-elapsed, time.Millisecond)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// RateLimitStore keeps the state of the keys, the memory store works for a single
+// instance, PostgresRateLimitStore shares the limits between instances
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+}
+
+// RateLimitKey identifies who is limited, requests with the same key share the quota,
+// an empty key skips the limit
+type RateLimitKey func(c *fiber.Ctx) string
+
+// RateLimitByIP limits each client IP (see fiber.Config.ProxyHeader behind a proxy)
+func RateLimitByIP() RateLimitKey {
+	return func(c *fiber.Ctx) string {
+		return "ip:" + c.IP()
+	}
+}
+
+// RateLimitByPrincipal limits each authenticated principal (user or API key), anonymous
+// requests by IP, must run after Authenticate
+func RateLimitByPrincipal() RateLimitKey {
+	return func(c *fiber.Ctx) string {
+		if principal, ok := auth.FromContext(c.UserContext()); ok {
+			return string(principal.Type) + ":" + principal.ID
+		}
+		return "ip:" + c.IP()
+	}
+}
+
+// RateLimitByRoute one quota per route shared by every client, the route is the template
+// the router matched (c.Route().Path), so the limiter must be registered on the routes
+// (app.Get("/users/:id", limiter, handler)), mounted with app.Use every request would share
+// the quota of the mount prefix
+func RateLimitByRoute() RateLimitKey {
+	return func(c *fiber.Ctx) string {
+		return "route:" + c.Method() + " " + c.Route().Path
+	}
+}
+
+// RateLimitByAll combines keys, e.g. a quota per route and IP, empty if any key is empty
+func RateLimitByAll(keys ...RateLimitKey) RateLimitKey {
+	return func(c *fiber.Ctx) string {
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			if parts[i] = key(c); parts[i] == "" {
+				return ""
+			}
+		}
+		return strings.Join(parts, "|")
+	}
+}
+
+// RateLimitRecorder counts the rejected requests and the store failures, implemented by
+// metrics.Metrics
+type RateLimitRecorder interface {
+	RecordRateLimited(method, path string)
+	RecordRateLimitStoreFailure(limiter string)
+}
+
+type RateLimitConfig struct {
+	// Next skips the middleware when it returns true, optional
+	Next  func(c *fiber.Ctx) bool
+	Limit RateLimit
+	// Name tells the limiters apart in the store keys and logs, default "default"
+	Name string
+	// Key default RateLimitByIP
+	Key RateLimitKey
+	// Store default a memory store
+	Store RateLimitStore
+	// Metrics counts the rejected requests and the store failures, optional
+	Metrics RateLimitRecorder
+	// Logger records the store failures, default the logger of the request context
+	Logger *logger.Logger
+}
+
+// ErrInvalidRateLimit the limit allows nothing
+var ErrInvalidRateLimit = errors.New("rate limit requests and window must be positive")
+
+// RateLimiter is a Fiber middleware that rejects (429) the requests over the limit of
+// their key, every response carries the RateLimit-Limit, RateLimit-Remaining,
+// RateLimit-Reset and RateLimit-Policy headers (IETF draft), 429 also Retry-After
+// store failures let the request through (fail open), they are logged and counted
+// returns ErrInvalidRateLimit or an unknown algorithm error for an unusable limit
+//
+// Usage:
+//
+//	limiter, err := middleware.RateLimiter(middleware.RateLimitConfig{
+//		Limit: middleware.RateLimit{Requests: 100, Window: time.Minute, Algorithm: middleware.TokenBucket},
+//		Key:   middleware.RateLimitByPrincipal(),
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	app.Use(apiBasePath, limiter)
+func RateLimiter(config RateLimitConfig) (fiber.Handler, error) {
+	if config.Limit.Requests <= 0 || config.Limit.Window <= 0 {
+		return nil, ErrInvalidRateLimit
+	}
+	if config.Limit.Algorithm == "" {
+		config.Limit.Algorithm = TokenBucket
+	}
+	if _, err := ParseRateLimitAlgorithm(string(config.Limit.Algorithm)); err != nil {
+		return nil, err
+	}
+	if config.Name == "" {
+		config.Name = "default"
+	}
+	if config.Key == nil {
+		config.Key = RateLimitByIP()
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryRateLimitStore()
+	}
+	policy := fmt.Sprintf("%d;w=%d", config.Limit.Requests, int(math.Ceil(config.Limit.Window.Seconds())))
+
+	return func(c *fiber.Ctx) error {
+		if config.Next != nil && config.Next(c) {
+			return c.Next()
+		}
+
+		key := config.Key(c)
+		if key == "" {
+			return c.Next()
+		}
+
+		result, err := config.Store.Take(c.UserContext(), config.Name+":"+key, config.Limit, time.Now())
+		if err != nil {
+			log := logger.FromContextOr(c.UserContext(), logger.Nop())
+			if config.Logger != nil {
+				log = withRequestID(c, config.Logger)
+			}
+			log.Error("rate limit store failed, request allowed",
+				zap.String("limiter", config.Name),
+				zap.String("path", c.Path()),
+				zap.Error(err),
+			)
+			if config.Metrics != nil {
+				config.Metrics.RecordRateLimitStoreFailure(config.Name)
+			}
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(config.Limit.Requests))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		c.Set("RateLimit-Policy", policy)
+
+		if !result.Allowed {
+			// the route template when the limiter is registered on the route, the mount
+			// prefix with app.Use, never the raw path so the label stays bounded
+			if config.Metrics != nil {
+				config.Metrics.RecordRateLimited(c.Method(), c.Route().Path)
+			}
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+			return errorJSON(c, fiber.StatusTooManyRequests, fiber.Map{
+				"error":   "rate_limited",
+				"message": "Too many requests, retry later",
+			})
+		}
+
+		return c.Next()
+	}, nil
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// postgresRateLimitPurge how often a Take also deletes the expired keys
+const postgresRateLimitPurge = time.Minute
+
+// PostgresRateLimitStore shares the limits between the instances of a deployment, one row
+// per key in rate_limit_buckets (migration 000010), the row is locked while a request is
+// applied so concurrent instances never admit more than the limit
+type PostgresRateLimitStore struct {
+	db *pgxpool.Pool
+	// lastPurge unix nanoseconds of the last expired keys purge
+	lastPurge atomic.Int64
+}
+
+var _ RateLimitStore = (*PostgresRateLimitStore)(nil)
+
+func NewPostgresRateLimitStore(db *pgxpool.Pool) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{db: db}
+}
+
+func (s *PostgresRateLimitStore) Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	var result RateLimitResult
+
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		// the row must exist to be locked, stamp NULL marks it as never used
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO rate_limit_buckets (key, expires_at) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING`,
+			key, now.Add(limit.TTL()),
+		); err != nil {
+			return err
+		}
+
+		var (
+			state     RateLimitState
+			stamp     *time.Time
+			expiresAt time.Time
+		)
+		if err := tx.QueryRow(ctx,
+			`SELECT count, previous, stamp, expires_at FROM rate_limit_buckets WHERE key = $1 FOR UPDATE`,
+			key,
+		).Scan(&state.Count, &state.Previous, &stamp, &expiresAt); err != nil {
+			return err
+		}
+
+		found := stamp != nil && now.Before(expiresAt)
+		if stamp != nil {
+			state.Stamp = *stamp
+		}
+
+		state, result = limit.Take(state, found, now)
+		_, err := tx.Exec(ctx,
+			`UPDATE rate_limit_buckets SET count = $2, previous = $3, stamp = $4, expires_at = $5 WHERE key = $1`,
+			key, state.Count, state.Previous, state.Stamp, now.Add(limit.TTL()),
+		)
+		return err
+	})
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("failed to take rate limit %s: %w", key, err)
+	}
+
+	s.purgeExpired(ctx, now)
+	return result, nil
+}
+
+// purgeExpired deletes the idle keys at most once per postgresRateLimitPurge and
+// instance, failures are logged with the logger of ctx and the next purge retries
+func (s *PostgresRateLimitStore) purgeExpired(ctx context.Context, now time.Time) {
+	last := s.lastPurge.Load()
+	if now.UnixNano()-last < int64(postgresRateLimitPurge) || !s.lastPurge.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	if _, err := s.db.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE expires_at < $1`, now); err != nil {
+		logger.FromContextOr(ctx, logger.Nop()).Warn("failed to purge expired rate limit keys",
+			zap.String("component", "postgres"),
+			zap.Error(err),
+		)
+	}
+}
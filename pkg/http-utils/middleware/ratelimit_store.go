@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRateLimitStore keeps the keys in process, each instance limits on its own
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryRateLimitEntry
+	lastSweep time.Time
+}
+
+type memoryRateLimitEntry struct {
+	state     RateLimitState
+	expiresAt time.Time
+}
+
+// memoryRateLimitSweep how often idle keys are dropped
+const memoryRateLimitSweep = time.Minute
+
+var _ RateLimitStore = (*MemoryRateLimitStore)(nil)
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{entries: make(map[string]memoryRateLimitEntry)}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= memoryRateLimitSweep {
+		s.sweep(now)
+	}
+
+	entry, found := s.entries[key]
+	found = found && now.Before(entry.expiresAt)
+
+	state, result := limit.Take(entry.state, found, now)
+	s.entries[key] = memoryRateLimitEntry{state: state, expiresAt: now.Add(limit.TTL())}
+
+	return result, nil
+}
+
+// sweep drops the expired keys, bounds the memory to the active clients
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
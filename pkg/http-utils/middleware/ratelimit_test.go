@@ -0,0 +1,319 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit_TokenBucket(t *testing.T) {
+	limit := RateLimit{Requests: 3, Window: 3 * time.Second, Algorithm: TokenBucket}
+	now := time.Now()
+
+	var state RateLimitState
+	var result RateLimitResult
+	found := false
+	for i := 2; i >= 0; i-- {
+		state, result = limit.Take(state, found, now)
+		found = true
+		require.True(t, result.Allowed, "burst up to the capacity")
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	state, result = limit.Take(state, found, now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter, "one token per second")
+	assert.Equal(t, 3*time.Second, result.Reset)
+
+	// refilled one token
+	state, result = limit.Take(state, found, now.Add(time.Second))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// idle keys never get more than the capacity
+	_, result = limit.Take(state, found, now.Add(time.Hour))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestRateLimit_SlidingWindow(t *testing.T) {
+	limit := RateLimit{Requests: 4, Window: 10 * time.Second, Algorithm: SlidingWindow}
+	start := time.Now().Truncate(limit.Window)
+
+	var state RateLimitState
+	var result RateLimitResult
+	found := false
+	for i := 0; i < 4; i++ {
+		state, result = limit.Take(state, found, start.Add(8*time.Second))
+		found = true
+		require.True(t, result.Allowed)
+	}
+	state, result = limit.Take(state, found, start.Add(9*time.Second))
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.Reset)
+	// next window: 4*(1-t/10) + 1 <= 4 at t=2.5s
+	assert.Equal(t, 3500*time.Millisecond, result.RetryAfter)
+
+	// no burst at the boundary, the previous window still weights 4*0.9
+	state, result = limit.Take(state, found, start.Add(11*time.Second))
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 1500*time.Millisecond, result.RetryAfter)
+
+	state, result = limit.Take(state, found, start.Add(12500*time.Millisecond))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// two windows later nothing counts
+	_, result = limit.Take(state, found, start.Add(30*time.Second))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 3, result.Remaining)
+}
+
+// failingStore always fails
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, RateLimit, time.Time) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("database down")
+}
+
+// countingRecorder counts the rejected requests per path and the store failures per limiter
+type countingRecorder struct {
+	mu       sync.Mutex
+	limited  map[string]int
+	failures map[string]int
+}
+
+func (r *countingRecorder) RecordRateLimited(method, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limited[method+" "+path]++
+}
+
+func (r *countingRecorder) RecordRateLimitStoreFailure(limiter string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures[limiter]++
+}
+
+// newRateLimiter RateLimiter for a valid config
+func newRateLimiter(t *testing.T, config RateLimitConfig) fiber.Handler {
+	t.Helper()
+	handler, err := RateLimiter(config)
+	require.NoError(t, err)
+	return handler
+}
+
+func TestRateLimiter(t *testing.T) {
+	recorder := &countingRecorder{limited: map[string]int{}}
+	app := fiber.New()
+	app.Use("/api", newRateLimiter(t, RateLimitConfig{
+		Limit:   RateLimit{Requests: 2, Window: time.Minute},
+		Metrics: recorder,
+	}))
+	app.Get("/api/users", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for i := 1; i >= 0; i-- {
+		resp, err := app.Test(httptest.NewRequest("GET", "/api/users", nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "2", resp.Header.Get("RateLimit-Limit"))
+		assert.Equal(t, string(rune('0'+i)), resp.Header.Get("RateLimit-Remaining"))
+		assert.Equal(t, "2;w=60", resp.Header.Get("RateLimit-Policy"))
+		assert.Empty(t, resp.Header.Get(fiber.HeaderRetryAfter))
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/users", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", resp.Header.Get(fiber.HeaderRetryAfter))
+	assert.Equal(t, map[string]int{"GET /api": 1}, recorder.limited, "labelled with the mount prefix, not the raw path")
+}
+
+func TestRateLimiter_Keys(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if id := c.Get("X-User"); id != "" {
+			c.SetUserContext(auth.WithPrincipal(c.UserContext(), &auth.Principal{Type: auth.PrincipalUser, ID: id}))
+		}
+		return c.Next()
+	})
+	app.Use(newRateLimiter(t, RateLimitConfig{
+		Limit: RateLimit{Requests: 1, Window: time.Minute},
+		Key:   RateLimitByPrincipal(),
+		Next: func(c *fiber.Ctx) bool {
+			return c.Path() == "/health"
+		},
+	}))
+	app.Get("/*", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	request := func(path, user string) int {
+		t.Helper()
+		req := httptest.NewRequest("GET", path, nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, request("/users", "alice"))
+	assert.Equal(t, fiber.StatusTooManyRequests, request("/users", "alice"))
+	assert.Equal(t, fiber.StatusOK, request("/users", "bob"), "own quota per principal")
+	assert.Equal(t, fiber.StatusOK, request("/users", ""), "anonymous by IP")
+	assert.Equal(t, fiber.StatusTooManyRequests, request("/users", ""))
+	assert.Equal(t, fiber.StatusOK, request("/health", ""), "skipped")
+}
+
+func TestRateLimiter_RouteKey(t *testing.T) {
+	recorder := &countingRecorder{limited: map[string]int{}}
+	limiter := newRateLimiter(t, RateLimitConfig{
+		Limit:   RateLimit{Requests: 1, Window: time.Minute},
+		Key:     RateLimitByRoute(),
+		Metrics: recorder,
+	})
+	ok := func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	app := fiber.New()
+	app.Get("/api/users", limiter, ok)
+	app.Get("/api/users/:id", limiter, ok)
+	app.Get("/api/users/:id/sessions", limiter, ok)
+
+	request := func(path string) int {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, request("/api/users"))
+	assert.Equal(t, fiber.StatusOK, request("/api/users/1"), "own quota per route")
+	assert.Equal(t, fiber.StatusTooManyRequests, request("/api/users/2"), "parameters share the quota of the route")
+	assert.Equal(t, fiber.StatusOK, request("/api/users/1/sessions"))
+	assert.Equal(t, fiber.StatusNotFound, request("/api/unknown/1"), "no route, no limiter")
+	assert.Equal(t, map[string]int{"GET /api/users/:id": 1}, recorder.limited)
+}
+
+func TestRateLimiter_InvalidConfig(t *testing.T) {
+	_, err := RateLimiter(RateLimitConfig{Limit: RateLimit{Requests: 0, Window: time.Minute}})
+	assert.ErrorIs(t, err, ErrInvalidRateLimit)
+
+	_, err = RateLimiter(RateLimitConfig{Limit: RateLimit{Requests: 1}})
+	assert.ErrorIs(t, err, ErrInvalidRateLimit)
+
+	_, err = RateLimiter(RateLimitConfig{Limit: RateLimit{Requests: 1, Window: time.Minute, Algorithm: "leaky_bucket"}})
+	assert.Error(t, err)
+}
+
+func TestRateLimiter_FailOpen(t *testing.T) {
+	recorder := &countingRecorder{failures: map[string]int{}}
+	app := fiber.New()
+	app.Use(newRateLimiter(t, RateLimitConfig{
+		Limit:   RateLimit{Requests: 1, Window: time.Minute},
+		Store:   failingStore{},
+		Metrics: recorder,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for i := 0; i < 3; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("RateLimit-Limit"))
+	}
+	assert.Equal(t, map[string]int{"default": 3}, recorder.failures, "every failure counted")
+}
+
+func TestRateLimitStores(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		testRateLimitStore(t, NewMemoryRateLimitStore())
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("skipping integration test")
+		}
+		dsn := os.Getenv("TEST_DATABASE_URL")
+		if dsn == "" {
+			t.Skip("TEST_DATABASE_URL not set")
+		}
+		pool, err := pgxpool.New(context.Background(), dsn)
+		require.NoError(t, err)
+		t.Cleanup(pool.Close)
+
+		testRateLimitStore(t, NewPostgresRateLimitStore(pool))
+	})
+}
+
+// testRateLimitStore the behaviour every RateLimitStore must have
+func testRateLimitStore(t *testing.T, store RateLimitStore) {
+	t.Helper()
+
+	ctx := context.Background()
+	now := time.Now()
+	limit := RateLimit{Requests: 5, Window: time.Minute, Algorithm: SlidingWindow}
+
+	t.Run("concurrent requests never exceed the limit", func(t *testing.T) {
+		key := "test:" + uuid.NewString()
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		allowed := 0
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := store.Take(ctx, key, limit, now)
+				assert.NoError(t, err)
+				if result.Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, limit.Requests, allowed)
+	})
+
+	t.Run("keys are independent and expire", func(t *testing.T) {
+		first, second := "test:"+uuid.NewString(), "test:"+uuid.NewString()
+		bucket := RateLimit{Requests: 1, Window: time.Minute, Algorithm: TokenBucket}
+
+		result, err := store.Take(ctx, first, bucket, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		result, err = store.Take(ctx, first, bucket, now)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+
+		result, err = store.Take(ctx, second, bucket, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+
+		result, err = store.Take(ctx, first, bucket, now.Add(bucket.TTL()))
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining, "expired key starts full")
+	})
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader header carrying the request id, read from the request and always echoed
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDLocalsKey is where RequestID stores the id of the request (string)
+const RequestIDLocalsKey = "request_id"
+
+// LoggerLocalsKey is where RequestID stores the request-scoped *logger.Logger
+const LoggerLocalsKey = "logger"
+
+// maxRequestIDLength longer client ids are replaced, they end up in every log line
+const maxRequestIDLength = 128
+
+type RequestIDConfig struct {
+	// Header default RequestIDHeader
+	Header string
+	// Generator creates the ids of requests without a valid one, default UUID v4
+	Generator func() string
+	// Logger base of the request-scoped logger (request_id field), nil stores none
+	Logger *logger.Logger
+}
+
+// RequestID is a Fiber middleware that accepts the client request id (or generates one),
+// echoes it in the response header and stores it, and a logger with it, in c.Locals and
+// c.UserContext() so handlers, use cases and repositories log with the same id, must run
+// first so every response carries it
+//
+// Usage:
+//
+//	app.Use(middleware.RequestID(middleware.RequestIDConfig{Logger: log}))
+func RequestID(config RequestIDConfig) fiber.Handler {
+	if config.Header == "" {
+		config.Header = RequestIDHeader
+	}
+	if config.Generator == nil {
+		config.Generator = uuid.NewString
+	}
+
+	return func(c *fiber.Ctx) error {
+		id := c.Get(config.Header)
+		if !validRequestID(id) {
+			id = config.Generator()
+		}
+
+		c.Set(config.Header, id)
+		c.Locals(RequestIDLocalsKey, id)
+		ctx := logger.ContextWithRequestID(c.UserContext(), id)
+
+		if config.Logger != nil {
+			requestLog := config.Logger.WithRequestID(id)
+			c.Locals(LoggerLocalsKey, requestLog)
+			ctx = logger.NewContext(ctx, requestLog)
+		}
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
+
+// RequestIDFrom returns the id stored by RequestID, "" when the middleware did not run
+func RequestIDFrom(c *fiber.Ctx) string {
+	id, _ := c.Locals(RequestIDLocalsKey).(string)
+	return id
+}
+
+// LoggerFrom returns the request-scoped logger of c, or fallback when there is none
+func LoggerFrom(c *fiber.Ctx, fallback *logger.Logger) *logger.Logger {
+	if l, ok := c.Locals(LoggerLocalsKey).(*logger.Logger); ok {
+		return l
+	}
+	return fallback
+}
+
+// withRequestID adds the request id and the trace of the request to a component logger of
+// a middleware
+func withRequestID(c *fiber.Ctx, l *logger.Logger) *logger.Logger {
+	if id := RequestIDFrom(c); id != "" {
+		l = l.WithRequestID(id)
+	}
+	return l.Ctx(c.UserContext())
+}
+
+// validRequestID accepts ids made of letters, digits and . _ : - only, anything else could
+// forge log lines or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '_', r == ':', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// errorJSON sends an error body of the middlewares, with the request id when known so
+// clients can report it
+func errorJSON(c *fiber.Ctx, status int, body fiber.Map) error {
+	if id := RequestIDFrom(c); id != "" {
+		body["request_id"] = id
+	}
+	return c.Status(status).JSON(body)
+}
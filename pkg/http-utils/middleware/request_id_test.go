@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestID(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestID(RequestIDConfig{}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		// the same id reaches the Go context of the use cases
+		return c.SendString(RequestIDFrom(c) + " " + logger.RequestIDFromContext(c.UserContext()))
+	})
+
+	request := func(id string) (header, body string) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/", nil)
+		if id != "" {
+			req.Header.Set(RequestIDHeader, id)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		content, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.Header.Get(RequestIDHeader), string(content)
+	}
+
+	header, body := request("")
+	_, err := uuid.Parse(header)
+	assert.NoError(t, err, "generated id")
+	assert.Equal(t, header+" "+header, body)
+
+	header, body = request("lb-7f3a:1")
+	assert.Equal(t, "lb-7f3a:1", header, "client id accepted")
+	assert.Equal(t, "lb-7f3a:1 lb-7f3a:1", body)
+
+	for _, invalid := range []string{"bad id", "x\"}\n{", strings.Repeat("a", maxRequestIDLength+1)} {
+		header, _ = request(invalid)
+		assert.NotEqual(t, invalid, header)
+		_, err := uuid.Parse(header)
+		assert.NoError(t, err, "invalid id %q replaced", invalid)
+	}
+}
+
+func TestRequestID_Logger(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	base := &logger.Logger{Logger: zap.New(core)}
+
+	app := fiber.New()
+	app.Use(RequestID(RequestIDConfig{Logger: base}))
+	app.Use(Tenant(TenantConfig{Resolvers: []TenantResolver{TenantFromHeader("X-Tenant-ID")}}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		// handlers and repositories log with the same fields
+		fromCtx, ok := logger.FromContext(c.UserContext())
+		require.True(t, ok)
+		fromCtx.Info("from context")
+		LoggerFrom(c, base).Info("from locals")
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set("X-Tenant-ID", "acme")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	require.Equal(t, 2, logs.Len())
+	for _, entry := range logs.All() {
+		fields := entry.ContextMap()
+		assert.Equal(t, "req-1", fields["request_id"], entry.Message)
+		assert.Equal(t, "acme", fields["tenant_id"], entry.Message)
+	}
+
+	// middleware errors carry the id too
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "req-2")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "missing_tenant", body["error"])
+	assert.Equal(t, "req-2", body["request_id"])
+	assert.Equal(t, "req-2", resp.Header.Get(RequestIDHeader))
+}
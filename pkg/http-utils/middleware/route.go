@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// routePath the route template once the router ran (after c.Next), bounded cardinality
+// like the http metrics
+func routePath(c *fiber.Ctx) string {
+	if path := c.Route().Path; path != "" {
+		return path
+	}
+	return c.Path()
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TenantResolver extracts a tenant id from the request, returns "" when not found
+type TenantResolver func(c *fiber.Ctx) string
+
+// ClaimsLocalsKey is where authentication middlewares store the token claims (map[string]any)
+const ClaimsLocalsKey = "claims"
+
+type TenantConfig struct {
+	// Resolvers are tried in order, first non empty result wins
+	Resolvers []TenantResolver
+	// Default is used when no resolver finds a tenant, empty means the tenant is required
+	Default string
+}
+
+// TenantFromHeader reads the tenant from a request header (e.g. X-Tenant-ID)
+func TenantFromHeader(header string) TenantResolver {
+	return func(c *fiber.Ctx) string {
+		return c.Get(header)
+	}
+}
+
+// TenantFromSubdomain reads the tenant from the host, acme.factorit.io -> acme
+// when baseDomain is factorit.io, hosts outside baseDomain are ignored
+func TenantFromSubdomain(baseDomain string) TenantResolver {
+	suffix := "." + strings.TrimPrefix(strings.ToLower(baseDomain), ".")
+
+	return func(c *fiber.Ctx) string {
+		host := strings.ToLower(c.Hostname())
+		// remove port if present
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		sub := strings.TrimSuffix(host, suffix)
+
+		// only the first label, app.acme.factorit.io is not a tenant
+		if strings.Contains(sub, ".") {
+			return ""
+		}
+		return sub
+	}
+}
+
+// TenantFromClaim reads the tenant from a token claim, the claims are expected in
+// c.Locals(ClaimsLocalsKey) as map[string]any, so it must run after the auth middleware
+func TenantFromClaim(claim string) TenantResolver {
+	return func(c *fiber.Ctx) string {
+		claims, ok := c.Locals(ClaimsLocalsKey).(map[string]any)
+		if !ok {
+			return ""
+		}
+		value, _ := claims[claim].(string)
+		return value
+	}
+}
+
+// Tenant is a Fiber middleware that resolves the tenant of every request, stores it
+// in c.Locals(tenant.LocalsKey) and in c.UserContext() so use cases and repositories
+// are scoped without extra parameters, requests no resolver matches fall back to the
+// tenant of the principal (Authenticate) and then to Default
+//
+// Usage:
+//
+//	app.Use(middleware.Tenant(middleware.TenantConfig{
+//		Resolvers: []middleware.TenantResolver{middleware.TenantFromHeader("X-Tenant-ID")},
+//		Default:   tenant.DefaultID,
+//	}))
+func Tenant(config TenantConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, authenticated := auth.FromContext(c.UserContext())
+
+		id := ""
+		for _, resolve := range config.Resolvers {
+			if id = strings.ToLower(strings.TrimSpace(resolve(c))); id != "" {
+				break
+			}
+		}
+
+		// same order as tenant.UnaryServerInterceptor: the request, the credential, Default
+		if id == "" && authenticated {
+			id = principal.TenantID
+		}
+		if id == "" {
+			id = config.Default
+		}
+
+		if id == "" {
+			return errorJSON(c, fiber.StatusBadRequest, fiber.Map{
+				"error":   "missing_tenant",
+				"message": "Tenant could not be resolved from the request",
+			})
+		}
+
+		if !tenant.IsValid(id) {
+			return errorJSON(c, fiber.StatusBadRequest, fiber.Map{
+				"error":   "invalid_tenant",
+				"message": "Invalid tenant identifier",
+			})
+		}
+
+		// a credential bound to a tenant can not be used against another one
+		if authenticated && principal.TenantID != "" && principal.TenantID != id {
+			return errorJSON(c, fiber.StatusForbidden, fiber.Map{
+				"error":   "tenant_mismatch",
+				"message": "Credentials do not belong to the requested tenant",
+			})
+		}
+
+		c.Locals(tenant.LocalsKey, id)
+		ctx := tenant.WithID(c.UserContext(), id)
+		// the request logger (see RequestID) records the tenant from here on
+		if requestLog, ok := logger.FromContext(ctx); ok {
+			requestLog = requestLog.WithTenantID(id)
+			c.Locals(LoggerLocalsKey, requestLog)
+			ctx = logger.NewContext(ctx, requestLog)
+		}
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
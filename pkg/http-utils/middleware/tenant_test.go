@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTenantApp returns an app that echoes the tenant found in the user context
+func newTenantApp(config TenantConfig, before ...fiber.Handler) *fiber.App {
+	app := fiber.New()
+	for _, h := range before {
+		app.Use(h)
+	}
+	app.Use(Tenant(config))
+	app.Get("/", func(c *fiber.Ctx) error {
+		id, _ := tenant.FromContext(c.UserContext())
+		return c.JSON(fiber.Map{
+			"context": id,
+			"locals":  c.Locals(tenant.LocalsKey),
+		})
+	})
+	return app
+}
+
+func doTenantRequest(t *testing.T, app *fiber.App, host string, headers map[string]string) (int, map[string]any) {
+	t.Helper()
+
+	req := httptest.NewRequest("GET", "/", nil)
+	if host != "" {
+		req.Host = host
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var result map[string]any
+	require.NoError(t, json.Unmarshal(body, &result))
+	return resp.StatusCode, result
+}
+
+func TestTenant_FromHeader(t *testing.T) {
+	app := newTenantApp(TenantConfig{
+		Resolvers: []TenantResolver{TenantFromHeader("X-Tenant-ID")},
+	})
+
+	status, body := doTenantRequest(t, app, "", map[string]string{"X-Tenant-ID": " Acme "})
+
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "acme", body["context"])
+	assert.Equal(t, "acme", body["locals"])
+}
+
+func TestTenant_FromSubdomain(t *testing.T) {
+	app := newTenantApp(TenantConfig{
+		Resolvers: []TenantResolver{TenantFromSubdomain("factorit.io")},
+		Default:   tenant.DefaultID,
+	})
+
+	tests := []struct {
+		name     string
+		host     string
+		expected string
+	}{
+		{name: "tenant subdomain", host: "acme.factorit.io", expected: "acme"},
+		{name: "with port", host: "acme.factorit.io:8080", expected: "acme"},
+		{name: "nested subdomain", host: "app.acme.factorit.io", expected: tenant.DefaultID},
+		{name: "other domain", host: "acme.example.com", expected: tenant.DefaultID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := doTenantRequest(t, app, tt.host, nil)
+
+			assert.Equal(t, fiber.StatusOK, status)
+			assert.Equal(t, tt.expected, body["context"])
+		})
+	}
+}
+
+func TestTenant_FromClaim(t *testing.T) {
+	auth := func(c *fiber.Ctx) error {
+		c.Locals(ClaimsLocalsKey, map[string]any{"tenant": "globex"})
+		return c.Next()
+	}
+	app := newTenantApp(TenantConfig{
+		Resolvers: []TenantResolver{TenantFromClaim("tenant")},
+	}, auth)
+
+	status, body := doTenantRequest(t, app, "", nil)
+
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "globex", body["context"])
+}
+
+func TestTenant_ResolversOrder(t *testing.T) {
+	app := newTenantApp(TenantConfig{
+		Resolvers: []TenantResolver{
+			TenantFromSubdomain("factorit.io"),
+			TenantFromHeader("X-Tenant-ID"),
+		},
+	})
+
+	_, body := doTenantRequest(t, app, "acme.factorit.io", map[string]string{"X-Tenant-ID": "globex"})
+	assert.Equal(t, "acme", body["context"])
+
+	_, body = doTenantRequest(t, app, "localhost", map[string]string{"X-Tenant-ID": "globex"})
+	assert.Equal(t, "globex", body["context"])
+}
+
+func TestTenant_Errors(t *testing.T) {
+	app := newTenantApp(TenantConfig{
+		Resolvers: []TenantResolver{TenantFromHeader("X-Tenant-ID")},
+	})
+
+	status, body := doTenantRequest(t, app, "", nil)
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Equal(t, "missing_tenant", body["error"])
+
+	status, body = doTenantRequest(t, app, "", map[string]string{"X-Tenant-ID": "acme_corp!"})
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Equal(t, "invalid_tenant", body["error"])
+}
+
+func TestTenant_PrincipalMismatch(t *testing.T) {
+	authenticated := func(c *fiber.Ctx) error {
+		c.SetUserContext(auth.WithPrincipal(c.UserContext(), &auth.Principal{
+			Type:     auth.PrincipalAPIKey,
+			TenantID: "acme",
+		}))
+		return c.Next()
+	}
+	app := newTenantApp(TenantConfig{
+		Resolvers: []TenantResolver{TenantFromHeader("X-Tenant-ID")},
+	}, authenticated)
+
+	status, _ := doTenantRequest(t, app, "", map[string]string{"X-Tenant-ID": "acme"})
+	assert.Equal(t, fiber.StatusOK, status)
+
+	status, body := doTenantRequest(t, app, "", map[string]string{"X-Tenant-ID": "globex"})
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Equal(t, "tenant_mismatch", body["error"])
+}
+
+func TestTenant_FallsBackToPrincipal(t *testing.T) {
+	authenticated := func(c *fiber.Ctx) error {
+		c.SetUserContext(auth.WithPrincipal(c.UserContext(), &auth.Principal{
+			Type:     auth.PrincipalUser,
+			TenantID: "acme",
+		}))
+		return c.Next()
+	}
+	app := newTenantApp(TenantConfig{
+		Resolvers: []TenantResolver{TenantFromHeader("X-Tenant-ID")},
+		Default:   tenant.DefaultID,
+	}, authenticated)
+
+	status, body := doTenantRequest(t, app, "", nil)
+	assert.Equal(t, fiber.StatusOK, status, "no tenant_mismatch against the default")
+	assert.Equal(t, "acme", body["context"])
+
+	// anonymous requests still get the default
+	app = newTenantApp(TenantConfig{
+		Resolvers: []TenantResolver{TenantFromHeader("X-Tenant-ID")},
+		Default:   tenant.DefaultID,
+	})
+	_, body = doTenantRequest(t, app, "", nil)
+	assert.Equal(t, tenant.DefaultID, body["context"])
+}
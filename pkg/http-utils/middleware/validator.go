@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -49,25 +50,35 @@ func getValidationErrors(err error) fiber.Map {
 //
 //	app.Post("/users", middleware.ValidateBody[dto.CreateUserRequest](), handler)
 func ValidateBody[T any]() fiber.Handler {
+	return validateBody(reflect.TypeFor[T]())
+}
+
+// ValidateBodyOf same as ValidateBody for the type of sample, for routes declared as
+// data (openapi.Operation.Body)
+func ValidateBodyOf(sample any) fiber.Handler {
+	return validateBody(reflect.TypeOf(sample))
+}
+
+func validateBody(t reflect.Type) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		var data T
+		data := reflect.New(t)
 
-		if err := c.BodyParser(&data); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		if err := c.BodyParser(data.Interface()); err != nil {
+			return errorJSON(c, fiber.StatusBadRequest, fiber.Map{
 				"error":   "invalid_json",
 				"message": "Failed to parse request body",
 			})
 		}
 
-		if fieldErrors, err := ValidateStruct(data); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		if fieldErrors, err := ValidateStruct(data.Elem().Interface()); err != nil {
+			return errorJSON(c, fiber.StatusBadRequest, fiber.Map{
 				"error":   "validation_error",
 				"message": "Request validation failed",
 				"fields":  fieldErrors,
 			})
 		}
 
-		c.Locals("validated_data", data)
+		c.Locals("validated_data", data.Elem().Interface())
 		return c.Next()
 	}
 }
@@ -78,34 +89,78 @@ func ValidateBody[T any]() fiber.Handler {
 //
 //	app.Get("/users", middleware.ValidateQuery[dto.ListUsersQuery](), handler)
 func ValidateQuery[T any]() fiber.Handler {
+	return validateQuery(reflect.TypeFor[T]())
+}
+
+// ValidateQueryOf same as ValidateQuery for the type of sample, for routes declared as
+// data (openapi.Operation.Query)
+func ValidateQueryOf(sample any) fiber.Handler {
+	return validateQuery(reflect.TypeOf(sample))
+}
+
+func validateQuery(t reflect.Type) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		var query T
+		query := reflect.New(t)
 
-		if err := c.QueryParser(&query); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		if err := c.QueryParser(query.Interface()); err != nil {
+			return errorJSON(c, fiber.StatusBadRequest, fiber.Map{
 				"error":   "invalid_query",
 				"message": "Failed to parse query parameters",
 			})
 		}
+		// QueryParser leaves map fields empty
+		bindDeepObjects(c, query.Interface())
 
 		// Set defaults if available
-		if setter, ok := any(&query).(interface{ SetDefaults() }); ok {
+		if setter, ok := query.Interface().(interface{ SetDefaults() }); ok {
 			setter.SetDefaults()
 		}
 
-		if fieldErrors, err := ValidateStruct(query); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		if fieldErrors, err := ValidateStruct(query.Elem().Interface()); err != nil {
+			return errorJSON(c, fiber.StatusBadRequest, fiber.Map{
 				"error":   "validation_error",
 				"message": "Query validation failed",
 				"fields":  fieldErrors,
 			})
 		}
 
-		c.Locals("validated_query", query)
+		c.Locals("validated_query", query.Elem().Interface())
 		return c.Next()
 	}
 }
 
+// bindDeepObjects fills map[string]string fields tagged `query:"name"` from
+// OpenAPI deepObject parameters: ?name[key]=value
+func bindDeepObjects(c *fiber.Ctx, dst any) {
+	v := reflect.ValueOf(dst).Elem()
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type != reflect.TypeOf(map[string]string(nil)) {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("query"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		values := make(map[string]string)
+		prefix := name + "["
+		for key, value := range c.Queries() {
+			if strings.HasPrefix(key, prefix) && strings.HasSuffix(key, "]") {
+				values[key[len(prefix):len(key)-1]] = value
+			}
+		}
+		if len(values) > 0 {
+			v.Field(i).Set(reflect.ValueOf(values))
+		}
+	}
+}
+
 // validateUUID is a Custom validator to check if a string is a valid UUID
 func validateUUID(fl validator.FieldLevel) bool {
 	value := fl.Field().String()
@@ -124,7 +179,7 @@ func ValidateParam(paramName string, validationType string) fiber.Handler {
 		switch validationType {
 		case "uuid":
 			if _, err := uuid.Parse(value); err != nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				return errorJSON(c, fiber.StatusBadRequest, fiber.Map{
 					"error":   "validation_error",
 					"message": fmt.Sprintf("Invalid %s format", paramName),
 					"fields": fiber.Map{
@@ -134,7 +189,7 @@ func ValidateParam(paramName string, validationType string) fiber.Handler {
 			}
 		case "numeric":
 			if _, err := strconv.Atoi(value); err != nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				return errorJSON(c, fiber.StatusBadRequest, fiber.Map{
 					"error":   "validation_error",
 					"message": fmt.Sprintf("Invalid %s format", paramName),
 					"fields": fiber.Map{
@@ -234,6 +234,46 @@ func TestValidateBodyMiddleware_StopsOnValidationError(t *testing.T) {
 // TESTS: ValidateQuery Middleware
 // ==============================================
 
+func TestValidateBodyOf(t *testing.T) {
+	app := fiber.New()
+	app.Post("/test", ValidateBodyOf(TestCreateRequest{}), func(c *fiber.Ctx) error {
+		req, ok := c.Locals("validated_data").(TestCreateRequest)
+		require.True(t, ok, "same type as ValidateBody stores")
+		assert.Equal(t, "John Doe", req.Name)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	post := func(body string) int {
+		req := httptest.NewRequest("POST", "/test", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, post(`{"name":"John Doe","email":"john@example.com"}`))
+	assert.Equal(t, fiber.StatusBadRequest, post(`{"name":"J","email":"john@example.com"}`))
+	assert.Equal(t, fiber.StatusBadRequest, post(`{invalid`))
+}
+
+func TestValidateQueryOf(t *testing.T) {
+	app := fiber.New()
+	app.Get("/test", ValidateQueryOf(TestQueryRequest{}), func(c *fiber.Ctx) error {
+		query, ok := c.Locals("validated_query").(TestQueryRequest)
+		require.True(t, ok, "same type as ValidateQuery stores")
+		assert.Equal(t, 2, query.Page)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/test?page=2&page_size=20", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/test?page=0&page_size=20", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
 func TestValidateQueryMiddleware_ValidQuery(t *testing.T) {
 	app := fiber.New()
 
@@ -294,6 +334,34 @@ func TestValidateQueryMiddleware_InvalidSortBy(t *testing.T) {
 	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
 }
 
+type TestFilterQuery struct {
+	Limit  int               `query:"limit"`
+	Filter map[string]string `query:"filter" validate:"omitempty,max=2"`
+}
+
+func TestValidateQueryMiddleware_DeepObject(t *testing.T) {
+	app := fiber.New()
+
+	var got TestFilterQuery
+	app.Get("/test", ValidateQuery[TestFilterQuery](), func(c *fiber.Ctx) error {
+		got = c.Locals("validated_query").(TestFilterQuery)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/test?limit=5&filter[department]=sales&filter[level]=2&other[x]=1", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 5, got.Limit)
+	assert.Equal(t, map[string]string{"department": "sales", "level": "2"}, got.Filter)
+
+	// map rules are validated too
+	req = httptest.NewRequest("GET", "/test?filter[a]=1&filter[b]=2&filter[c]=3", nil)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
 // ==============================================
 // BENCHMARKS
 // ==============================================
@@ -0,0 +1,86 @@
+package openapi
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"path"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:embed docs.html
+var docsTemplate string
+
+// swaggerUI the swagger-ui-dist files of the docs page, vendored by make swagger-ui at the
+// version of swagger-ui/VERSION so the page loads no third party script
+//
+//go:embed swagger-ui
+var swaggerUI embed.FS
+
+// assetsPath the path the Swagger UI files are served under
+const assetsPath = "/swagger-ui"
+
+// Handler serves the generated document and the interactive docs UI
+type Handler struct {
+	spec []byte
+	docs []byte
+}
+
+// NewHandler renders the document once, the spec does not change at runtime
+func NewHandler(doc *Document, specPath string) (*Handler, error) {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("docs").Parse(docsTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs bytes.Buffer
+	if err := tmpl.Execute(&docs, map[string]string{
+		"Title":      doc.Info.Title,
+		"SpecPath":   specPath,
+		"AssetsPath": assetsPath,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		spec: spec,
+		docs: docs.Bytes(),
+	}, nil
+}
+
+// HandleSpec manages EP /openapi.json
+func (h *Handler) HandleSpec(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(h.spec)
+}
+
+// HandleDocs manages EP /docs, a Swagger UI page pointing to the spec
+func (h *Handler) HandleDocs(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(h.docs)
+}
+
+// HandleAsset manages EP /swagger-ui/*, the vendored Swagger UI files
+func (h *Handler) HandleAsset(c *fiber.Ctx) error {
+	content, err := fs.ReadFile(swaggerUI, path.Join("swagger-ui", path.Clean("/"+c.Params("*"))))
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+	c.Type(path.Ext(c.Params("*")))
+	return c.Send(content)
+}
+
+// RegisterRoutes register the spec, docs and Swagger UI routes in Fiber app
+func (h *Handler) RegisterRoutes(app *fiber.App, specPath, docsPath string) {
+	app.Get(specPath, h.HandleSpec)
+	app.Get(docsPath, h.HandleDocs)
+	app.Get(assetsPath+"/*", h.HandleAsset)
+}
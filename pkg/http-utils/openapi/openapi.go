@@ -0,0 +1,233 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version of the OpenAPI specification generated by this package
+const Version = "3.0.3"
+
+// Document is the root of an OpenAPI 3 document, only the subset of the spec
+// used by our modules is modelled
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// PathItem groups the operations of a single path, keyed by lowercase method
+type PathItem map[string]*OperationObject
+
+type OperationObject struct {
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	OperationID string               `json:"operationId,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"` // path | query | header
+	Required bool    `json:"required,omitempty"`
+	Style    string  `json:"style,omitempty"` // deepObject for map query params
+	Explode  bool    `json:"explode,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Operation describes a route the way modules declare it, with Fiber style paths
+// (/users/:id) and Go types for bodies, they are converted into OperationObject
+// when added to the Builder
+//
+// Usage in any module:
+//
+//	openapi.Operation{
+//		Method:    fiber.MethodPost,
+//		Path:      "/users",
+//		Summary:   "Create a new user",
+//		Body:      dto.CreateUserRequestDto{},
+//		Responses: map[int]any{fiber.StatusCreated: dto.UserResponseDto{}},
+//	}
+type Operation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Tags        []string
+	OperationID string
+	// PathParams maps each :param to its format (uuid, numeric...), same values as middleware.ValidateParam
+	PathParams map[string]string
+	// Query is a struct with `query` tags (e.g. dto.ListUsersQueryDto)
+	Query any
+	// Body is the JSON request body type
+	Body any
+	// Responses maps status code to body type, nil means no content
+	Responses map[int]any
+}
+
+// Builder creates a Document from the operations declared by every module
+type Builder struct {
+	doc     *Document
+	schemas *schemaRegistry
+}
+
+// New creates a new document builder
+func New(info Info) *Builder {
+	schemas := newSchemaRegistry()
+	return &Builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]*PathItem),
+			Components: Components{
+				Schemas: schemas.components,
+			},
+		},
+		schemas: schemas,
+	}
+}
+
+// Add registers operations under basePath (e.g. "/api/v1")
+func (b *Builder) Add(basePath string, operations ...Operation) *Builder {
+	for _, op := range operations {
+		path := ToOpenAPIPath(JoinPath(basePath, op.Path))
+
+		item, ok := b.doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			b.doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(op.Method)] = b.operationObject(op)
+	}
+	return b
+}
+
+// Document returns the generated document
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+func (b *Builder) operationObject(op Operation) *OperationObject {
+	obj := &OperationObject{
+		Summary:     op.Summary,
+		Tags:        op.Tags,
+		OperationID: op.OperationID,
+		Responses:   make(map[string]*Response),
+	}
+
+	// path params in declaration order
+	for _, name := range pathParamNames(op.Path) {
+		obj.Parameters = append(obj.Parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   paramSchema(op.PathParams[name]),
+		})
+	}
+
+	if op.Query != nil {
+		obj.Parameters = append(obj.Parameters, b.schemas.queryParameters(op.Query)...)
+	}
+
+	if op.Body != nil {
+		obj.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(b.schemas.schemaFor(op.Body)),
+		}
+	}
+
+	codes := make([]int, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		resp := &Response{Description: http.StatusText(code)}
+		if body := op.Responses[code]; body != nil {
+			resp.Content = jsonContent(b.schemas.schemaFor(body))
+		}
+		obj.Responses[strconv.Itoa(code)] = resp
+	}
+
+	return obj
+}
+
+// ============================================================
+// PATH HELPERS
+// ============================================================
+
+// JoinPath joins a base path and a route path the way Fiber groups do, without trailing slash
+func JoinPath(basePath, path string) string {
+	joined := strings.TrimRight(basePath, "/") + "/" + strings.TrimLeft(path, "/")
+	if len(joined) > 1 {
+		joined = strings.TrimRight(joined, "/")
+	}
+	return joined
+}
+
+// ToOpenAPIPath converts Fiber params (/users/:id) to OpenAPI templates (/users/{id})
+func ToOpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func pathParamNames(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?"))
+		}
+	}
+	return names
+}
+
+func paramSchema(format string) *Schema {
+	switch format {
+	case "uuid":
+		return &Schema{Type: "string", Format: "uuid"}
+	case "numeric":
+		return &Schema{Type: "integer"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{
+		"application/json": {Schema: schema},
+	}
+}
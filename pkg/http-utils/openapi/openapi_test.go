@@ -0,0 +1,120 @@
+package openapi
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRequest struct {
+	Name  string  `json:"name" validate:"required,min=2,max=100"`
+	Email *string `json:"email,omitempty" validate:"omitempty,email"`
+}
+
+type testResponse struct {
+	ID        string            `json:"id"`
+	Tags      []string          `json:"tags"`
+	Meta      map[string]string `json:"meta,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	Secret    string            `json:"-"`
+}
+
+type testQuery struct {
+	Limit  int               `query:"limit" validate:"omitempty,min=1,max=100"`
+	Filter map[string]string `query:"filter" validate:"omitempty,max=5,dive,max=10"`
+}
+
+func TestToOpenAPIPath(t *testing.T) {
+	assert.Equal(t, "/api/v1/users/{id}", ToOpenAPIPath("/api/v1/users/:id"))
+	assert.Equal(t, "/api/v1/users", ToOpenAPIPath("/api/v1/users"))
+}
+
+func TestJoinPath(t *testing.T) {
+	assert.Equal(t, "/api/v1/users", JoinPath("/api/v1", "/users/"))
+	assert.Equal(t, "/api/v1/users", JoinPath("/api/v1/", "users"))
+	assert.Equal(t, "/", JoinPath("/", ""))
+}
+
+func TestBuilder_Add(t *testing.T) {
+	doc := New(Info{Title: "test", Version: "1.0.0"}).Add("/api", Operation{
+		Method:     fiber.MethodPut,
+		Path:       "/items/:id",
+		PathParams: map[string]string{"id": "uuid"},
+		Query:      testQuery{},
+		Body:       testRequest{},
+		Responses: map[int]any{
+			fiber.StatusOK:        testResponse{},
+			fiber.StatusNoContent: nil,
+		},
+	}).Document()
+
+	require.Contains(t, doc.Paths, "/api/items/{id}")
+	op := (*doc.Paths["/api/items/{id}"])["put"]
+	require.NotNil(t, op)
+
+	// path param + query params
+	require.Len(t, op.Parameters, 3)
+	assert.Equal(t, "path", op.Parameters[0].In)
+	assert.Equal(t, "uuid", op.Parameters[0].Schema.Format)
+	assert.Equal(t, "limit", op.Parameters[1].Name)
+	assert.Equal(t, float64(100), *op.Parameters[1].Schema.Maximum)
+
+	// maps are deepObject params, rules after dive do not apply to the map itself
+	filter := op.Parameters[2]
+	assert.Equal(t, "deepObject", filter.Style)
+	assert.True(t, filter.Explode)
+	assert.Equal(t, "object", filter.Schema.Type)
+	assert.Equal(t, 5, *filter.Schema.MaxProperties)
+	assert.Nil(t, filter.Schema.MaxLength)
+
+	assert.Equal(t, "#/components/schemas/testRequest",
+		op.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Nil(t, op.Responses["204"].Content)
+
+	// request schema reflects validate tags
+	req := doc.Components.Schemas["testRequest"]
+	assert.Equal(t, []string{"name"}, req.Required)
+	assert.Equal(t, 2, *req.Properties["name"].MinLength)
+	assert.Equal(t, "email", req.Properties["email"].Format)
+	assert.True(t, req.Properties["email"].Nullable)
+
+	// response schema
+	resp := doc.Components.Schemas["testResponse"]
+	assert.Equal(t, "date-time", resp.Properties["created_at"].Format)
+	assert.Equal(t, "array", resp.Properties["tags"].Type)
+	assert.Equal(t, "object", resp.Properties["meta"].Type)
+	assert.NotContains(t, resp.Properties, "Secret")
+	assert.ElementsMatch(t, []string{"id", "tags", "created_at"}, resp.Required)
+}
+
+func TestHandler_Docs(t *testing.T) {
+	handler, err := NewHandler(New(Info{Title: "test", Version: "1.0.0"}).Document(), "/openapi.json")
+	require.NoError(t, err)
+
+	app := fiber.New()
+	handler.RegisterRoutes(app, "/openapi.json", "/docs")
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/docs", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "swagger-ui")
+	assert.Contains(t, string(body), "openapi.json")
+	// the Swagger UI is served by the handler itself, never from a CDN
+	assert.Contains(t, string(body), `src="/swagger-ui/swagger-ui-bundle.js"`)
+	assert.NotContains(t, string(body), "https://")
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/swagger-ui/VERSION", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/swagger-ui/missing.js", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
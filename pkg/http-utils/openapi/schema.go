@@ -0,0 +1,241 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is an OpenAPI 3.0 schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaRegistry turns Go types into schemas, named structs are stored once in
+// components/schemas and referenced with $ref
+type schemaRegistry struct {
+	components map[string]*Schema
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{components: make(map[string]*Schema)}
+}
+
+// schemaFor returns the schema for the type of v
+func (r *schemaRegistry) schemaFor(v any) *Schema {
+	return r.schemaForType(reflect.TypeOf(v))
+}
+
+func (r *schemaRegistry) schemaForType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: r.schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaForType(t.Elem())}
+	case reflect.Struct:
+		return r.structRef(t)
+	default:
+		// interface{} and friends, any JSON value
+		return &Schema{}
+	}
+}
+
+// structRef registers a named struct in components and returns a $ref to it
+func (r *schemaRegistry) structRef(t reflect.Type) *Schema {
+	name := t.Name()
+	if name == "" {
+		return r.structSchema(t)
+	}
+
+	if _, ok := r.components[name]; !ok {
+		// placeholder first, protects against recursive types
+		r.components[name] = &Schema{}
+		*r.components[name] = *r.structSchema(t)
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		prop := r.schemaForType(field.Type)
+		required := applyValidateTag(prop, field)
+		if field.Type.Kind() == reflect.Pointer && prop.Ref == "" {
+			prop.Nullable = true
+		}
+
+		schema.Properties[name] = prop
+		// without omitempty the field is always present in responses
+		if required || (!omitEmpty && field.Tag.Get("validate") == "" && field.Type.Kind() != reflect.Pointer) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+// queryParameters converts a struct with `query` tags into query parameters
+func (r *schemaRegistry) queryParameters(v any) []Parameter {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("query"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		schema := r.schemaForType(field.Type)
+		required := applyValidateTag(schema, field)
+		param := Parameter{
+			Name:     name,
+			In:       "query",
+			Required: required,
+			Schema:   schema,
+		}
+		// maps are sent as ?name[key]=value, see middleware.ValidateQuery
+		if field.Type.Kind() == reflect.Map {
+			param.Style = "deepObject"
+			param.Explode = true
+		}
+		params = append(params, param)
+	}
+
+	return params
+}
+
+// jsonName reads the json tag like encoding/json does
+func jsonName(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, false
+}
+
+// applyValidateTag maps go-playground/validator rules to schema constraints,
+// returns true when the field is required
+func applyValidateTag(schema *Schema, field reflect.StructField) bool {
+	required := false
+
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		key, value, _ := strings.Cut(rule, "=")
+
+		// rules after dive apply to the elements, not to the field
+		if key == "dive" {
+			break
+		}
+
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "uuid":
+			schema.Format = "uuid"
+		case "oneof":
+			schema.Enum = strings.Fields(value)
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			applyBound(schema, key, n)
+		}
+	}
+
+	return required
+}
+
+// applyBound min/max means length for strings, number of keys for maps and value for numbers
+func applyBound(schema *Schema, key string, n float64) {
+	if schema.Type == "object" {
+		count := int(n)
+		if key == "min" {
+			schema.MinProperties = &count
+		} else {
+			schema.MaxProperties = &count
+		}
+		return
+	}
+
+	if schema.Type == "string" {
+		length := int(n)
+		if key == "min" {
+			schema.MinLength = &length
+		} else {
+			schema.MaxLength = &length
+		}
+		return
+	}
+
+	if key == "min" {
+		schema.Minimum = &n
+	} else {
+		schema.Maximum = &n
+	}
+}
@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type contextKey struct{}
+
+type requestIDKey struct{}
+
+// NewContext stores a (request-scoped) logger in ctx, see FromContext
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored by NewContext
+func FromContext(ctx context.Context) (*Logger, bool) {
+	l, ok := ctx.Value(contextKey{}).(*Logger)
+	return l, ok && l != nil
+}
+
+// FromContextOr returns the logger of ctx or fallback, components with their own logger
+// use it to keep the request fields (request_id, tenant_id) of the current request, the
+// trace fields of the active span are added too (see Ctx)
+func FromContextOr(ctx context.Context, fallback *Logger) *Logger {
+	if l, ok := FromContext(ctx); ok {
+		return l.Ctx(ctx)
+	}
+	return fallback.Ctx(ctx)
+}
+
+// Nop a logger that discards everything, fallback of FromContextOr when there is none
+func Nop() *Logger {
+	return &Logger{Logger: zap.NewNop()}
+}
+
+// ContextWithRequestID stores the id of the request being served in ctx
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the id stored by ContextWithRequestID, "" when none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
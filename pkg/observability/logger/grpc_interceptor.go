@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor logs every unary RPC with its status code and duration
+// OK -> debug, caller errors -> warn, server errors -> error
+func UnaryServerInterceptor(log *Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		fields := []zap.Field{
+			zap.String("grpc_method", info.FullMethod),
+			zap.String("grpc_code", code.String()),
+			zap.Duration("duration", time.Since(start)),
+		}
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			fields = append(fields, zap.String("peer", p.Addr.String()))
+		}
+
+		switch code {
+		case codes.OK:
+			log.Debug("gRPC request", fields...)
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable,
+			codes.Unimplemented, codes.DeadlineExceeded:
+			log.Error("gRPC request failed", append(fields, zap.Error(err))...)
+		default:
+			log.Warn("gRPC request rejected", append(fields, zap.Error(err))...)
+		}
+
+		return resp, err
+	}
+}
@@ -56,6 +56,11 @@ func (l *Logger) WithRequestID(requestID string) *Logger {
 	return l.WithFields(zap.String("request_id", requestID))
 }
 
+// WithTenantID adds the tenant the log line belongs to
+func (l *Logger) WithTenantID(tenantID string) *Logger {
+	return l.WithFields(zap.String("tenant_id", tenantID))
+}
+
 // Sync force log flush
 func (l *Logger) Sync() error {
 	return l.Logger.Sync()
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// spanFieldKey key of the field carrying the active span to the span event core, the
+// field is skipped by the encoders so it never reaches the output
+const spanFieldKey = "otel_span"
+
+// Ctx returns the logger with the trace_id, span_id and trace_flags of the active span of
+// ctx, so a log line found in the log search leads to its trace and vice versa, ctx
+// without a span returns l unchanged
+//
+// Usage:
+//
+//	log.Ctx(ctx).Error("failed to create user", zap.Error(err))
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	span := trace.SpanFromContext(ctx)
+	spanContext := span.SpanContext()
+	if !spanContext.IsValid() {
+		return l
+	}
+
+	fields := []zap.Field{
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+		zap.String("trace_flags", spanContext.TraceFlags().String()),
+	}
+	if span.IsRecording() {
+		fields = append(fields, zap.Field{Key: spanFieldKey, Type: zapcore.SkipType, Interface: span})
+	}
+	return l.WithFields(fields...)
+}
+
+// WithSpanEvents returns the logger that also records its error entries as events of the
+// span of the entry (see Ctx), so the error is visible in the trace next to the failed
+// operation, the entry is still written as before
+func (l *Logger) WithSpanEvents() *Logger {
+	return &Logger{
+		Logger: l.Logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, NewSpanEventCore(zapcore.ErrorLevel))
+		})),
+	}
+}
+
+// spanEventCore a zapcore.Core that writes the entries as span events instead of output,
+// the span comes from the fields added by Logger.Ctx
+type spanEventCore struct {
+	zapcore.LevelEnabler
+	span   trace.Span
+	fields []zapcore.Field
+}
+
+// NewSpanEventCore creates a core that records the entries of level or above as events
+// of their span, combine it with the output core with zapcore.NewTee (see WithSpanEvents)
+func NewSpanEventCore(level zapcore.LevelEnabler) zapcore.Core {
+	return &spanEventCore{LevelEnabler: level}
+}
+
+func (c *spanEventCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &spanEventCore{
+		LevelEnabler: c.LevelEnabler,
+		span:         c.span,
+		fields:       append(slices.Clip(c.fields), fields...),
+	}
+	if span, ok := spanFromFields(fields); ok {
+		clone.span = span
+	}
+	return clone
+}
+
+func (c *spanEventCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *spanEventCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	span := c.span
+	if fromEntry, ok := spanFromFields(fields); ok {
+		span = fromEntry
+	}
+	if span == nil || !span.IsRecording() {
+		return nil
+	}
+
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	attributes := []attribute.KeyValue{
+		attribute.String("log.severity", entry.Level.String()),
+		attribute.String("log.message", entry.Message),
+	}
+	for _, key := range slices.Sorted(maps.Keys(encoder.Fields)) {
+		attributes = append(attributes, attribute.String(key, fmt.Sprint(encoder.Fields[key])))
+	}
+
+	span.AddEvent("log", trace.WithAttributes(attributes...), trace.WithTimestamp(entry.Time))
+	return nil
+}
+
+func (c *spanEventCore) Sync() error {
+	return nil
+}
+
+// spanFromFields the span of the field added by Logger.Ctx
+func spanFromFields(fields []zapcore.Field) (trace.Span, bool) {
+	for _, field := range fields {
+		if field.Key == spanFieldKey && field.Type == zapcore.SkipType {
+			span, ok := field.Interface.(trace.Span)
+			return span, ok
+		}
+	}
+	return nil, false
+}
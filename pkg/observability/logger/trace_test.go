@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger_Ctx(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	log := &Logger{Logger: zap.New(core)}
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "operation")
+	defer span.End()
+
+	log.Ctx(ctx).Info("with span")
+	log.Ctx(context.Background()).Info("without span")
+
+	require.Equal(t, 2, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, span.SpanContext().TraceID().String(), fields["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), fields["span_id"])
+	assert.Equal(t, "01", fields["trace_flags"], "sampled")
+	assert.NotContains(t, fields, spanFieldKey, "the span itself is never written")
+
+	assert.Empty(t, logs.All()[1].ContextMap())
+}
+
+func TestLogger_WithSpanEvents(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	log := (&Logger{Logger: zap.New(core)}).WithSpanEvents().WithComponent("users")
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "operation")
+
+	requestLog := log.Ctx(ctx)
+	requestLog.Info("user created")
+	requestLog.Error("failed to create user", zap.Error(errors.New("database down")))
+	span.End()
+
+	assert.Equal(t, 2, logs.Len(), "entries are still written")
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	events := spans[0].Events()
+	require.Len(t, events, 1, "only error entries")
+	assert.Equal(t, "log", events[0].Name)
+
+	attributes := attribute.NewSet(events[0].Attributes...)
+	for key, want := range map[attribute.Key]string{
+		"log.severity": "error",
+		"log.message":  "failed to create user",
+		"error":        "database down",
+		"component":    "users",
+	} {
+		value, ok := attributes.Value(key)
+		assert.True(t, ok, key)
+		assert.Equal(t, want, value.AsString(), key)
+	}
+}
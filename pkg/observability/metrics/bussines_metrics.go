@@ -4,47 +4,62 @@ import "github.com/prometheus/client_golang/prometheus"
 
 // UserMetrics contains business metrics for the Users service
 type UserMetrics struct {
-	UsersCreated    prometheus.Counter
-	UsersDeleted    prometheus.Counter
-	UsersUpdated    prometheus.Counter
-	DBQueryDuration prometheus.Histogram
+	UsersCreated prometheus.Counter
+	UsersDeleted prometheus.Counter
+	UsersUpdated prometheus.Counter
 }
 
-func NewUserMetrics(namespace string) *UserMetrics {
+// userDescriptors the metrics of NewUserMetrics, named without the business namespace
+var userDescriptors = []Descriptor{
+	{Name: "users_created_total"},
+	{Name: "users_deleted_total"},
+	{Name: "users_updated_total"},
+}
+
+// NewUserMetrics creates the users business metrics of serviceName and registers them in
+// the registry of opts (see New), the names are prefixed with the namespace of opts or the
+// service name when not given (factorit_users_created_total)
+func NewUserMetrics(serviceName string, opts ...Option) *UserMetrics {
+	o := newOptions(serviceName, nil, opts)
+	namespace := businessNamespace(o, serviceName)
+
 	m := &UserMetrics{
 		UsersCreated: prometheus.NewCounter(prometheus.CounterOpts{
-			Namespace: namespace,
-			Subsystem: "users",
-			Name:      "created_total",
-			Help:      "Total number of users created",
+			Namespace:   namespace,
+			Subsystem:   "users",
+			Name:        "created_total",
+			Help:        "Total number of users created",
+			ConstLabels: o.constLabels,
 		}),
 		UsersDeleted: prometheus.NewCounter(prometheus.CounterOpts{
-			Namespace: namespace,	
-			Subsystem: "users",
-			Name:      "deleted_total",
-			Help:      "Total number of users deleted",
+			Namespace:   namespace,
+			Subsystem:   "users",
+			Name:        "deleted_total",
+			Help:        "Total number of users deleted",
+			ConstLabels: o.constLabels,
 		}),
 		UsersUpdated: prometheus.NewCounter(prometheus.CounterOpts{
-			Namespace: namespace,
-			Subsystem: "users",
-			Name:      "updated_total",
-			Help:      "Total number of users updated",
-		}),
-		DBQueryDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
-			Namespace: namespace,
-			Subsystem: "database",
-			Name:      "query_duration_seconds",
-			Help:      "Duration of database queries in seconds",
-			Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1.0, 2.0},
+			Namespace:   namespace,
+			Subsystem:   "users",
+			Name:        "updated_total",
+			Help:        "Total number of users updated",
+			ConstLabels: o.constLabels,
 		}),
 	}
 	// Register all metrics
-	prometheus.MustRegister(
+	o.registry.MustRegister(
 		m.UsersCreated,
 		m.UsersDeleted,
 		m.UsersUpdated,
-		m.DBQueryDuration,
 	)
 
 	return m
 }
+
+// businessNamespace the namespace of the business metrics, historically the service name
+func businessNamespace(o *options, serviceName string) string {
+	if o.namespace != "" {
+		return o.namespace
+	}
+	return serviceName
+}
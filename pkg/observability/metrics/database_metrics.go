@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DatabaseMetrics contains the metrics of every database query, recorded by the pgx
+// tracer of pkg/database, query is a name from the SQL (-- name: comment or the table)
+// so cardinality stays bounded
+type DatabaseMetrics struct {
+	queryDuration *prometheus.HistogramVec
+	queryErrors   *prometheus.CounterVec
+}
+
+// databaseDescriptors the metrics of NewDatabaseMetrics, named without the namespace
+var databaseDescriptors = []Descriptor{
+	{Name: "db_query_duration_seconds", Labels: []string{"operation", "query"}},
+	{Name: "db_query_errors_total", Labels: []string{"operation", "query", "sqlstate"}},
+}
+
+// NewDatabaseMetrics creates the database metrics of serviceName and registers them in the
+// registry of opts (see New)
+func NewDatabaseMetrics(serviceName string, opts ...Option) *DatabaseMetrics {
+	o := newOptions(serviceName, []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.0}, opts)
+
+	m := &DatabaseMetrics{
+		queryDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "db_query_duration_seconds",
+				Help:        "Duration of database queries in seconds",
+				Buckets:     o.buckets,
+			},
+			[]string{"operation", "query"},
+		),
+		queryErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "db_query_errors_total",
+				Help:        "Total number of failed database queries by SQLSTATE",
+			},
+			[]string{"operation", "query", "sqlstate"},
+		),
+	}
+
+	// Register all metrics
+	o.registry.MustRegister(
+		m.queryDuration,
+		m.queryErrors,
+	)
+
+	return m
+}
+
+// RecordQueryDuration observes the duration of a query in the histogram, with the trace
+// of ctx as exemplar
+func (m *DatabaseMetrics) RecordQueryDuration(ctx context.Context, operation, query string, duration float64) {
+	observeWithExemplar(ctx, m.queryDuration.WithLabelValues(operation, query), duration)
+}
+
+// RecordQueryError records a failed query, sqlstate is the Postgres error code (23505...)
+// or "other" for connection and context errors
+func (m *DatabaseMetrics) RecordQueryError(operation, query, sqlstate string) {
+	m.queryErrors.WithLabelValues(operation, query, sqlstate).Inc()
+}
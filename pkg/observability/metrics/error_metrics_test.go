@@ -18,6 +18,7 @@ func TestErrorMetrics(t *testing.T) {
 		metrics.httpClientErrors,
 		metrics.httpServerErrors,
 		metrics.httpSlowRequests,
+		metrics.httpRateLimited,
 	)
 
 	tests := []struct {
@@ -31,7 +32,7 @@ func TestErrorMetrics(t *testing.T) {
 			statusCode:     "404",
 			expectedMetric: "http_client_errors_total",
 			action: func() {
-				metrics.RecordHTTPClientError("test-service", "GET", "/api/users", "404")
+				metrics.RecordHTTPClientError("GET", "/api/users", "404")
 			},
 		},
 		{
@@ -39,7 +40,7 @@ func TestErrorMetrics(t *testing.T) {
 			statusCode:     "500",
 			expectedMetric: "http_server_errors_total",
 			action: func() {
-				metrics.RecordHTTPServerError("test-service", "POST", "/api/users", "500")
+				metrics.RecordHTTPServerError("POST", "/api/users", "500")
 			},
 		},
 		{
@@ -47,7 +48,15 @@ func TestErrorMetrics(t *testing.T) {
 			statusCode:     "200",
 			expectedMetric: "http_slow_requests_total",
 			action: func() {
-				metrics.RecordSlowRequest("test-service", "GET", "/api/users", "1s")
+				metrics.RecordSlowRequest("GET", "/api/users", "1s")
+			},
+		},
+		{
+			name:           "should record rate limited request",
+			statusCode:     "429",
+			expectedMetric: "rate_limited_requests_total",
+			action: func() {
+				metrics.RecordRateLimited("GET", "/api/users")
 			},
 		},
 	}
@@ -101,17 +110,18 @@ func TestMetricLabels(t *testing.T) {
 	// Create metrics manually to avoid global registry conflicts
 	clientErrors := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "http_client_errors_total",
-			Help: "Total number of HTTP 4xx client errors",
+			Name:        "http_client_errors_total",
+			Help:        "Total number of HTTP 4xx client errors",
+			ConstLabels: prometheus.Labels{"service": "test-service"},
 		},
-		[]string{"service", "method", "path", "status"},
+		[]string{"method", "path", "status"},
 	)
 
 	// Register in custom registry
 	registry.MustRegister(clientErrors)
 
 	// Record an error
-	clientErrors.WithLabelValues("test-service", "GET", "/api/users/:id", "404").Inc()
+	clientErrors.WithLabelValues("GET", "/api/users/:id", "404").Inc()
 
 	// Gather metrics
 	families, err := registry.Gather()
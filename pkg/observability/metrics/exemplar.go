@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// observeWithExemplar records value in o, with the trace id of the sampled span of ctx as
+// exemplar so a latency spike in Grafana links to a trace, exemplars are only exposed in
+// the OpenMetrics format (see Handler)
+func observeWithExemplar(ctx context.Context, o prometheus.Observer, value float64) {
+	if span := trace.SpanContextFromContext(ctx); span.IsSampled() {
+		if eo, ok := o.(prometheus.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(value, prometheus.Labels{"trace_id": span.TraceID().String()})
+			return
+		}
+	}
+	o.Observe(value)
+}
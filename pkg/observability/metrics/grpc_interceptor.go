@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type GRPCMetricsConfig struct {
+	Metrics *GRPCMetrics
+	// SLO latency objective per RPC, keyed by full method name
+	// (/users.v1.UserService/GetUser), 1s for every RPC by default
+	SLO LatencyThresholds
+}
+
+// UnaryServerInterceptor is the gRPC counterpart of Middleware, records the same
+// signals for every unary RPC, info.FullMethod (/users.v1.UserService/GetUser)
+// plays the role of the route template so cardinality stays bounded
+func UnaryServerInterceptor(config GRPCMetricsConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		//1. captures start information
+		start := time.Now()
+		method := info.FullMethod
+
+		//2. increase in flight RPCs (gauge +1)
+		config.Metrics.IncActiveRequests()
+		defer config.Metrics.DecActiveRequests()
+
+		//3. register request size (summary)
+		if msg, ok := req.(proto.Message); ok {
+			config.Metrics.RecordRequestSize(method, float64(proto.Size(msg)))
+		}
+
+		//4. executes the real RPC
+		resp, err := handler(ctx, req)
+
+		//5. captures info after the handler
+		duration := time.Since(start).Seconds()
+		code := status.Code(err)
+
+		//6. register final metrics
+		config.Metrics.RecordRequest(method, code.String())
+		config.Metrics.RecordDuration(method, code.String(), duration)
+
+		if msg, ok := resp.(proto.Message); ok && err == nil {
+			config.Metrics.RecordResponseSize(method, float64(proto.Size(msg)))
+		}
+
+		// Record error metrics based on status code
+		if code != codes.OK {
+			if IsClientErrorCode(code) {
+				config.Metrics.RecordClientError(method, code.String())
+			} else {
+				config.Metrics.RecordServerError(method, code.String())
+			}
+		}
+
+		// Record slow requests against the latency objective of the RPC
+		if threshold := config.SLO.For("", method); duration > threshold.Seconds() {
+			config.Metrics.RecordSlowRequest(method, threshold.String())
+		}
+
+		return resp, err
+	}
+}
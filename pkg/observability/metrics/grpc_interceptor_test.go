@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// setupTestGRPCMetrics creates gRPC metrics without touching the default registry
+func setupTestGRPCMetrics(_ *testing.T) *GRPCMetrics {
+	return &GRPCMetrics{
+		requestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "grpc_server_handled_total"},
+			[]string{"method", "code"},
+		),
+		requestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{Name: "grpc_server_handling_seconds"},
+			[]string{"method", "code"},
+		),
+		requestSize: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{Name: "grpc_server_request_size_bytes"},
+			[]string{"method"},
+		),
+		responseSize: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{Name: "grpc_server_response_size_bytes"},
+			[]string{"method"},
+		),
+		clientErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "grpc_server_client_errors_total"},
+			[]string{"method", "code"},
+		),
+		serverErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "grpc_server_server_errors_total"},
+			[]string{"method", "code"},
+		),
+		slowRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "grpc_server_slow_requests_total"},
+			[]string{"method", "threshold"},
+		),
+		activeRequests: prometheus.NewGauge(
+			prometheus.GaugeOpts{Name: "grpc_server_active_requests"},
+		),
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	const method = "/users.v1.UserService/GetUser"
+
+	tests := []struct {
+		name         string
+		handlerErr   error
+		code         string
+		clientErrors float64
+		serverErrors float64
+	}{
+		{name: "ok", handlerErr: nil, code: "OK"},
+		{name: "not found is a client error", handlerErr: status.Error(codes.NotFound, "nf"), code: "NotFound", clientErrors: 1},
+		{name: "internal is a server error", handlerErr: status.Error(codes.Internal, "boom"), code: "Internal", serverErrors: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := setupTestGRPCMetrics(t)
+			interceptor := UnaryServerInterceptor(GRPCMetricsConfig{
+				Metrics: m,
+			})
+
+			handler := func(ctx context.Context, req any) (any, error) {
+				return &emptypb.Empty{}, tt.handlerErr
+			}
+			_, err := interceptor(context.Background(), &emptypb.Empty{},
+				&grpc.UnaryServerInfo{FullMethod: method}, handler)
+
+			if err != tt.handlerErr {
+				t.Fatalf("Expected handler error to be returned untouched, got %v", err)
+			}
+
+			if v := testutil.ToFloat64(m.requestsTotal.WithLabelValues(method, tt.code)); v != 1 {
+				t.Errorf("Expected 1 handled RPC with code %s, got %f", tt.code, v)
+			}
+			if v := testutil.ToFloat64(m.clientErrors.WithLabelValues(method, tt.code)); v != tt.clientErrors {
+				t.Errorf("Expected %f client errors, got %f", tt.clientErrors, v)
+			}
+			if v := testutil.ToFloat64(m.serverErrors.WithLabelValues(method, tt.code)); v != tt.serverErrors {
+				t.Errorf("Expected %f server errors, got %f", tt.serverErrors, v)
+			}
+			if v := testutil.ToFloat64(m.activeRequests); v != 0 {
+				t.Errorf("Expected active requests 0, got %f", v)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptorSLOThresholds(t *testing.T) {
+	const slow, fast = "/users.v1.UserService/ListUsers", "/users.v1.UserService/GetUser"
+
+	m := setupTestGRPCMetrics(t)
+	interceptor := UnaryServerInterceptor(GRPCMetricsConfig{
+		Metrics: m,
+		SLO: LatencyThresholds{
+			Default: time.Hour,
+			Routes:  map[string]time.Duration{slow: time.Millisecond},
+		},
+	})
+	handler := func(ctx context.Context, req any) (any, error) {
+		time.Sleep(5 * time.Millisecond)
+		return &emptypb.Empty{}, nil
+	}
+
+	for _, method := range []string{slow, fast} {
+		if _, err := interceptor(context.Background(), &emptypb.Empty{}, &grpc.UnaryServerInfo{FullMethod: method}, handler); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if v := testutil.ToFloat64(m.slowRequests.WithLabelValues(slow, "1ms")); v != 1 {
+		t.Errorf("Expected 1 slow RPC against its own objective, got %f", v)
+	}
+	if series := testutil.CollectAndCount(m.slowRequests); series != 1 {
+		t.Errorf("Expected only the RPC with the 1ms objective to be slow, got %d series", series)
+	}
+}
+
+func TestIsClientErrorCode(t *testing.T) {
+	if !IsClientErrorCode(codes.InvalidArgument) {
+		t.Error("InvalidArgument must be a client error")
+	}
+	if IsClientErrorCode(codes.Unavailable) {
+		t.Error("Unavailable must be a server error")
+	}
+}
@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+)
+
+// GRPCMetrics contains the gRPC server metrics, same RED signals than the HTTP ones
+// but labelled with gRPC semantics (full method + status code)
+type GRPCMetrics struct {
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	requestSize     *prometheus.SummaryVec
+	responseSize    *prometheus.SummaryVec
+
+	// Error metrics
+	clientErrors *prometheus.CounterVec // caller side codes (InvalidArgument, NotFound...)
+	serverErrors *prometheus.CounterVec // server side codes (Internal, Unavailable...)
+	slowRequests *prometheus.CounterVec // Requests exceeding SLO threshold
+
+	activeRequests prometheus.Gauge
+}
+
+// grpcDescriptors the metrics of NewGRPCMetrics, named without the namespace
+var grpcDescriptors = []Descriptor{
+	{Name: "grpc_server_handled_total", Labels: []string{"method", "code"}},
+	{Name: "grpc_server_handling_seconds", Labels: []string{"method", "code"}},
+	{Name: "grpc_server_request_size_bytes", Labels: []string{"method"}},
+	{Name: "grpc_server_response_size_bytes", Labels: []string{"method"}},
+	{Name: "grpc_server_client_errors_total", Labels: []string{"method", "code"}},
+	{Name: "grpc_server_server_errors_total", Labels: []string{"method", "code"}},
+	{Name: "grpc_server_slow_requests_total", Labels: []string{"method", "threshold"}},
+	{Name: "grpc_server_active_requests"},
+}
+
+// NewGRPCMetrics creates the gRPC server metrics of serviceName and registers them in the
+// registry of opts (see New)
+func NewGRPCMetrics(serviceName string, opts ...Option) *GRPCMetrics {
+	o := newOptions(serviceName, prometheus.DefBuckets, opts)
+
+	m := &GRPCMetrics{
+		requestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "grpc_server_handled_total",
+				Help:        "Total number of RPCs completed on the server",
+			},
+			[]string{"method", "code"},
+		),
+		requestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "grpc_server_handling_seconds",
+				Help:        "RPC handling duration in seconds",
+				Buckets:     o.buckets,
+			},
+			[]string{"method", "code"},
+		),
+		requestSize: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "grpc_server_request_size_bytes",
+				Help:        "RPC request message size in bytes",
+			},
+			[]string{"method"},
+		),
+		responseSize: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "grpc_server_response_size_bytes",
+				Help:        "RPC response message size in bytes",
+			},
+			[]string{"method"},
+		),
+		clientErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "grpc_server_client_errors_total",
+				Help:        "Total number of RPCs failed by a client side status code",
+			},
+			[]string{"method", "code"},
+		),
+		serverErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "grpc_server_server_errors_total",
+				Help:        "Total number of RPCs failed by a server side status code",
+			},
+			[]string{"method", "code"},
+		),
+		slowRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "grpc_server_slow_requests_total",
+				Help:        "Total number of RPCs exceeding SLO threshold",
+			},
+			[]string{"method", "threshold"},
+		),
+		activeRequests: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "grpc_server_active_requests",
+				Help:        "Number of RPCs currently being handled",
+			},
+		),
+	}
+
+	// Register all metrics
+	o.registry.MustRegister(
+		m.requestsTotal,
+		m.requestDuration,
+		m.requestSize,
+		m.responseSize,
+		m.clientErrors,
+		m.serverErrors,
+		m.slowRequests,
+		m.activeRequests,
+	)
+
+	return m
+}
+
+// RecordRequest increments the handled RPCs counter
+func (m *GRPCMetrics) RecordRequest(method, code string) {
+	m.requestsTotal.WithLabelValues(method, code).Inc()
+}
+
+// RecordDuration observes the RPC duration in the histogram
+func (m *GRPCMetrics) RecordDuration(method, code string, duration float64) {
+	m.requestDuration.WithLabelValues(method, code).Observe(duration)
+}
+
+// RecordRequestSize observes the request message size
+func (m *GRPCMetrics) RecordRequestSize(method string, size float64) {
+	m.requestSize.WithLabelValues(method).Observe(size)
+}
+
+// RecordResponseSize observes the response message size
+func (m *GRPCMetrics) RecordResponseSize(method string, size float64) {
+	m.responseSize.WithLabelValues(method).Observe(size)
+}
+
+// RecordClientError records an RPC failed by the caller (bad input, missing entity...)
+func (m *GRPCMetrics) RecordClientError(method, code string) {
+	m.clientErrors.WithLabelValues(method, code).Inc()
+}
+
+// RecordServerError records an RPC failed by the server
+func (m *GRPCMetrics) RecordServerError(method, code string) {
+	m.serverErrors.WithLabelValues(method, code).Inc()
+}
+
+// RecordSlowRequest records an RPC that exceeded the SLO threshold
+func (m *GRPCMetrics) RecordSlowRequest(method, threshold string) {
+	m.slowRequests.WithLabelValues(method, threshold).Inc()
+}
+
+// IncActiveRequests increase the in flight RPCs gauge in 1
+func (m *GRPCMetrics) IncActiveRequests() {
+	m.activeRequests.Inc()
+}
+
+// DecActiveRequests decrease the in flight RPCs gauge in 1
+func (m *GRPCMetrics) DecActiveRequests() {
+	m.activeRequests.Dec()
+}
+
+// IsClientErrorCode reports if a gRPC code is caused by the caller, the gRPC
+// equivalent of an HTTP 4xx, any other non OK code is a server error (5xx)
+func IsClientErrorCode(code codes.Code) bool {
+	switch code {
+	case codes.Canceled,
+		codes.InvalidArgument,
+		codes.NotFound,
+		codes.AlreadyExists,
+		codes.PermissionDenied,
+		codes.Unauthenticated,
+		codes.FailedPrecondition,
+		codes.OutOfRange,
+		codes.ResourceExhausted,
+		codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Descriptor the name and variable labels of a metric, the tables of descriptors live next
+// to the constructors of the metrics they describe and are checked against them by tests
+type Descriptor struct {
+	Name   string
+	Labels []string
+}
+
+// Inventory the metrics a service exposes, including the vectors without samples yet
+// (Gather only returns those with samples), so the rules and dashboards generated from it
+// (cmd/observgen) only reference metrics the service really exposes
+type Inventory []Descriptor
+
+// Describe the inventory of the metrics New, NewGRPCMetrics, NewDatabaseMetrics,
+// NewUserMetrics and NewRetentionMetrics create with opts, add the metrics of the other
+// collectors of the service with Add
+func Describe(serviceName string, opts ...Option) Inventory {
+	o := newOptions(serviceName, nil, opts)
+	return Inventory{}.
+		Add(o.namespace, httpDescriptors...).
+		Add(o.namespace, grpcDescriptors...).
+		Add(o.namespace, databaseDescriptors...).
+		Add(businessNamespace(o, serviceName), userDescriptors...).
+		Add(businessNamespace(o, serviceName), retentionDescriptors...)
+}
+
+// Add returns the inventory with descriptors prefixed by namespace, sorted by name
+func (i Inventory) Add(namespace string, descriptors ...Descriptor) Inventory {
+	inventory := slices.Clone(i)
+	for _, descriptor := range descriptors {
+		descriptor.Name = prometheus.BuildFQName(namespace, "", descriptor.Name)
+		inventory = append(inventory, descriptor)
+	}
+	slices.SortFunc(inventory, func(a, b Descriptor) int { return strings.Compare(a.Name, b.Name) })
+	return inventory
+}
+
+// Lookup the metric named name or, for the namespaced ones, ending in _name
+// (users_created_total finds factorit_users_created_total)
+func (i Inventory) Lookup(name string) (Descriptor, error) {
+	var found []Descriptor
+	for _, descriptor := range i {
+		if descriptor.Name == name || strings.HasSuffix(descriptor.Name, "_"+name) {
+			found = append(found, descriptor)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Descriptor{}, fmt.Errorf("metric %s is not registered", name)
+	case 1:
+		return found[0], nil
+	default:
+		return Descriptor{}, fmt.Errorf("metric %s is ambiguous, %d metrics match", name, len(found))
+	}
+}
+
+// Has reports whether a metric named name is registered
+func (i Inventory) Has(name string) bool {
+	return slices.ContainsFunc(i, func(d Descriptor) bool { return d.Name == name })
+}
+
+// SortLabels returns the inventory with the labels of every descriptor sorted, the order
+// Gathered returns them in
+func (i Inventory) SortLabels() Inventory {
+	inventory := slices.Clone(i)
+	for n := range inventory {
+		inventory[n].Labels = slices.Sorted(slices.Values(inventory[n].Labels))
+	}
+	return inventory
+}
+
+// Gathered the inventory of the metrics of registry with samples, without the labels in
+// constLabels, tests record a sample in every vector and compare it with the descriptor
+// tables (SortLabels)
+func Gathered(registry prometheus.Gatherer, constLabels prometheus.Labels) (Inventory, error) {
+	families, err := registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+	var inventory Inventory
+	for _, family := range families {
+		descriptor := Descriptor{Name: family.GetName()}
+		if metrics := family.GetMetric(); len(metrics) > 0 {
+			for _, label := range metrics[0].GetLabel() {
+				if _, ok := constLabels[label.GetName()]; !ok {
+					descriptor.Labels = append(descriptor.Labels, label.GetName())
+				}
+			}
+		}
+		inventory = append(inventory, descriptor)
+	}
+	return inventory, nil
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribe(t *testing.T) {
+	inventory := Describe("test-service", WithNamespace("acme"))
+
+	requests, err := inventory.Lookup("http_request_total")
+	require.NoError(t, err)
+	assert.Equal(t, "acme_http_request_total", requests.Name)
+	assert.Equal(t, []string{"method", "path", "status"}, requests.Labels)
+
+	created, err := inventory.Lookup("users_created_total")
+	require.NoError(t, err)
+	assert.Equal(t, "acme_users_created_total", created.Name)
+	assert.Empty(t, created.Labels)
+
+	assert.True(t, inventory.Has("acme_http_active_connections"))
+	assert.False(t, inventory.Has("http_active_connections"))
+
+	_, err = inventory.Lookup("missing_total")
+	assert.Error(t, err)
+
+	// the business metrics are named after the service without namespace
+	assert.True(t, Describe("factorit").Has("factorit_users_created_total"))
+}
+
+func TestDescribe_MatchesRegisteredMetrics(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"default":   nil,
+		"namespace": {WithNamespace("acme"), WithConstLabels(prometheus.Labels{"env": "prod"})},
+	} {
+		t.Run(name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			opts := append([]Option{WithRegistry(registry)}, opts...)
+
+			// a sample in every vector, Gather leaves out the empty ones
+			m := New("test-service", opts...)
+			m.RecordHTTPRequest("GET", "/", "200")
+			m.RecordHTTPDuration(context.Background(), "GET", "/", "200", 0.1)
+			m.RecordHTTPRequestSize("GET", "/", 1)
+			m.RecordHTTPResponseSize("GET", "/", 1)
+			m.IncActiveConnections()
+			m.RecordHTTPClientError("GET", "/", "404")
+			m.RecordHTTPServerError("GET", "/", "500")
+			m.RecordSlowRequest("GET", "/", "1s")
+			m.RecordWithinSLO("GET", "/", "1s")
+			m.RecordRateLimited("GET", "/")
+			m.RecordRateLimitStoreFailure("api")
+			m.RecordTenantRequest("acme", "GET", "200")
+			m.RecordAuthenticatedRequest("user", "GET", "200")
+
+			grpcMetrics := NewGRPCMetrics("test-service", opts...)
+			grpcMetrics.RecordRequest("/users.v1.UserService/GetUser", "OK")
+			grpcMetrics.RecordDuration("/users.v1.UserService/GetUser", "OK", 0.1)
+			grpcMetrics.RecordRequestSize("/users.v1.UserService/GetUser", 1)
+			grpcMetrics.RecordResponseSize("/users.v1.UserService/GetUser", 1)
+			grpcMetrics.RecordClientError("/users.v1.UserService/GetUser", "NotFound")
+			grpcMetrics.RecordServerError("/users.v1.UserService/GetUser", "Internal")
+			grpcMetrics.RecordSlowRequest("/users.v1.UserService/GetUser", "1s")
+			grpcMetrics.IncActiveRequests()
+
+			databaseMetrics := NewDatabaseMetrics("test-service", opts...)
+			databaseMetrics.RecordQueryDuration(context.Background(), "select", "users", 0.1)
+			databaseMetrics.RecordQueryError("select", "users", "23505")
+
+			users := NewUserMetrics("test-service", opts...)
+			users.UsersCreated.Inc()
+			users.UsersDeleted.Inc()
+			users.UsersUpdated.Inc()
+
+			retention := NewRetentionMetrics("test-service", opts...)
+			retention.RunDuration.WithLabelValues("success").Observe(1)
+			retention.RowsPurged.WithLabelValues("delete").Inc()
+			retention.LastSuccess.SetToCurrentTime()
+
+			gathered, err := Gathered(registry, prometheus.Labels{"service": "", "env": ""})
+			require.NoError(t, err)
+			assert.Equal(t, Describe("test-service", opts...).SortLabels(), gathered)
+		})
+	}
+}
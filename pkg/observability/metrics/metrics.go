@@ -1,11 +1,13 @@
 package metrics
 
 import (
+	"context"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-// Metrics contains all service metrics
+// Metrics contains all service metrics, build it with New
 type Metrics struct {
 	//http metrics
 	httpRequestsTotal   *prometheus.CounterVec
@@ -17,103 +19,220 @@ type Metrics struct {
 	httpClientErrors *prometheus.CounterVec // 4xx errors
 	httpServerErrors *prometheus.CounterVec // 5xx errors
 	httpSlowRequests *prometheus.CounterVec // Requests exceeding SLO threshold
+	httpWithinSLO    *prometheus.CounterVec // Requests meeting their SLO threshold
+	httpRateLimited  *prometheus.CounterVec // Requests rejected by the rate limiter (429)
+	// Requests let through because the rate limit store failed (fail open)
+	rateLimitStoreFailures *prometheus.CounterVec
+
+	// tenant metrics, kept apart from the path labelled ones to bound cardinality
+	httpTenantRequests *prometheus.CounterVec
+	// requests per authenticated principal type (user, api_key)
+	httpAuthenticatedRequests *prometheus.CounterVec
 
 	//system metrics
 	activeConnections prometheus.Gauge
+
+	// registry the metrics are registered in, served by Handler
+	registry *prometheus.Registry
+}
+
+// httpDescriptors the metrics of New, named without the namespace (see Describe)
+var httpDescriptors = []Descriptor{
+	{Name: "http_request_total", Labels: []string{"method", "path", "status"}},
+	{Name: "http_request_duration_seconds", Labels: []string{"method", "path", "status"}},
+	{Name: "http_request_size_bytes", Labels: []string{"method", "path"}},
+	{Name: "http_response_size_bytes", Labels: []string{"method", "path"}},
+	{Name: "http_active_connections"},
+	{Name: "http_client_errors_total", Labels: []string{"method", "path", "status"}},
+	{Name: "http_server_errors_total", Labels: []string{"method", "path", "status"}},
+	{Name: "http_slow_requests_total", Labels: []string{"method", "path", "threshold"}},
+	{Name: "http_requests_within_slo_total", Labels: []string{"method", "path", "threshold"}},
+	{Name: "rate_limited_requests_total", Labels: []string{"method", "path"}},
+	{Name: "rate_limit_store_failures_total", Labels: []string{"limiter"}},
+	{Name: "http_tenant_requests_total", Labels: []string{"tenant", "method", "status"}},
+	{Name: "http_authenticated_requests_total", Labels: []string{"principal_type", "method", "status"}},
 }
 
-// New creates a new instance of metrics
-func New(serviceName string) *Metrics {
+// New creates the HTTP metrics of serviceName and registers them in the registry of opts,
+// a new one when not given so calling it twice never panics, serviceName is the service
+// const label of every metric
+//
+// Usage:
+//
+//	registry := metrics.NewRegistry()
+//	m := metrics.New("factorit", metrics.WithRegistry(registry), metrics.WithConstLabels(prometheus.Labels{"env": "prod"}))
+func New(serviceName string, opts ...Option) *Metrics {
+	o := newOptions(serviceName, prometheus.DefBuckets, opts)
+	factory := promauto.With(o.registry)
+
 	return &Metrics{
+		registry: o.registry,
+
 		// counter: Total http requests
-		httpRequestsTotal: promauto.NewCounterVec(
+		httpRequestsTotal: factory.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "http_request_total",
-				Help: "Total number of HTTP requests",
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "http_request_total",
+				Help:        "Total number of HTTP requests",
 			},
-			[]string{"service", "method", "path", "status"},
+			[]string{"method", "path", "status"},
 		),
 		// Histogram: HTTP request duration
-		httpRequestDuration: promauto.NewHistogramVec(
+		httpRequestDuration: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
-				Name:    "http_request_duration_seconds",
-				Help:    "HTTP request duration in seconds",
-				Buckets: prometheus.DefBuckets, // [0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10]
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "http_request_duration_seconds",
+				Help:        "HTTP request duration in seconds",
+				Buckets:     o.buckets, // prometheus.DefBuckets unless WithBuckets
 			},
-			[]string{"service", "method", "path", "status"},
+			[]string{"method", "path", "status"},
 		),
-		httpRequestSize: promauto.NewSummaryVec(
+		httpRequestSize: factory.NewSummaryVec(
 			prometheus.SummaryOpts{
-				Name: "http_request_size_bytes",
-				Help: "HTTP request size in bytes",
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "http_request_size_bytes",
+				Help:        "HTTP request size in bytes",
 			},
-			[]string{"service", "method", "path"},
+			[]string{"method", "path"},
 		),
 
 		// Summary: response size
-		httpResponseSize: promauto.NewSummaryVec(
+		httpResponseSize: factory.NewSummaryVec(
 			prometheus.SummaryOpts{
-				Name: "http_response_size_bytes",
-				Help: "HTTP response size in bytes",
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "http_response_size_bytes",
+				Help:        "HTTP response size in bytes",
 			},
-			[]string{"service", "method", "path"},
+			[]string{"method", "path"},
 		),
 
 		// Gauge: active connections
-		activeConnections: promauto.NewGauge(
+		activeConnections: factory.NewGauge(
 			prometheus.GaugeOpts{
-				Name: "http_active_connections",
-				Help: "Number of active HTTP connections",
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "http_active_connections",
+				Help:        "Number of active HTTP connections",
 			},
 		),
 
 		// Counter: HTTP 4xx client errors
-		httpClientErrors: promauto.NewCounterVec(
+		httpClientErrors: factory.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "http_client_errors_total",
-				Help: "Total number of HTTP 4xx client errors",
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "http_client_errors_total",
+				Help:        "Total number of HTTP 4xx client errors",
 			},
-			[]string{"service", "method", "path", "status"},
+			[]string{"method", "path", "status"},
 		),
 
 		// Counter: HTTP 5xx server errors
-		httpServerErrors: promauto.NewCounterVec(
+		httpServerErrors: factory.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "http_server_errors_total",
-				Help: "Total number of HTTP 5xx server errors",
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "http_server_errors_total",
+				Help:        "Total number of HTTP 5xx server errors",
 			},
-			[]string{"service", "method", "path", "status"},
+			[]string{"method", "path", "status"},
 		),
 
 		// Counter: Slow requests (exceeding SLO threshold)
-		httpSlowRequests: promauto.NewCounterVec(
+		httpSlowRequests: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "http_slow_requests_total",
+				Help:        "Total number of HTTP requests exceeding SLO threshold",
+			},
+			[]string{"method", "path", "threshold"},
+		),
+
+		// Counter: requests meeting their latency objective, latency SLI = within / total
+		httpWithinSLO: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "http_requests_within_slo_total",
+				Help:        "Total number of HTTP requests meeting their latency SLO threshold",
+			},
+			[]string{"method", "path", "threshold"},
+		),
+
+		// Counter: requests rejected by the rate limiter
+		httpRateLimited: factory.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "http_slow_requests_total",
-				Help: "Total number of HTTP requests exceeding SLO threshold",
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "rate_limited_requests_total",
+				Help:        "Total number of HTTP requests rejected by the rate limiter",
 			},
-			[]string{"service", "method", "path", "threshold"},
+			[]string{"method", "path"},
+		),
+
+		// Counter: rate limit store failures, the requests were allowed without a limit
+		rateLimitStoreFailures: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "rate_limit_store_failures_total",
+				Help:        "Total number of requests allowed because the rate limit store failed",
+			},
+			[]string{"limiter"},
+		),
+
+		// Counter: HTTP requests per tenant
+		httpTenantRequests: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "http_tenant_requests_total",
+				Help:        "Total number of HTTP requests per tenant",
+			},
+			[]string{"tenant", "method", "status"},
+		),
+
+		// Counter: HTTP requests per principal type
+		httpAuthenticatedRequests: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   o.namespace,
+				ConstLabels: o.constLabels,
+				Name:        "http_authenticated_requests_total",
+				Help:        "Total number of authenticated HTTP requests per principal type",
+			},
+			[]string{"principal_type", "method", "status"},
 		),
 	}
 }
 
+// Registry the registry of the metrics, register the other collectors of the service in it
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
 // RecordHTTPRequest register a new HTTP request, increments total requests counter
-func (m *Metrics) RecordHTTPRequest(serviceName, method, path, status string) {
-	m.httpRequestsTotal.WithLabelValues(serviceName, method, path, status).Inc()
+func (m *Metrics) RecordHTTPRequest(method, path, status string) {
+	m.httpRequestsTotal.WithLabelValues(method, path, status).Inc()
 }
 
 // RecordHTTP register HTTP request duration, watch the value in the histogram to calculate percentiles
-func (m *Metrics) RecordHTTPDuration(serviceName, method, path, status string, duration float64) {
-	m.httpRequestDuration.WithLabelValues(serviceName, method, path, status).Observe(duration)
+func (m *Metrics) RecordHTTPDuration(ctx context.Context, method, path, status string, duration float64) {
+	observeWithExemplar(ctx, m.httpRequestDuration.WithLabelValues(method, path, status), duration)
 }
 
 // RecordHTTPRequestSize register Request size, watch the value in the symarry to calculate average
-func (m *Metrics) RecordHTTPRequestSize(serviceName, method, path string, size float64) {
-	m.httpRequestSize.WithLabelValues(serviceName, method, path).Observe(size)
+func (m *Metrics) RecordHTTPRequestSize(method, path string, size float64) {
+	m.httpRequestSize.WithLabelValues(method, path).Observe(size)
 }
 
 // RecordHTTPRequestSize register Response size, watch the value in the sumary to calculate average
-func (m *Metrics) RecordHTTPResponseSize(serviceName, method, path string, size float64) {
-	m.httpResponseSize.WithLabelValues(serviceName, method, path).Observe(size)
+func (m *Metrics) RecordHTTPResponseSize(method, path string, size float64) {
+	m.httpResponseSize.WithLabelValues(method, path).Observe(size)
 }
 
 // IncActiveConnections increments the active connections counter, increase the gauge in 1
@@ -127,16 +246,43 @@ func (m *Metrics) DecActiveConnections() {
 }
 
 // RecordHTTPClientError records a 4xx client error
-func (m *Metrics) RecordHTTPClientError(serviceName, method, path, status string) {
-	m.httpClientErrors.WithLabelValues(serviceName, method, path, status).Inc()
+func (m *Metrics) RecordHTTPClientError(method, path, status string) {
+	m.httpClientErrors.WithLabelValues(method, path, status).Inc()
 }
 
 // RecordHTTPServerError records a 5xx server error
-func (m *Metrics) RecordHTTPServerError(serviceName, method, path, status string) {
-	m.httpServerErrors.WithLabelValues(serviceName, method, path, status).Inc()
+func (m *Metrics) RecordHTTPServerError(method, path, status string) {
+	m.httpServerErrors.WithLabelValues(method, path, status).Inc()
 }
 
 // RecordSlowRequest records a request that exceeded the SLO threshold
-func (m *Metrics) RecordSlowRequest(serviceName, method, path, threshold string) {
-	m.httpSlowRequests.WithLabelValues(serviceName, method, path, threshold).Inc()
+func (m *Metrics) RecordSlowRequest(method, path, threshold string) {
+	m.httpSlowRequests.WithLabelValues(method, path, threshold).Inc()
+}
+
+// RecordWithinSLO records a request that met the latency objective of its route
+func (m *Metrics) RecordWithinSLO(method, path, threshold string) {
+	m.httpWithinSLO.WithLabelValues(method, path, threshold).Inc()
+}
+
+// RecordRateLimited records a request rejected by the rate limiter, implements
+// middleware.RateLimitRecorder
+func (m *Metrics) RecordRateLimited(method, path string) {
+	m.httpRateLimited.WithLabelValues(method, path).Inc()
+}
+
+// RecordRateLimitStoreFailure records a request allowed because the rate limit store of
+// limiter failed, implements middleware.RateLimitRecorder
+func (m *Metrics) RecordRateLimitStoreFailure(limiter string) {
+	m.rateLimitStoreFailures.WithLabelValues(limiter).Inc()
+}
+
+// RecordTenantRequest records a request of a resolved tenant
+func (m *Metrics) RecordTenantRequest(tenantID, method, status string) {
+	m.httpTenantRequests.WithLabelValues(tenantID, method, status).Inc()
+}
+
+// RecordAuthenticatedRequest records a request of an authenticated principal
+func (m *Metrics) RecordAuthenticatedRequest(principalType, method, status string) {
+	m.httpAuthenticatedRequests.WithLabelValues(principalType, method, status).Inc()
 }
@@ -13,10 +13,11 @@ type Handler struct {
 	gatherer prometheus.Gatherer
 }
 
+// NewHandler serves the registry of metrics (see New and WithRegistry)
 func NewHandler(metrics *Metrics) *Handler {
 	return &Handler{
 		metrics:  metrics,
-		gatherer: prometheus.DefaultGatherer, //production use
+		gatherer: metrics.Registry(),
 	}
 }
 
@@ -25,8 +26,11 @@ func NewHandler(metrics *Metrics) *Handler {
 func (h *Handler) HandleMetrics(c *fiber.Ctx) error {
 	//promhttp.Handler() returns http.Handler (std library)
 	// adaptor.HTTPHandler converts http.Handler to fiber.Handler
-	//  h.gatherer (default in prod, custom for testing)
-	handler := adaptor.HTTPHandler(promhttp.HandlerFor(h.gatherer, promhttp.HandlerOpts{}))
+	//  h.gatherer (the registry of the metrics, custom for testing)
+	//  OpenMetrics when the scraper accepts it (Prometheus does), the only format with exemplars
+	handler := adaptor.HTTPHandler(promhttp.HandlerFor(h.gatherer, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	}))
 	return handler(c)
 
 }
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"context"
 	"io"
 	"net/http/httptest"
 	"strings"
@@ -8,60 +9,17 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestHandleMetrics(t *testing.T) {
 	// 1. creates metrics for testing purpuses only
 	registry := prometheus.NewRegistry()
-	metrics := &Metrics{
-		httpRequestsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "http_requests_total",
-				Help: "Total number of HTTP requests",
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestDuration: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "http_request_duration_seconds",
-				Help:    "HTTP request duration in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_request_size_bytes",
-				Help: "HTTP request size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		httpResponseSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_response_size_bytes",
-				Help: "HTTP response size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		activeConnections: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "http_active_connections",
-				Help: "Number of active HTTP connections",
-			},
-		),
-	}
-
-	registry.MustRegister(
-		metrics.httpRequestsTotal,
-		metrics.httpRequestDuration,
-		metrics.httpRequestSize,
-		metrics.httpResponseSize,
-		metrics.activeConnections,
-	)
+	metrics := New("test-service", WithRegistry(registry))
 
 	// 2. Register some metrics for testing
-	metrics.RecordHTTPRequest("test-service", "GET", "/api/users", "200")
-	metrics.RecordHTTPDuration("test-service", "GET", "/api/users", "200", 0.125)
+	metrics.RecordHTTPRequest("GET", "/api/users", "200")
+	metrics.RecordHTTPDuration(context.Background(), "GET", "/api/users", "200", 0.125)
 	metrics.IncActiveConnections()
 
 	// 3. Creates handler
@@ -100,9 +58,9 @@ func TestHandleMetrics(t *testing.T) {
 
 	// 9. check for expected metrics
 	expectedMetrics := []string{
-		"# HELP http_requests_total",
-		"# TYPE http_requests_total counter",
-		"http_requests_total",
+		"# HELP http_request_total",
+		"# TYPE http_request_total counter",
+		"http_request_total",
 		"# HELP http_request_duration_seconds",
 		"# TYPE http_request_duration_seconds histogram",
 		"http_request_duration_seconds",
@@ -121,56 +79,12 @@ func TestHandleMetrics(t *testing.T) {
 func TestHandleMetricsFormat(t *testing.T) {
 	// Test más específico del formato
 	registry := prometheus.NewRegistry()
-	metrics := &Metrics{
-		httpRequestsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "http_requests_total",
-				Help: "Total number of HTTP requests",
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestDuration: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "http_request_duration_seconds",
-				Help:    "HTTP request duration in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_request_size_bytes",
-				Help: "HTTP request size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		httpResponseSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_response_size_bytes",
-				Help: "HTTP response size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		activeConnections: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "http_active_connections",
-				Help: "Number of active HTTP connections",
-			},
-		),
-	}
-
-	registry.MustRegister(
-		metrics.httpRequestsTotal,
-		metrics.httpRequestDuration,
-		metrics.httpRequestSize,
-		metrics.httpResponseSize,
-		metrics.activeConnections,
-	)
+	metrics := New("users-service", WithRegistry(registry))
 
 	// Registrar métricas específicas
-	metrics.RecordHTTPRequest("users-service", "POST", "/api/users", "201")
-	metrics.RecordHTTPRequest("users-service", "GET", "/api/users/:id", "200")
-	metrics.RecordHTTPRequest("users-service", "GET", "/api/users/:id", "404")
+	metrics.RecordHTTPRequest("POST", "/api/users", "201")
+	metrics.RecordHTTPRequest("GET", "/api/users/:id", "200")
+	metrics.RecordHTTPRequest("GET", "/api/users/:id", "404")
 
 	handler := newHandlerWithGatherer(metrics, registry)
 	app := fiber.New()
@@ -197,15 +111,15 @@ func TestHandleMetricsFormat(t *testing.T) {
 	}{
 		{
 			description: "Counter con labels específicos",
-			contains:    `http_requests_total{method="POST",path="/api/users",service="users-service",status="201"}`,
+			contains:    `http_request_total{method="POST",path="/api/users",service="users-service",status="201"}`,
 		},
 		{
 			description: "Counter con status 200",
-			contains:    `http_requests_total{method="GET",path="/api/users/:id",service="users-service",status="200"}`,
+			contains:    `http_request_total{method="GET",path="/api/users/:id",service="users-service",status="200"}`,
 		},
 		{
 			description: "Counter con status 404",
-			contains:    `http_requests_total{method="GET",path="/api/users/:id",service="users-service",status="404"}`,
+			contains:    `http_request_total{method="GET",path="/api/users/:id",service="users-service",status="404"}`,
 		},
 	}
 
@@ -221,18 +135,7 @@ func TestRegisterRoutes(t *testing.T) {
 	// Usar un registro personalizado para evitar conflictos
 	registry := prometheus.NewRegistry()
 
-	// Crear métricas manualmente con el registro personalizado
-	counter := prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_request_total",
-			Help: "Total number of HTTP requests",
-		},
-		[]string{"service", "method", "path", "status"},
-	)
-	registry.MustRegister(counter)
-
-	// Crear un struct Metrics vacío (solo para cumplir la firma)
-	metrics := &Metrics{}
+	metrics := New("test-service", WithRegistry(registry))
 
 	// Crear un handler con gatherer personalizado
 	handler := newHandlerWithGatherer(metrics, registry)
@@ -257,51 +160,7 @@ func TestRegisterRoutes(t *testing.T) {
 func TestHandleMetricsWithNoMetrics(t *testing.T) {
 	// Test del endpoint cuando no hay métricas registradas
 	registry := prometheus.NewRegistry()
-	metrics := &Metrics{
-		httpRequestsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "http_requests_total",
-				Help: "Total number of HTTP requests",
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestDuration: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "http_request_duration_seconds",
-				Help:    "HTTP request duration in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_request_size_bytes",
-				Help: "HTTP request size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		httpResponseSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_response_size_bytes",
-				Help: "HTTP response size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		activeConnections: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "http_active_connections",
-				Help: "Number of active HTTP connections",
-			},
-		),
-	}
-
-	registry.MustRegister(
-		metrics.httpRequestsTotal,
-		metrics.httpRequestDuration,
-		metrics.httpRequestSize,
-		metrics.httpResponseSize,
-		metrics.activeConnections,
-	)
+	metrics := New("test-service", WithRegistry(registry))
 
 	// NO registrar ninguna métrica
 
@@ -333,3 +192,50 @@ func TestHandleMetricsWithNoMetrics(t *testing.T) {
 		t.Error("Expected response to contain metric help text")
 	}
 }
+
+func TestHandleMetrics_Exemplars(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := New("test-service", WithRegistry(registry))
+
+	traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	sampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	notSampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{9},
+		SpanID:  trace.SpanID{9},
+	}))
+	metrics.RecordHTTPDuration(sampled, "GET", "/api/users", "200", 0.125)
+	metrics.RecordHTTPDuration(notSampled, "GET", "/api/users", "200", 0.300)
+
+	app := fiber.New()
+	newHandlerWithGatherer(metrics, registry).RegisterRoutes(app)
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); !strings.Contains(contentType, "application/openmetrics-text") {
+		t.Errorf("Expected OpenMetrics Content-Type, got '%s'", contentType)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %v", err)
+	}
+	bodyStr := string(body)
+
+	// the 0.25 bucket links to the sampled trace
+	if !strings.Contains(bodyStr, `# {trace_id="`+traceID.String()+`"} 0.125`) {
+		t.Errorf("Expected an exemplar with the trace id, got:\n%s", bodyStr)
+	}
+	if strings.Contains(bodyStr, trace.TraceID{9}.String()) {
+		t.Error("Expected no exemplar for a not sampled span")
+	}
+}
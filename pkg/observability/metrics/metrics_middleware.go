@@ -4,12 +4,16 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
 	"github.com/gofiber/fiber/v2"
 )
 
 type MetricsConfig struct {
-	ServiceName string
-	Metrics     *Metrics
+	Metrics *Metrics
+	// SLO latency objective per route, drives the slow and within SLO counters, 1s for
+	// every route by default
+	SLO LatencyThresholds
 }
 
 func Middleware(config MetricsConfig) fiber.Handler {
@@ -17,11 +21,18 @@ func Middleware(config MetricsConfig) fiber.Handler {
 		//1. captures start information
 		start := time.Now()
 
-		//2. increase active conns (gauge +1)
+		//2. increase active conns (gauge +1), decreased even when a handler panics
 		config.Metrics.IncActiveConnections()
+		defer config.Metrics.DecActiveConnections()
 
 		//4. executes next handler (real EP)
-		err := c.Next()
+		// the status of an error is only known once the error handler wrote the response,
+		// same as tracing.Middleware and AccessLog
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
 
 		//5. captures info after the handler
 		// IMPORTANT: Use c.Route().Path instead of c.Path() for better cardinality
@@ -40,35 +51,46 @@ func Middleware(config MetricsConfig) fiber.Handler {
 
 		//3. register size request (summary)
 		requestSize := float64(len(c.Request().Body()))
-		config.Metrics.RecordHTTPRequestSize(config.ServiceName, method, path, requestSize)
+		config.Metrics.RecordHTTPRequestSize(method, path, requestSize)
 
 		//6. register final metrics
 		//counter: Total of requests
-		config.Metrics.RecordHTTPRequest(config.ServiceName, method, path, status)
+		config.Metrics.RecordHTTPRequest(method, path, status)
 
 		//histogram : request duration
-		config.Metrics.RecordHTTPDuration(config.ServiceName, method, path, status, duration)
+		// with the trace of the request as exemplar (tracing.Middleware runs first)
+		config.Metrics.RecordHTTPDuration(c.UserContext(), method, path, status, duration)
 
 		//summary: response size
 		responseSize := float64(len(c.Response().Body()))
-		config.Metrics.RecordHTTPResponseSize(config.ServiceName, method, path, responseSize)
+		config.Metrics.RecordHTTPResponseSize(method, path, responseSize)
+
+		// tenant label, only when the tenant middleware resolved one
+		if tenantID, ok := tenant.FromContext(c.UserContext()); ok {
+			config.Metrics.RecordTenantRequest(tenantID, method, status)
+		}
+
+		// principal type, only when the auth middleware authenticated the request
+		if principal, ok := auth.FromContext(c.UserContext()); ok {
+			config.Metrics.RecordAuthenticatedRequest(string(principal.Type), method, status)
+		}
 
 		// Record error metrics based on status code
 		statusCode := c.Response().StatusCode()
 		if statusCode >= 400 && statusCode < 500 {
-			config.Metrics.RecordHTTPClientError(config.ServiceName, method, path, status)
+			config.Metrics.RecordHTTPClientError(method, path, status)
 		} else if statusCode >= 500 {
-			config.Metrics.RecordHTTPServerError(config.ServiceName, method, path, status)
+			config.Metrics.RecordHTTPServerError(method, path, status)
 		}
 
-		// Record slow requests (threshold: 1 second for demo, adjust based on your SLO)
-		if duration > 1.0 {
-			config.Metrics.RecordSlowRequest(config.ServiceName, method, path, "1s")
+		// Record slow requests against the latency objective of the route
+		threshold := config.SLO.For(method, path)
+		if duration > threshold.Seconds() {
+			config.Metrics.RecordSlowRequest(method, path, threshold.String())
+		} else {
+			config.Metrics.RecordWithinSLO(method, path, threshold.String())
 		}
 
-		//7. decrease active connections gauge
-		config.Metrics.DecActiveConnections()
-
-		return err
+		return nil
 	}
 }
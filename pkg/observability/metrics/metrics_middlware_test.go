@@ -3,8 +3,12 @@ package metrics
 import (
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/cristianortiz/observ-monit-go/pkg/tenant"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 )
@@ -13,58 +17,12 @@ func TestMiddleware(t *testing.T) {
 
 	// 1. creates test metrics, NOTE: use registry custom to avoid conflicts between tests
 	registry := prometheus.NewRegistry()
-	metrics := &Metrics{
-		httpRequestsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "http_requests_total",
-				Help: "Total number of HTTP requests",
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestDuration: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "http_request_duration_seconds",
-				Help:    "HTTP request duration in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_request_size_bytes",
-				Help: "HTTP request size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		httpResponseSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_response_size_bytes",
-				Help: "HTTP response size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		activeConnections: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "http_active_connections",
-				Help: "Number of active HTTP connections",
-			},
-		),
-	}
-
-	// Register metrics in the registry custom
-	registry.MustRegister(
-		metrics.httpRequestsTotal,
-		metrics.httpRequestDuration,
-		metrics.httpRequestSize,
-		metrics.httpResponseSize,
-		metrics.activeConnections,
-	)
+	metrics := New("test-service", WithRegistry(registry))
 
 	//2. creates app Fiber with the middleware
 	app := fiber.New()
 	app.Use(Middleware(MetricsConfig{
-		ServiceName: "test-service",
-		Metrics:     metrics,
+		Metrics: metrics,
 	}))
 
 	// 3. Create test EP
@@ -86,7 +44,7 @@ func TestMiddleware(t *testing.T) {
 	}
 
 	// 6. check the counter already increments
-	counterValue := testutil.ToFloat64(metrics.httpRequestsTotal.WithLabelValues("test-service", "GET", "/test", "200"))
+	counterValue := testutil.ToFloat64(metrics.httpRequestsTotal.WithLabelValues("GET", "/test", "200"))
 	if counterValue != 1 {
 		t.Errorf("Expected counter value 1, got %f", counterValue)
 	}
@@ -101,56 +59,11 @@ func TestMiddleware(t *testing.T) {
 func TestMiddlewareMultipleRequests(t *testing.T) {
 	// Test to check  multiples requests
 	registry := prometheus.NewRegistry()
-	metrics := &Metrics{
-		httpRequestsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "http_requests_total",
-				Help: "Total number of HTTP requests",
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestDuration: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "http_request_duration_seconds",
-				Help:    "HTTP request duration in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_request_size_bytes",
-				Help: "HTTP request size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		httpResponseSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_response_size_bytes",
-				Help: "HTTP response size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		activeConnections: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "http_active_connections",
-				Help: "Number of active HTTP connections",
-			},
-		),
-	}
-
-	registry.MustRegister(
-		metrics.httpRequestsTotal,
-		metrics.httpRequestDuration,
-		metrics.httpRequestSize,
-		metrics.httpResponseSize,
-		metrics.activeConnections,
-	)
+	metrics := New("test-service", WithRegistry(registry))
 
 	app := fiber.New()
 	app.Use(Middleware(MetricsConfig{
-		ServiceName: "test-service",
-		Metrics:     metrics,
+		Metrics: metrics,
 	}))
 
 	app.Get("/test", func(c *fiber.Ctx) error {
@@ -168,7 +81,7 @@ func TestMiddlewareMultipleRequests(t *testing.T) {
 	}
 
 	// check the counter at 5
-	counterValue := testutil.ToFloat64(metrics.httpRequestsTotal.WithLabelValues("test-service", "GET", "/test", "200"))
+	counterValue := testutil.ToFloat64(metrics.httpRequestsTotal.WithLabelValues("GET", "/test", "200"))
 	if counterValue != 5 {
 		t.Errorf("Expected counter value 5, got %f", counterValue)
 	}
@@ -183,83 +96,13 @@ func TestMiddlewareMultipleRequests(t *testing.T) {
 func TestMiddlewareWithDifferentStatusCodes(t *testing.T) {
 	// Test to check differents status codes
 	registry := prometheus.NewRegistry()
-	metrics := &Metrics{
-		httpRequestsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "http_requests_total",
-				Help: "Total number of HTTP requests",
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestDuration: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "http_request_duration_seconds",
-				Help:    "HTTP request duration in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_request_size_bytes",
-				Help: "HTTP request size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		httpResponseSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_response_size_bytes",
-				Help: "HTTP response size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		activeConnections: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "http_active_connections",
-				Help: "Number of active HTTP connections",
-			},
-		),
-		// Agregar las nuevas métricas de errores
-		httpClientErrors: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "http_client_errors_total",
-				Help: "Total number of HTTP 4xx client errors",
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpServerErrors: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "http_server_errors_total",
-				Help: "Total number of HTTP 5xx server errors",
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpSlowRequests: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "http_slow_requests_total",
-				Help: "Total number of HTTP requests exceeding SLO threshold",
-			},
-			[]string{"service", "method", "path", "threshold"},
-		),
-	}
-
-	registry.MustRegister(
-		metrics.httpRequestsTotal,
-		metrics.httpRequestDuration,
-		metrics.httpRequestSize,
-		metrics.httpResponseSize,
-		metrics.activeConnections,
-		metrics.httpClientErrors,
-		metrics.httpServerErrors,
-		metrics.httpSlowRequests,
-	)
+	metrics := New("test-service", WithRegistry(registry))
 
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true,
 	})
 	app.Use(Middleware(MetricsConfig{
-		ServiceName: "test-service",
-		Metrics:     metrics,
+		Metrics: metrics,
 	}))
 
 	// Endpoint that returns 200
@@ -302,9 +145,9 @@ func TestMiddlewareWithDifferentStatusCodes(t *testing.T) {
 	}
 
 	// Verify metrics by status code
-	success := testutil.ToFloat64(metrics.httpRequestsTotal.WithLabelValues("test-service", "GET", "/success", "200"))
-	notFound := testutil.ToFloat64(metrics.httpRequestsTotal.WithLabelValues("test-service", "GET", "/not-found", "404"))
-	errorCount := testutil.ToFloat64(metrics.httpRequestsTotal.WithLabelValues("test-service", "GET", "/error", "500"))
+	success := testutil.ToFloat64(metrics.httpRequestsTotal.WithLabelValues("GET", "/success", "200"))
+	notFound := testutil.ToFloat64(metrics.httpRequestsTotal.WithLabelValues("GET", "/not-found", "404"))
+	errorCount := testutil.ToFloat64(metrics.httpRequestsTotal.WithLabelValues("GET", "/error", "500"))
 
 	// Debug: Print all metrics to see what labels are actually registered
 	if notFound != 1 {
@@ -312,8 +155,8 @@ func TestMiddlewareWithDifferentStatusCodes(t *testing.T) {
 		metricFamilies, err := registry.Gather()
 		if err == nil {
 			for _, mf := range metricFamilies {
-				if mf.GetName() == "http_requests_total" {
-					t.Logf("Found http_requests_total metrics:")
+				if mf.GetName() == "http_request_total" {
+					t.Logf("Found http_request_total metrics:")
 					for _, m := range mf.GetMetric() {
 						labels := m.GetLabel()
 						labelStr := ""
@@ -337,3 +180,198 @@ func TestMiddlewareWithDifferentStatusCodes(t *testing.T) {
 		t.Errorf("Expected 1 error request, got %f", errorCount)
 	}
 }
+
+func TestMiddlewareRecordsTenant(t *testing.T) {
+	metrics := New("test-service", WithRegistry(prometheus.NewRegistry()))
+
+	app := fiber.New()
+	app.Use(Middleware(MetricsConfig{Metrics: metrics}))
+	// stands in for middleware.Tenant, which runs after the metrics middleware
+	app.Use(func(c *fiber.Ctx) error {
+		if id := c.Get("X-Tenant-ID"); id != "" {
+			c.SetUserContext(tenant.WithID(c.UserContext(), id))
+		}
+		return c.Next()
+	})
+	app.Get("/test", func(c *fiber.Ctx) error {
+		return c.SendString("OK")
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	_, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+
+	// without tenant nothing is recorded
+	_, err = app.Test(httptest.NewRequest("GET", "/test", nil))
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+
+	count := testutil.ToFloat64(metrics.httpTenantRequests.WithLabelValues("acme", "GET", "200"))
+	if count != 1 {
+		t.Errorf("Expected 1 request for tenant acme, got %f", count)
+	}
+	if series := testutil.CollectAndCount(metrics.httpTenantRequests); series != 1 {
+		t.Errorf("Expected 1 tenant series, got %d", series)
+	}
+}
+
+func TestMiddlewareRecordsPrincipalType(t *testing.T) {
+	metrics := New("test-service", WithRegistry(prometheus.NewRegistry()))
+
+	app := fiber.New()
+	app.Use(Middleware(MetricsConfig{Metrics: metrics}))
+	// stands in for middleware.Authenticate, which runs after the metrics middleware
+	app.Use(func(c *fiber.Ctx) error {
+		if key := c.Get("X-API-Key"); key != "" {
+			c.SetUserContext(auth.WithPrincipal(c.UserContext(), &auth.Principal{Type: auth.PrincipalAPIKey}))
+		}
+		return c.Next()
+	})
+	app.Get("/test", func(c *fiber.Ctx) error {
+		return c.SendString("OK")
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-API-Key", "fk_abc_secret")
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+
+	// anonymous requests are not recorded
+	if _, err := app.Test(httptest.NewRequest("GET", "/test", nil)); err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+
+	count := testutil.ToFloat64(metrics.httpAuthenticatedRequests.WithLabelValues("api_key", "GET", "200"))
+	if count != 1 {
+		t.Errorf("Expected 1 api_key request, got %f", count)
+	}
+	if series := testutil.CollectAndCount(metrics.httpAuthenticatedRequests); series != 1 {
+		t.Errorf("Expected 1 principal series, got %d", series)
+	}
+}
+
+func TestMiddlewareSLOThresholds(t *testing.T) {
+	metrics := New("test-service")
+
+	app := fiber.New()
+	app.Use(Middleware(MetricsConfig{
+		Metrics: metrics,
+		SLO: LatencyThresholds{
+			Default: time.Minute,
+			Routes: map[string]time.Duration{
+				"GET /users/:id": time.Millisecond,
+				"/users/:id":     time.Hour,
+			},
+		},
+	}))
+	app.All("/users/:id", func(c *fiber.Ctx) error {
+		time.Sleep(5 * time.Millisecond)
+		return c.SendString("OK")
+	})
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return c.SendString("OK")
+	})
+
+	for _, req := range []struct{ method, path string }{
+		{"GET", "/users/1"},
+		{"DELETE", "/users/1"},
+		{"GET", "/health"},
+	} {
+		if _, err := app.Test(httptest.NewRequest(req.method, req.path, nil)); err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+	}
+
+	tests := []struct {
+		counter  *prometheus.CounterVec
+		labels   []string
+		expected float64
+	}{
+		// method specific objective
+		{metrics.httpSlowRequests, []string{"GET", "/users/:id", "1ms"}, 1},
+		// route objective for the other methods
+		{metrics.httpWithinSLO, []string{"DELETE", "/users/:id", "1h0m0s"}, 1},
+		// default objective
+		{metrics.httpWithinSLO, []string{"GET", "/health", "1m0s"}, 1},
+	}
+	for _, tt := range tests {
+		if value := testutil.ToFloat64(tt.counter.WithLabelValues(tt.labels...)); value != tt.expected {
+			t.Errorf("Expected %f for %v, got %f", tt.expected, tt.labels, value)
+		}
+	}
+	if series := testutil.CollectAndCount(metrics.httpSlowRequests); series != 1 {
+		t.Errorf("Expected 1 slow series, got %d", series)
+	}
+}
+
+func TestMiddlewareResolvesErrors(t *testing.T) {
+	metrics := New("test-service")
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			code := fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				code = e.Code
+			}
+			return c.Status(code).SendString(err.Error())
+		},
+	})
+	app.Use(Middleware(MetricsConfig{Metrics: metrics}))
+	app.Use(recover.New())
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+	app.Get("/panic", func(c *fiber.Ctx) error {
+		panic("boom")
+	})
+
+	for _, path := range []string{"/missing", "/panic"} {
+		if _, err := app.Test(httptest.NewRequest("GET", path, nil)); err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+	}
+
+	// the status written by the error handler, not the 200 set before it ran
+	if v := testutil.ToFloat64(metrics.httpRequestsTotal.WithLabelValues("GET", "/missing", "404")); v != 1 {
+		t.Errorf("Expected 1 request with status 404, got %f", v)
+	}
+	if v := testutil.ToFloat64(metrics.httpClientErrors.WithLabelValues("GET", "/missing", "404")); v != 1 {
+		t.Errorf("Expected 1 client error, got %f", v)
+	}
+	if v := testutil.ToFloat64(metrics.httpServerErrors.WithLabelValues("GET", "/panic", "500")); v != 1 {
+		t.Errorf("Expected 1 server error for the panic, got %f", v)
+	}
+	if v := testutil.ToFloat64(metrics.activeConnections); v != 0 {
+		t.Errorf("Expected active connections 0, got %f", v)
+	}
+}
+
+func TestMiddlewareActiveConnectionsOnPanic(t *testing.T) {
+	metrics := New("test-service")
+
+	// recover outside the middleware, the panic goes through it
+	app := fiber.New()
+	app.Use(recover.New())
+	app.Use(Middleware(MetricsConfig{Metrics: metrics}))
+	app.Get("/panic", func(c *fiber.Ctx) error {
+		panic("boom")
+	})
+
+	if _, err := app.Test(httptest.NewRequest("GET", "/panic", nil)); err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	if v := testutil.ToFloat64(metrics.activeConnections); v != 0 {
+		t.Errorf("Expected active connections 0 after a panic, got %f", v)
+	}
+}
+
+func TestLatencyThresholdsDefault(t *testing.T) {
+	if threshold := (LatencyThresholds{}).For("GET", "/users"); threshold != DefaultLatencyThreshold {
+		t.Errorf("Expected %s without objectives, got %s", DefaultLatencyThreshold, threshold)
+	}
+}
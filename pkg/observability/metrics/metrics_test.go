@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"context"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -10,107 +11,16 @@ import (
 // Helper function para crear métricas de test con registry custom
 func setupTestMetrics(_ *testing.T) (*Metrics, *prometheus.Registry) {
 	registry := prometheus.NewRegistry()
-
-	metrics := &Metrics{
-		httpRequestsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "http_requests_total",
-				Help: "Total number of HTTP requests",
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestDuration: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "http_request_duration_seconds",
-				Help:    "HTTP request duration in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_request_size_bytes",
-				Help: "HTTP request size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		httpResponseSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_response_size_bytes",
-				Help: "HTTP response size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		activeConnections: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "http_active_connections",
-				Help: "Number of active HTTP connections",
-			},
-		),
-	}
-
-	registry.MustRegister(
-		metrics.httpRequestsTotal,
-		metrics.httpRequestDuration,
-		metrics.httpRequestSize,
-		metrics.httpResponseSize,
-		metrics.activeConnections,
-	)
-
-	return metrics, registry
+	return New("test-service", WithRegistry(registry)), registry
 }
 
 func TestNew(t *testing.T) {
 	registry := prometheus.NewRegistry()
+	metrics := New("test-service", WithRegistry(registry))
 
-	//serviceName := "test-service"
-
-	// Crear métricas manualmente (como en los otros tests)
-	metrics := &Metrics{
-		httpRequestsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Name: "http_requests_total",
-				Help: "Total number of HTTP requests",
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestDuration: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "http_request_duration_seconds",
-				Help:    "HTTP request duration in seconds",
-				Buckets: prometheus.DefBuckets,
-			},
-			[]string{"service", "method", "path", "status"},
-		),
-		httpRequestSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_request_size_bytes",
-				Help: "HTTP request size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		httpResponseSize: prometheus.NewSummaryVec(
-			prometheus.SummaryOpts{
-				Name: "http_response_size_bytes",
-				Help: "HTTP response size in bytes",
-			},
-			[]string{"service", "method", "path"},
-		),
-		activeConnections: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "http_active_connections",
-				Help: "Number of active HTTP connections",
-			},
-		),
-	}
-
-	registry.MustRegister(
-		metrics.httpRequestsTotal,
-		metrics.httpRequestDuration,
-		metrics.httpRequestSize,
-		metrics.httpResponseSize,
-		metrics.activeConnections,
-	)
+	if metrics.Registry() != registry {
+		t.Error("metrics were not registered in the given registry")
+	}
 
 	if metrics.httpRequestsTotal == nil {
 		t.Error("httpRequestsTotal was not initialized")
@@ -137,11 +47,11 @@ func TestRecordHTTPRequest(t *testing.T) {
 	metrics, _ := setupTestMetrics(t)
 
 	// Registrar una request
-	metrics.RecordHTTPRequest("test-service", "GET", "/api/users", "200")
+	metrics.RecordHTTPRequest("GET", "/api/users", "200")
 
 	// Verificar que el counter se incrementó
 	value := testutil.ToFloat64(
-		metrics.httpRequestsTotal.WithLabelValues("test-service", "GET", "/api/users", "200"),
+		metrics.httpRequestsTotal.WithLabelValues("GET", "/api/users", "200"),
 	)
 
 	if value != 1 {
@@ -149,11 +59,11 @@ func TestRecordHTTPRequest(t *testing.T) {
 	}
 
 	// Registrar otra request al mismo endpoint
-	metrics.RecordHTTPRequest("test-service", "GET", "/api/users", "200")
+	metrics.RecordHTTPRequest("GET", "/api/users", "200")
 
 	// Verificar que el counter ahora es 2
 	value = testutil.ToFloat64(
-		metrics.httpRequestsTotal.WithLabelValues("test-service", "GET", "/api/users", "200"),
+		metrics.httpRequestsTotal.WithLabelValues("GET", "/api/users", "200"),
 	)
 
 	if value != 2 {
@@ -165,10 +75,10 @@ func TestRecordHTTPRequestWithDifferentLabels(t *testing.T) {
 	metrics, _ := setupTestMetrics(t)
 
 	// Registrar requests con diferentes combinaciones de labels
-	metrics.RecordHTTPRequest("test-service", "GET", "/api/users", "200")
-	metrics.RecordHTTPRequest("test-service", "POST", "/api/users", "201")
-	metrics.RecordHTTPRequest("test-service", "GET", "/api/products", "200")
-	metrics.RecordHTTPRequest("test-service", "GET", "/api/users", "404")
+	metrics.RecordHTTPRequest("GET", "/api/users", "200")
+	metrics.RecordHTTPRequest("POST", "/api/users", "201")
+	metrics.RecordHTTPRequest("GET", "/api/products", "200")
+	metrics.RecordHTTPRequest("GET", "/api/users", "404")
 
 	// Verificar cada combinación de labels
 	tests := []struct {
@@ -185,7 +95,7 @@ func TestRecordHTTPRequestWithDifferentLabels(t *testing.T) {
 
 	for _, tt := range tests {
 		value := testutil.ToFloat64(
-			metrics.httpRequestsTotal.WithLabelValues("test-service", tt.method, tt.path, tt.status),
+			metrics.httpRequestsTotal.WithLabelValues(tt.method, tt.path, tt.status),
 		)
 
 		if value != tt.expected {
@@ -202,7 +112,7 @@ func TestRecordHTTPDuration(t *testing.T) {
 	durations := []float64{0.001, 0.015, 0.120, 0.350, 1.500}
 
 	for _, duration := range durations {
-		metrics.RecordHTTPDuration("test-service", "GET", "/api/users", "200", duration)
+		metrics.RecordHTTPDuration(context.Background(), "GET", "/api/users", "200", duration)
 	}
 
 	// Para histograms, verificamos usando el registry completo
@@ -246,7 +156,7 @@ func TestRecordHTTPRequestSize(t *testing.T) {
 	sizes := []float64{100, 200, 1500, 5000}
 
 	for _, size := range sizes {
-		metrics.RecordHTTPRequestSize("test-service", "POST", "/api/users", size)
+		metrics.RecordHTTPRequestSize("POST", "/api/users", size)
 	}
 
 	// Para summaries, verificamos usando el registry completo
@@ -298,7 +208,7 @@ func TestRecordHTTPResponseSize(t *testing.T) {
 	sizes := []float64{250, 1000, 3500, 10000}
 
 	for _, size := range sizes {
-		metrics.RecordHTTPResponseSize("test-service", "GET", "/api/products", size)
+		metrics.RecordHTTPResponseSize("GET", "/api/products", size)
 	}
 
 	// Para summaries, verificamos usando el registry completo
@@ -452,7 +362,6 @@ func TestMultipleMetricsRecording(t *testing.T) {
 	metrics, _ := setupTestMetrics(t)
 
 	// Simular el registro completo de una request
-	serviceName := "test-service"
 	method := "POST"
 	path := "/api/orders"
 	status := "201"
@@ -461,23 +370,23 @@ func TestMultipleMetricsRecording(t *testing.T) {
 	metrics.IncActiveConnections()
 
 	// 2. Registrar tamaño de request
-	metrics.RecordHTTPRequestSize(serviceName, method, path, 1024)
+	metrics.RecordHTTPRequestSize(method, path, 1024)
 
 	// 3. Registrar la request
-	metrics.RecordHTTPRequest(serviceName, method, path, status)
+	metrics.RecordHTTPRequest(method, path, status)
 
 	// 4. Registrar duración
-	metrics.RecordHTTPDuration(serviceName, method, path, status, 0.125)
+	metrics.RecordHTTPDuration(context.Background(), method, path, status, 0.125)
 
 	// 5. Registrar tamaño de response
-	metrics.RecordHTTPResponseSize(serviceName, method, path, 2048)
+	metrics.RecordHTTPResponseSize(method, path, 2048)
 
 	// 6. Decrementar conexiones activas
 	metrics.DecActiveConnections()
 
 	// Verificar que todas las métricas se registraron
 	counterValue := testutil.ToFloat64(
-		metrics.httpRequestsTotal.WithLabelValues(serviceName, method, path, status),
+		metrics.httpRequestsTotal.WithLabelValues(method, path, status),
 	)
 	if counterValue != 1 {
 		t.Errorf("Expected counter value 1, got %f", counterValue)
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"maps"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Option configures the registry, names and labels of the metrics created by New and the
+// New*Metrics constructors, pass the same options to all of them so they end up in one
+// registry with the same labels
+type Option func(*options)
+
+type options struct {
+	registry    *prometheus.Registry
+	namespace   string
+	constLabels prometheus.Labels
+	buckets     []float64
+}
+
+// WithRegistry registers the metrics in registry instead of a new one, see NewRegistry
+func WithRegistry(registry *prometheus.Registry) Option {
+	return func(o *options) {
+		o.registry = registry
+	}
+}
+
+// WithNamespace prefixes the metric names with namespace (namespace_http_request_total)
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels with a fixed value to every metric, e.g. env or region, the
+// service label is always added from the service name
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(o *options) {
+		maps.Copy(o.constLabels, labels)
+	}
+}
+
+// WithBuckets replaces the default buckets of the latency histogram
+func WithBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// newOptions applies opts over the defaults: a new registry, the service const label and
+// the given buckets
+func newOptions(serviceName string, buckets []float64, opts []Option) *options {
+	o := &options{
+		constLabels: prometheus.Labels{"service": serviceName},
+		buckets:     buckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.registry == nil {
+		o.registry = prometheus.NewRegistry()
+	}
+	return o
+}
+
+// NewRegistry creates a registry with the Go runtime and process collectors, the same
+// content the global registry starts with, share it between the metrics of the service
+// with WithRegistry and serve it with NewHandler
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// Settings the registry, namespace and const labels a set of options resolves to
+type Settings struct {
+	Registry    *prometheus.Registry
+	Namespace   string
+	ConstLabels prometheus.Labels
+}
+
+// Resolve the settings of opts for serviceName, for the collectors built outside this
+// package (prometheus.NewDesc) to be registered, named and labelled like the metrics of New
+func Resolve(serviceName string, opts ...Option) Settings {
+	o := newOptions(serviceName, nil, opts)
+	return Settings{Registry: o.registry, Namespace: o.namespace, ConstLabels: o.constLabels}
+}
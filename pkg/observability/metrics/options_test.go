@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_DedicatedRegistry(t *testing.T) {
+	// the global registry is never used, so creating the metrics twice does not panic
+	first := New("test-service")
+	second := New("test-service")
+	assert.NotSame(t, first.Registry(), second.Registry())
+
+	first.RecordHTTPRequest("GET", "/api/users", "200")
+	assert.Equal(t, 1, testutil.CollectAndCount(first.httpRequestsTotal))
+	assert.Equal(t, 0, testutil.CollectAndCount(second.httpRequestsTotal))
+}
+
+func TestNew_Options(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	opts := []Option{
+		WithRegistry(registry),
+		WithNamespace("acme"),
+		WithConstLabels(prometheus.Labels{"env": "prod"}),
+		WithBuckets([]float64{0.1, 1}),
+	}
+	m := New("test-service", opts...)
+	users := NewUserMetrics("test-service", opts...)
+	grpcMetrics := NewGRPCMetrics("test-service", opts...)
+
+	m.RecordHTTPRequest("GET", "/api/users", "200")
+	m.RecordHTTPDuration(t.Context(), "GET", "/api/users", "200", 0.5)
+	users.UsersCreated.Inc()
+	grpcMetrics.RecordRequest("/users.v1.UserService/GetUser", "OK")
+
+	assert.Same(t, registry, m.Registry())
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP acme_http_request_total Total number of HTTP requests
+# TYPE acme_http_request_total counter
+acme_http_request_total{env="prod",method="GET",path="/api/users",service="test-service",status="200"} 1
+# HELP acme_http_request_duration_seconds HTTP request duration in seconds
+# TYPE acme_http_request_duration_seconds histogram
+acme_http_request_duration_seconds_bucket{env="prod",method="GET",path="/api/users",service="test-service",status="200",le="0.1"} 0
+acme_http_request_duration_seconds_bucket{env="prod",method="GET",path="/api/users",service="test-service",status="200",le="1"} 1
+acme_http_request_duration_seconds_bucket{env="prod",method="GET",path="/api/users",service="test-service",status="200",le="+Inf"} 1
+acme_http_request_duration_seconds_sum{env="prod",method="GET",path="/api/users",service="test-service",status="200"} 0.5
+acme_http_request_duration_seconds_count{env="prod",method="GET",path="/api/users",service="test-service",status="200"} 1
+# HELP acme_users_created_total Total number of users created
+# TYPE acme_users_created_total counter
+acme_users_created_total{env="prod",service="test-service"} 1
+# HELP acme_grpc_server_handled_total Total number of RPCs completed on the server
+# TYPE acme_grpc_server_handled_total counter
+acme_grpc_server_handled_total{code="OK",env="prod",method="/users.v1.UserService/GetUser",service="test-service"} 1
+`),
+		"acme_http_request_total",
+		"acme_http_request_duration_seconds",
+		"acme_users_created_total",
+		"acme_grpc_server_handled_total",
+	))
+}
+
+func TestNewUserMetrics_DefaultNamespace(t *testing.T) {
+	users := NewUserMetrics("factorit")
+	users.UsersDeleted.Inc()
+
+	require.NoError(t, testutil.CollectAndCompare(users.UsersDeleted, strings.NewReader(`
+# HELP factorit_users_deleted_total Total number of users deleted
+# TYPE factorit_users_deleted_total counter
+factorit_users_deleted_total{service="factorit"} 1
+`)))
+}
+
+func TestNewHandler_ServesRegistry(t *testing.T) {
+	registry := NewRegistry()
+	m := New("test-service", WithRegistry(registry))
+	m.RecordHTTPRequest("GET", "/api/users", "200")
+
+	app := fiber.New()
+	NewHandler(m).RegisterRoutes(app)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body), `http_request_total{method="GET",path="/api/users",service="test-service",status="200"} 1`)
+	assert.Contains(t, string(body), "go_goroutines", "runtime collectors of NewRegistry")
+}
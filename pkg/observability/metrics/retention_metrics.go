@@ -0,0 +1,61 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// RetentionMetrics contains the metrics of the retention purge job
+type RetentionMetrics struct {
+	// RunDuration by result: success, error, skipped (another replica holds the lock)
+	RunDuration *prometheus.HistogramVec
+	// RowsPurged by mode: delete, anonymize
+	RowsPurged *prometheus.CounterVec
+	// LastSuccess unix time of the last successful run, alert when it gets old
+	LastSuccess prometheus.Gauge
+}
+
+// retentionDescriptors the metrics of NewRetentionMetrics, named without the business
+// namespace
+var retentionDescriptors = []Descriptor{
+	{Name: "users_purge_run_duration_seconds", Labels: []string{"result"}},
+	{Name: "users_purge_rows_total", Labels: []string{"mode"}},
+	{Name: "users_purge_last_success_timestamp_seconds"},
+}
+
+// NewRetentionMetrics creates the purge job metrics of serviceName and registers them in
+// the registry of opts, named like the business metrics (see NewUserMetrics)
+func NewRetentionMetrics(serviceName string, opts ...Option) *RetentionMetrics {
+	o := newOptions(serviceName, []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300}, opts)
+	namespace := businessNamespace(o, serviceName)
+
+	m := &RetentionMetrics{
+		RunDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Subsystem:   "users_purge",
+			Name:        "run_duration_seconds",
+			Help:        "Duration of the users retention purge runs in seconds",
+			ConstLabels: o.constLabels,
+			Buckets:     o.buckets,
+		}, []string{"result"}),
+		RowsPurged: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "users_purge",
+			Name:        "rows_total",
+			Help:        "Total number of soft deleted users purged",
+			ConstLabels: o.constLabels,
+		}, []string{"mode"}),
+		LastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   "users_purge",
+			Name:        "last_success_timestamp_seconds",
+			Help:        "Unix time of the last successful users retention purge",
+			ConstLabels: o.constLabels,
+		}),
+	}
+	// Register all metrics
+	o.registry.MustRegister(
+		m.RunDuration,
+		m.RowsPurged,
+		m.LastSuccess,
+	)
+
+	return m
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import "time"
+
+// DefaultLatencyThreshold latency objective of the routes without one
+const DefaultLatencyThreshold = time.Second
+
+// LatencyThresholds the latency objective (SLO) of every route, a request slower than the
+// objective of its route is counted as slow, a faster one as within its objective
+//
+// Routes keys are "METHOD /route/template" or "/route/template" for every method, the
+// method specific entry wins:
+//
+//	metrics.LatencyThresholds{
+//		Default: time.Second,
+//		Routes: map[string]time.Duration{
+//			"GET /api/v1/users/:id": 200 * time.Millisecond,
+//			"/api/v1/users/export":  10 * time.Second,
+//		},
+//	}
+type LatencyThresholds struct {
+	// Default DefaultLatencyThreshold when 0
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+// For the latency objective of the route template path requested with method, an empty
+// method looks up path only (gRPC full method names)
+func (t LatencyThresholds) For(method, path string) time.Duration {
+	if threshold, ok := t.Routes[method+" "+path]; ok && method != "" {
+		return threshold
+	}
+	if threshold, ok := t.Routes[path]; ok {
+		return threshold
+	}
+	if t.Default > 0 {
+		return t.Default
+	}
+	return DefaultLatencyThreshold
+}
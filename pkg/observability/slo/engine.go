@@ -0,0 +1,375 @@
+package slo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/logger"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+// HTTP metrics the SLIs are computed from, recorded by metrics.Middleware
+const (
+	requestsMetric  = "http_request_total"
+	withinSLOMetric = "http_requests_within_slo_total"
+)
+
+// default evaluation settings
+const (
+	DefaultPeriod   = 30 * 24 * time.Hour
+	DefaultInterval = time.Minute
+)
+
+// BurnRateAlert a multi-window burn rate condition, it fires when the error budget burns
+// Factor times faster than allowed in both windows: the long one proves it is significant,
+// the short one that it is still happening
+type BurnRateAlert struct {
+	Severity    string
+	LongWindow  time.Duration
+	ShortWindow time.Duration
+	Factor      float64
+}
+
+// BurnRateAlerts the multi-window, multi burn rate alerts of the SRE workbook for a 30
+// days period: 2% of the budget in 1h or 5% in 6h pages, 10% in 1d or 3d opens a ticket
+var BurnRateAlerts = []BurnRateAlert{
+	{Severity: "page", LongWindow: time.Hour, ShortWindow: 5 * time.Minute, Factor: 14.4},
+	{Severity: "page", LongWindow: 6 * time.Hour, ShortWindow: 30 * time.Minute, Factor: 6},
+	{Severity: "ticket", LongWindow: 24 * time.Hour, ShortWindow: 2 * time.Hour, Factor: 3},
+	{Severity: "ticket", LongWindow: 72 * time.Hour, ShortWindow: 6 * time.Hour, Factor: 1},
+}
+
+// Windows the burn rate windows of BurnRateAlerts, shortest first
+func Windows() []time.Duration {
+	return []time.Duration{
+		5 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour,
+		6 * time.Hour, 24 * time.Hour, 72 * time.Hour,
+	}
+}
+
+type Config struct {
+	Objectives []Objective
+	// Registry of the HTTP metrics (metrics.Metrics.Registry), the SLO gauges are
+	// registered in it too
+	Registry *prometheus.Registry
+	// Namespace and ConstLabels of the HTTP metrics (metrics.WithNamespace and
+	// metrics.WithConstLabels), the SLO gauges get the same
+	Namespace   string
+	ConstLabels prometheus.Labels
+	// Period compliance period of the error budget, DefaultPeriod when 0
+	Period time.Duration
+	// Interval between evaluations, DefaultInterval when 0
+	Interval time.Duration
+	Logger   *logger.Logger
+}
+
+// Engine evaluates the objectives from the HTTP metrics of the service itself: every
+// Interval it reads the counters from the registry and keeps them for the Period, so the
+// SLIs, burn rates and remaining error budget are known without Prometheus
+//
+// The history lives in memory, after a restart the budget is computed over the time
+// since the start until a full period is recorded
+//
+// Usage:
+//
+//	engine, err := slo.New(slo.Config{Objectives: objectives, Registry: metricsSystem.Registry(), Logger: log})
+//	go engine.Run(ctx)
+//	slo.NewHandler(engine).RegisterRoutes(app, "/slo")
+type Engine struct {
+	config Config
+	log    *logger.Logger
+	now    func() time.Time
+
+	budgetRemaining *prometheus.GaugeVec
+	burnRate        *prometheus.GaugeVec
+	compliance      *prometheus.GaugeVec
+	target          *prometheus.GaugeVec
+
+	mu      sync.RWMutex
+	history map[string][]sample
+	status  Status
+}
+
+// sample the cumulative counters of an objective at a time
+type sample struct {
+	at     time.Time
+	total  float64
+	errors float64 // 5xx
+	within float64 // within the latency threshold
+}
+
+// Descriptors the gauges of the engine without the namespace, see metrics.Inventory
+var Descriptors = []metrics.Descriptor{
+	{Name: "slo_error_budget_remaining", Labels: []string{"slo", "sli"}},
+	{Name: "slo_burn_rate", Labels: []string{"slo", "sli", "window"}},
+	{Name: "slo_compliance_ratio", Labels: []string{"slo", "sli"}},
+	{Name: "slo_target_ratio", Labels: []string{"slo", "sli"}},
+}
+
+// New creates the engine and registers its gauges
+func New(config Config) (*Engine, error) {
+	if err := validateObjectives(config.Objectives); err != nil {
+		return nil, err
+	}
+	if config.Registry == nil {
+		return nil, fmt.Errorf("SLO engine requires the registry of the HTTP metrics")
+	}
+	if config.Period <= 0 {
+		config.Period = DefaultPeriod
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+	log := config.Logger
+	if log == nil {
+		log = logger.Nop()
+	}
+
+	e := &Engine{
+		config:  config,
+		log:     log.WithComponent("slo"),
+		now:     time.Now,
+		history: map[string][]sample{},
+		budgetRemaining: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   config.Namespace,
+			ConstLabels: config.ConstLabels,
+			Name:        "slo_error_budget_remaining",
+			Help:        "Fraction of the error budget of the period left (1 untouched, negative overspent)",
+		}, []string{"slo", "sli"}),
+		burnRate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   config.Namespace,
+			ConstLabels: config.ConstLabels,
+			Name:        "slo_burn_rate",
+			Help:        "Rate the error budget is spent at over the window (1 spends it exactly in the period)",
+		}, []string{"slo", "sli", "window"}),
+		compliance: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   config.Namespace,
+			ConstLabels: config.ConstLabels,
+			Name:        "slo_compliance_ratio",
+			Help:        "Ratio of good requests over the period",
+		}, []string{"slo", "sli"}),
+		target: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   config.Namespace,
+			ConstLabels: config.ConstLabels,
+			Name:        "slo_target_ratio",
+			Help:        "Target ratio of good requests of the objective",
+		}, []string{"slo", "sli"}),
+	}
+
+	config.Registry.MustRegister(e.budgetRemaining, e.burnRate, e.compliance, e.target)
+	return e, nil
+}
+
+// Run evaluates the objectives every Interval until ctx is done
+func (e *Engine) Run(ctx context.Context) {
+	e.log.Info("SLO evaluation scheduled",
+		zap.Int("objectives", len(e.config.Objectives)),
+		zap.Duration("interval", e.config.Interval),
+		zap.Duration("period", e.config.Period),
+	)
+
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		// the error is already logged by Evaluate
+		_ = e.Evaluate()
+
+		select {
+		case <-ctx.Done():
+			e.log.Info("SLO evaluation stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate reads the HTTP counters, updates the gauges and the Status
+func (e *Engine) Evaluate() error {
+	families, err := e.config.Registry.Gather()
+	if err != nil {
+		e.log.Error("failed to gather the SLO metrics", zap.Error(err))
+		return fmt.Errorf("failed to gather the SLO metrics: %w", err)
+	}
+	now := e.now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	status := Status{
+		EvaluatedAt: now,
+		Period:      e.config.Period.String(),
+		Objectives:  make([]ObjectiveStatus, 0, len(e.config.Objectives)),
+	}
+	for _, objective := range e.config.Objectives {
+		history := e.record(objective, e.read(objective, families, now))
+		status.Objectives = append(status.Objectives, e.evaluate(objective, history))
+	}
+	e.status = status
+	return nil
+}
+
+// Status the result of the last evaluation
+func (e *Engine) Status() Status {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.status
+}
+
+// read sums the counters of the requests of objective
+func (e *Engine) read(objective Objective, families []*dto.MetricFamily, now time.Time) sample {
+	s := sample{at: now}
+	requests := prometheus.BuildFQName(e.config.Namespace, "", requestsMetric)
+	within := prometheus.BuildFQName(e.config.Namespace, "", withinSLOMetric)
+
+	for _, family := range families {
+		switch family.GetName() {
+		case requests:
+			for _, metric := range family.GetMetric() {
+				labels := labelValues(metric)
+				if !objective.Matches(labels["method"], labels["path"]) {
+					continue
+				}
+				value := metric.GetCounter().GetValue()
+				s.total += value
+				if status, _ := strconv.Atoi(labels["status"]); status >= 500 {
+					s.errors += value
+				}
+			}
+		case within:
+			for _, metric := range family.GetMetric() {
+				labels := labelValues(metric)
+				if objective.Matches(labels["method"], labels["path"]) {
+					s.within += metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return s
+}
+
+// record appends s to the history of objective and drops the samples no longer needed:
+// the oldest one kept is the last one before the period
+func (e *Engine) record(objective Objective, s sample) []sample {
+	history := append(e.history[objective.Name], s)
+	start := s.at.Add(-e.config.Period)
+	drop := 0
+	for drop+1 < len(history) && !history[drop+1].at.After(start) {
+		drop++
+	}
+	history = history[drop:]
+	e.history[objective.Name] = history
+	return history
+}
+
+// evaluate computes the SLIs of objective from its history and updates the gauges
+func (e *Engine) evaluate(objective Objective, history []sample) ObjectiveStatus {
+	status := ObjectiveStatus{
+		Name:   objective.Name,
+		Method: objective.Method,
+		Route:  objective.Route,
+	}
+	last := history[len(history)-1]
+
+	for _, sli := range []string{SLIAvailability, SLILatency} {
+		target, ok := objective.Targets()[sli]
+		if !ok {
+			continue
+		}
+		budget := 1 - target
+
+		first := history[0]
+		total, bad := events(sli, first, last)
+		compliance := 1.0
+		if total > 0 {
+			compliance = 1 - bad/total
+		}
+		sliStatus := SLIStatus{
+			SLI:                  sli,
+			Target:               target,
+			Compliance:           compliance,
+			ErrorBudgetRemaining: 1 - (1-compliance)/budget,
+			TotalEvents:          total,
+			BadEvents:            bad,
+			BurnRates:            map[string]float64{},
+		}
+
+		burnRates := map[time.Duration]float64{}
+		for _, window := range Windows() {
+			total, bad := events(sli, sampleBefore(history, last.at.Add(-window)), last)
+			burnRate := 0.0
+			if total > 0 {
+				burnRate = bad / total / budget
+			}
+			burnRates[window] = burnRate
+			sliStatus.BurnRates[WindowLabel(window)] = burnRate
+			e.burnRate.WithLabelValues(objective.Name, sli, WindowLabel(window)).Set(burnRate)
+		}
+		for _, alert := range BurnRateAlerts {
+			sliStatus.Alerts = append(sliStatus.Alerts, AlertStatus{
+				Severity:    alert.Severity,
+				LongWindow:  WindowLabel(alert.LongWindow),
+				ShortWindow: WindowLabel(alert.ShortWindow),
+				Factor:      alert.Factor,
+				Firing:      burnRates[alert.LongWindow] > alert.Factor && burnRates[alert.ShortWindow] > alert.Factor,
+			})
+		}
+
+		e.target.WithLabelValues(objective.Name, sli).Set(target)
+		e.compliance.WithLabelValues(objective.Name, sli).Set(sliStatus.Compliance)
+		e.budgetRemaining.WithLabelValues(objective.Name, sli).Set(sliStatus.ErrorBudgetRemaining)
+		status.SLIs = append(status.SLIs, sliStatus)
+	}
+	return status
+}
+
+// events the requests and the bad ones of sli between two samples
+func events(sli string, from, to sample) (total, bad float64) {
+	total = to.total - from.total
+	switch sli {
+	case SLIAvailability:
+		bad = to.errors - from.errors
+	case SLILatency:
+		bad = total - (to.within - from.within)
+	}
+	return total, max(bad, 0)
+}
+
+// sampleBefore the last sample at or before t, the oldest one when the history is shorter
+func sampleBefore(history []sample, t time.Time) sample {
+	found := history[0]
+	for _, s := range history {
+		if s.at.After(t) {
+			break
+		}
+		found = s
+	}
+	return found
+}
+
+// labelValues the labels of metric by name
+func labelValues(metric *dto.Metric) map[string]string {
+	labels := make(map[string]string, len(metric.GetLabel()))
+	for _, label := range metric.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	return labels
+}
+
+// WindowLabel the window label of the burn rate gauge: 5m, 1h, 3d
+func WindowLabel(window time.Duration) string {
+	switch {
+	case window%(24*time.Hour) == 0:
+		return strconv.Itoa(int(window/(24*time.Hour))) + "d"
+	case window%time.Hour == 0:
+		return strconv.Itoa(int(window/time.Hour)) + "h"
+	default:
+		return strconv.Itoa(int(window/time.Minute)) + "m"
+	}
+}
@@ -0,0 +1,194 @@
+package slo
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestEngine an engine over the HTTP metrics of a new registry, with a clock moved by
+// the returned function
+func newTestEngine(t *testing.T, objectives ...Objective) (*Engine, *metrics.Metrics, func(time.Duration)) {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	m := metrics.New("test-service", metrics.WithRegistry(registry))
+
+	engine, err := New(Config{Objectives: objectives, Registry: registry})
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+	return engine, m, func(d time.Duration) { now = now.Add(d) }
+}
+
+// serve records requests like metrics.Middleware
+func serve(m *metrics.Metrics, method, path, status string, within bool, count int) {
+	for range count {
+		m.RecordHTTPRequest(method, path, status)
+		if within {
+			m.RecordWithinSLO(method, path, "1s")
+		} else {
+			m.RecordSlowRequest(method, path, "1s")
+		}
+	}
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	engine, m, advance := newTestEngine(t, Objective{
+		Name:         "users",
+		Route:        "/users/*",
+		Availability: 0.99,
+		Latency:      0.9,
+	})
+	require.NoError(t, engine.Evaluate())
+
+	serve(m, "GET", "/users/:id", "200", true, 93)
+	serve(m, "GET", "/users/:id", "200", false, 5)
+	serve(m, "POST", "/users/", "500", true, 2)
+	serve(m, "GET", "/health", "500", false, 50) // other route
+	advance(10 * time.Minute)
+	require.NoError(t, engine.Evaluate())
+
+	status := engine.Status()
+	require.Len(t, status.Objectives, 1)
+	slis := status.Objectives[0].SLIs
+	require.Len(t, slis, 2)
+
+	availability := slis[0]
+	assert.Equal(t, SLIAvailability, availability.SLI)
+	assert.Equal(t, float64(100), availability.TotalEvents)
+	assert.Equal(t, float64(2), availability.BadEvents)
+	assert.InDelta(t, 0.98, availability.Compliance, 1e-9)
+	assert.InDelta(t, -1, availability.ErrorBudgetRemaining, 1e-9, "spent twice")
+	assert.InDelta(t, 2, availability.BurnRates["5m"], 1e-9)
+	assert.InDelta(t, 2, availability.BurnRates["3d"], 1e-9, "the history is shorter than the window")
+
+	latency := slis[1]
+	assert.Equal(t, SLILatency, latency.SLI)
+	assert.Equal(t, float64(5), latency.BadEvents)
+	assert.InDelta(t, 0.5, latency.ErrorBudgetRemaining, 1e-9)
+	assert.InDelta(t, 0.5, latency.BurnRates["1h"], 1e-9)
+
+	// only the slowest burn rate alert, the budget would be spent in half the period
+	for _, alert := range availability.Alerts {
+		assert.Equal(t, alert.Factor == 1, alert.Firing, alert)
+	}
+	for _, alert := range latency.Alerts {
+		assert.False(t, alert.Firing, alert)
+	}
+
+	assert.InDelta(t, -1, testutil.ToFloat64(engine.budgetRemaining.WithLabelValues("users", SLIAvailability)), 1e-9)
+	assert.InDelta(t, 0.5, testutil.ToFloat64(engine.burnRate.WithLabelValues("users", SLILatency, "5m")), 1e-9)
+	assert.Equal(t, 0.9, testutil.ToFloat64(engine.target.WithLabelValues("users", SLILatency)))
+}
+
+func TestEngine_BurnRateAlerts(t *testing.T) {
+	engine, m, advance := newTestEngine(t, Objective{Name: "api", Availability: 0.999})
+
+	// a quiet hour, then an outage in the last 5 minutes
+	require.NoError(t, engine.Evaluate())
+	serve(m, "GET", "/users", "200", true, 10000)
+	advance(55 * time.Minute)
+	require.NoError(t, engine.Evaluate())
+	serve(m, "GET", "/users", "500", true, 1000)
+	advance(5 * time.Minute)
+	require.NoError(t, engine.Evaluate())
+
+	availability := engine.Status().Objectives[0].SLIs[0]
+	assert.InDelta(t, 1000, availability.BurnRates["5m"], 1e-6, "every request failed")
+	assert.InDelta(t, 1000.0/11000/0.001, availability.BurnRates["1h"], 1e-6)
+
+	firing := map[string]bool{}
+	for _, alert := range availability.Alerts {
+		firing[alert.LongWindow+"/"+alert.ShortWindow] = alert.Firing
+	}
+	assert.Equal(t, map[string]bool{"1h/5m": true, "6h/30m": true, "1d/2h": true, "3d/6h": true}, firing)
+
+	// recovered: the short window no longer burns
+	serve(m, "GET", "/users", "200", true, 10000)
+	advance(5 * time.Minute)
+	require.NoError(t, engine.Evaluate())
+	availability = engine.Status().Objectives[0].SLIs[0]
+	assert.Zero(t, availability.BurnRates["5m"])
+	assert.False(t, availability.Alerts[0].Firing)
+}
+
+func TestEngine_HistoryBoundedByPeriod(t *testing.T) {
+	engine, m, advance := newTestEngine(t, Objective{Name: "api", Availability: 0.99})
+	engine.config.Period = time.Hour
+
+	serve(m, "GET", "/users", "500", true, 10)
+	for range 10 {
+		require.NoError(t, engine.Evaluate())
+		advance(30 * time.Minute)
+	}
+	serve(m, "GET", "/users", "200", true, 10)
+	require.NoError(t, engine.Evaluate())
+
+	assert.Len(t, engine.history["api"], 3, "the samples of the last hour and the one before")
+	availability := engine.Status().Objectives[0].SLIs[0]
+	assert.Equal(t, float64(10), availability.TotalEvents, "the errors are older than the period")
+	assert.Equal(t, float64(1), availability.Compliance)
+}
+
+func TestDescriptors(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	engine, err := New(Config{
+		Objectives: []Objective{{Name: "api", Availability: 0.99, Latency: 0.9}},
+		Registry:   registry,
+		Namespace:  "acme",
+	})
+	require.NoError(t, err)
+	require.NoError(t, engine.Evaluate())
+
+	gathered, err := metrics.Gathered(registry, nil)
+	require.NoError(t, err)
+	assert.Equal(t, metrics.Inventory{}.Add("acme", Descriptors...).SortLabels(), gathered, "the table of the engine")
+}
+
+func TestNew_InvalidObjectives(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	for name, objectives := range map[string][]Objective{
+		"without target": {{Name: "api"}},
+		"target of 1":    {{Name: "api", Availability: 1}},
+		"duplicated":     {{Name: "api", Latency: 0.9}, {Name: "api", Latency: 0.99}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(Config{Objectives: objectives, Registry: registry})
+			assert.ErrorIs(t, err, ErrInvalidObjective)
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	engine, m, _ := newTestEngine(t, Objective{Name: "api", Route: "/api/*", Availability: 0.999})
+
+	app := fiber.New()
+	NewHandler(engine).RegisterRoutes(app, "/slo")
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/slo", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode, "before the first evaluation")
+
+	serve(m, "GET", "/api/users", "200", true, 10)
+	require.NoError(t, engine.Evaluate())
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/slo", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var status Status
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	require.Len(t, status.Objectives, 1)
+	assert.Equal(t, "/api/*", status.Objectives[0].Route)
+	assert.Equal(t, float64(1), status.Objectives[0].SLIs[0].ErrorBudgetRemaining)
+	assert.Len(t, status.Objectives[0].SLIs[0].Alerts, len(BurnRateAlerts))
+}
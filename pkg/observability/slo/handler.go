@@ -0,0 +1,32 @@
+package slo
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler serves the SLO report of an Engine
+type Handler struct {
+	engine *Engine
+}
+
+// NewHandler creates the SLO report handler
+func NewHandler(engine *Engine) *Handler {
+	return &Handler{engine: engine}
+}
+
+// HandleSLO returns the last evaluation of the objectives, 503 before the first one
+func (h *Handler) HandleSLO(c *fiber.Ctx) error {
+	status := h.engine.Status()
+	if status.EvaluatedAt.IsZero() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   "not_evaluated",
+			"message": "SLOs not evaluated yet",
+		})
+	}
+	return c.JSON(status)
+}
+
+// RegisterRoutes registers the SLO report route in the Fiber app
+func (h *Handler) RegisterRoutes(app *fiber.App, path string) {
+	app.Get(path, h.HandleSLO)
+}
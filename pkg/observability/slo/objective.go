@@ -0,0 +1,135 @@
+package slo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/config"
+)
+
+// SLI kinds of an objective
+const (
+	// SLIAvailability ratio of requests answered without a server error (5xx)
+	SLIAvailability = "availability"
+	// SLILatency ratio of requests answered within the latency threshold of their route
+	// (metrics.LatencyThresholds)
+	SLILatency = "latency"
+)
+
+// ErrInvalidObjective is returned for objectives that can not be evaluated
+var ErrInvalidObjective = errors.New("invalid SLO objective")
+
+// Objective the availability and latency targets of a set of routes, a zero target
+// disables that SLI
+//
+// In JSON (see LoadObjectives):
+//
+//	[
+//		{"name": "users-read", "method": "GET", "route": "/api/v1/users/:id", "availability": 0.999, "latency": 0.99},
+//		{"name": "api", "route": "/api/v1/*", "availability": 0.995}
+//	]
+type Objective struct {
+	Name string `json:"name"`
+	// Method of the requests, every method when empty
+	Method string `json:"method,omitempty"`
+	// Route template of the requests (/api/v1/users/:id), a trailing * matches every route
+	// with that prefix, every route when empty
+	Route string `json:"route,omitempty"`
+	// Availability target (0..1), e.g. 0.999
+	Availability float64 `json:"availability,omitempty"`
+	// Latency target (0..1), e.g. 0.99
+	Latency float64 `json:"latency,omitempty"`
+}
+
+// Targets the target of every enabled SLI of the objective
+func (o Objective) Targets() map[string]float64 {
+	targets := map[string]float64{}
+	if o.Availability > 0 {
+		targets[SLIAvailability] = o.Availability
+	}
+	if o.Latency > 0 {
+		targets[SLILatency] = o.Latency
+	}
+	return targets
+}
+
+// Matches reports whether the requests of method and route template count for o
+func (o Objective) Matches(method, route string) bool {
+	if o.Method != "" && !strings.EqualFold(o.Method, method) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(o.Route, "*"); ok {
+		return strings.HasPrefix(route, prefix)
+	}
+	return o.Route == "" || o.Route == route
+}
+
+// Validate checks the name and targets
+func (o Objective) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidObjective)
+	}
+	if o.Availability == 0 && o.Latency == 0 {
+		return fmt.Errorf("%w: %s has no availability nor latency target", ErrInvalidObjective, o.Name)
+	}
+	for sli, target := range o.Targets() {
+		if target >= 1 {
+			return fmt.Errorf("%w: %s %s target must be between 0 and 1", ErrInvalidObjective, o.Name, sli)
+		}
+	}
+	if o.Availability < 0 || o.Latency < 0 {
+		return fmt.Errorf("%w: %s targets must be between 0 and 1", ErrInvalidObjective, o.Name)
+	}
+	return nil
+}
+
+// LoadObjectives reads a JSON array of objectives and validates them
+func LoadObjectives(path string) ([]Objective, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLO objectives: %w", err)
+	}
+
+	var objectives []Objective
+	if err := json.Unmarshal(content, &objectives); err != nil {
+		return nil, fmt.Errorf("failed to parse SLO objectives %s: %w", path, err)
+	}
+	if err := validateObjectives(objectives); err != nil {
+		return nil, err
+	}
+	return objectives, nil
+}
+
+// validateObjectives validates every objective and that names are unique, the name is the
+// slo label of the metrics
+func validateObjectives(objectives []Objective) error {
+	names := map[string]bool{}
+	for _, objective := range objectives {
+		if err := objective.Validate(); err != nil {
+			return err
+		}
+		if names[objective.Name] {
+			return fmt.Errorf("%w: duplicated name %s", ErrInvalidObjective, objective.Name)
+		}
+		names[objective.Name] = true
+	}
+	return nil
+}
+
+// ObjectivesFromConfig the objectives of SLO_OBJECTIVES or, when not set, one "api"
+// objective for every route under apiBasePath with the default targets
+func ObjectivesFromConfig(cfg config.SLOConfig, apiBasePath string) ([]Objective, error) {
+	if cfg.ObjectivesPath != "" {
+		return LoadObjectives(cfg.ObjectivesPath)
+	}
+	objectives := []Objective{{
+		Name:         "api",
+		Route:        strings.TrimSuffix(apiBasePath, "/") + "/*",
+		Availability: cfg.AvailabilityTarget,
+		Latency:      cfg.LatencyTarget,
+	}}
+	return objectives, validateObjectives(objectives)
+}
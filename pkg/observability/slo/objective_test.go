@@ -0,0 +1,64 @@
+package slo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjective_Matches(t *testing.T) {
+	tests := []struct {
+		objective Objective
+		method    string
+		route     string
+		expected  bool
+	}{
+		{Objective{}, "GET", "/health", true},
+		{Objective{Route: "/api/v1/users/:id"}, "DELETE", "/api/v1/users/:id", true},
+		{Objective{Route: "/api/v1/users/:id"}, "GET", "/api/v1/users", false},
+		{Objective{Method: "GET", Route: "/api/v1/*"}, "get", "/api/v1/users", true},
+		{Objective{Method: "GET", Route: "/api/v1/*"}, "POST", "/api/v1/users", false},
+		{Objective{Route: "/api/v1/*"}, "GET", "/metrics", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.objective.Matches(tt.method, tt.route), "%+v %s %s", tt.objective, tt.method, tt.route)
+	}
+}
+
+func TestLoadObjectives(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "objectives.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"name": "users-read", "method": "GET", "route": "/api/v1/users/:id", "availability": 0.999, "latency": 0.99},
+		{"name": "api", "route": "/api/v1/*", "availability": 0.995}
+	]`), 0o644))
+
+	objectives, err := LoadObjectives(path)
+	require.NoError(t, err)
+	require.Len(t, objectives, 2)
+	assert.Equal(t, map[string]float64{SLIAvailability: 0.999, SLILatency: 0.99}, objectives[0].Targets())
+	assert.Equal(t, map[string]float64{SLIAvailability: 0.995}, objectives[1].Targets())
+
+	require.NoError(t, os.WriteFile(path, []byte(`[{"name": "api", "latency": 99}]`), 0o644))
+	_, err = LoadObjectives(path)
+	assert.ErrorIs(t, err, ErrInvalidObjective)
+}
+
+func TestLoadObjectives_RepositoryFile(t *testing.T) {
+	objectives, err := LoadObjectives("../../../config/slo/objectives.json")
+	require.NoError(t, err)
+	assert.NotEmpty(t, objectives)
+}
+
+func TestObjectivesFromConfig(t *testing.T) {
+	objectives, err := ObjectivesFromConfig(config.SLOConfig{AvailabilityTarget: 0.999, LatencyTarget: 0.99}, "/api/v1")
+	require.NoError(t, err)
+	assert.Equal(t, []Objective{{Name: "api", Route: "/api/v1/*", Availability: 0.999, Latency: 0.99}}, objectives)
+
+	_, err = ObjectivesFromConfig(config.SLOConfig{ObjectivesPath: "missing.json"}, "/api/v1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+package slo
+
+import "time"
+
+// Status the SLO report served by the Handler
+type Status struct {
+	EvaluatedAt time.Time         `json:"evaluated_at"`
+	Period      string            `json:"period"`
+	Objectives  []ObjectiveStatus `json:"objectives"`
+}
+
+type ObjectiveStatus struct {
+	Name   string      `json:"name"`
+	Method string      `json:"method,omitempty"`
+	Route  string      `json:"route,omitempty"`
+	SLIs   []SLIStatus `json:"slis"`
+}
+
+// SLIStatus compliance and error budget of an SLI over the period (or since the start)
+type SLIStatus struct {
+	SLI        string  `json:"sli"`
+	Target     float64 `json:"target"`
+	Compliance float64 `json:"compliance"`
+	// ErrorBudgetRemaining 1 untouched, 0 spent, negative overspent
+	ErrorBudgetRemaining float64            `json:"error_budget_remaining"`
+	TotalEvents          float64            `json:"total_events"`
+	BadEvents            float64            `json:"bad_events"`
+	BurnRates            map[string]float64 `json:"burn_rates"`
+	Alerts               []AlertStatus      `json:"alerts"`
+}
+
+// AlertStatus a BurnRateAlert evaluated
+type AlertStatus struct {
+	Severity    string  `json:"severity"`
+	LongWindow  string  `json:"long_window"`
+	ShortWindow string  `json:"short_window"`
+	Factor      float64 `json:"factor"`
+	Firing      bool    `json:"firing"`
+}
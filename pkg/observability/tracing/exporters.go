@@ -0,0 +1,186 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// span exporters of TracingConfig.Exporter
+const (
+	ExporterOTLP   = "otlp"   // collector (Jaeger, Tempo...), default
+	ExporterStdout = "stdout" // standard output, for development
+	ExporterFile   = "file"   // JSON lines file rotated by size
+)
+
+// formats of the stdout exporter
+const (
+	FormatPretty = "pretty" // indented JSON, one block per span
+	FormatJSON   = "json"   // one JSON object per line
+)
+
+// defaultFileMaxSize size of the file exporter rotation when not configured
+const defaultFileMaxSize = 100 << 20 // 100 MiB
+
+// newLocalExporter creates the stdout or file exporter of config.Exporter, the spans are
+// written as the JSON of stdouttrace so they can be read with jq
+func newLocalExporter(config TracingConfig) (sdktrace.SpanExporter, error) {
+	switch config.Exporter {
+	case ExporterStdout:
+		opts := []stdouttrace.Option{stdouttrace.WithWriter(os.Stdout)}
+		switch config.StdoutFormat {
+		case FormatPretty, "":
+			opts = append(opts, stdouttrace.WithPrettyPrint())
+		case FormatJSON:
+		default:
+			return nil, fmt.Errorf("%w: unknown stdout format %q", ErrInvalidConfig, config.StdoutFormat)
+		}
+		exporter, err := stdouttrace.New(opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout trace exporter: %w", err)
+		}
+		return exporter, nil
+
+	case ExporterFile:
+		if config.FilePath == "" {
+			return nil, fmt.Errorf("%w: file exporter without path", ErrInvalidConfig)
+		}
+		file, err := newRotatingFile(config.FilePath, config.FileMaxSize, config.FileMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to create file trace exporter: %w", err)
+		}
+		return &closingExporter{SpanExporter: exporter, closer: file}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unknown exporter %q", ErrInvalidConfig, config.Exporter)
+	}
+}
+
+// closingExporter closes the destination of the exporter on shutdown, stdouttrace never
+// closes its writer
+type closingExporter struct {
+	sdktrace.SpanExporter
+	closer io.Closer
+}
+
+func (e *closingExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.SpanExporter.Shutdown(ctx), e.closer.Close())
+}
+
+// rotatingFile an io.WriteCloser that appends to path and, when a write would make it
+// larger than maxSize, renames it to path.1 (path.1 to path.2 and so on) keeping
+// maxBackups files, so the spans of a long running process never fill the disk
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	if maxSize <= 0 {
+		maxSize = defaultFileMaxSize
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create trace file directory: %w", err)
+	}
+
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	// a single write larger than maxSize goes to an empty file instead of rotating forever
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// open opens path for append, continuing the size of an existing file
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open trace file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat trace file: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backups, dropping the oldest, and starts a new file
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close trace file: %w", err)
+	}
+	r.file = nil
+
+	if r.maxBackups == 0 {
+		if err := os.Remove(r.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove trace file: %w", err)
+		}
+		return r.open()
+	}
+
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		err := os.Rename(r.backup(i), r.backup(i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate trace file: %w", err)
+		}
+	}
+	if err := os.Rename(r.path, r.backup(1)); err != nil {
+		return fmt.Errorf("failed to rotate trace file: %w", err)
+	}
+	return r.open()
+}
+
+// backup the path of the i-th backup, 1 is the newest
+func (r *rotatingFile) backup(i int) string {
+	return r.path + "." + strconv.Itoa(i)
+}
@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestInitTracing_FileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "spans.jsonl")
+	shutdown, err := InitTracing(TracingConfig{
+		ServiceName: "test",
+		Enabled:     true,
+		Exporter:    ExporterFile,
+		FilePath:    path,
+		SampleRatio: 1,
+	})
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "users.Create")
+	span.End()
+	require.NoError(t, shutdown(context.Background()), "flushes the spans and closes the file")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 1, "one span per line")
+
+	var exported struct{ Name string }
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &exported))
+	assert.Equal(t, "users.Create", exported.Name)
+}
+
+func TestInitTracing_InvalidLocalExporter(t *testing.T) {
+	tests := map[string]TracingConfig{
+		"unknown exporter":      {Enabled: true, Exporter: "jaeger"},
+		"unknown stdout format": {Enabled: true, Exporter: ExporterStdout, StdoutFormat: "yaml"},
+		"file without path":     {Enabled: true, Exporter: ExporterFile},
+	}
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := InitTracing(config)
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+		})
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	file, err := newRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Close())
+
+	read := func(name string) string {
+		t.Helper()
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return string(content)
+	}
+	assert.Equal(t, "fourth\n", read(path))
+	assert.Equal(t, "third\n", read(path+".1"))
+	assert.Equal(t, "second\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3", "oldest dropped")
+
+	_, err = file.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestRotatingFile_ContinuesExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("previous\n"), 0o644))
+
+	file, err := newRotatingFile(path, 12, 0)
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.Write([]byte("next\n"))
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "next\n", string(content), "rotated without backups")
+	assert.NoFileExists(t, path+".1")
+}
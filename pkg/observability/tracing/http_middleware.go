@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const httpTracerName = "github.com/cristianortiz/observ-monit-go/pkg/observability/tracing/http"
+
+type MiddlewareConfig struct {
+	// Next skips the middleware when it returns true (e.g. metrics and probes)
+	Next func(c *fiber.Ctx) bool
+	// TracerProvider default the global one (see InitTracing)
+	TracerProvider trace.TracerProvider
+	// Propagator default the global one, W3C traceparent and baggage after InitTracing
+	Propagator propagation.TextMapPropagator
+}
+
+// Middleware creates a server span for every HTTP request, the incoming trace context
+// (traceparent, baggage) is used as parent and the span is stored in c.UserContext() so
+// use cases and repositories create child spans. The span is named by the route template
+// ("GET /api/v1/users/:id") to keep the cardinality low, 5xx responses mark it as error
+//
+// Errors returned by the handlers are passed to the app error handler here, so the
+// recorded status is the one sent to the client, register it before recover
+//
+// Usage:
+//
+//	app.Use(tracing.Middleware(tracing.MiddlewareConfig{}))
+func Middleware(config MiddlewareConfig) fiber.Handler {
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+	if config.Propagator == nil {
+		config.Propagator = otel.GetTextMapPropagator()
+	}
+	tracer := config.TracerProvider.Tracer(httpTracerName)
+
+	return func(c *fiber.Ctx) error {
+		if config.Next != nil && config.Next(c) {
+			return c.Next()
+		}
+
+		ctx := config.Propagator.Extract(c.UserContext(), headerCarrier{c})
+		// the values are copied, fiber reuses its buffers and spans are exported later
+		method := utils.CopyString(c.Method())
+
+		ctx, span := tracer.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.URLScheme(utils.CopyString(c.Protocol())),
+				semconv.URLPath(utils.CopyString(c.Path())),
+				semconv.ServerAddress(utils.CopyString(c.Hostname())),
+				semconv.ClientAddress(utils.CopyString(c.IP())),
+				semconv.UserAgentOriginal(utils.CopyString(c.Get(fiber.HeaderUserAgent))),
+				semconv.NetworkProtocolVersion(protocolVersion(c)),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+
+		if err := c.Next(); err != nil {
+			span.RecordError(err)
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// the matched route is only known after the handlers ran
+		if route := c.Route().Path; route != "" {
+			span.SetName(method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+
+		status := c.Response().StatusCode()
+		span.SetAttributes(
+			semconv.HTTPResponseStatusCode(status),
+			semconv.HTTPRequestBodySize(len(c.Request().Body())),
+			semconv.HTTPResponseBodySize(len(c.Response().Body())),
+		)
+		// 4xx are caller errors, not server span errors
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, utils.StatusMessage(status))
+		}
+
+		return nil
+	}
+}
+
+// protocolVersion of the request, fasthttp serves HTTP/1.x only
+func protocolVersion(c *fiber.Ctx) string {
+	if c.Request().Header.IsHTTP11() {
+		return "1.1"
+	}
+	return "1.0"
+}
+
+// headerCarrier adapts the Fiber request headers to the propagation.TextMapCarrier interface
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	headers := h.c.GetReqHeaders()
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	app := fiber.New()
+	app.Use(Middleware(MiddlewareConfig{
+		TracerProvider: provider,
+		Propagator:     propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+		Next: func(c *fiber.Ctx) bool {
+			return c.Path() == "/health"
+		},
+	}))
+
+	var handlerSpan trace.SpanContext
+	var member string
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		handlerSpan = trace.SpanContextFromContext(c.UserContext())
+		member = baggage.FromContext(c.UserContext()).Member("tenant").Value()
+
+		switch c.Params("id") {
+		case "missing":
+			return fiber.NewError(fiber.StatusNotFound, "user not found")
+		case "broken":
+			return fiber.NewError(fiber.StatusInternalServerError, "database down")
+		}
+		return c.SendString("ok")
+	})
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	request := func(path string, headers map[string]string) int {
+		t.Helper()
+		req := httptest.NewRequest("GET", path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	t.Run("continues the incoming trace", func(t *testing.T) {
+		recorder.Reset()
+		status := request("/users/42", map[string]string{
+			"traceparent": "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01",
+			"baggage":     "tenant=acme",
+		})
+		require.Equal(t, fiber.StatusOK, status)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		span := spans[0]
+		assert.Equal(t, "GET /users/:id", span.Name(), "named by the route template")
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+		assert.Equal(t, "0102030405060708090a0b0c0d0e0f10", span.SpanContext().TraceID().String())
+		assert.Equal(t, "0102030405060708", span.Parent().SpanID().String())
+		assert.Equal(t, span.SpanContext().SpanID(), handlerSpan.SpanID(), "span in c.UserContext()")
+		assert.Equal(t, "acme", member)
+
+		assert.Equal(t, "/users/:id", attributeValue(span, "http.route").AsString())
+		assert.Equal(t, "/users/42", attributeValue(span, "url.path").AsString())
+		assert.Equal(t, int64(200), attributeValue(span, "http.response.status_code").AsInt64())
+		assert.Equal(t, codes.Unset, span.Status().Code)
+	})
+
+	t.Run("5xx are errors, 4xx are not", func(t *testing.T) {
+		recorder.Reset()
+		assert.Equal(t, fiber.StatusNotFound, request("/users/missing", nil))
+		assert.Equal(t, fiber.StatusInternalServerError, request("/users/broken", nil))
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+		assert.Equal(t, codes.Error, spans[1].Status().Code)
+
+		assert.Equal(t, int64(500), attributeValue(spans[1], "http.response.status_code").AsInt64(),
+			"status sent by the error handler")
+		assert.False(t, spans[0].Parent().IsValid(), "new trace without traceparent")
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		recorder.Reset()
+		request("/health", nil)
+		assert.Empty(t, recorder.Ended())
+	})
+}
+
+// attributeValue the value of the key attribute of span
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
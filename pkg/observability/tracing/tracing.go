@@ -2,42 +2,102 @@ package tracing
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
-	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
 )
 
+// OTLP protocols of the exporter
+const (
+	ProtocolGRPC = "grpc" // port 4317
+	ProtocolHTTP = "http" // http/protobuf, port 4318
+)
+
+// ErrInvalidConfig is returned by InitTracing for settings that can not work
+var ErrInvalidConfig = errors.New("invalid tracing config")
+
 type TracingConfig struct {
 	ServiceName    string
 	ServiceVersion string
 	Environment    string
-	OTLPEndpoint   string // OTLP gRPC endpoint (e.g., "localhost:4317")
+	OTLPEndpoint   string // host:port of the collector (e.g., "localhost:4317")
 	Enabled        bool
+
+	// Exporter ExporterOTLP (default), ExporterStdout or ExporterFile, the OTLP settings
+	// below only apply to ExporterOTLP
+	Exporter string
+	// StdoutFormat FormatPretty (default) or FormatJSON
+	StdoutFormat string
+	// FilePath JSON lines file of ExporterFile, rotated when it reaches FileMaxSize bytes
+	// (100 MiB when 0) keeping FileMaxBackups old files (path.1, path.2...)
+	FilePath       string
+	FileMaxSize    int64
+	FileMaxBackups int
+
+	// Protocol ProtocolGRPC (default) or ProtocolHTTP
+	Protocol string
+	// Insecure plain text connection, TLS otherwise
+	Insecure bool
+	// CACertFile PEM file of the CA of the collector, the system pool when empty
+	CACertFile string
+	// ClientCertFile and ClientKeyFile PEM files of the client certificate (mTLS), optional
+	ClientCertFile string
+	ClientKeyFile  string
+	// Headers sent with every export, e.g. the API key of a hosted collector
+	Headers map[string]string
+	// Compression "gzip" or "none" (default)
+	Compression string
+
+	// SampleRatio fraction (0..1) of the new traces sampled, spans with a parent follow the
+	// decision of the parent (parent based), 1 samples every trace
+	SampleRatio float64
+
+	// batch span processor, zero values use the SDK defaults
+	BatchTimeout       time.Duration
+	ExportTimeout      time.Duration
+	MaxExportBatchSize int
+	MaxQueueSize       int
 }
 
-// InitTracing initializes OpenTelemetry tracing with OTLP gRPC exporter
-// Uses gRPC protocol which is the recommended approach for production
+// InitTracing initializes OpenTelemetry tracing with the exporter of config.Exporter and
+// sets the global tracer provider and propagator, it never waits for the collector: spans
+// are exported in background and dropped while it is down, so a collector outage does
+// not stop the service
 func InitTracing(config TracingConfig) (func(context.Context) error, error) {
+	// W3C trace context and baggage, read by the HTTP and gRPC server middlewares
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
 	if !config.Enabled {
 		return func(context.Context) error { return nil }, nil
 	}
 
 	ctx := context.Background()
 
-	// Configure OTLP gRPC exporter
-	exporter, err := otlptracegrpc.New(ctx,
-		otlptracegrpc.WithEndpoint(config.OTLPEndpoint),
-		otlptracegrpc.WithInsecure(),                   // Use WithTLSCredentials for production
-		otlptracegrpc.WithDialOption(grpc.WithBlock()), // Wait for connection
-	)
+	var exporter sdktrace.SpanExporter
+	var err error
+	if config.Exporter == ExporterOTLP || config.Exporter == "" {
+		exporter, err = newExporter(ctx, config)
+	} else {
+		exporter, err = newLocalExporter(config)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create OTLP gRPC trace exporter: %w", err)
+		return nil, err
 	}
 
 	// Create resource with service information
@@ -54,12 +114,9 @@ func InitTracing(config TracingConfig) (func(context.Context) error, error) {
 
 	// Create tracer provider with batch span processor
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter,
-			sdktrace.WithBatchTimeout(5*time.Second),
-			sdktrace.WithMaxExportBatchSize(512),
-		),
+		sdktrace.WithBatcher(exporter, batchOptions(config)...),
 		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.AlwaysSample()), // Use ParentBased or TraceIDRatioBased in production
+		sdktrace.WithSampler(newSampler(config.SampleRatio)),
 	)
 
 	// Set global tracer provider
@@ -68,3 +125,127 @@ func InitTracing(config TracingConfig) (func(context.Context) error, error) {
 	// Return shutdown function
 	return tp.Shutdown, nil
 }
+
+// newExporter creates the OTLP exporter of config.Protocol, the connection is established
+// lazily by the first export
+func newExporter(ctx context.Context, config TracingConfig) (sdktrace.SpanExporter, error) {
+	var tlsConfig *tls.Config
+	if !config.Insecure {
+		var err error
+		if tlsConfig, err = newTLSConfig(config); err != nil {
+			return nil, err
+		}
+	}
+
+	switch config.Protocol {
+	case ProtocolGRPC, "":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.OTLPEndpoint)}
+		if tlsConfig != nil {
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+		} else {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if len(config.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(config.Headers))
+		}
+		if config.ExportTimeout > 0 {
+			opts = append(opts, otlptracegrpc.WithTimeout(config.ExportTimeout))
+		}
+		switch config.Compression {
+		case "", "none":
+		case "gzip":
+			opts = append(opts, otlptracegrpc.WithCompressor(gzip.Name))
+		default:
+			return nil, fmt.Errorf("%w: unknown compression %q", ErrInvalidConfig, config.Compression)
+		}
+
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP gRPC trace exporter: %w", err)
+		}
+		return exporter, nil
+
+	case ProtocolHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.OTLPEndpoint)}
+		if tlsConfig != nil {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+		} else {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(config.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(config.Headers))
+		}
+		if config.ExportTimeout > 0 {
+			opts = append(opts, otlptracehttp.WithTimeout(config.ExportTimeout))
+		}
+		switch config.Compression {
+		case "", "none":
+		case "gzip":
+			opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+		default:
+			return nil, fmt.Errorf("%w: unknown compression %q", ErrInvalidConfig, config.Compression)
+		}
+
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP HTTP trace exporter: %w", err)
+		}
+		return exporter, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unknown OTLP protocol %q", ErrInvalidConfig, config.Protocol)
+	}
+}
+
+// newTLSConfig the TLS settings of the connection to the collector
+func newTLSConfig(config TracingConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.CACertFile != "" {
+		pem, err := os.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tracing CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificate found in %s", ErrInvalidConfig, config.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tracing client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// newSampler parent based ratio sampler, a trace is sampled entirely or not at all
+func newSampler(ratio float64) sdktrace.Sampler {
+	if ratio >= 1 {
+		return sdktrace.ParentBased(sdktrace.AlwaysSample())
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+// batchOptions the batch span processor tuning of config
+func batchOptions(config TracingConfig) []sdktrace.BatchSpanProcessorOption {
+	var opts []sdktrace.BatchSpanProcessorOption
+	if config.BatchTimeout > 0 {
+		opts = append(opts, sdktrace.WithBatchTimeout(config.BatchTimeout))
+	}
+	if config.ExportTimeout > 0 {
+		opts = append(opts, sdktrace.WithExportTimeout(config.ExportTimeout))
+	}
+	if config.MaxExportBatchSize > 0 {
+		opts = append(opts, sdktrace.WithMaxExportBatchSize(config.MaxExportBatchSize))
+	}
+	if config.MaxQueueSize > 0 {
+		opts = append(opts, sdktrace.WithMaxQueueSize(config.MaxQueueSize))
+	}
+	return opts
+}
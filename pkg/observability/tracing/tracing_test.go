@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitTracing_InvalidConfig(t *testing.T) {
+	tests := map[string]TracingConfig{
+		"unknown protocol":    {Enabled: true, Insecure: true, Protocol: "thrift"},
+		"unknown compression": {Enabled: true, Insecure: true, Protocol: ProtocolHTTP, Compression: "zstd"},
+	}
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := InitTracing(config)
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+		})
+	}
+
+	_, err := InitTracing(TracingConfig{Enabled: true, CACertFile: "/nonexistent/ca.pem"})
+	assert.Error(t, err, "missing CA file")
+}
+
+func TestInitTracing_CollectorDown(t *testing.T) {
+	for _, protocol := range []string{ProtocolGRPC, ProtocolHTTP} {
+		t.Run(protocol, func(t *testing.T) {
+			start := time.Now()
+			shutdown, err := InitTracing(TracingConfig{
+				ServiceName:   "test",
+				Enabled:       true,
+				OTLPEndpoint:  "127.0.0.1:1", // nothing listens
+				Protocol:      protocol,
+				Insecure:      true,
+				Compression:   "gzip",
+				SampleRatio:   0.5,
+				ExportTimeout: 100 * time.Millisecond,
+			})
+			require.NoError(t, err)
+			assert.Less(t, time.Since(start), time.Second, "never waits for the collector")
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			_ = shutdown(ctx)
+		})
+	}
+}
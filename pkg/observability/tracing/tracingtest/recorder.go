@@ -0,0 +1,110 @@
+// Package tracingtest records the spans of a test in memory, so the spans emitted by the
+// handlers, usecases and repositories can be asserted without a collector
+package tracingtest
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Recorder keeps every span of its Provider in memory
+type Recorder struct {
+	*tracetest.SpanRecorder
+	// Provider samples every span, pass it to the components with a TracerProvider option
+	Provider *sdktrace.TracerProvider
+}
+
+// NewRecorder creates a recorder and installs its provider as the global tracer provider
+// for the duration of the test, the previous one is restored by t.Cleanup, the tests
+// using it can not run in parallel
+//
+// Usage:
+//
+//	spans := tracingtest.NewRecorder(t)
+//	app.Test(httptest.NewRequest("GET", "/api/v1/users/1", nil))
+//	span := spans.Span(t, "GET /api/v1/users/:id")
+//	value, _ := tracingtest.Attribute(span, "http.response.status_code")
+func NewRecorder(t testing.TB) *Recorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+
+	return &Recorder{SpanRecorder: recorder, Provider: provider}
+}
+
+// Named the ended spans with the given name, in the order they ended
+func (r *Recorder) Named(name string) []sdktrace.ReadOnlySpan {
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range r.Ended() {
+		if span.Name() == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// Span the last ended span with the given name, fails the test when there is none
+func (r *Recorder) Span(t testing.TB, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	spans := r.Named(name)
+	if len(spans) == 0 {
+		names := make([]string, 0, len(r.Ended()))
+		for _, span := range r.Ended() {
+			names = append(names, span.Name())
+		}
+		t.Fatalf("no span %q recorded, got %q", name, names)
+	}
+	return spans[len(spans)-1]
+}
+
+// Children the ended spans whose parent is parent
+func (r *Recorder) Children(parent sdktrace.ReadOnlySpan) []sdktrace.ReadOnlySpan {
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range r.Ended() {
+		if span.Parent().SpanID() == parent.SpanContext().SpanID() {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// Attribute the value of the attribute key of span
+func Attribute(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+// SameTrace reports whether all the spans belong to one trace
+func SameTrace(spans ...sdktrace.ReadOnlySpan) bool {
+	var traceID trace.TraceID
+	for i, span := range spans {
+		if i == 0 {
+			traceID = span.SpanContext().TraceID()
+		} else if span.SpanContext().TraceID() != traceID {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package tracingtest_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/tracing"
+	"github.com/cristianortiz/observ-monit-go/pkg/observability/tracing/tracingtest"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestRecorder(t *testing.T) {
+	spans := tracingtest.NewRecorder(t)
+
+	// the middleware and the handler use the global provider
+	app := fiber.New()
+	app.Use(tracing.Middleware(tracing.MiddlewareConfig{}))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		_, span := otel.Tracer("users").Start(c.UserContext(), "users.GetByID")
+		span.End()
+		return c.SendString("ok")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/users/42", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	server := spans.Span(t, "GET /users/:id")
+	status, ok := tracingtest.Attribute(server, "http.response.status_code")
+	require.True(t, ok)
+	assert.Equal(t, int64(200), status.AsInt64())
+
+	children := spans.Children(server)
+	require.Len(t, children, 1)
+	assert.Equal(t, "users.GetByID", children[0].Name())
+	assert.True(t, tracingtest.SameTrace(server, children[0]))
+
+	spans.Reset()
+	assert.Empty(t, spans.Named("GET /users/:id"))
+}
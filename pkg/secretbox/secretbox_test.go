@@ -0,0 +1,39 @@
+package secretbox
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	box, err := New(DeriveKey([]byte("master"), "test"))
+	require.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "JBSWY3DPEHPK3PXP")
+
+	again, err := box.Seal([]byte("JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "random nonce")
+
+	plain, err := box.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", string(plain))
+
+	other, err := New(DeriveKey([]byte("master"), "other"))
+	require.NoError(t, err)
+	_, err = other.Open(sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = box.Open("not base64!")
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestNew_KeySize(t *testing.T) {
+	_, err := New(bytes.Repeat([]byte{1}, 16))
+	assert.Error(t, err)
+}
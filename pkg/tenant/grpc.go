@@ -0,0 +1,50 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor resolves the tenant from the gRPC metadata key (e.g. x-tenant-id),
+// the gRPC counterpart of middleware.Tenant, falls back to the tenant of the principal
+// (auth.UnaryServerInterceptor) and then to defaultID when the key is missing, an empty
+// defaultID makes the tenant mandatory. A credential bound to a tenant can not be used
+// against another one
+func UnaryServerInterceptor(metadataKey, defaultID string) grpc.UnaryServerInterceptor {
+	metadataKey = strings.ToLower(metadataKey)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		principal, authenticated := auth.FromContext(ctx)
+
+		id := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(metadataKey); len(values) > 0 && values[0] != "" {
+				id = strings.ToLower(values[0])
+			}
+		}
+		if id == "" && authenticated {
+			id = principal.TenantID
+		}
+		if id == "" {
+			id = defaultID
+		}
+
+		if id == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "missing tenant (%s metadata)", metadataKey)
+		}
+		if !IsValid(id) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid tenant: %s", id)
+		}
+		if authenticated && principal.TenantID != "" && principal.TenantID != id {
+			return nil, status.Error(codes.PermissionDenied, "credentials do not belong to the requested tenant")
+		}
+
+		return handler(WithID(ctx, id), req)
+	}
+}
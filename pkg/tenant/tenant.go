@@ -0,0 +1,43 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// DefaultID is the tenant used when none was resolved, it matches the default
+// value of users.tenant_id so single tenant deployments keep working
+const DefaultID = "default"
+
+// LocalsKey is the Fiber locals key where the resolved tenant is stored
+const LocalsKey = "tenant_id"
+
+type contextKey struct{}
+
+// validID same rules as a DNS label, safe for subdomains, headers and metric labels
+var validID = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// WithID returns a copy of ctx carrying the tenant id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant stored in ctx, false if there is none
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// IDFromContext returns the tenant stored in ctx or DefaultID, repositories use it
+// to scope every query
+func IDFromContext(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id
+	}
+	return DefaultID
+}
+
+// IsValid checks the tenant id format
+func IsValid(id string) bool {
+	return validID.MatchString(id)
+}
@@ -0,0 +1,98 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cristianortiz/observ-monit-go/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+
+	_, ok := FromContext(ctx)
+	assert.False(t, ok)
+	assert.Equal(t, DefaultID, IDFromContext(ctx))
+
+	ctx = WithID(ctx, "acme")
+	id, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "acme", id)
+	assert.Equal(t, "acme", IDFromContext(ctx))
+}
+
+func TestIsValid(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"acme", true},
+		{"acme-corp", true},
+		{"t1", true},
+		{"", false},
+		{"-acme", false},
+		{"acme-", false},
+		{"Acme", false},
+		{"acme_corp", false},
+		{"acme.corp", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.valid, IsValid(tt.id), "id %q", tt.id)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor("x-tenant-id", "")
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.UserService/GetUser"}
+
+	var got string
+	handler := func(ctx context.Context, req any) (any, error) {
+		got = IDFromContext(ctx)
+		return nil, nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant-id", "acme"))
+	_, err := interceptor(ctx, nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", got)
+
+	_, err = interceptor(context.Background(), nil, info, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant-id", "not valid"))
+	_, err = interceptor(ctx, nil, info, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUnaryServerInterceptor_Principal(t *testing.T) {
+	interceptor := UnaryServerInterceptor("x-tenant-id", DefaultID)
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.UserService/GetUser"}
+
+	var got string
+	handler := func(ctx context.Context, req any) (any, error) {
+		got = IDFromContext(ctx)
+		return nil, nil
+	}
+	acme := auth.WithPrincipal(context.Background(), &auth.Principal{Type: auth.PrincipalAPIKey, ID: "key-1", TenantID: "acme"})
+
+	// the tenant of the credential when the metadata has none
+	_, err := interceptor(acme, nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", got)
+
+	ctx := metadata.NewIncomingContext(acme, metadata.Pairs("x-tenant-id", "acme"))
+	_, err = interceptor(ctx, nil, info, handler)
+	require.NoError(t, err)
+
+	// a credential of one tenant is rejected for another
+	ctx = metadata.NewIncomingContext(acme, metadata.Pairs("x-tenant-id", "globex"))
+	_, err = interceptor(ctx, nil, info, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}